@@ -0,0 +1,77 @@
+package cmdsfx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// HTTPTimeouts bound how long the HTTP transports wait on clients
+type HTTPTimeouts struct {
+	// ReadHeader limits reading a request's headers
+	ReadHeader time.Duration
+	// Read limits reading a whole request, body included
+	Read time.Duration
+	// Write limits writing a response. Zero leaves it unlimited, which SSE
+	// streams need: they stay open for the whole session.
+	Write time.Duration
+	// Idle limits how long a keep-alive connection waits for its next request
+	Idle time.Duration
+	// Shutdown is how long in-flight requests get to finish on shutdown
+	Shutdown time.Duration
+}
+
+// DefaultHTTPTimeouts drop stalled clients without cutting off SSE streams
+func DefaultHTTPTimeouts() HTTPTimeouts {
+	return HTTPTimeouts{
+		ReadHeader: 10 * time.Second,
+		Read:       30 * time.Second,
+		Idle:       2 * time.Minute,
+		Shutdown:   10 * time.Second,
+	}
+}
+
+// NewHTTPServer returns a server for handler on addr with the timeouts t
+func NewHTTPServer(addr string, handler http.Handler, t HTTPTimeouts) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
+
+// ServeHTTP runs srv until ctx is done, then shuts it down gracefully
+func ServeHTTP(ctx context.Context, srv *http.Server, t HTTPTimeouts) error {
+	return serveUntilDone(ctx, srv.ListenAndServe, srv.Shutdown, t.Shutdown)
+}
+
+// serveUntilDone runs start until it fails or ctx is done. Then shutdown gets
+// timeout to stop accepting connections and drain in-flight requests.
+func serveUntilDone(
+	ctx context.Context,
+	start func() error,
+	shutdown func(context.Context) error,
+	timeout time.Duration,
+) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- start() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
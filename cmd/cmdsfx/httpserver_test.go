@@ -0,0 +1,63 @@
+package cmdsfx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeHTTPDrainsInFlightRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+	srv := NewHTTPServer("", handler, DefaultHTTPTimeouts())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilDone(ctx, func() error { return srv.Serve(ln) }, srv.Shutdown, time.Second)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+	<-started
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Fatalf("expected the in-flight request to finish, got %q", got)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("expected a clean shutdown, got %v", err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Fatal("expected the server to refuse new connections")
+	}
+}
+
+func TestServeHTTPReturnsStartError(t *testing.T) {
+	failed := errors.New("address in use")
+	err := serveUntilDone(context.Background(), func() error { return failed }, nil, time.Second)
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+}
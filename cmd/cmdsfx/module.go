@@ -2,11 +2,18 @@ package cmdsfx
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
 
 	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/mcp"
+	"github.com/0x5457/ts-index/internal/models"
 	"github.com/0x5457/ts-index/internal/search"
+	"github.com/0x5457/ts-index/internal/storage"
 	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/fx"
 )
@@ -47,6 +54,7 @@ func (r *CommandRunner) RunIndex(ctx context.Context, projectPath string) error
 
 	// Run indexing with progress
 	progCh, errCh := r.indexer.IndexProjectProgress(ctx, projectPath)
+	skipped := 0
 	for progCh != nil || errCh != nil {
 		select {
 		case p, ok := <-progCh:
@@ -54,6 +62,7 @@ func (r *CommandRunner) RunIndex(ctx context.Context, projectPath string) error
 				progCh = nil
 				continue
 			}
+			skipped = p.SkippedFiles
 			fmt.Printf("\r[%3.0f%%] stage=%s files:%d/%d chunks:%d/%d %-40s",
 				p.Percent*100,
 				p.Stage,
@@ -76,6 +85,9 @@ func (r *CommandRunner) RunIndex(ctx context.Context, projectPath string) error
 		}
 	}
 	fmt.Println()
+	if skipped > 0 {
+		fmt.Printf("skipped %d generated files\n", skipped)
+	}
 	fmt.Println("index completed")
 	return nil
 }
@@ -102,31 +114,152 @@ func (r *CommandRunner) RunSearch(ctx context.Context, query string, topK int) e
 	return nil
 }
 
-// RunMCPServer executes the MCP server
-func (r *CommandRunner) RunMCPServer(transport, address string) error {
+// RunAdhocSearch indexes projectPath into the in-memory store and answers a
+// single semantic search query. Nothing is written to disk.
+func (r *CommandRunner) RunAdhocSearch(ctx context.Context, projectPath, query string, topK int) error {
+	if r.indexer == nil {
+		return fmt.Errorf("indexer not available")
+	}
+	if !r.config.InMemory {
+		return fmt.Errorf("ad hoc search needs an in-memory index")
+	}
+	fmt.Fprintln(os.Stderr, "warning: the index is kept in memory and discarded after this search")
+
+	progCh, errCh := r.indexer.IndexProjectProgress(ctx, projectPath)
+	for progCh != nil || errCh != nil {
+		select {
+		case _, ok := <-progCh:
+			if !ok {
+				progCh = nil
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := r.RunSearch(ctx, query, topK); errors.Is(err, storage.ErrNoEmbeddings) {
+		return fmt.Errorf("no code to search in %s", projectPath)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RunEval runs the labeled queries in queriesPath and prints the search
+// quality report as a table, or as JSON when asJSON is set
+func (r *CommandRunner) RunEval(ctx context.Context, queriesPath string, topK int, asJSON bool) error {
+	if r.searchService == nil {
+		return fmt.Errorf("search service not available")
+	}
+
+	f, err := os.Open(queriesPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	queries, err := search.LoadEvalQueries(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", queriesPath, err)
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries in %s", queriesPath)
+	}
+
+	report, err := r.searchService.Evaluate(ctx, queries, topK)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.WriteText(os.Stdout)
+}
+
+// RunWhichSymbol prints the symbols enclosing a 0-based position of file,
+// innermost first, as text or as JSON when asJSON is set
+func (r *CommandRunner) RunWhichSymbol(projectPath, file string, line, character int, asJSON bool) error {
+	finder, ok := r.indexer.(indexer.PositionSymbolFinder)
+	if !ok {
+		return fmt.Errorf("indexer cannot resolve symbols by position")
+	}
+
+	symbols, err := finder.SymbolsAt(projectPath, file, line, character)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if symbols == nil {
+			symbols = []models.PositionSymbol{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(symbols)
+	}
+	if len(symbols) == 0 {
+		fmt.Printf("no symbol at %s:%d:%d\n", file, line, character)
+		return nil
+	}
+	for i, sym := range symbols {
+		prefix := ""
+		if i > 0 {
+			prefix = "in "
+		}
+		fmt.Printf("%s%s (%s) %s:%d-%d\n",
+			prefix, sym.Name, models.SymbolKindName(sym.Kind), sym.File, sym.StartLine, sym.EndLine)
+		if i == 0 && sym.Signature != "" {
+			fmt.Printf("  %s\n", sym.Signature)
+		}
+	}
+	return nil
+}
+
+// RunMCPServer executes the MCP server until ctx is done. The HTTP transports
+// then stop accepting connections and give in-flight requests
+// timeouts.Shutdown to finish.
+func (r *CommandRunner) RunMCPServer(ctx context.Context, transport, address string, timeouts HTTPTimeouts) error {
 	if r.mcpServer == nil {
 		return fmt.Errorf("MCP server not available")
 	}
 
 	switch transport {
 	case "stdio":
-		return server.ServeStdio(r.mcpServer)
+		err := server.NewStdioServer(r.mcpServer).Listen(ctx, os.Stdin, os.Stdout)
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
 	case "http":
 		// Streamable HTTP server on address, default ":8080" if empty
 		addr := address
 		if addr == "" {
 			addr = ":8080"
 		}
-		httpSrv := server.NewStreamableHTTPServer(r.mcpServer)
-		return httpSrv.Start(addr)
+		// responses are gzip-compressed for clients that accept it
+		mux := http.NewServeMux()
+		srv := NewHTTPServer(addr, mux, timeouts)
+		httpSrv := server.NewStreamableHTTPServer(r.mcpServer, server.WithStreamableHTTPServer(srv))
+		mux.Handle("/mcp", mcp.GzipHandler(httpSrv))
+		return serveUntilDone(ctx, func() error { return httpSrv.Start(addr) }, httpSrv.Shutdown, timeouts.Shutdown)
 	case "sse":
 		// SSE server exposes two endpoints; default base path "/mcp"
 		addr := address
 		if addr == "" {
 			addr = ":8080"
 		}
-		sseSrv := server.NewSSEServer(r.mcpServer)
-		return sseSrv.Start(addr)
+		srv := NewHTTPServer(addr, nil, timeouts)
+		sseSrv := server.NewSSEServer(r.mcpServer, server.WithHTTPServer(srv))
+		srv.Handler = sseSrv
+		// Shutdown ends the open SSE streams first, so they do not hold it up
+		return serveUntilDone(ctx, func() error { return sseSrv.Start(addr) }, sseSrv.Shutdown, timeouts.Shutdown)
 	default:
 		return fmt.Errorf(
 			"unsupported transport: %s (supported: stdio, http, sse)",
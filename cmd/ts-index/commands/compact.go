@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
+	"github.com/spf13/cobra"
+)
+
+func NewCompactCommand() *cobra.Command {
+	var dbPath string
+
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Reclaim free space in the index database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(dbPath); err != nil {
+				return fmt.Errorf("index database not found: %w", err)
+			}
+			before := databaseSize(dbPath)
+
+			store, err := sqlvec.New(dbPath, 0)
+			if err != nil {
+				return err
+			}
+			if err := store.Compact(); err != nil {
+				_ = store.Close()
+				return fmt.Errorf("compact failed: %w", err)
+			}
+			// closing releases the WAL, so the size read afterwards is final
+			if err := store.Close(); err != nil {
+				return err
+			}
+
+			after := databaseSize(dbPath)
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "compacted %s: %s -> %s (reclaimed %s)\n",
+				dbPath,
+				formatBytes(before),
+				formatBytes(after),
+				formatBytes(max(before-after, 0)),
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dbPath, "db", config.LoadDefaults().IndexDB(), "SQLite DB path ($"+config.DBEnvVar+")")
+
+	return cmd
+}
+
+// databaseSize is the size of the database file plus its write-ahead log,
+// which holds committed pages not yet checkpointed into the file
+func databaseSize(path string) int64 {
+	var size int64
+	for _, name := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(name); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// formatBytes renders a byte count with a binary unit suffix
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
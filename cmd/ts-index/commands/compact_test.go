@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
+)
+
+func TestCompactCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	store, err := sqlvec.New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Repeat("export const x = 1\n", 50)
+	chunks := make([]models.CodeChunk, 400)
+	vecs := make([][]float32, len(chunks))
+	for i := range chunks {
+		chunks[i] = models.CodeChunk{ID: fmt.Sprint(i), File: "big.ts", Content: content}
+		vecs[i] = []float32{float32(i), 1}
+	}
+	chunks[0].File = "keep.ts"
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.DeleteByFile("big.ts"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_ = store.Close()
+	before := databaseSize(path)
+
+	var out bytes.Buffer
+	cmd := NewCompactCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--db", path})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	after := databaseSize(path)
+	if after >= before {
+		t.Fatalf("expected compaction to shrink the index from %d bytes, got %d", before, after)
+	}
+	want := fmt.Sprintf("compacted %s: %s -> %s (reclaimed %s)\n",
+		path, formatBytes(before), formatBytes(after), formatBytes(before-after))
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() != 0 {
+		t.Fatalf("expected no pending WAL after compaction, got %d bytes", info.Size())
+	}
+}
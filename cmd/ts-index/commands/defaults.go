@@ -0,0 +1,18 @@
+package commands
+
+import (
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// projectUsage documents --project for commands that fall back to the default project
+const projectUsage = "Path to project root (default: $" + config.ProjectEnvVar +
+	", the config file's project, the git root or the working directory)"
+
+// resolveProject returns project, or the default project when it is empty
+func resolveProject(cmd *cobra.Command, project string) string {
+	if project != "" {
+		return project
+	}
+	return config.LoadDefaults().ProjectRoot(cmd.Context())
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x5457/ts-index/cmd/cmdsfx"
+	"github.com/0x5457/ts-index/internal/app/appfx"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+// Output formats of the eval command
+const (
+	evalFormatTable = "table"
+	evalFormatJSON  = "json"
+)
+
+func NewEvalCommand() *cobra.Command {
+	var (
+		queries string
+		dbPath  string
+		embUrl  string
+		model   string
+		topK    int
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Measure semantic search quality against labeled queries",
+		Long: "Run each query of a JSONL file against the index and report recall@k, MRR and mean latency.\n" +
+			`Each line holds a query and the file or symbol it should find, e.g.` + "\n" +
+			`{"query": "refresh the auth token", "file": "src/auth.ts", "symbol": "refreshToken"}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != evalFormatTable && format != evalFormatJSON {
+				return fmt.Errorf("unknown --format %q, want %s or %s", format, evalFormatTable, evalFormatJSON)
+			}
+			if topK <= 0 {
+				return fmt.Errorf("--top-k must be positive")
+			}
+
+			app := fx.New(
+				appfx.Module,
+				fx.Supply(
+					fx.Annotate(dbPath, fx.ResultTags(`name:"dbPath"`)),
+					fx.Annotate(embUrl, fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(model, fx.ResultTags(`name:"embedModel"`)),
+					fx.Annotate("", fx.ResultTags(`name:"project"`)),
+				),
+				fx.Invoke(func(runner *cmdsfx.CommandRunner) error {
+					return runner.RunEval(cmd.Context(), queries, topK, format == evalFormatJSON)
+				}),
+			)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			if err := app.Start(ctx); err != nil {
+				return fmt.Errorf("failed to start application: %w", err)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), fx.DefaultTimeout)
+			defer cancel()
+
+			if err := app.Stop(ctx); err != nil {
+				return fmt.Errorf("failed to stop application: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	defaults := config.LoadDefaults()
+
+	cmd.Flags().StringVar(&queries, "queries", "", "JSONL file of labeled queries")
+	_ = cmd.MarkFlagRequired("queries")
+	cmd.Flags().StringVar(&dbPath, "db", defaults.IndexDB(), "SQLite DB path ($"+config.DBEnvVar+")")
+	cmd.Flags().StringVar(&embUrl, "embed-url", defaults.EmbedURL, "Embedding API URL ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"Model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
+	cmd.Flags().IntVar(&topK, "top-k", 10, "Hits per query; recall is measured at this k")
+	cmd.Flags().StringVar(
+		&format,
+		"format",
+		evalFormatTable,
+		"Output format: table (summary and per-query hits) or json",
+	)
+
+	return cmd
+}
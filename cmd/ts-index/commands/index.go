@@ -3,12 +3,12 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/0x5457/ts-index/cmd/cmdsfx"
 	"github.com/0x5457/ts-index/internal/app/appfx"
-	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 )
@@ -18,14 +18,38 @@ func NewIndexCommand() *cobra.Command {
 		project string
 		dbPath  string
 		embUrl  string
+		model   string
+		enrich  bool
+		symOnly bool
+		reduce  int
+		quant   string
+		embConc int
+		embRPS  float64
+		retries int
+		noStore bool
+		maxText int
+		commits bool
+		vue     bool
+		gitOnly bool
+		calls   bool
+		callees []string
+		nodes   []string
+		skipGen bool
+		markers []string
+		tmpls   []string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "index",
 		Short: "Index a TypeScript project",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if project == "" {
-				return fmt.Errorf("--project is required")
+			project = resolveProject(cmd, project)
+			if !sqlvec.ValidQuantize(quant) {
+				return fmt.Errorf("--quantize must be %s or %s", sqlvec.QuantizeFloat32, sqlvec.QuantizeInt8)
+			}
+			if retries == 0 {
+				// the embedder reads zero as its default number of retries
+				retries = -1
 			}
 
 			// Create Fx app with configuration
@@ -34,7 +58,26 @@ func NewIndexCommand() *cobra.Command {
 				fx.Supply(
 					fx.Annotate(dbPath, fx.ResultTags(`name:"dbPath"`)),
 					fx.Annotate(embUrl, fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(model, fx.ResultTags(`name:"embedModel"`)),
 					fx.Annotate("", fx.ResultTags(`name:"project"`)),
+					fx.Annotate(enrich, fx.ResultTags(`name:"enrichWithLSP"`)),
+					fx.Annotate(symOnly, fx.ResultTags(`name:"symbolsOnly"`)),
+					fx.Annotate(reduce, fx.ResultTags(`name:"reduceDim"`)),
+					fx.Annotate(quant, fx.ResultTags(`name:"quantize"`)),
+					fx.Annotate(embConc, fx.ResultTags(`name:"embedConcurrency"`)),
+					fx.Annotate(embRPS, fx.ResultTags(`name:"embedRPS"`)),
+					fx.Annotate(retries, fx.ResultTags(`name:"embedRetries"`)),
+					fx.Annotate(noStore, fx.ResultTags(`name:"noStoreContent"`)),
+					fx.Annotate(maxText, fx.ResultTags(`name:"maxChunkContentBytes"`)),
+					fx.Annotate(commits, fx.ResultTags(`name:"fileCommits"`)),
+					fx.Annotate(vue, fx.ResultTags(`name:"vue"`)),
+					fx.Annotate(gitOnly, fx.ResultTags(`name:"gitOnly"`)),
+					fx.Annotate(calls || len(callees) > 0, fx.ResultTags(`name:"indexCallSites"`)),
+					fx.Annotate(callees, fx.ResultTags(`name:"callSiteCallees"`)),
+					fx.Annotate(nodes, fx.ResultTags(`name:"nodeKinds"`)),
+					fx.Annotate(skipGen || len(markers) > 0, fx.ResultTags(`name:"skipGenerated"`)),
+					fx.Annotate(markers, fx.ResultTags(`name:"generatedMarkers"`)),
+					fx.Annotate(tmpls, fx.ResultTags(`name:"embedTemplates"`)),
 				),
 				fx.Invoke(func(runner *cmdsfx.CommandRunner) error {
 					return runner.RunIndex(cmd.Context(), project)
@@ -60,12 +103,126 @@ func NewIndexCommand() *cobra.Command {
 		},
 	}
 
-	defaultEmbUrl := constants.DefaultEmbedURL
-	defaultDbPath := filepath.Join(os.TempDir(), "ts_index.db")
+	defaults := config.LoadDefaults()
 
-	cmd.Flags().StringVar(&project, "project", "", "Path to project root")
-	cmd.Flags().StringVar(&dbPath, "db", defaultDbPath, "SQLite DB path")
-	cmd.Flags().StringVar(&embUrl, "embed-url", defaultEmbUrl, "Embedding API URL")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().StringVar(&dbPath, "db", defaults.IndexDB(), "SQLite DB path ($"+config.DBEnvVar+")")
+	cmd.Flags().StringVar(&embUrl, "embed-url", defaults.EmbedURL, "Embedding API URL ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"Model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
+	cmd.Flags().BoolVar(
+		&enrich,
+		"enrich-lsp",
+		false,
+		"Enrich embeddings with language-server-resolved signatures (best-effort)",
+	)
+	cmd.Flags().BoolVar(
+		&symOnly,
+		"symbols-only",
+		false,
+		"Only build the symbol index; skip embedding (no embed server needed)",
+	)
+	cmd.Flags().IntVar(
+		&reduce,
+		"reduce-dim",
+		0,
+		"Store vectors randomly projected to this many dimensions (smaller DB, lower recall)",
+	)
+	cmd.Flags().StringVar(
+		&quant,
+		"quantize",
+		"",
+		"Store vectors as float32 or int8 (about 4x smaller, slightly less accurate ranking)",
+	)
+	cmd.Flags().IntVar(
+		&embConc,
+		"embed-concurrency",
+		embeddings.DefaultMaxConcurrentRequests,
+		"Maximum concurrent requests to the embedding API",
+	)
+	cmd.Flags().Float64Var(
+		&embRPS,
+		"embed-rps",
+		0,
+		"Maximum requests per second started against the embedding API (0: no limit)",
+	)
+	cmd.Flags().IntVar(
+		&retries,
+		"embed-retries",
+		embeddings.DefaultMaxRetries,
+		"Retries of embed requests failing with a server error, 408, 429 or a network error (0 disables)",
+	)
+	cmd.Flags().BoolVar(
+		&noStore,
+		"no-store-content",
+		false,
+		"Keep source code out of the DB; search results return locations only",
+	)
+	cmd.Flags().IntVar(
+		&maxText,
+		"max-chunk-content-bytes",
+		0,
+		"Cap the source stored per chunk, cutting huge symbols like generated enums (0: no cap)",
+	)
+	cmd.Flags().BoolVar(
+		&commits,
+		"file-commits",
+		false,
+		"Record the git commit that last modified each file (slower on long histories)",
+	)
+	cmd.Flags().BoolVar(
+		&vue,
+		"vue",
+		false,
+		"Also index the <script> blocks of .vue single-file components",
+	)
+	cmd.Flags().BoolVar(
+		&gitOnly,
+		"git-only",
+		false,
+		"Index only files tracked by git (falls back to walking the project outside a repository)",
+	)
+	cmd.Flags().BoolVar(
+		&calls,
+		"call-sites",
+		false,
+		"Also embed call expressions for usage search (calls to imported functions by default)",
+	)
+	cmd.Flags().StringArrayVar(
+		&callees,
+		"call-callee",
+		nil,
+		"Embed calls to this callee, e.g. fetch or axios.post (repeatable; implies --call-sites)",
+	)
+	cmd.Flags().StringArrayVar(
+		&nodes,
+		"node-kind",
+		nil,
+		"Also index a tree-sitter node kind as a symbol kind, e.g. abstract_class_declaration=class (repeatable)",
+	)
+	cmd.Flags().BoolVar(
+		&skipGen,
+		"skip-generated",
+		false,
+		"Skip files whose first lines carry a codegen marker such as \"Code generated ... DO NOT EDIT\" or @generated",
+	)
+	cmd.Flags().StringArrayVar(
+		&markers,
+		"generated-marker",
+		nil,
+		"Regular expression marking generated files, replacing the defaults (repeatable; implies --skip-generated)",
+	)
+	cmd.Flags().StringArrayVar(
+		&tmpls,
+		"embed-template",
+		nil,
+		`Embed text template for a language (ts, tsx, vue or * for the rest), e.g. 'ts={{.Signature}}\n{{.Content}}'`+
+			" (repeatable)",
+	)
 
 	return cmd
 }
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
+	"github.com/spf13/cobra"
+)
+
+func NewInfoCommand() *cobra.Command {
+	var dbPath string
+
+	cmd := &cobra.Command{
+		Use:   "info",
+		Short: "Show the embedding model and source version an index was built with",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(dbPath); err != nil {
+				return fmt.Errorf("index database not found: %w", err)
+			}
+
+			store, err := sqlvec.New(dbPath, 0)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = store.Close() }()
+
+			fmt.Printf("database:  %s\n", dbPath)
+			model, err := store.EmbeddingModel()
+			if err != nil {
+				return err
+			}
+			if model == nil {
+				fmt.Println("model:     not recorded (symbols only, or indexed by an older version)")
+			} else {
+				fmt.Printf("model:     %s\n", model.Name)
+				fmt.Printf("dimension: %d\n", model.Dimension)
+			}
+			prov, err := store.Provenance()
+			if err != nil {
+				return err
+			}
+			if prov != nil {
+				commit := prov.Commit
+				if prov.Dirty {
+					commit += " (with uncommitted changes)"
+				}
+				fmt.Printf("commit:    %s\n", commit)
+				fmt.Printf("indexed:   %s\n", prov.IndexedAt.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dbPath, "db", config.LoadDefaults().IndexDB(), "SQLite DB path ($"+config.DBEnvVar+")")
+
+	return cmd
+}
@@ -23,21 +23,32 @@ func NewLSPCommand() *cobra.Command {
 		newLSPImplementationCommand(),
 		newLSPTypeDefinitionCommand(),
 		newLSPDeclarationCommand(),
+		newLSPTypeHierarchyCommand(),
+		newLSPRenameCommand(),
 		newLSPInstallCommand(),
 		newLSPInstallByLanguageCommand(),
 		newLSPListCommand(),
 		newLSPHealthCommand(),
+		newLSPRawCommand(),
 	)
 
 	return lspCmd
 }
 
 func newLSPInfoCommand() *cobra.Command {
-	return &cobra.Command{
+	var project string
+
+	cmd := &cobra.Command{
 		Use:   "info",
 		Short: "Show LSP server information",
+		Long: "Show the language server adapters and running servers. Warns when the project has no\n" +
+			"tsconfig.json or jsconfig.json, which limits what the language server can resolve.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cli, err := mcpclient.NewStdioClient(cmd.Context())
+			project = resolveProject(cmd, project)
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
 			if err != nil {
 				return err
 			}
@@ -51,6 +62,10 @@ func newLSPInfoCommand() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+
+	return cmd
 }
 
 func newLSPAnalyzeCommand() *cobra.Command {
@@ -61,6 +76,9 @@ func newLSPAnalyzeCommand() *cobra.Command {
 		includeHover bool
 		includeRefs  bool
 		includeDefs  bool
+		relative     bool
+		output       string
+		pretty       bool
 	)
 
 	cmd := &cobra.Command{
@@ -68,38 +86,103 @@ func newLSPAnalyzeCommand() *cobra.Command {
 		Short: "Analyze symbol at position using LSP",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if project == "" {
-				return fmt.Errorf("--project is required")
+			project = resolveProject(cmd, project)
+			if pretty {
+				output = outputPretty
+			}
+			if output != outputJSON && output != outputPretty {
+				return fmt.Errorf("unknown --output %q, want json or pretty", output)
 			}
 
-			cli, err := mcpclient.NewStdioClient(cmd.Context())
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
 			if err != nil {
 				return err
 			}
 			defer func() { _ = cli.Close() }()
 			res, err := cli.Call(cmd.Context(), "lsp_analyze", map[string]any{
-				"file":      args[0],
-				"line":      lspLine,
-				"character": lspCharacter,
-				"hover":     includeHover,
-				"refs":      includeRefs,
-				"defs":      includeDefs,
+				"file":           args[0],
+				"line":           lspLine,
+				"character":      lspCharacter,
+				"hover":          includeHover,
+				"refs":           includeRefs,
+				"defs":           includeDefs,
+				"relative_paths": relative,
 			})
 			if err != nil {
 				return err
 			}
+			if output == outputPretty {
+				return printAnalyzePretty(cmd.OutOrStdout(), res.StructuredContent, project)
+			}
 			data, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
 			fmt.Println(string(data))
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&project, "project", "", "Path to project root")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
 	cmd.Flags().IntVar(&lspLine, "line", 0, "Line number (0-based)")
 	cmd.Flags().IntVar(&lspCharacter, "character", 0, "Character number (0-based)")
 	cmd.Flags().BoolVar(&includeHover, "hover", true, "Include hover information")
 	cmd.Flags().BoolVar(&includeRefs, "refs", false, "Include references")
 	cmd.Flags().BoolVar(&includeDefs, "defs", true, "Include definitions")
+	cmd.Flags().BoolVar(&relative, "relative", false, "Report project-relative paths")
+	cmd.Flags().StringVar(&output, "output", outputJSON, "Output format (json, pretty)")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "Shorthand for --output pretty")
+
+	return cmd
+}
+
+func newLSPRawCommand() *cobra.Command {
+	var (
+		project string
+		method  string
+		params  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "raw [file-path]",
+		Short: "Send a request to the language server and print its raw result",
+		Long: "Send any LSP request for a file and print the result exactly as the language server returned\n" +
+			"it, to debug results that look wrong. For textDocument/ methods the file's textDocument is\n" +
+			"added to --params unless given, e.g.\n\n" +
+			"  ts-index lsp raw src/a.ts --method textDocument/hover --params '{\"position\":{\"line\":0,\"character\":16}}'",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project = resolveProject(cmd, project)
+			if method == "" {
+				return fmt.Errorf("--method is required")
+			}
+
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = cli.Close() }()
+			res, err := cli.Call(cmd.Context(), "lsp_raw", map[string]any{
+				"file":   args[0],
+				"method": method,
+				"params": params,
+			})
+			if err != nil {
+				return err
+			}
+			data, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().StringVar(&method, "method", "", "LSP method, e.g. textDocument/hover")
+	cmd.Flags().StringVar(&params, "params", "", "Request params as a JSON object (0-based positions)")
 
 	return cmd
 }
@@ -110,6 +193,8 @@ func newLSPCompletionCommand() *cobra.Command {
 		lspLine      int
 		lspCharacter int
 		maxResults   int
+		retry        bool
+		groupByKind  bool
 	)
 
 	cmd := &cobra.Command{
@@ -117,20 +202,23 @@ func newLSPCompletionCommand() *cobra.Command {
 		Short: "Get completion items at position using LSP",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if project == "" {
-				return fmt.Errorf("--project is required")
-			}
+			project = resolveProject(cmd, project)
 
-			cli, err := mcpclient.NewStdioClient(cmd.Context())
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
 			if err != nil {
 				return err
 			}
 			defer func() { _ = cli.Close() }()
 			res, err := cli.Call(cmd.Context(), "lsp_completion", map[string]any{
-				"file":        args[0],
-				"line":        lspLine,
-				"character":   lspCharacter,
-				"max_results": maxResults,
+				"file":             args[0],
+				"line":             lspLine,
+				"character":        lspCharacter,
+				"max_results":      maxResults,
+				"retry_incomplete": retry,
+				"group_by_kind":    groupByKind,
 			})
 			if err != nil {
 				return err
@@ -141,10 +229,12 @@ func newLSPCompletionCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&project, "project", "", "Path to project root")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
 	cmd.Flags().IntVar(&lspLine, "line", 0, "Line number (0-based)")
 	cmd.Flags().IntVar(&lspCharacter, "character", 0, "Character number (0-based)")
 	cmd.Flags().IntVar(&maxResults, "max-results", 20, "Maximum number of results")
+	cmd.Flags().BoolVar(&retry, "retry-incomplete", false, "Re-request once if the list is incomplete")
+	cmd.Flags().BoolVar(&groupByKind, "group-by-kind", false, "Group the items by completion kind")
 
 	return cmd
 }
@@ -154,27 +244,30 @@ func newLSPSymbolCommand() *cobra.Command {
 		project    string
 		query      string
 		maxResults int
+		relative   bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "symbols",
 		Short: "Search workspace symbols using LSP",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if project == "" {
-				return fmt.Errorf("--project is required")
-			}
+			project = resolveProject(cmd, project)
 			if query == "" {
 				return fmt.Errorf("--query is required")
 			}
 
-			cli, err := mcpclient.NewStdioClient(cmd.Context())
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
 			if err != nil {
 				return err
 			}
 			defer func() { _ = cli.Close() }()
 			res, err := cli.Call(cmd.Context(), "lsp_symbols", map[string]any{
-				"query":       query,
-				"max_results": maxResults,
+				"query":          query,
+				"max_results":    maxResults,
+				"relative_paths": relative,
 			})
 			if err != nil {
 				return err
@@ -185,9 +278,10 @@ func newLSPSymbolCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&project, "project", "", "Path to project root")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
 	cmd.Flags().StringVar(&query, "query", "", "Search query")
 	cmd.Flags().IntVar(&maxResults, "max-results", 50, "Maximum number of results")
+	cmd.Flags().BoolVar(&relative, "relative", false, "Report project-relative paths")
 
 	return cmd
 }
@@ -197,25 +291,28 @@ func newLSPGotoCommand(use, short, mcpMethod string) *cobra.Command {
 	var project string
 	var lspLine int
 	var lspCharacter int
+	var relative bool
 
 	cmd := &cobra.Command{
 		Use:   use + " [file-path]",
 		Short: short,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if project == "" {
-				return fmt.Errorf("--project is required")
-			}
+			project = resolveProject(cmd, project)
 
-			cli, err := mcpclient.NewStdioClient(cmd.Context())
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
 			if err != nil {
 				return err
 			}
 			defer func() { _ = cli.Close() }()
 			res, err := cli.Call(cmd.Context(), mcpMethod, map[string]any{
-				"file":      args[0],
-				"line":      lspLine,
-				"character": lspCharacter,
+				"file":           args[0],
+				"line":           lspLine,
+				"character":      lspCharacter,
+				"relative_paths": relative,
 			})
 			if err != nil {
 				return err
@@ -226,9 +323,10 @@ func newLSPGotoCommand(use, short, mcpMethod string) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&project, "project", "", "Path to project root")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
 	cmd.Flags().IntVar(&lspLine, "line", 0, "Line number (0-based)")
 	cmd.Flags().IntVar(&lspCharacter, "character", 0, "Character number (0-based)")
+	cmd.Flags().BoolVar(&relative, "relative", false, "Report project-relative paths")
 
 	return cmd
 }
@@ -257,6 +355,108 @@ func newLSPDeclarationCommand() *cobra.Command {
 	)
 }
 
+func newLSPTypeHierarchyCommand() *cobra.Command {
+	var (
+		project      string
+		lspLine      int
+		lspCharacter int
+		direction    string
+		depth        int
+		relative     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "type-hierarchy [file-path]",
+		Short: "Show the supertypes and subtypes of the class or interface at position using LSP",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project = resolveProject(cmd, project)
+
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = cli.Close() }()
+			res, err := cli.Call(cmd.Context(), "type_hierarchy", map[string]any{
+				"file":           args[0],
+				"line":           lspLine,
+				"character":      lspCharacter,
+				"direction":      direction,
+				"depth":          depth,
+				"relative_paths": relative,
+			})
+			if err != nil {
+				return err
+			}
+			data, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().IntVar(&lspLine, "line", 0, "Line number (0-based)")
+	cmd.Flags().IntVar(&lspCharacter, "character", 0, "Character number (0-based)")
+	cmd.Flags().StringVar(&direction, "direction", lsp.TypeHierarchyBoth, "supertypes, subtypes or both")
+	cmd.Flags().IntVar(&depth, "depth", lsp.DefaultTypeHierarchyDepth, "Levels to follow in each direction")
+	cmd.Flags().BoolVar(&relative, "relative", false, "Report project-relative paths")
+
+	return cmd
+}
+
+func newLSPRenameCommand() *cobra.Command {
+	var (
+		project      string
+		lspLine      int
+		lspCharacter int
+		newName      string
+		apply        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rename [file-path]",
+		Short: "Rename symbol at position using LSP, previewing the diff unless --apply is set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project = resolveProject(cmd, project)
+			if newName == "" {
+				return fmt.Errorf("--new-name is required")
+			}
+
+			cli, err := mcpclient.NewStdioClientWithConfig(
+				cmd.Context(),
+				mcpclient.ServerConfig{Project: project},
+			)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = cli.Close() }()
+			res, err := cli.Call(cmd.Context(), "lsp_rename", map[string]any{
+				"file":      args[0],
+				"line":      lspLine,
+				"character": lspCharacter,
+				"new_name":  newName,
+				"dry_run":   !apply,
+			})
+			if err != nil {
+				return err
+			}
+			return printRename(cmd.OutOrStdout(), res.StructuredContent, apply)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().IntVar(&lspLine, "line", 0, "Line number (0-based)")
+	cmd.Flags().IntVar(&lspCharacter, "character", 0, "Character number (0-based)")
+	cmd.Flags().StringVar(&newName, "new-name", "", "New symbol name")
+	cmd.Flags().BoolVar(&apply, "apply", false, "Write the edits instead of printing their diff")
+
+	return cmd
+}
+
 func newLSPInstallCommand() *cobra.Command {
 	var (
 		installVersion string
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/logging"
+	"github.com/0x5457/ts-index/internal/lsp"
+)
+
+const (
+	outputJSON   = "json"
+	outputPretty = "pretty"
+)
+
+// maxSnippetLen bounds the code shown next to each location
+const maxSnippetLen = 120
+
+// printAnalyzePretty renders an lsp_analyze result for the terminal: the hover as
+// plain text and each location as path:line:col with its source line
+func printAnalyzePretty(w io.Writer, structured any, project string) error {
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return err
+	}
+	var res lsp.AnalyzeSymbolResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	if res.Error != "" {
+		return fmt.Errorf("%s", res.Error)
+	}
+
+	if res.Hover != nil && res.Hover.Contents != "" {
+		_, _ = fmt.Fprintln(w, "Hover:")
+		for _, line := range strings.Split(plainHover(res.Hover.Contents), "\n") {
+			_, _ = fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	printLocations(w, "Definitions", res.Definitions, project)
+	printLocations(w, "References", res.References, project)
+	printLocations(w, "Implementations", res.Implementations, project)
+	printLocations(w, "Type definitions", res.TypeDefinitions, project)
+	printLocations(w, "Declarations", res.Declarations, project)
+	return nil
+}
+
+func printLocations(w io.Writer, title string, locations []lsp.LocationResult, project string) {
+	if len(locations) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s (%d):\n", title, len(locations))
+	for _, loc := range locations {
+		display, path := locationPaths(loc, project)
+		pos := loc.Range.Start
+		line := fmt.Sprintf("  %s:%d:%d", display, pos.Line+1, pos.Character+1)
+		if snippet := readSourceLine(path, pos.Line); snippet != "" {
+			line += "  " + snippet
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+// locationPaths returns the path to show for loc, relative to project when
+// possible, and the path to read its source from
+func locationPaths(loc lsp.LocationResult, project string) (string, string) {
+	uri := loc.URI
+	if loc.AbsoluteURI != "" {
+		uri = loc.AbsoluteURI
+	}
+	if !strings.HasPrefix(uri, "file://") {
+		return uri, filepath.Join(project, filepath.FromSlash(uri))
+	}
+	path := lsp.URIToPath(uri)
+	if absProject, err := filepath.Abs(project); err == nil {
+		if rel, err := filepath.Rel(absProject, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel, path
+		}
+	}
+	return path, path
+}
+
+// readSourceLine returns the trimmed 0-based line of path, or "" if it cannot be read
+func readSourceLine(path string, line int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for i := 0; scanner.Scan(); i++ {
+		if i == line {
+			text := strings.TrimSpace(scanner.Text())
+			if runes := []rune(text); len(runes) > maxSnippetLen {
+				text = string(runes[:maxSnippetLen]) + "…"
+			}
+			return text
+		}
+	}
+	return ""
+}
+
+// plainHover strips markdown code fences from hover contents
+func plainHover(contents string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(contents), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// printRename prints the diffs of a previewed lsp_rename, or the files an
+// applied one rewrote
+func printRename(w io.Writer, structured any, applied bool) error {
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Files        []string       `json:"files"`
+		Diffs        []lsp.FileDiff `json:"diffs"`
+		ReindexError string         `json:"reindex_error"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	if len(res.Files) == 0 {
+		_, _ = fmt.Fprintln(w, "No changes")
+		return nil
+	}
+	if !applied {
+		for _, d := range res.Diffs {
+			_, _ = fmt.Fprint(w, d.Diff)
+		}
+		_, _ = fmt.Fprintf(w, "\n%d file(s) would change; rerun with --apply to write them\n", len(res.Files))
+		return nil
+	}
+	_, _ = fmt.Fprintf(w, "Renamed in %d file(s):\n", len(res.Files))
+	for _, f := range res.Files {
+		_, _ = fmt.Fprintf(w, "  %s\n", f)
+	}
+	if res.ReindexError != "" {
+		logging.L().Warn("renamed files were not reindexed", "error", res.ReindexError)
+	}
+	return nil
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/0x5457/ts-index/cmd/cmdsfx"
 	"github.com/0x5457/ts-index/internal/app/appfx"
-	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/0x5457/ts-index/internal/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -17,12 +22,23 @@ import (
 func NewMCPServeCommand() *cobra.Command {
 	var (
 		project   string
-		db        string
+		dbs       []string
 		embedURL  string
+		model     string
 		transport string
 		address   string
+		syncSyms  bool
+		cacheSize int
+		cacheTTL  time.Duration
+		sgConfig  string
+		rerank    bool
+		embedRPS  float64
+		readOnly  bool
+		timeouts  = cmdsfx.DefaultHTTPTimeouts()
 	)
 
+	defaults := config.LoadDefaults()
+
 	cmd := &cobra.Command{
 		Use:   "mcp",
 		Short: "Run MCP server",
@@ -30,9 +46,20 @@ func NewMCPServeCommand() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Set default values
 			if embedURL == "" {
-				embedURL = constants.DefaultEmbedURL
+				embedURL = defaults.EmbedURL
+			}
+
+			// The first --db is the primary index; the rest are only searched
+			var db string
+			var searchDBs []string
+			if len(dbs) > 0 {
+				db, searchDBs = dbs[0], dbs[1:]
 			}
 
+			// SIGINT and SIGTERM stop the server, letting in-flight requests finish
+			serveCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			// Create result channel for server errors
 			resultCh := make(chan error, 1)
 
@@ -42,13 +69,22 @@ func NewMCPServeCommand() *cobra.Command {
 				fx.Supply(
 					fx.Annotate(db, fx.ResultTags(`name:"dbPath"`)),
 					fx.Annotate(embedURL, fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(model, fx.ResultTags(`name:"embedModel"`)),
 					fx.Annotate(project, fx.ResultTags(`name:"project"`)),
+					fx.Annotate(searchDBs, fx.ResultTags(`name:"searchDBPaths"`)),
+					fx.Annotate(syncSyms, fx.ResultTags(`name:"syncLSPSymbols"`)),
+					fx.Annotate(cacheSize, fx.ResultTags(`name:"searchCacheSize"`)),
+					fx.Annotate(cacheTTL, fx.ResultTags(`name:"searchCacheTTL"`)),
+					fx.Annotate(sgConfig, fx.ResultTags(`name:"astGrepConfig"`)),
+					fx.Annotate(rerank, fx.ResultTags(`name:"rerank"`)),
+					fx.Annotate(embedRPS, fx.ResultTags(`name:"embedRPS"`)),
+					fx.Annotate(readOnly, fx.ResultTags(`name:"readOnly"`)),
 				),
 				fx.Invoke(func(lc fx.Lifecycle, runner *cmdsfx.CommandRunner) {
 					lc.Append(fx.Hook{
 						OnStart: func(ctx context.Context) error {
 							go func() {
-								resultCh <- runner.RunMCPServer(transport, address)
+								resultCh <- runner.RunMCPServer(serveCtx, transport, address, timeouts)
 							}()
 							return nil
 						},
@@ -71,11 +107,20 @@ func NewMCPServeCommand() *cobra.Command {
 					fx.Supply(
 						fx.Annotate(db, fx.ResultTags(`name:"dbPath"`)),
 						fx.Annotate(embedURL, fx.ResultTags(`name:"embedURL"`)),
+						fx.Annotate(model, fx.ResultTags(`name:"embedModel"`)),
 						fx.Annotate(project, fx.ResultTags(`name:"project"`)),
+						fx.Annotate(searchDBs, fx.ResultTags(`name:"searchDBPaths"`)),
+						fx.Annotate(syncSyms, fx.ResultTags(`name:"syncLSPSymbols"`)),
+						fx.Annotate(cacheSize, fx.ResultTags(`name:"searchCacheSize"`)),
+						fx.Annotate(cacheTTL, fx.ResultTags(`name:"searchCacheTTL"`)),
+						fx.Annotate(sgConfig, fx.ResultTags(`name:"astGrepConfig"`)),
+						fx.Annotate(rerank, fx.ResultTags(`name:"rerank"`)),
+						fx.Annotate(embedRPS, fx.ResultTags(`name:"embedRPS"`)),
+						fx.Annotate(readOnly, fx.ResultTags(`name:"readOnly"`)),
 					),
 					fx.Invoke(func(srv *server.MCPServer) {
 						sh := server.NewStreamableHTTPServer(srv)
-						http.Handle("/mcp", sh)
+						http.Handle("/mcp", mcp.GzipHandler(sh))
 					}),
 				}
 
@@ -88,7 +133,8 @@ func NewMCPServeCommand() *cobra.Command {
 					return fmt.Errorf("failed to start application: %w", err)
 				}
 
-				return http.ListenAndServe(address, nil)
+				err := cmdsfx.ServeHTTP(serveCtx, cmdsfx.NewHTTPServer(address, nil, timeouts), timeouts)
+				return stopApp(app, err)
 			}
 
 			// Start the app
@@ -99,23 +145,111 @@ func NewMCPServeCommand() *cobra.Command {
 				return fmt.Errorf("failed to start application: %w", err)
 			}
 
-			// Wait for server result or context cancellation
-			select {
-			case err := <-resultCh:
-				return err
-			case <-cmd.Context().Done():
-				return cmd.Context().Err()
-			}
+			// The server returns once serveCtx is done and it has shut down
+			return stopApp(app, <-resultCh)
 		},
 	}
 
+	var defaultDBs []string
+	if defaults.DB != "" {
+		defaultDBs = []string{defaults.DB}
+	}
 	cmd.Flags().StringVarP(&project, "project", "p", "", "project path")
-	cmd.Flags().StringVarP(&db, "db", "d", "", "SQLite database path")
+	cmd.Flags().StringArrayVarP(
+		&dbs,
+		"db",
+		"d",
+		defaultDBs,
+		"SQLite database path (repeatable; additional databases are searched together; $"+config.DBEnvVar+")",
+	)
 	cmd.Flags().
-		StringVar(&embedURL, "embed-url", constants.DefaultEmbedURL, "embed API address")
+		StringVar(&embedURL, "embed-url", defaults.EmbedURL, "embed API address ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
 	cmd.Flags().
 		StringVarP(&transport, "transport", "t", "stdio", "transport (stdio, http, sse, http-handler)")
 	cmd.Flags().StringVarP(&address, "address", "a", "", "server address (http modes), e.g. :8080")
+	cmd.Flags().BoolVar(
+		&syncSyms,
+		"sync-lsp-symbols",
+		false,
+		"Store language server symbols of every project file in the index after startup",
+	)
+	cmd.Flags().IntVar(
+		&cacheSize,
+		"search-cache-size",
+		128,
+		"Number of recent semantic search results to reuse for repeated queries (0 disables the cache)",
+	)
+	cmd.Flags().DurationVar(
+		&cacheTTL,
+		"search-cache-ttl",
+		5*time.Minute,
+		"How long a cached search result may be reused; results are also dropped when the index changes",
+	)
+	cmd.Flags().StringVar(
+		&sgConfig,
+		"ast-grep-config",
+		"",
+		"ast-grep sgconfig.yml with custom languages and rule directories (default: the project's sgconfig.yml)",
+	)
+	cmd.Flags().BoolVar(
+		&rerank,
+		"rerank",
+		false,
+		"Rerank semantic search hits by name matches, test files, call sites and length",
+	)
+	cmd.Flags().Float64Var(
+		&embedRPS,
+		"embed-rps",
+		0,
+		"Maximum requests per second started against the embedding API, shared by indexing and search (0: no limit)",
+	)
+	cmd.Flags().BoolVar(
+		&readOnly,
+		"read-only",
+		false,
+		"Open the index read-only, to search a database another process is indexing; tools that write fail",
+	)
+	cmd.Flags().DurationVar(
+		&timeouts.Read,
+		"read-timeout",
+		timeouts.Read,
+		"Longest time to read an HTTP request, body included (http modes; 0 disables)",
+	)
+	cmd.Flags().DurationVar(
+		&timeouts.Write,
+		"write-timeout",
+		timeouts.Write,
+		"Longest time to write an HTTP response (http modes; 0 disables, which SSE streams need)",
+	)
+	cmd.Flags().DurationVar(
+		&timeouts.Idle,
+		"idle-timeout",
+		timeouts.Idle,
+		"How long an idle keep-alive connection stays open (http modes; 0 uses the read timeout)",
+	)
+	cmd.Flags().DurationVar(
+		&timeouts.Shutdown,
+		"shutdown-timeout",
+		timeouts.Shutdown,
+		"How long in-flight requests get to finish after SIGINT or SIGTERM (http modes)",
+	)
 
 	return cmd
 }
+
+// stopApp stops app after its server returned serveErr, reporting serveErr
+// first
+func stopApp(app *fx.App, serveErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+	if err := app.Stop(ctx); err != nil && serveErr == nil {
+		return fmt.Errorf("failed to stop application: %w", err)
+	}
+	return serveErr
+}
@@ -9,7 +9,7 @@ import (
 	"time"
 
 	"github.com/0x5457/ts-index/internal/app/appfx"
-	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/0x5457/ts-index/internal/config"
 	"github.com/0x5457/ts-index/internal/indexer"
 	appmcp "github.com/0x5457/ts-index/internal/mcp"
 	"github.com/0x5457/ts-index/internal/search"
@@ -48,6 +48,7 @@ func newMCPCallCommand() *cobra.Command {
 		project   string
 		db        string
 		embedURL  string
+		model     string
 		transport string
 		address   string
 	)
@@ -65,9 +66,21 @@ Examples:
   # LSP analyze
   ts-index mcp-client call lsp_analyze file="src/index.ts" line=10 character=5
 
+  # LSP rename (edits are applied and the modified files reindexed)
+  ts-index mcp-client call lsp_rename file="src/index.ts" line=10 character=5 new_name="newName"
+
+  # LSP rename preview (only the unified diffs are returned)
+  ts-index mcp-client call lsp_rename file="src/index.ts" line=10 character=5 new_name="newName" dry_run=true
+
   # AST grep search
   ts-index mcp-client call ast_grep_search pattern="function $$name" language="typescript"
 
+  # Run every ast-grep rule in .ts-index/lint/strict
+  ts-index mcp-client call ast_grep_lint profile="strict"
+
+  # Show node kinds of a snippet to help write a pattern
+  ts-index mcp-client call ast_grep_dump_tree code="const x = await f()" format="ast"
+
   # List available tools
   ts-index mcp-client call --list-tools`,
 		Args: cobra.MinimumNArgs(1),
@@ -108,9 +121,10 @@ Examples:
 			defer cancel()
 
 			config := appmcp.ServerConfig{
-				Project:  project,
-				DB:       db,
-				EmbedURL: embedURL,
+				Project:    project,
+				DB:         db,
+				EmbedURL:   embedURL,
+				EmbedModel: model,
 			}
 			client, err := createMCPClient(ctx, transport, address, config)
 			if err != nil {
@@ -134,8 +148,16 @@ Examples:
 	}
 
 	cmd.Flags().StringVarP(&project, "project", "p", "", "project path")
-	cmd.Flags().StringVarP(&db, "db", "d", "", "SQLite database path")
-	cmd.Flags().StringVar(&embedURL, "embed-url", constants.DefaultEmbedURL, "embed API address")
+	defaults := config.LoadDefaults()
+	cmd.Flags().StringVarP(&db, "db", "d", defaults.DB, "SQLite database path ($"+config.DBEnvVar+")")
+	cmd.Flags().
+		StringVar(&embedURL, "embed-url", defaults.EmbedURL, "embed API address ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
 	cmd.Flags().
 		StringVarP(&transport, "transport", "t", transportStdio, "transport (stdio, http, sse, inproc)")
 	cmd.Flags().
@@ -172,6 +194,7 @@ func createMCPClient(
 				fx.Supply(
 					fx.Annotate(config.DB, fx.ResultTags(`name:"dbPath"`)),
 					fx.Annotate(config.EmbedURL, fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(config.EmbedModel, fx.ResultTags(`name:"embedModel"`)),
 					fx.Annotate(config.Project, fx.ResultTags(`name:"project"`)),
 				),
 				fx.Populate(&searchService, &indexer),
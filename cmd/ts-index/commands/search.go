@@ -2,24 +2,35 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 
-	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/0x5457/ts-index/internal/logging"
 	mcpclient "github.com/0x5457/ts-index/internal/mcp"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
 )
 
+// Output formats of the search command
+const (
+	searchFormatJSON = "json"
+	searchFormatLSP  = "lsp"
+)
+
 func NewSearchCommand() *cobra.Command {
 	var (
 		project   string
-		dbPath    string
+		dbPaths   []string
 		embUrl    string
+		model     string
 		topK      int
 		symbol    bool
 		transport string
 		address   string
+		format    string
+		rerank    bool
 	)
 
 	cmd := &cobra.Command{
@@ -28,12 +39,31 @@ func NewSearchCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			query := args[0]
+			project = resolveProject(cmd, project)
+			if format != searchFormatJSON && format != searchFormatLSP {
+				return fmt.Errorf("unknown --format %q, want json or lsp", format)
+			}
+			toolFormat := "hits"
+			if format == searchFormatLSP {
+				toolFormat = searchFormatLSP
+			}
+			// The first --db is the primary index; the rest are searched together with it
+			dbPath := dbPaths[0]
 			// choose transport
 			var cli *mcpclient.Client
 			var err error
 			switch transport {
 			case "", "stdio":
-				cli, err = mcpclient.NewStdioClient(cmd.Context())
+				// read-only, as an indexer may be updating the index meanwhile
+				cli, err = mcpclient.NewStdioClientWithConfig(cmd.Context(), mcpclient.ServerConfig{
+					Project:    project,
+					DB:         dbPath,
+					EmbedURL:   embUrl,
+					EmbedModel: model,
+					SearchDBs:  dbPaths[1:],
+					Rerank:     rerank,
+					ReadOnly:   true,
+				})
 			case "http":
 				addr := address
 				if addr == "" {
@@ -59,18 +89,17 @@ func NewSearchCommand() *cobra.Command {
 
 			if symbol {
 				res, err := cli.Call(cmd.Context(), "symbol_search", map[string]any{
-					"name": query,
-					"db":   dbPath,
+					"name":   query,
+					"db":     dbPath,
+					"format": toolFormat,
 				})
 				if err != nil {
 					return err
 				}
 				if res.IsError {
-					b, _ := json.Marshal(res.StructuredContent)
-					return fmt.Errorf("%s", string(b))
+					return toolError(res)
 				}
-				b, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
-				fmt.Println(string(b))
+				printSearchResult(res.StructuredContent, format)
 				return nil
 			}
 
@@ -80,31 +109,83 @@ func NewSearchCommand() *cobra.Command {
 				"embed_url": embUrl,
 				"top_k":     topK,
 				"project":   project,
+				"format":    toolFormat,
 			})
 			if err != nil {
 				return err
 			}
 			if res.IsError {
-				b, _ := json.Marshal(res.StructuredContent)
-				return fmt.Errorf("%s", string(b))
+				return toolError(res)
 			}
-			b, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
-			fmt.Println(string(b))
+			if content, ok := res.StructuredContent.(map[string]any); ok {
+				if warning, ok := content["warning"].(string); ok {
+					logging.L().Warn(warning)
+				}
+			}
+			printSearchResult(res.StructuredContent, format)
 			return nil
 		},
 	}
 
-	defaultEmbUrl := constants.DefaultEmbedURL
-	defaultDbPath := filepath.Join(os.TempDir(), "ts_index.db")
+	defaults := config.LoadDefaults()
 
-	cmd.Flags().
-		StringVar(&project, "project", "", "Path to project root (optional to build memory index)")
-	cmd.Flags().StringVar(&dbPath, "db", defaultDbPath, "SQLite DB path")
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().StringArrayVar(
+		&dbPaths,
+		"db",
+		[]string{defaults.IndexDB()},
+		"SQLite DB path (repeatable to search several indexes at once; $"+config.DBEnvVar+")",
+	)
 	cmd.Flags().IntVar(&topK, "top-k", 5, "Top K results")
 	cmd.Flags().BoolVar(&symbol, "symbol", false, "Use exact symbol name search")
-	cmd.Flags().StringVar(&embUrl, "embed-url", defaultEmbUrl, "Embedding API URL")
+	cmd.Flags().StringVar(&embUrl, "embed-url", defaults.EmbedURL, "Embedding API URL ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"Model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
 	cmd.Flags().StringVarP(&transport, "transport", "t", "stdio", "transport (stdio, http, sse)")
 	cmd.Flags().StringVarP(&address, "address", "a", "", "server URL (http/sse)")
+	cmd.Flags().StringVar(
+		&format,
+		"format",
+		searchFormatJSON,
+		"Output format: json, or lsp for an array of LSP Locations (file URIs, 0-based lines)",
+	)
+	cmd.Flags().BoolVar(
+		&rerank,
+		"rerank",
+		false,
+		"Rerank semantic hits by name matches, test files, call sites and length (stdio transport)",
+	)
 
 	return cmd
 }
+
+// printSearchResult prints a search tool result as indented JSON; in the lsp
+// format only its array of locations
+func printSearchResult(structured any, format string) {
+	out := structured
+	if content, ok := structured.(map[string]any); ok && format == searchFormatLSP {
+		out = content["locations"]
+	}
+	b, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(b))
+}
+
+// toolError returns the error a failed tool call reports, as text or as its
+// structured content
+func toolError(res *mcp.CallToolResult) error {
+	if res.StructuredContent == nil {
+		var texts []string
+		for _, content := range res.Content {
+			if text, ok := mcp.AsTextContent(content); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		return errors.New(strings.Join(texts, "\n"))
+	}
+	b, _ := json.Marshal(res.StructuredContent)
+	return errors.New(string(b))
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x5457/ts-index/cmd/cmdsfx"
+	"github.com/0x5457/ts-index/internal/app/appfx"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func NewSearchAdhocCommand() *cobra.Command {
+	var (
+		project string
+		embUrl  string
+		model   string
+		topK    int
+		vue     bool
+		gitOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search-adhoc [query]",
+		Short: "Index a project in memory and run one semantic search, without writing a DB",
+		Long: "Parse and embed the project into an in-memory index, answer a single query and discard the index.\n" +
+			"Every run embeds the whole project again; use index and search to keep the index.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := args[0]
+			project = resolveProject(cmd, project)
+			if topK <= 0 {
+				return fmt.Errorf("--top-k must be positive")
+			}
+
+			app := fx.New(
+				appfx.Module,
+				fx.Supply(
+					fx.Annotate("", fx.ResultTags(`name:"dbPath"`)),
+					fx.Annotate(true, fx.ResultTags(`name:"inMemory"`)),
+					fx.Annotate(embUrl, fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(model, fx.ResultTags(`name:"embedModel"`)),
+					fx.Annotate("", fx.ResultTags(`name:"project"`)),
+					fx.Annotate(vue, fx.ResultTags(`name:"vue"`)),
+					fx.Annotate(gitOnly, fx.ResultTags(`name:"gitOnly"`)),
+				),
+				fx.Invoke(func(runner *cmdsfx.CommandRunner) error {
+					return runner.RunAdhocSearch(cmd.Context(), project, query, topK)
+				}),
+			)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			if err := app.Start(ctx); err != nil {
+				return fmt.Errorf("failed to start application: %w", err)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), fx.DefaultTimeout)
+			defer cancel()
+
+			if err := app.Stop(ctx); err != nil {
+				return fmt.Errorf("failed to stop application: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	defaults := config.LoadDefaults()
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().StringVar(&embUrl, "embed-url", defaults.EmbedURL, "Embedding API URL ($"+config.EmbedURLEnvVar+")")
+	cmd.Flags().StringVar(
+		&model,
+		"embed-model",
+		defaults.EmbedModel,
+		"Model name sent with embed requests ($"+config.EmbedModelEnvVar+")",
+	)
+	cmd.Flags().IntVar(&topK, "top-k", 5, "Top K results")
+	cmd.Flags().BoolVar(
+		&vue,
+		"vue",
+		false,
+		"Also index the <script> blocks of .vue single-file components",
+	)
+	cmd.Flags().BoolVar(
+		&gitOnly,
+		"git-only",
+		false,
+		"Index only files tracked by git (falls back to walking the project outside a repository)",
+	)
+
+	return cmd
+}
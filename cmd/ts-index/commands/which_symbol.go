@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/0x5457/ts-index/cmd/cmdsfx"
+	"github.com/0x5457/ts-index/internal/app/appfx"
+	"github.com/0x5457/ts-index/internal/config"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func NewWhichSymbolCommand() *cobra.Command {
+	var (
+		project   string
+		dbPath    string
+		line      int
+		character int
+		asJSON    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "which-symbol <file>",
+		Short: "Show the symbols enclosing a position, without a language server",
+		Long: "Report the innermost symbol containing a position of a file, followed by the symbols around it.\n" +
+			"Symbols stored in the index are matched by line. Files that are not indexed, or positions no\n" +
+			"stored symbol encloses, are parsed on the fly and matched by character as well.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project = resolveProject(cmd, project)
+			file, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(dbPath); err != nil {
+				// parse instead of creating an empty index
+				dbPath = ""
+			}
+
+			app := fx.New(
+				appfx.Module,
+				fx.Supply(
+					fx.Annotate(dbPath, fx.ResultTags(`name:"dbPath"`)),
+					fx.Annotate("", fx.ResultTags(`name:"embedURL"`)),
+					fx.Annotate(project, fx.ResultTags(`name:"project"`)),
+				),
+				fx.Invoke(func(runner *cmdsfx.CommandRunner) error {
+					return runner.RunWhichSymbol(project, file, line, character, asJSON)
+				}),
+			)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			if err := app.Start(ctx); err != nil {
+				return fmt.Errorf("failed to start application: %w", err)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), fx.DefaultTimeout)
+			defer cancel()
+
+			if err := app.Stop(ctx); err != nil {
+				return fmt.Errorf("failed to stop application: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", projectUsage)
+	cmd.Flags().StringVar(&dbPath, "db", config.LoadDefaults().IndexDB(), "SQLite DB path ($"+config.DBEnvVar+")")
+	cmd.Flags().IntVar(&line, "line", 0, "Line number (0-based)")
+	cmd.Flags().IntVar(&character, "character", 0, "Character number (0-based)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the symbols as JSON")
+
+	return cmd
+}
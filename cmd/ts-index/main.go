@@ -1,9 +1,11 @@
 package main
 
 import (
-	"log"
+	"os"
 
 	"github.com/0x5457/ts-index/cmd/ts-index/commands"
+	"github.com/0x5457/ts-index/internal/logging"
+	"github.com/0x5457/ts-index/internal/lsp"
 	"github.com/spf13/cobra"
 )
 
@@ -15,16 +17,46 @@ func main() {
 		and performing semantic search with Language Server Protocol support.`,
 	}
 
+	// Exported through the environment so spawned MCP servers inherit it
+	var shellEnv bool
+	rootCmd.PersistentFlags().BoolVar(&shellEnv, "shell-env", false,
+		"Load PATH and other variables from the login shell for language servers")
+	var logLevel string
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "",
+		"Log level on stderr: debug, info, warn or error (default warn, or $"+logging.LevelEnvVar+")")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("log-level") {
+			level, err := logging.ParseLevel(logLevel)
+			if err != nil {
+				return err
+			}
+			logging.SetLogger(logging.New(os.Stderr, level))
+			if err := os.Setenv(logging.LevelEnvVar, logLevel); err != nil {
+				return err
+			}
+		}
+		if shellEnv {
+			return os.Setenv(lsp.ShellEnvVar, "1")
+		}
+		return nil
+	}
+
 	// Add all command modules - now using Fx for dependency injection
 	rootCmd.AddCommand(
 		commands.NewIndexCommand(),
 		commands.NewSearchCommand(),
+		commands.NewSearchAdhocCommand(),
+		commands.NewEvalCommand(),
+		commands.NewWhichSymbolCommand(),
+		commands.NewInfoCommand(),
+		commands.NewCompactCommand(),
 		commands.NewLSPCommand(),
 		commands.NewMCPServeCommand(),
 		commands.NewMCPClientCommand(),
 	)
 
+	// cobra has already printed the error
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		os.Exit(1)
 	}
 }
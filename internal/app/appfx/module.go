@@ -1,15 +1,19 @@
 package appfx
 
 import (
+	"log/slog"
+
 	"github.com/0x5457/ts-index/cmd/cmdsfx"
 	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/embeddings/embeddingsfx"
 	"github.com/0x5457/ts-index/internal/indexer/indexerfx"
+	"github.com/0x5457/ts-index/internal/logging"
 	"github.com/0x5457/ts-index/internal/mcp/mcpfx"
 	"github.com/0x5457/ts-index/internal/parser/parserfx"
 	"github.com/0x5457/ts-index/internal/search/searchfx"
 	"github.com/0x5457/ts-index/internal/storage/storagefx"
 	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
 )
 
 // Module combines all application modules
@@ -22,8 +26,16 @@ var Module = fx.Options(
 	indexerfx.Module,
 	mcpfx.Module,
 	cmdsfx.Module,
+	fx.WithLogger(newFxLogger),
 )
 
+// newFxLogger routes Fx's own events through the shared logger at debug level
+func newFxLogger() fxevent.Logger {
+	l := &fxevent.SlogLogger{Logger: logging.L()}
+	l.UseLogLevel(slog.LevelDebug)
+	return l
+}
+
 // NewAppWithConfig creates an Fx app with the given configuration values
 func NewAppWithConfig(dbPath, embedURL, project string) *fx.App {
 	return fx.New(
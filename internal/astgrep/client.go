@@ -1,26 +1,84 @@
 package astgrep
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
+// DefaultTimeout bounds a single ast-grep invocation
+const DefaultTimeout = 60 * time.Second
+
+// ConfigFileNames are the names of the project configuration ast-grep reads,
+// looked up in the project root when a client has no configuration set
+var ConfigFileNames = []string{"sgconfig.yml", "sgconfig.yaml"}
+
 // Client wraps ast-grep command execution
 type Client struct {
 	executable  string
 	projectPath string
+	timeout     time.Duration
+	// config is passed to every invocation with --config when set
+	config string
+}
+
+// Options configures a Client
+type Options struct {
+	// Timeout bounds each invocation. A non-positive timeout falls back to
+	// DefaultTimeout.
+	Timeout time.Duration
+	// Config is the sgconfig.yml registering custom languages and rule
+	// directories. When empty, FindConfig looks for one in the project root.
+	Config string
 }
 
 // NewClient creates a new ast-grep client with project path
 func NewClient(projectPath string) *Client {
+	return NewClientWithOptions(projectPath, Options{})
+}
+
+// NewClientWithTimeout creates a new ast-grep client whose invocations are killed after timeout.
+// A non-positive timeout falls back to DefaultTimeout.
+func NewClientWithTimeout(projectPath string, timeout time.Duration) *Client {
+	return NewClientWithOptions(projectPath, Options{Timeout: timeout})
+}
+
+// NewClientWithOptions creates a new ast-grep client configured by opts
+func NewClientWithOptions(projectPath string, opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Config == "" {
+		opts.Config = FindConfig(projectPath)
+	}
 	return &Client{
 		executable:  "ast-grep", // assume ast-grep is in PATH
 		projectPath: projectPath,
+		timeout:     opts.Timeout,
+		config:      opts.Config,
+	}
+}
+
+// FindConfig returns the ast-grep configuration in the project root, or ""
+// when there is none. ast-grep itself only looks in its working directory.
+func FindConfig(projectPath string) string {
+	if projectPath == "" {
+		return ""
+	}
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(projectPath, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
 	}
+	return ""
 }
 
 // Match represents an ast-grep match result
@@ -93,8 +151,15 @@ type SyntaxTreeRequest struct {
 
 	// Language specifies the programming language
 	Language string `json:"language,omitempty"`
+
+	// Format selects the dump format: ast (named nodes), cst (all nodes), sexp or pattern.
+	// Defaults to DefaultDumpFormat.
+	Format string `json:"format,omitempty"`
 }
 
+// DefaultDumpFormat is the syntax tree format used when none is requested
+const DefaultDumpFormat = "ast"
+
 // SearchResponse represents the result of a search operation
 type SearchResponse struct {
 	Matches []Match `json:"matches"`
@@ -195,37 +260,121 @@ func (c *Client) TestRule(ctx context.Context, req TestRuleRequest) TestRuleResp
 	}
 }
 
-// DumpSyntaxTree dumps the syntax tree of code
+// DumpSyntaxTree dumps the tree-sitter syntax tree of code.
+// The snippet is parsed as an ast-grep pattern with --debug-query, so the node kinds shown
+// are exactly the ones a pattern written from the same code would match.
 func (c *Client) DumpSyntaxTree(ctx context.Context, req SyntaxTreeRequest) SyntaxTreeResponse {
-	// Create temporary code file
-	codeFile, err := c.createTempCodeFile(req.Code, req.Language)
+	if strings.TrimSpace(req.Code) == "" {
+		return SyntaxTreeResponse{Error: "code is empty"}
+	}
+	language := req.Language
+	if language == "" {
+		language = "typescript"
+	}
+	format := req.Format
+	if format == "" {
+		format = DefaultDumpFormat
+	}
+
+	// --stdin with no input keeps ast-grep from scanning the project
+	args := []string{
+		"run",
+		"--pattern", req.Code,
+		"--lang", language,
+		"--debug-query=" + format,
+		"--stdin",
+	}
+	stdout, stderr, err := c.execute(ctx, args, "")
+
+	// The dump is written to stderr and ast-grep exits 1 because nothing matched
+	tree := strings.TrimSpace(string(stderr))
+	if strings.HasPrefix(tree, "Debug") {
+		return SyntaxTreeResponse{Tree: tree}
+	}
 	if err != nil {
-		return SyntaxTreeResponse{Error: fmt.Sprintf("failed to create code file: %v", err)}
+		return SyntaxTreeResponse{Error: c.runError(err, stderr).Error()}
 	}
-	defer c.cleanupTempFile(codeFile)
+	return SyntaxTreeResponse{Tree: strings.TrimSpace(string(stdout))}
+}
 
-	args := []string{"scan", codeFile}
+// ValidatePattern checks that pattern compiles for language without scanning the project.
+// An empty language defaults to typescript, since ast-grep cannot read --stdin without one.
+// The returned error carries ast-grep's diagnostic explaining why a pattern is invalid.
+func (c *Client) ValidatePattern(ctx context.Context, pattern, language string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("invalid pattern: pattern is empty")
+	}
+	if language == "" {
+		language = "typescript"
+	}
+	args := []string{"run", "--pattern", pattern, "--lang", language, "--json", "--stdin"}
+	if _, err := c.runWithInput(ctx, args, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
 
-	// Add language if specified
-	if req.Language != "" {
-		args = append(args, "--lang", req.Language)
+// run executes ast-grep with the client timeout, returning stdout.
+// Failures carry ast-grep's stderr so callers see the actual diagnostic.
+func (c *Client) run(ctx context.Context, args []string) ([]byte, error) {
+	return c.runWithInput(ctx, args, "")
+}
+
+// runWithInput is run with the given text piped to ast-grep's stdin
+func (c *Client) runWithInput(ctx context.Context, args []string, input string) ([]byte, error) {
+	output, stderr, err := c.execute(ctx, args, input)
+	if err == nil {
+		return output, nil
+	}
+
+	// ast-grep exits with status 1 and no diagnostic when nothing matched
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 &&
+		len(bytes.TrimSpace(stderr)) == 0 {
+		return output, nil
 	}
+	return nil, c.runError(err, stderr)
+}
 
+// execute runs ast-grep with the client timeout and returns stdout and stderr as-is
+func (c *Client) execute(
+	ctx context.Context,
+	args []string,
+	input string,
+) ([]byte, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if c.config != "" {
+		args = append([]string{"--config", c.config}, args...)
+	}
+	var stderr bytes.Buffer
 	cmd := exec.CommandContext(ctx, c.executable, args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = &stderr
 	output, err := cmd.Output()
-	if err != nil {
-		return SyntaxTreeResponse{Error: fmt.Sprintf("ast-grep command failed: %v", err)}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = context.DeadlineExceeded
 	}
+	return output, stderr.Bytes(), err
+}
 
-	return SyntaxTreeResponse{Tree: string(output)}
+// runError describes a failed invocation, preferring ast-grep's own diagnostic
+func (c *Client) runError(err error, stderr []byte) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("ast-grep command timed out after %s", c.timeout)
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("ast-grep command failed: %v: %s", err, msg)
+	}
+	return fmt.Errorf("ast-grep command failed: %v", err)
 }
 
 // executeSearch is a helper to execute search commands
 func (c *Client) executeSearch(ctx context.Context, args []string, maxResults int) SearchResponse {
-	cmd := exec.CommandContext(ctx, c.executable, args...)
-	output, err := cmd.Output()
+	output, err := c.run(ctx, args)
 	if err != nil {
-		return SearchResponse{Error: fmt.Sprintf("ast-grep command failed: %v", err)}
+		return SearchResponse{Error: err.Error()}
 	}
 
 	// Parse JSON output
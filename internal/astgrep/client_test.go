@@ -0,0 +1,142 @@
+package astgrep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// scriptExecutable writes a shell script standing in for ast-grep
+func scriptExecutable(t *testing.T, body string) string {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "ast-grep")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// fakeExecutable writes a script standing in for ast-grep that records its
+// arguments in the returned file and prints no matches
+func fakeExecutable(t *testing.T) (string, string) {
+	t.Helper()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	return scriptExecutable(t, "echo \"$@\" > "+argsFile+"\necho '[]'\n"), argsFile
+}
+
+func TestClientConfig(t *testing.T) {
+	project := t.TempDir()
+	script, argsFile := fakeExecutable(t)
+	search := func(client *Client) string {
+		t.Helper()
+		client.executable = script
+		if res := client.Search(context.Background(), SearchRequest{Pattern: "f()"}); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+		args, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(args))
+	}
+
+	if args := search(NewClient(project)); strings.Contains(args, "--config") {
+		t.Fatalf("expected no --config without sgconfig.yml, got %q", args)
+	}
+
+	detected := filepath.Join(project, "sgconfig.yml")
+	if err := os.WriteFile(detected, []byte("ruleDirs: [rules]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if args := search(NewClient(project)); !strings.HasPrefix(args, "--config "+detected+" run ") {
+		t.Fatalf("expected the project's sgconfig.yml, got %q", args)
+	}
+
+	explicit := filepath.Join(t.TempDir(), "team.yml")
+	client := NewClientWithOptions(project, Options{Config: explicit})
+	if args := search(client); !strings.HasPrefix(args, "--config "+explicit+" run ") {
+		t.Fatalf("expected the explicit config, got %q", args)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	client := NewClientWithTimeout(t.TempDir(), 200*time.Millisecond)
+	client.executable = scriptExecutable(t, "echo $$ > "+pidFile+"\nexec sleep 30\n")
+
+	start := time.Now()
+	res := client.Search(context.Background(), SearchRequest{Pattern: "f()"})
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the search to return at the timeout, took %s", elapsed)
+	}
+	if !strings.Contains(res.Error, "timed out after 200ms") {
+		t.Fatalf("expected a timeout error, got %+v", res)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(pid, 0); err == nil {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatalf("expected ast-grep process %d to be killed", pid)
+	}
+}
+
+func TestClientFailureReportsStderr(t *testing.T) {
+	client := NewClient(t.TempDir())
+	client.executable = scriptExecutable(t, "echo 'Error: Cannot parse query as a valid pattern.' >&2\nexit 2\n")
+
+	res := client.Search(context.Background(), SearchRequest{Pattern: "f("})
+	if !strings.Contains(res.Error, "Cannot parse query as a valid pattern.") {
+		t.Fatalf("expected the error to carry ast-grep's stderr, got %+v", res)
+	}
+	if !strings.Contains(res.Error, "exit status 2") {
+		t.Fatalf("expected the exit status in the error, got %q", res.Error)
+	}
+
+	// exit status 1 without a diagnostic means nothing matched
+	client.executable = scriptExecutable(t, "exit 1\n")
+	res = client.Search(context.Background(), SearchRequest{Pattern: "f()"})
+	if res.Error != "" || len(res.Matches) != 0 {
+		t.Fatalf("expected no matches and no error, got %+v", res)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	client := NewClient(t.TempDir())
+	// like ast-grep, the stub rejects --stdin without a language and
+	// reports unmatched input with exit status 1
+	client.executable = scriptExecutable(t, `case "$*" in
+*"--lang "*) ;;
+*) echo "Error: --lang is required when using --stdin" >&2; exit 2 ;;
+esac
+case "$*" in
+*"--pattern f( "*) echo "Error: Cannot parse query as a valid pattern." >&2; exit 2 ;;
+esac
+exit 1
+`)
+	ctx := context.Background()
+
+	for _, language := range []string{"", "typescript"} {
+		if err := client.ValidatePattern(ctx, "f($A)", language); err != nil {
+			t.Fatalf("expected f($A) to be valid for language %q, got %v", language, err)
+		}
+		err := client.ValidatePattern(ctx, "f(", language)
+		if err == nil || !strings.Contains(err.Error(), "Cannot parse query as a valid pattern.") {
+			t.Fatalf("expected f( to be rejected for language %q, got %v", language, err)
+		}
+	}
+	if err := client.ValidatePattern(ctx, "  ", ""); err == nil {
+		t.Fatal("expected an empty pattern to be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+package astgrep
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LintProfilesDir is the project-relative directory holding named lint profiles.
+// Each profile is a subdirectory containing ast-grep YAML rule files.
+const LintProfilesDir = ".ts-index/lint"
+
+// LintRequest represents parameters for running a lint profile
+type LintRequest struct {
+	// Profile is a profile name under LintProfilesDir, or a path to a rule
+	// directory inside the project
+	Profile string `json:"profile"`
+
+	// MaxResults limits the number of matches per rule
+	MaxResults int `json:"max_results,omitempty"`
+}
+
+// RuleResult holds the matches produced by a single rule
+type RuleResult struct {
+	RuleID  string  `json:"rule_id"`
+	File    string  `json:"file"`
+	Matches []Match `json:"matches"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// LintResponse represents the aggregated result of a lint profile run
+type LintResponse struct {
+	Profile      string       `json:"profile"`
+	Rules        []RuleResult `json:"rules"`
+	TotalMatches int          `json:"total_matches"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// Lint runs every rule in a profile against the project and groups matches by rule id
+func (c *Client) Lint(ctx context.Context, req LintRequest) LintResponse {
+	dir, err := c.resolveProfileDir(req.Profile)
+	if err != nil {
+		return LintResponse{Profile: req.Profile, Error: err.Error()}
+	}
+
+	files, err := ruleFiles(dir)
+	if err != nil {
+		return LintResponse{Profile: req.Profile, Error: err.Error()}
+	}
+	if len(files) == 0 {
+		return LintResponse{
+			Profile: req.Profile,
+			Error:   fmt.Sprintf("no rule files found in %s", dir),
+		}
+	}
+
+	response := LintResponse{Profile: req.Profile, Rules: make([]RuleResult, 0, len(files))}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			response.Rules = append(response.Rules, RuleResult{
+				RuleID: ruleIDFromFile(file),
+				File:   file,
+				Error:  fmt.Sprintf("failed to read rule: %v", err),
+			})
+			continue
+		}
+
+		ruleID := ruleID(string(content))
+		if ruleID == "" {
+			ruleID = ruleIDFromFile(file)
+		}
+
+		result := c.SearchByRule(ctx, RuleSearchRequest{
+			Rule:       string(content),
+			MaxResults: req.MaxResults,
+		})
+		response.Rules = append(response.Rules, RuleResult{
+			RuleID:  ruleID,
+			File:    file,
+			Matches: result.Matches,
+			Error:   result.Error,
+		})
+		response.TotalMatches += len(result.Matches)
+
+		if ctx.Err() != nil {
+			response.Error = ctx.Err().Error()
+			break
+		}
+	}
+
+	return response
+}
+
+// resolveProfileDir maps a profile name or path to an existing rule directory.
+// Profiles are served to MCP clients, so directories outside the project are
+// rejected, including ones reached through symlinks.
+func (c *Client) resolveProfileDir(profile string) (string, error) {
+	if profile == "" {
+		return "", fmt.Errorf("lint profile is required")
+	}
+
+	root, err := filepath.Abs(c.projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute project path: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	candidates := []string{profile}
+	if !filepath.IsAbs(profile) {
+		candidates = []string{
+			filepath.Join(root, LintProfilesDir, profile),
+			filepath.Join(root, profile),
+		}
+	}
+	for _, dir := range candidates {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(realRoot, realDir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("lint profile %s is outside the project", profile)
+		}
+		return dir, nil
+	}
+	return "", fmt.Errorf("lint profile not found: %s", profile)
+}
+
+// ruleFiles lists YAML rule files in dir, sorted by name for stable output
+func ruleFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext == ".yml" || ext == ".yaml" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ruleID extracts the top-level `id:` value from a YAML rule
+func ruleID(rule string) string {
+	return ruleField(rule, "id")
+}
+
+// ruleField extracts a top-level scalar value from a YAML rule
+func ruleField(rule, key string) string {
+	for _, line := range strings.Split(rule, "\n") {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(line, key+":"))
+		return strings.Trim(value, `"'`)
+	}
+	return ""
+}
+
+func ruleIDFromFile(file string) string {
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,105 @@
+package astgrep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lintProject creates a project with a "strict" lint profile of two rules and
+// a client whose ast-grep stub reports two matches for the no-console rule
+func lintProject(t *testing.T) (string, *Client) {
+	t.Helper()
+	project := t.TempDir()
+	profile := filepath.Join(project, LintProfilesDir, "strict")
+	files := map[string]string{
+		"no-console.yml":   "id: no-console\nlanguage: typescript\nrule:\n  pattern: console.log($A)\n",
+		"no-debugger.yaml": "language: typescript\nrule:\n  kind: debugger_statement\n",
+		"README.md":        "not a rule\n",
+	}
+	if err := os.MkdirAll(filepath.Join(profile, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(profile, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := NewClient(project)
+	client.executable = scriptExecutable(t, `while [ $# -gt 0 ]; do
+	if [ "$1" = --rule ]; then rule=$2; fi
+	shift
+done
+if grep -q 'id: no-console' "$rule"; then
+	echo '[{"text":"console.log(1)","file":"a.ts"},{"text":"console.log(2)","file":"b.ts"}]'
+else
+	echo '[]'
+fi
+`)
+	return project, client
+}
+
+func TestLint(t *testing.T) {
+	project, client := lintProject(t)
+	ctx := context.Background()
+
+	res := client.Lint(ctx, LintRequest{Profile: "strict"})
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if len(res.Rules) != 2 || res.TotalMatches != 2 {
+		t.Fatalf("expected 2 rules with 2 matches, got %+v", res)
+	}
+	// rules are run in file name order; a rule without an id is named after its file
+	if res.Rules[0].RuleID != "no-console" || len(res.Rules[0].Matches) != 2 {
+		t.Fatalf("expected no-console to match twice, got %+v", res.Rules[0])
+	}
+	if res.Rules[1].RuleID != "no-debugger" || len(res.Rules[1].Matches) != 0 {
+		t.Fatalf("expected no-debugger without matches, got %+v", res.Rules[1])
+	}
+
+	res = client.Lint(ctx, LintRequest{Profile: "strict", MaxResults: 1})
+	if res.TotalMatches != 1 || len(res.Rules[0].Matches) != 1 {
+		t.Fatalf("expected max_results to cap matches per rule, got %+v", res)
+	}
+
+	// a project-relative rule directory works as well
+	res = client.Lint(ctx, LintRequest{Profile: filepath.Join(LintProfilesDir, "strict")})
+	if res.Error != "" || res.TotalMatches != 2 {
+		t.Fatalf("expected the profile by path to match twice, got %+v", res)
+	}
+	res = client.Lint(ctx, LintRequest{Profile: filepath.Join(project, LintProfilesDir, "strict")})
+	if res.Error != "" || res.TotalMatches != 2 {
+		t.Fatalf("expected the profile by absolute path to match twice, got %+v", res)
+	}
+
+	if res = client.Lint(ctx, LintRequest{Profile: "missing"}); !strings.Contains(res.Error, "not found") {
+		t.Fatalf("expected a missing profile to be reported, got %+v", res)
+	}
+}
+
+func TestLintOutsideProject(t *testing.T) {
+	project, client := lintProject(t)
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "rule.yml"), []byte("id: outside\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(project, outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(project, LintProfilesDir, "linked")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, profile := range []string{outside, rel, "linked"} {
+		res := client.Lint(context.Background(), LintRequest{Profile: profile})
+		if !strings.Contains(res.Error, "outside the project") || len(res.Rules) != 0 {
+			t.Fatalf("expected profile %s to be rejected, got %+v", profile, res)
+		}
+	}
+}
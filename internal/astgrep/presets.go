@@ -0,0 +1,109 @@
+package astgrep
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed presets/*.yml
+var presetFS embed.FS
+
+// presetLanguages are the languages every preset runs in. Preset rules are
+// written for TypeScript and rerun for TSX, which ast-grep scans separately.
+var presetLanguages = []string{"TypeScript", "Tsx"}
+
+// Preset is a bundled ast-grep rule for a common TypeScript scan
+type Preset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PresetRequest represents parameters for running a bundled preset
+type PresetRequest struct {
+	// Preset is the name of a bundled preset, as listed by Presets
+	Preset string `json:"preset"`
+
+	// MaxResults limits the number of results
+	MaxResults int `json:"max_results,omitempty"`
+}
+
+// PresetResponse represents the result of a preset scan
+type PresetResponse struct {
+	Preset      string  `json:"preset"`
+	Description string  `json:"description"`
+	Matches     []Match `json:"matches"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Presets lists the bundled presets by name
+func Presets() []Preset {
+	entries, err := presetFS.ReadDir("presets")
+	if err != nil {
+		return nil
+	}
+	presets := make([]Preset, 0, len(entries))
+	for _, entry := range entries {
+		rule, err := presetFS.ReadFile(path.Join("presets", entry.Name()))
+		if err != nil {
+			continue
+		}
+		presets = append(presets, Preset{
+			Name:        ruleIDFromFile(entry.Name()),
+			Description: ruleField(string(rule), "message"),
+		})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// PresetRule returns the YAML rule of a bundled preset
+func PresetRule(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\.`) {
+		return "", fmt.Errorf("unknown preset %q", name)
+	}
+	rule, err := presetFS.ReadFile(path.Join("presets", name+".yml"))
+	if err != nil {
+		return "", fmt.Errorf("unknown preset %q", name)
+	}
+	return string(rule), nil
+}
+
+// SearchPreset runs a bundled preset against the project's TypeScript and TSX files
+func (c *Client) SearchPreset(ctx context.Context, req PresetRequest) PresetResponse {
+	rule, err := PresetRule(req.Preset)
+	if err != nil {
+		return PresetResponse{Preset: req.Preset, Error: err.Error()}
+	}
+	response := PresetResponse{
+		Preset:      req.Preset,
+		Description: ruleField(rule, "message"),
+		Matches:     []Match{},
+	}
+	for _, language := range presetLanguages {
+		result := c.SearchByRule(ctx, RuleSearchRequest{Rule: withRuleLanguage(rule, language)})
+		if result.Error != "" {
+			response.Error = result.Error
+			return response
+		}
+		response.Matches = append(response.Matches, result.Matches...)
+	}
+	if req.MaxResults > 0 && len(response.Matches) > req.MaxResults {
+		response.Matches = response.Matches[:req.MaxResults]
+	}
+	return response
+}
+
+// withRuleLanguage returns rule with its top-level language replaced
+func withRuleLanguage(rule, language string) string {
+	lines := strings.Split(rule, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "language:") {
+			lines[i] = "language: " + language
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,43 @@
+package astgrep
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	presets := Presets()
+	var names []string
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+		if preset.Description == "" {
+			t.Errorf("preset %s has no description", preset.Name)
+		}
+		rule, err := PresetRule(preset.Name)
+		if err != nil {
+			t.Fatalf("load preset %s: %v", preset.Name, err)
+		}
+		if ruleID(rule) != preset.Name || ruleField(rule, "language") != presetLanguages[0] {
+			t.Errorf("preset %s must have a matching id and language %s", preset.Name, presetLanguages[0])
+		}
+		if !strings.Contains(rule, "\nrule:\n") {
+			t.Errorf("preset %s has no rule", preset.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "any-type,console-log,empty-catch,todo-comment" {
+		t.Fatalf("unexpected presets %s", got)
+	}
+
+	for _, name := range []string{"", "missing", "../lint", "console-log.yml"} {
+		if _, err := PresetRule(name); err == nil {
+			t.Errorf("expected preset %q to be unknown", name)
+		}
+	}
+}
+
+func TestWithRuleLanguage(t *testing.T) {
+	rule := "id: x\nlanguage: TypeScript\nrule:\n  pattern: f()\n"
+	if got := withRuleLanguage(rule, "Tsx"); got != "id: x\nlanguage: Tsx\nrule:\n  pattern: f()\n" {
+		t.Fatalf("unexpected rule %q", got)
+	}
+}
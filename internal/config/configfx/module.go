@@ -1,7 +1,9 @@
 package configfx
 
 import (
-	"github.com/0x5457/ts-index/internal/constants"
+	"time"
+
+	appconfig "github.com/0x5457/ts-index/internal/config"
 	"go.uber.org/fx"
 )
 
@@ -9,32 +11,157 @@ import (
 type Config struct {
 	DBPath          string
 	EmbedURL        string
+	EmbedModel      string // Sent as the "model" field of embed requests when set
 	VectorDimension int
 	Project         string // Optional project path for pre-indexing
+	EnrichWithLSP   bool   // Enrich embed text with LSP-resolved signatures during indexing
+	SymbolsOnly     bool   // Index symbols only, skipping embedding and vector storage
+	ReduceDim       int    // Project stored vectors down to this dimension (0 keeps full size)
+	Quantize        string // Stored vector element type, "float32" (default) or "int8"
+	SyncLSPSymbols  bool   // Persist language server symbols into the index (MCP server)
+	// EmbedConcurrency caps concurrent embed API requests (0 uses the embedder default)
+	EmbedConcurrency int
+	// EmbedRPS caps the embed API requests started per second (0: no cap)
+	EmbedRPS float64
+	// EmbedRetries is how often a failing embed request is retried (0 uses the
+	// embedder default, negative disables retries)
+	EmbedRetries int
+	// EmbedInputField, EmbedOutputField and EmbedVectorField name the fields of
+	// embed requests and responses, as in embeddings.ApiOptions; empty uses the
+	// embedder defaults
+	EmbedInputField  string
+	EmbedOutputField string
+	EmbedVectorField string
+	// NoStoreContent indexes without storing source code in the database
+	NoStoreContent bool
+	// MaxChunkContentBytes caps the source stored per chunk (0 stores it whole)
+	MaxChunkContentBytes int
+	// FileCommits records each indexed file's last-modifying git commit
+	FileCommits bool
+	// Vue also indexes the script blocks of .vue single-file components
+	Vue bool
+	// GitOnly indexes only git-tracked files when the project is a repository
+	GitOnly bool
+	// IndexCallSites embeds call expressions whose callee is in CallSiteCallees,
+	// or that call an imported binding when the list is empty
+	IndexCallSites  bool
+	CallSiteCallees []string
+	// NodeKinds are extra tree-sitter node kinds to index, as "node_kind=symbol_kind"
+	NodeKinds []string
+	// SkipGenerated leaves out files whose header matches GeneratedMarkers,
+	// regular expressions defaulting to common codegen markers
+	SkipGenerated    bool
+	GeneratedMarkers []string
+	// SearchDBPaths are additional read-only index databases searched together with DBPath
+	SearchDBPaths []string
+	// SearchCacheSize and SearchCacheTTL bound the cache of repeated semantic
+	// search results; a zero value disables it
+	SearchCacheSize int
+	SearchCacheTTL  time.Duration
+	// AstGrepConfig is the sgconfig.yml passed to ast-grep; empty uses the
+	// one in the project root, if any
+	AstGrepConfig string
+	// InMemory keeps the index in memory instead of in DBPath
+	InMemory bool
+	// EmbedTemplates lay out chunk embed text per language, as "language=template"
+	EmbedTemplates []string
+	// Rerank reorders semantic search hits with the default heuristic weights
+	Rerank bool
+	// ReadOnly opens the index at DBPath without writing to it, so it can be
+	// searched while another process updates it
+	ReadOnly bool
 }
 
 // Params represents the parameters needed to create configuration
 type Params struct {
 	fx.In
 
-	DBPath   string `name:"dbPath"   optional:"true"`
-	EmbedURL string `name:"embedURL" optional:"true"`
-	Project  string `name:"project"  optional:"true"`
+	DBPath     string `name:"dbPath"     optional:"true"`
+	EmbedURL   string `name:"embedURL"   optional:"true"`
+	EmbedModel string `name:"embedModel" optional:"true"`
+	Project    string `name:"project"    optional:"true"`
+
+	EnrichWithLSP bool     `name:"enrichWithLSP" optional:"true"`
+	SearchDBPaths []string `name:"searchDBPaths" optional:"true"`
+	SymbolsOnly   bool     `name:"symbolsOnly"   optional:"true"`
+	ReduceDim     int      `name:"reduceDim"     optional:"true"`
+	Quantize      string   `name:"quantize"      optional:"true"`
+
+	SyncLSPSymbols       bool `name:"syncLSPSymbols"       optional:"true"`
+	EmbedConcurrency     int  `name:"embedConcurrency"     optional:"true"`
+	EmbedRetries         int  `name:"embedRetries"         optional:"true"`
+	NoStoreContent       bool `name:"noStoreContent"       optional:"true"`
+	MaxChunkContentBytes int  `name:"maxChunkContentBytes" optional:"true"`
+	FileCommits          bool `name:"fileCommits"          optional:"true"`
+	Vue                  bool `name:"vue"                  optional:"true"`
+	GitOnly              bool `name:"gitOnly"              optional:"true"`
+
+	IndexCallSites  bool     `name:"indexCallSites"  optional:"true"`
+	CallSiteCallees []string `name:"callSiteCallees" optional:"true"`
+	NodeKinds       []string `name:"nodeKinds"       optional:"true"`
+
+	SkipGenerated    bool     `name:"skipGenerated"    optional:"true"`
+	GeneratedMarkers []string `name:"generatedMarkers" optional:"true"`
+
+	SearchCacheSize int           `name:"searchCacheSize" optional:"true"`
+	SearchCacheTTL  time.Duration `name:"searchCacheTTL"  optional:"true"`
+
+	AstGrepConfig string `name:"astGrepConfig" optional:"true"`
+	InMemory      bool   `name:"inMemory"      optional:"true"`
+
+	EmbedTemplates []string `name:"embedTemplates" optional:"true"`
+	Rerank         bool     `name:"rerank"         optional:"true"`
+	EmbedRPS       float64  `name:"embedRPS"       optional:"true"`
+	ReadOnly       bool     `name:"readOnly"       optional:"true"`
 }
 
 // NewConfig creates a new configuration with defaults
 func NewConfig(params Params) *Config {
 	config := &Config{
-		DBPath:          params.DBPath,
-		EmbedURL:        params.EmbedURL,
-		VectorDimension: 0, // Will be inferred
-		Project:         params.Project,
+		DBPath:               params.DBPath,
+		EmbedURL:             params.EmbedURL,
+		EmbedModel:           params.EmbedModel,
+		VectorDimension:      0, // Will be inferred
+		Project:              params.Project,
+		EnrichWithLSP:        params.EnrichWithLSP,
+		SearchDBPaths:        params.SearchDBPaths,
+		SymbolsOnly:          params.SymbolsOnly,
+		ReduceDim:            params.ReduceDim,
+		Quantize:             params.Quantize,
+		SyncLSPSymbols:       params.SyncLSPSymbols,
+		EmbedConcurrency:     params.EmbedConcurrency,
+		EmbedRetries:         params.EmbedRetries,
+		NoStoreContent:       params.NoStoreContent,
+		MaxChunkContentBytes: params.MaxChunkContentBytes,
+		FileCommits:          params.FileCommits,
+		Vue:                  params.Vue,
+		GitOnly:              params.GitOnly,
+		IndexCallSites:       params.IndexCallSites,
+		CallSiteCallees:      params.CallSiteCallees,
+		NodeKinds:            params.NodeKinds,
+		SkipGenerated:        params.SkipGenerated,
+		GeneratedMarkers:     params.GeneratedMarkers,
+		SearchCacheSize:      params.SearchCacheSize,
+		SearchCacheTTL:       params.SearchCacheTTL,
+		AstGrepConfig:        params.AstGrepConfig,
+		InMemory:             params.InMemory,
+		EmbedTemplates:       params.EmbedTemplates,
+		Rerank:               params.Rerank,
+		EmbedRPS:             params.EmbedRPS,
+		ReadOnly:             params.ReadOnly,
 	}
 
 	// Set defaults
+	defaults := appconfig.LoadDefaults()
 	if config.EmbedURL == "" {
-		config.EmbedURL = constants.DefaultEmbedURL
+		config.EmbedURL = defaults.EmbedURL
+	}
+	if config.EmbedModel == "" {
+		config.EmbedModel = defaults.EmbedModel
 	}
+	config.EmbedInputField = defaults.EmbedInputField
+	config.EmbedOutputField = defaults.EmbedOutputField
+	config.EmbedVectorField = defaults.EmbedVectorField
 
 	return config
 }
@@ -2,8 +2,10 @@ package configfx
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
+	appconfig "github.com/0x5457/ts-index/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
@@ -54,3 +56,15 @@ func TestConfigDefaults(t *testing.T) {
 	assert.NotNil(t, config)
 	assert.Equal(t, "http://localhost:8000/embed", config.EmbedURL) // Default value
 }
+
+func TestConfigEmbedFields(t *testing.T) {
+	t.Setenv(appconfig.FileEnvVar, filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv(appconfig.EmbedInputFieldEnvVar, "input")
+	t.Setenv(appconfig.EmbedOutputFieldEnvVar, "data")
+	t.Setenv(appconfig.EmbedVectorFieldEnvVar, "embedding")
+
+	config := NewConfig(Params{})
+	assert.Equal(t, "input", config.EmbedInputField)
+	assert.Equal(t, "data", config.EmbedOutputField)
+	assert.Equal(t, "embedding", config.EmbedVectorField)
+}
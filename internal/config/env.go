@@ -0,0 +1,96 @@
+// Package config resolves the defaults shared by ts-index commands from the
+// environment and an optional config file, so containers, CI and day-to-day
+// use can configure the tool without repeating flags.
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/0x5457/ts-index/internal/gitinfo"
+)
+
+// Environment variables read as command defaults; flags override them
+const (
+	EmbedURLEnvVar   = "TS_INDEX_EMBED_URL"
+	EmbedModelEnvVar = "TS_INDEX_EMBED_MODEL"
+	DBEnvVar         = "TS_INDEX_DB"
+	ProjectEnvVar    = "TS_INDEX_PROJECT"
+
+	EmbedInputFieldEnvVar  = "TS_INDEX_EMBED_INPUT_FIELD"
+	EmbedOutputFieldEnvVar = "TS_INDEX_EMBED_OUTPUT_FIELD"
+	EmbedVectorFieldEnvVar = "TS_INDEX_EMBED_VECTOR_FIELD"
+)
+
+// Defaults are the values commands use for flags that are not given
+type Defaults struct {
+	EmbedURL string
+	// EmbedModel is sent as the "model" field of embed requests; empty sends none
+	EmbedModel string
+	// EmbedInputField, EmbedOutputField and EmbedVectorField name the fields of
+	// embed requests and responses; empty uses the embedder defaults
+	EmbedInputField  string
+	EmbedOutputField string
+	EmbedVectorField string
+	// DB is the index database from the environment, empty when unset
+	DB string
+	// Project is the configured project root, empty when unset
+	Project string
+}
+
+// LoadDefaults reads Defaults from the environment, then the config file,
+// falling back to the built-in embed URL
+func LoadDefaults() Defaults {
+	file := loadFile()
+	return Defaults{
+		EmbedURL:         firstSet(env(EmbedURLEnvVar), file.EmbedURL, constants.DefaultEmbedURL),
+		EmbedModel:       firstSet(env(EmbedModelEnvVar), file.EmbedModel),
+		EmbedInputField:  firstSet(env(EmbedInputFieldEnvVar), file.EmbedInputField),
+		EmbedOutputField: firstSet(env(EmbedOutputFieldEnvVar), file.EmbedOutputField),
+		EmbedVectorField: firstSet(env(EmbedVectorFieldEnvVar), file.EmbedVectorField),
+		DB:               firstSet(env(DBEnvVar), file.DB),
+		Project:          firstSet(env(ProjectEnvVar), file.Project),
+	}
+}
+
+// IndexDB is the database used by commands that always need one: DB, or
+// ts_index.db in the temp directory
+func (d Defaults) IndexDB() string {
+	if d.DB != "" {
+		return d.DB
+	}
+	return filepath.Join(os.TempDir(), "ts_index.db")
+}
+
+// ProjectRoot is the project used by commands when --project is not given:
+// Project, or the root of the git work tree containing the working directory,
+// or the working directory itself
+func (d Defaults) ProjectRoot(ctx context.Context) string {
+	if d.Project != "" {
+		return d.Project
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	if root, err := gitinfo.TopLevel(ctx, wd); err == nil && root != "" {
+		return filepath.FromSlash(root)
+	}
+	return wd
+}
+
+func env(name string) string {
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
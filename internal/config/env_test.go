@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv(FileEnvVar, filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv(EmbedURLEnvVar, "")
+	t.Setenv(EmbedModelEnvVar, "")
+	t.Setenv(DBEnvVar, "")
+	t.Setenv(ProjectEnvVar, "")
+	t.Setenv(EmbedInputFieldEnvVar, "")
+	t.Setenv(EmbedOutputFieldEnvVar, "")
+	t.Setenv(EmbedVectorFieldEnvVar, "")
+	d := LoadDefaults()
+	assert.Equal(t, constants.DefaultEmbedURL, d.EmbedURL)
+	assert.Empty(t, d.EmbedModel)
+	assert.Empty(t, d.EmbedInputField)
+	assert.Empty(t, d.EmbedOutputField)
+	assert.Empty(t, d.EmbedVectorField)
+	assert.Empty(t, d.DB)
+	assert.Empty(t, d.Project)
+	assert.Equal(t, filepath.Join(os.TempDir(), "ts_index.db"), d.IndexDB())
+
+	t.Setenv(EmbedURLEnvVar, "http://embed:9000/embed")
+	t.Setenv(EmbedModelEnvVar, " gte-small ")
+	t.Setenv(DBEnvVar, "/data/index.db")
+	t.Setenv(EmbedInputFieldEnvVar, "input")
+	t.Setenv(EmbedOutputFieldEnvVar, "data")
+	t.Setenv(EmbedVectorFieldEnvVar, "embedding")
+	d = LoadDefaults()
+	assert.Equal(t, "http://embed:9000/embed", d.EmbedURL)
+	assert.Equal(t, "gte-small", d.EmbedModel)
+	assert.Equal(t, "input", d.EmbedInputField)
+	assert.Equal(t, "data", d.EmbedOutputField)
+	assert.Equal(t, "embedding", d.EmbedVectorField)
+	assert.Equal(t, "/data/index.db", d.IndexDB())
+}
+
+func TestProjectRoot(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "/src/app", Defaults{Project: "/src/app"}.ProjectRoot(ctx))
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	t.Chdir(dir)
+	assert.Equal(t, dir, Defaults{}.ProjectRoot(ctx))
+
+	if err := exec.Command("git", "init", "-q", dir).Run(); err != nil {
+		t.Skipf("git unavailable: %v", err)
+	}
+	sub := filepath.Join(dir, "src", "lib")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	t.Chdir(sub)
+	assert.Equal(t, dir, Defaults{}.ProjectRoot(ctx))
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/logging"
+)
+
+// FileEnvVar overrides the location of the config file
+const FileEnvVar = "TS_INDEX_CONFIG"
+
+// fileDefaults is the content of the config file, e.g.
+//
+//	{"project": "~/src/app", "embed_url": "http://localhost:8000/embed"}
+type fileDefaults struct {
+	Project          string `json:"project"`
+	EmbedURL         string `json:"embed_url"`
+	EmbedModel       string `json:"embed_model"`
+	EmbedInputField  string `json:"embed_input_field"`
+	EmbedOutputField string `json:"embed_output_field"`
+	EmbedVectorField string `json:"embed_vector_field"`
+	DB               string `json:"db"`
+}
+
+// FilePath returns the config file: $TS_INDEX_CONFIG, or ts-index/config.json
+// in the user config directory. It is empty when neither is known.
+func FilePath() string {
+	if path := env(FileEnvVar); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ts-index", "config.json")
+}
+
+// loadFile reads the config file. A missing file configures nothing; an
+// unreadable one is reported and ignored.
+func loadFile() fileDefaults {
+	path := FilePath()
+	if path == "" {
+		return fileDefaults{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logging.L().Warn("failed to read config file", "path", path, "error", err)
+		}
+		return fileDefaults{}
+	}
+	var f fileDefaults
+	if err := json.Unmarshal(data, &f); err != nil {
+		logging.L().Warn("ignoring invalid config file", "path", path, "error", err)
+		return fileDefaults{}
+	}
+	f.Project = resolvePath(strings.TrimSpace(f.Project), filepath.Dir(path))
+	f.DB = resolvePath(strings.TrimSpace(f.DB), filepath.Dir(path))
+	f.EmbedURL = strings.TrimSpace(f.EmbedURL)
+	f.EmbedModel = strings.TrimSpace(f.EmbedModel)
+	f.EmbedInputField = strings.TrimSpace(f.EmbedInputField)
+	f.EmbedOutputField = strings.TrimSpace(f.EmbedOutputField)
+	f.EmbedVectorField = strings.TrimSpace(f.EmbedVectorField)
+	return f
+}
+
+// resolvePath expands a leading ~ to the home directory and makes relative
+// paths relative to dir, the directory of the config file
+func resolvePath(path, dir string) string {
+	if path == "" {
+		return ""
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
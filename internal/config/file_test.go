@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaultsConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	content := `{"project": "app", "embed_url": "http://file:8000/embed", "embed_model": "gte", "db": "/data/a.db",
+		"embed_input_field": "input", "embed_output_field": "data", "embed_vector_field": "embedding"}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	t.Setenv(FileEnvVar, path)
+	t.Setenv(EmbedURLEnvVar, "")
+	t.Setenv(EmbedModelEnvVar, "")
+	t.Setenv(DBEnvVar, "")
+	t.Setenv(ProjectEnvVar, "")
+	t.Setenv(EmbedInputFieldEnvVar, "")
+	t.Setenv(EmbedOutputFieldEnvVar, "")
+	t.Setenv(EmbedVectorFieldEnvVar, "")
+
+	d := LoadDefaults()
+	assert.Equal(t, filepath.Join(dir, "app"), d.Project, "relative to the config file")
+	assert.Equal(t, "http://file:8000/embed", d.EmbedURL)
+	assert.Equal(t, "gte", d.EmbedModel)
+	assert.Equal(t, "/data/a.db", d.DB)
+	assert.Equal(t, "input", d.EmbedInputField)
+	assert.Equal(t, "data", d.EmbedOutputField)
+	assert.Equal(t, "embedding", d.EmbedVectorField)
+
+	// the environment wins over the file
+	t.Setenv(ProjectEnvVar, "/src/other")
+	t.Setenv(EmbedModelEnvVar, "e5")
+	d = LoadDefaults()
+	assert.Equal(t, "/src/other", d.Project)
+	assert.Equal(t, "e5", d.EmbedModel)
+
+	// an invalid file is ignored
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	t.Setenv(ProjectEnvVar, "")
+	assert.Empty(t, LoadDefaults().Project)
+}
@@ -2,23 +2,167 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"time"
 )
 
+// Default JSON shape of the embedding endpoint: the request is
+// {"sentences": ["..."]} and the response body is a bare array of vectors.
+const (
+	DefaultInputField  = "sentences"
+	DefaultOutputField = ""
+	DefaultVectorField = ""
+)
+
+// Connection defaults of the embedding endpoint
+const (
+	DefaultMaxConcurrentRequests = 4
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxRetries            = 2
+	DefaultRetryBackoff          = 500 * time.Millisecond
+)
+
+// ApiOptions configures the request/response field names of the embedding endpoint
+// so it can target servers with slightly different schemas.
+type ApiOptions struct {
+	// InputField is the request field holding the texts (e.g. "input", "texts").
+	// Defaults to DefaultInputField.
+	InputField string
+
+	// OutputField is the response field holding the list of embeddings
+	// (e.g. "embeddings", "data"). Empty means the body itself is the list.
+	OutputField string
+
+	// VectorField, when set, means each list element is an object holding its
+	// vector under this key (e.g. "embedding" for {"data":[{"embedding":[...]}]}).
+	// Empty means each element is the vector itself.
+	VectorField string
+
+	// ExtraFields are merged into every request body, e.g. {"model": "gte-small"}
+	ExtraFields map[string]any
+
+	// MaxConcurrentRequests caps the requests in flight across all callers of
+	// the embedder, so parallel embed workers cannot overwhelm the backend.
+	// Defaults to DefaultMaxConcurrentRequests.
+	MaxConcurrentRequests int
+
+	// MaxIdleConnsPerHost is the number of keep-alive connections kept to the
+	// endpoint. Defaults to MaxConcurrentRequests so every slot can reuse one.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout closes keep-alive connections idle for this long.
+	// Defaults to DefaultIdleConnTimeout.
+	IdleConnTimeout time.Duration
+
+	// MaxRetries is how many times a request failing with a retryable error is
+	// sent again. Defaults to DefaultMaxRetries; a negative value disables
+	// retries.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled before each
+	// further one. Defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+}
+
+// StatusError is a non-2xx response of the embedding endpoint
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// Body is the start of the response body, which usually explains the error
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("embed endpoint returned %s: %s", e.Status, e.Body)
+}
+
+// Retryable reports whether the request may succeed when sent again: on a
+// server error, 408 Request Timeout or 429 Too Many Requests. Other client
+// errors mean the request itself is wrong.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode >= 500 ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsRetryable reports whether a failed embed request is worth sending again:
+// a retryable StatusError, or a connection failure or timeout on the way to
+// the endpoint. Malformed responses and canceled requests are not.
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
 type ApiEmbedder struct {
 	url    string
 	client *http.Client
+	opts   ApiOptions
+	slots  chan struct{} // semaphore bounding concurrent requests
 }
 
 func NewApi(url string) *ApiEmbedder {
-	return &ApiEmbedder{url: url, client: &http.Client{}}
+	return NewApiWithOptions(url, ApiOptions{})
 }
 
-func (e *ApiEmbedder) ModelName() string { return "api" }
+// NewApiWithOptions creates an API embedder with a custom request/response shape
+func NewApiWithOptions(url string, opts ApiOptions) *ApiEmbedder {
+	if opts.InputField == "" {
+		opts.InputField = DefaultInputField
+	}
+	if opts.MaxConcurrentRequests <= 0 {
+		opts.MaxConcurrentRequests = DefaultMaxConcurrentRequests
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = opts.MaxConcurrentRequests
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	return &ApiEmbedder{
+		url:    url,
+		client: &http.Client{Transport: transport},
+		opts:   opts,
+		slots:  make(chan struct{}, opts.MaxConcurrentRequests),
+	}
+}
+
+// ModelName returns the "model" sent with each request, or "api" if none is
+func (e *ApiEmbedder) ModelName() string {
+	if model, ok := e.opts.ExtraFields["model"].(string); ok && model != "" {
+		return model
+	}
+	return "api"
+}
 
 func (e *ApiEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
-	embeddings, err := e.embedRequest(texts)
+	return e.EmbedTextsContext(context.Background(), texts)
+}
+
+// EmbedTextsContext embeds texts, aborting the HTTP request when ctx is canceled
+func (e *ApiEmbedder) EmbedTextsContext(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := e.embedRequest(ctx, texts)
 	if err != nil {
 		return nil, err
 	}
@@ -26,33 +170,125 @@ func (e *ApiEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
 }
 
 func (e *ApiEmbedder) EmbedQuery(text string) ([]float32, error) {
-	embeddings, err := e.embedRequest([]string{text})
+	embeddings, err := e.embedRequest(context.Background(), []string{text})
 	if err != nil {
 		return nil, err
 	}
 	return embeddings[0], nil
 }
 
-type embedRequest struct {
-	Sentences []string `json:"sentences"`
-}
-
-func (e *ApiEmbedder) embedRequest(texts []string) ([][]float32, error) {
-	request := &embedRequest{
-		Sentences: texts,
+// embedRequest embeds texts, retrying retryable failures with exponential
+// backoff. The error tells whether the request was rejected outright or failed
+// on every attempt.
+func (e *ApiEmbedder) embedRequest(ctx context.Context, texts []string) ([][]float32, error) {
+	request := make(map[string]any, len(e.opts.ExtraFields)+1)
+	for k, v := range e.opts.ExtraFields {
+		request[k] = v
 	}
+	request[e.opts.InputField] = texts
 	body, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	response, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+
+	backoff := e.opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		embeddings, err := e.send(ctx, body)
+		if err == nil {
+			if len(embeddings) != len(texts) {
+				return nil, fmt.Errorf(
+					"embed response has %d vectors for %d texts",
+					len(embeddings),
+					len(texts),
+				)
+			}
+			return embeddings, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if !IsRetryable(err) {
+			return nil, fmt.Errorf("embed request rejected, not retried: %w", err)
+		}
+		if attempt > e.opts.MaxRetries {
+			if attempt == 1 {
+				return nil, fmt.Errorf("embed request failed: %w", err)
+			}
+			return nil, fmt.Errorf("embed request failed after %d attempts: %w", attempt, err)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// send posts one embed request and decodes its response
+func (e *ApiEmbedder) send(ctx context.Context, body []byte) ([][]float32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-e.slots }()
+	response, err := e.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = response.Body.Close() }()
-	var embeddings [][]float32
-	if err := json.NewDecoder(response.Body).Decode(&embeddings); err != nil {
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return nil, &StatusError{StatusCode: response.StatusCode, Status: response.Status, Body: string(msg)}
+	}
+	return e.decodeResponse(response.Body)
+}
+
+// decodeResponse extracts the vectors according to OutputField and VectorField
+func (e *ApiEmbedder) decodeResponse(r io.Reader) ([][]float32, error) {
+	var list json.RawMessage
+	if e.opts.OutputField == "" {
+		if err := json.NewDecoder(r).Decode(&list); err != nil {
+			return nil, err
+		}
+	} else {
+		var obj map[string]json.RawMessage
+		if err := json.NewDecoder(r).Decode(&obj); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if list, ok = obj[e.opts.OutputField]; !ok {
+			return nil, fmt.Errorf("embed response missing field %q", e.opts.OutputField)
+		}
+	}
+
+	if e.opts.VectorField == "" {
+		var embeddings [][]float32
+		if err := json.Unmarshal(list, &embeddings); err != nil {
+			return nil, err
+		}
+		return embeddings, nil
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(list, &items); err != nil {
 		return nil, err
 	}
+	embeddings := make([][]float32, len(items))
+	for i, item := range items {
+		raw, ok := item[e.opts.VectorField]
+		if !ok {
+			return nil, fmt.Errorf("embed response item missing field %q", e.opts.VectorField)
+		}
+		if err := json.Unmarshal(raw, &embeddings[i]); err != nil {
+			return nil, err
+		}
+	}
 	return embeddings, nil
 }
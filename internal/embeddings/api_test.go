@@ -0,0 +1,172 @@
+package embeddings_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+)
+
+func Test_ApiEmbedder_DefaultShape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string][]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		out := make([][]float32, len(req["sentences"]))
+		for i := range out {
+			out[i] = []float32{1, 2}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	vecs, err := embeddings.NewApi(srv.URL).EmbedTexts([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vecs) != 2 || len(vecs[0]) != 2 {
+		t.Fatalf("unexpected vectors: %v", vecs)
+	}
+}
+
+func Test_ApiEmbedder_CustomShape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "m" {
+			t.Fatalf("expected extra field model, got %q", req.Model)
+		}
+		type item struct {
+			Embedding []float32 `json:"embedding"`
+		}
+		data := make([]item, len(req.Input))
+		for i := range data {
+			data[i] = item{Embedding: []float32{float32(i)}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	e := embeddings.NewApiWithOptions(srv.URL, embeddings.ApiOptions{
+		InputField:  "input",
+		OutputField: "data",
+		VectorField: "embedding",
+		ExtraFields: map[string]any{"model": "m"},
+	})
+	vecs, err := e.EmbedTexts([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vecs) != 2 || vecs[1][0] != 1 {
+		t.Fatalf("unexpected vectors: %v", vecs)
+	}
+	if e.ModelName() != "m" {
+		t.Fatalf("expected the model as model name, got %q", e.ModelName())
+	}
+}
+
+func Test_ApiEmbedder_MaxConcurrentRequests(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode([][]float32{{1}})
+	}))
+	defer srv.Close()
+
+	e := embeddings.NewApiWithOptions(srv.URL, embeddings.ApiOptions{MaxConcurrentRequests: 2})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.EmbedTexts([]string{"a"}); err != nil {
+				t.Errorf("embed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 2 {
+		t.Fatalf("expected at most 2 concurrent requests, saw %d", got)
+	}
+}
+
+func Test_ApiEmbedder_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // responses before a successful one
+		requests int32
+		wantErr  string
+	}{
+		{name: "bad request fails fast", statuses: []int{400, 400, 400}, requests: 1, wantErr: "not retried"},
+		{name: "server error is retried", statuses: []int{503, 500}, requests: 3},
+		{name: "rate limit is retried", statuses: []int{429}, requests: 2},
+		{name: "retries run out", statuses: []int{502, 502, 502}, requests: 3, wantErr: "after 3 attempts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				if n <= len(tt.statuses) {
+					http.Error(w, "boom", tt.statuses[n-1])
+					return
+				}
+				_ = json.NewEncoder(w).Encode([][]float32{{1}})
+			}))
+			defer srv.Close()
+
+			e := embeddings.NewApiWithOptions(srv.URL, embeddings.ApiOptions{RetryBackoff: time.Millisecond})
+			_, err := e.EmbedTexts([]string{"a"})
+			if got := requests.Load(); got != tt.requests {
+				t.Fatalf("expected %d requests, got %d", tt.requests, got)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("embed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			var statusErr *embeddings.StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.statuses[0] {
+				t.Fatalf("expected a StatusError with status %d, got %v", tt.statuses[0], err)
+			}
+		})
+	}
+
+	// connection failures are retried, unless retries are disabled
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	_, err := embeddings.NewApiWithOptions(srv.URL, embeddings.ApiOptions{RetryBackoff: time.Millisecond}).
+		EmbedQuery("a")
+	if !embeddings.IsRetryable(err) || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected a retried connection error, got %v", err)
+	}
+	_, err = embeddings.NewApiWithOptions(srv.URL, embeddings.ApiOptions{MaxRetries: -1}).EmbedQuery("a")
+	if err == nil || strings.Contains(err.Error(), "attempts") {
+		t.Fatalf("expected a single attempt, got %v", err)
+	}
+}
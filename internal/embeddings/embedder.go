@@ -1,7 +1,26 @@
 package embeddings
 
+import "context"
+
 type Embedder interface {
 	EmbedTexts(texts []string) ([][]float32, error)
 	EmbedQuery(text string) ([]float32, error)
 	ModelName() string
 }
+
+// ContextEmbedder is implemented by embedders whose requests can be canceled
+type ContextEmbedder interface {
+	EmbedTextsContext(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedTexts embeds texts with e, aborting the request when ctx is canceled if
+// e supports it and otherwise only checking ctx before starting
+func EmbedTexts(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	if ce, ok := e.(ContextEmbedder); ok {
+		return ce.EmbedTextsContext(ctx, texts)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.EmbedTexts(texts)
+}
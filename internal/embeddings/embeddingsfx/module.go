@@ -15,7 +15,22 @@ type Params struct {
 
 // NewEmbedder creates a new embedder instance
 func NewEmbedder(params Params) embeddings.Embedder {
-	return embeddings.NewApi(params.Config.EmbedURL)
+	opts := embeddings.ApiOptions{
+		MaxConcurrentRequests: params.Config.EmbedConcurrency,
+		MaxRetries:            params.Config.EmbedRetries,
+		InputField:            params.Config.EmbedInputField,
+		OutputField:           params.Config.EmbedOutputField,
+		VectorField:           params.Config.EmbedVectorField,
+	}
+	if params.Config.EmbedModel != "" {
+		opts.ExtraFields = map[string]any{"model": params.Config.EmbedModel}
+	}
+	api := embeddings.NewApiWithOptions(params.Config.EmbedURL, opts)
+	if params.Config.EmbedRPS > 0 {
+		// one limiter shared by indexing and search
+		return embeddings.NewRateLimited(api, 0, params.Config.EmbedRPS)
+	}
+	return api
 }
 
 // NewLocalEmbedder creates a local embedder for testing
@@ -0,0 +1,104 @@
+package embeddings
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ Embedder        = (*RateLimited)(nil)
+	_ ContextEmbedder = (*RateLimited)(nil)
+)
+
+// RateLimited throttles the requests of every caller sharing it to an
+// embedder: at most maxConcurrent in flight, started at most rps per second.
+// Queries count like any other request.
+type RateLimited struct {
+	inner    Embedder
+	slots    chan struct{} // nil when concurrency is not limited
+	interval time.Duration // between request starts; zero when not limited
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+}
+
+// NewRateLimited wraps inner. A maxConcurrent or rps of zero or less leaves
+// that limit off.
+func NewRateLimited(inner Embedder, maxConcurrent int, rps float64) *RateLimited {
+	r := &RateLimited{inner: inner}
+	if maxConcurrent > 0 {
+		r.slots = make(chan struct{}, maxConcurrent)
+	}
+	if rps > 0 {
+		r.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return r
+}
+
+func (r *RateLimited) ModelName() string {
+	return r.inner.ModelName()
+}
+
+func (r *RateLimited) EmbedTexts(texts []string) ([][]float32, error) {
+	return r.EmbedTextsContext(context.Background(), texts)
+}
+
+// EmbedTextsContext waits for its turn, giving up when ctx is done, and then
+// embeds texts with the wrapped embedder
+func (r *RateLimited) EmbedTextsContext(ctx context.Context, texts []string) ([][]float32, error) {
+	release, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return EmbedTexts(ctx, r.inner, texts)
+}
+
+func (r *RateLimited) EmbedQuery(text string) ([]float32, error) {
+	release, err := r.acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return r.inner.EmbedQuery(text)
+}
+
+// acquire takes a concurrency slot and then waits for the next start time.
+// The returned func gives the slot back.
+func (r *RateLimited) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if r.slots != nil {
+		select {
+		case r.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-r.slots }
+	}
+	if r.interval <= 0 {
+		return release, nil
+	}
+
+	// reserve a start time, so waiting callers keep their order
+	r.mu.Lock()
+	now := time.Now()
+	start := r.next
+	if start.Before(now) {
+		start = now
+	}
+	r.next = start.Add(r.interval)
+	r.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,79 @@
+package embeddings_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+)
+
+// slowEmbedder records how many requests overlap
+type slowEmbedder struct {
+	embeddings.Embedder
+	inFlight, peak atomic.Int32
+}
+
+func (e *slowEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		peak := e.peak.Load()
+		if n <= peak || e.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return e.Embedder.EmbedTexts(texts)
+}
+
+func Test_RateLimited_MaxConcurrent(t *testing.T) {
+	inner := &slowEmbedder{Embedder: embeddings.NewLocal(4)}
+	e := embeddings.NewRateLimited(inner, 2, 0)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.EmbedTexts([]string{"a"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak := inner.peak.Load(); peak != 2 {
+		t.Fatalf("expected 2 requests in flight at most, got %d", peak)
+	}
+}
+
+func Test_RateLimited_RPS(t *testing.T) {
+	e := embeddings.NewRateLimited(embeddings.NewLocal(4), 0, 50)
+	start := time.Now()
+	for range 6 {
+		if _, err := e.EmbedQuery("a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first request starts at once, the other five 20ms apart
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected 6 requests at 50/s to take 100ms, took %v", elapsed)
+	}
+	if e.ModelName() != embeddings.NewLocal(4).ModelName() {
+		t.Fatalf("expected the wrapped model name, got %s", e.ModelName())
+	}
+
+	// a caller waiting for its turn gives up with its context
+	e = embeddings.NewRateLimited(embeddings.NewLocal(4), 0, 1)
+	if _, err := e.EmbedTexts([]string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := e.EmbedTextsContext(ctx, []string{"a"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context's error, got %v", err)
+	}
+}
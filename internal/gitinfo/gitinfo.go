@@ -0,0 +1,136 @@
+// Package gitinfo reads the git state of an indexed project by shelling out to git.
+package gitinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// HeadCommit returns the commit checked out in the repository containing dir.
+// It fails when dir is not inside a git work tree or git is not installed.
+func HeadCommit(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// TopLevel returns the root directory of the work tree containing dir
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// IsDirty reports whether tracked files under dir have uncommitted changes
+func IsDirty(ctx context.Context, dir string) (bool, error) {
+	out, err := run(ctx, dir, "status", "--porcelain", "--untracked-files=no", "--", ".")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// LastCommits returns the commit that last modified each of files, given with
+// forward slashes relative to dir. History is read newest first and stops once
+// every file is found; untracked files are missing from the result.
+func LastCommits(ctx context.Context, dir string, files []string) (map[string]string, error) {
+	out := make(map[string]string, len(files))
+	if len(files) == 0 {
+		return out, nil
+	}
+	wanted := make(map[string]bool, len(files))
+	for _, f := range files {
+		wanted[f] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// --relative limits the log to dir and prints paths relative to it
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotepath=off",
+		"log", "--format=%x00%H", "--name-only", "--relative")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var commit string
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for len(out) < len(wanted) && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			commit = line[1:]
+			continue
+		}
+		if wanted[line] && out[line] == "" {
+			out[line] = commit
+		}
+	}
+	if len(out) == len(wanted) {
+		// the rest of history is not needed
+		cancel()
+		_ = cmd.Wait()
+		return out, nil
+	}
+	if err := scanner.Err(); err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, gitError(err, stderr.String())
+	}
+	return out, nil
+}
+
+// TrackedFiles returns the files under dir that git tracks and that match one
+// of patterns, as git pathspecs such as "*.ts". Paths are relative to dir with
+// forward slashes. Entries missing from the work tree, like those outside a
+// sparse checkout, are still listed.
+func TrackedFiles(ctx context.Context, dir string, patterns ...string) ([]string, error) {
+	args := append([]string{"-c", "core.quotepath=off", "ls-files", "-z", "--"}, patterns...)
+	out, err := run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", gitError(err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func gitError(err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("git: %s", msg)
+	}
+	return fmt.Errorf("git: %w", err)
+}
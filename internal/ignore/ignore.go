@@ -0,0 +1,199 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// GitIgnoreFile is the version-control ignore file honored during indexing
+	GitIgnoreFile = ".gitignore"
+	// IndexIgnoreFile holds indexing-specific rules that take precedence over .gitignore
+	IndexIgnoreFile = ".ts-indexignore"
+)
+
+// defaultPatterns are always excluded before any ignore file is applied
+var defaultPatterns = []string{"node_modules/", ".git/", "dist/", "build/"}
+
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether project-relative paths are excluded from indexing.
+// Rules follow gitignore syntax; later rules win, so .ts-indexignore rules
+// (loaded after .gitignore) can both add exclusions and re-include paths via "!".
+type Matcher struct {
+	rules []rule
+}
+
+// New builds a matcher from gitignore-style pattern lines, applied after the defaults
+func New(lines ...string) *Matcher {
+	m := &Matcher{}
+	m.add(defaultPatterns)
+	m.add(lines)
+	return m
+}
+
+// Load builds a matcher for root from the default exclusions, root/.gitignore and
+// root/.ts-indexignore, in that order of increasing precedence. Missing files are skipped.
+func Load(root string) (*Matcher, error) {
+	m := New()
+	for _, name := range []string{GitIgnoreFile, IndexIgnoreFile} {
+		lines, err := readLines(filepath.Join(root, name))
+		if err != nil {
+			return nil, err
+		}
+		m.add(lines)
+	}
+	return m, nil
+}
+
+// Ignored reports whether rel (relative to the project root) is excluded.
+// A path is excluded when it or any of its parent directories is matched.
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if rel == "" || rel == "." {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+func (m *Matcher) match(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(path) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (m *Matcher) add(lines []string) {
+	for _, line := range lines {
+		if r, ok := compile(line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// compile converts a single gitignore pattern line into a rule
+func compile(line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the root
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := translate(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// translate turns gitignore glob syntax into a regular expression fragment
+func translate(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				atStart := i == 0 || pattern[i-1] == '/'
+				i++
+				switch {
+				case atStart && i+1 < len(pattern) && pattern[i+1] == '/':
+					// "**/" matches zero or more leading directories
+					b.WriteString("(?:.*/)?")
+					i++
+				default:
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
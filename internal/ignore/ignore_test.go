@@ -0,0 +1,53 @@
+package ignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/ignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcherPatterns(t *testing.T) {
+	m := ignore.New(
+		"*.gen.ts",
+		"/tmp",
+		"logs/",
+		"docs/**/*.md",
+		"!keep.gen.ts",
+	)
+
+	assert.True(t, m.Ignored("node_modules/pkg/index.ts", false))
+	assert.True(t, m.Ignored("src/api.gen.ts", false))
+	assert.False(t, m.Ignored("src/keep.gen.ts", false))
+	assert.True(t, m.Ignored("tmp/a.ts", false))
+	assert.False(t, m.Ignored("src/tmp/a.ts", false))
+	assert.True(t, m.Ignored("src/logs", true))
+	assert.False(t, m.Ignored("src/logs", false))
+	assert.True(t, m.Ignored("docs/a/b/c.md", false))
+	assert.True(t, m.Ignored("docs/c.md", false))
+	assert.False(t, m.Ignored("src/index.ts", false))
+}
+
+func TestLoadIndexIgnorePrecedence(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(
+		filepath.Join(root, ignore.GitIgnoreFile),
+		[]byte("generated/\nsrc/local.ts\n"),
+		0o644,
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(root, ignore.IndexIgnoreFile),
+		[]byte("# index generated clients\n!generated/\nfixtures/\n"),
+		0o644,
+	))
+
+	m, err := ignore.Load(root)
+	require.NoError(t, err)
+
+	assert.False(t, m.Ignored("generated/client.ts", false))
+	assert.True(t, m.Ignored("src/local.ts", false))
+	assert.True(t, m.Ignored("test/fixtures/a.ts", false))
+}
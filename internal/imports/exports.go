@@ -0,0 +1,144 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// exportsNode is a decoded package.json "exports" value. Object keys keep their
+// file order because conditions are matched in the order the package lists them.
+type exportsNode struct {
+	target string         // string target
+	array  []*exportsNode // fallback array
+	keys   []string       // object keys in file order
+	values map[string]*exportsNode
+	null   bool
+}
+
+func parseExports(raw json.RawMessage) (*exportsNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	node, err := decodeExportsNode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exports field: %w", err)
+	}
+	return node, nil
+}
+
+func decodeExportsNode(dec *json.Decoder) (*exportsNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case nil:
+		return &exportsNode{null: true}, nil
+	case string:
+		return &exportsNode{target: v}, nil
+	case json.Delim:
+		switch v {
+		case '[':
+			node := &exportsNode{array: []*exportsNode{}}
+			for dec.More() {
+				item, err := decodeExportsNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.array = append(node.array, item)
+			}
+			_, err := dec.Token()
+			return node, err
+		case '{':
+			node := &exportsNode{values: make(map[string]*exportsNode)}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				value, err := decodeExportsNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, dup := node.values[key]; !dup {
+					node.keys = append(node.keys, key)
+				}
+				node.values[key] = value
+			}
+			_, err := dec.Token()
+			return node, err
+		}
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+// subpaths returns the exports as a subpath map. A string, array or conditions
+// object is shorthand for the "." entry.
+func (n *exportsNode) subpaths() map[string]*exportsNode {
+	if n.values != nil && len(n.keys) > 0 && strings.HasPrefix(n.keys[0], ".") {
+		return n.values
+	}
+	return map[string]*exportsNode{".": n}
+}
+
+// resolveSubpath returns the package-relative target exported for subpath
+// ("." or "./feature"), following Node's PACKAGE_EXPORTS_RESOLVE for exact
+// entries and single-"*" patterns. ok is false when the subpath is not exported.
+func (n *exportsNode) resolveSubpath(subpath string, conditions map[string]bool) (string, bool) {
+	entries := n.subpaths()
+	if target, found := entries[subpath]; found && !strings.Contains(subpath, "*") {
+		return target.resolveTarget("", conditions)
+	}
+
+	// the pattern with the longest prefix before "*" wins
+	bestKey, bestMatch := "", ""
+	for key := range entries {
+		prefix, suffix, ok := strings.Cut(key, "*")
+		if !ok || strings.Contains(suffix, "*") {
+			continue
+		}
+		if !strings.HasPrefix(subpath, prefix) || !strings.HasSuffix(subpath, suffix) ||
+			len(subpath) < len(prefix)+len(suffix) {
+			continue
+		}
+		if bestKey == "" || len(prefix) > strings.Index(bestKey, "*") {
+			bestKey = key
+			bestMatch = subpath[len(prefix) : len(subpath)-len(suffix)]
+		}
+	}
+	if bestKey == "" {
+		return "", false
+	}
+	return entries[bestKey].resolveTarget(bestMatch, conditions)
+}
+
+func (n *exportsNode) resolveTarget(match string, conditions map[string]bool) (string, bool) {
+	switch {
+	case n.null:
+		return "", false
+	case n.array != nil:
+		for _, item := range n.array {
+			if target, ok := item.resolveTarget(match, conditions); ok {
+				return target, true
+			}
+		}
+		return "", false
+	case n.values != nil:
+		for _, key := range n.keys {
+			if key != "default" && !conditions[key] {
+				continue
+			}
+			if target, ok := n.values[key].resolveTarget(match, conditions); ok {
+				return target, true
+			}
+		}
+		return "", false
+	default:
+		// targets must stay inside the package
+		if !strings.HasPrefix(n.target, "./") {
+			return "", false
+		}
+		return strings.ReplaceAll(n.target, "*", match), true
+	}
+}
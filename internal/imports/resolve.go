@@ -0,0 +1,272 @@
+// Package imports resolves TypeScript import specifiers to project source files.
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/ignore"
+)
+
+// ErrUnresolved is returned for specifiers that do not name a project file,
+// such as third-party packages and Node built-ins
+var ErrUnresolved = errors.New("import not resolved to a project file")
+
+// DefaultConditions are the export conditions matched when resolving package "exports"
+var DefaultConditions = []string{"types", "import", "require", "module", "node", "default"}
+
+// sourceExtensions are probed, in order, when a specifier omits the extension
+var sourceExtensions = []string{".ts", ".tsx", ".d.ts"}
+
+type workspacePackage struct {
+	dir     string // relative to the project root
+	types   string
+	module  string
+	main    string
+	exports *exportsNode
+}
+
+type packageJSON struct {
+	Name    string          `json:"name"`
+	Types   string          `json:"types"`
+	Typings string          `json:"typings"`
+	Module  string          `json:"module"`
+	Main    string          `json:"main"`
+	Exports json.RawMessage `json:"exports"`
+}
+
+// Resolver maps import specifiers to source files of a project. Bare specifiers
+// resolve when they name a first-party workspace package, i.e. a package.json
+// found under the project root; its "exports" map is honored, falling back to
+// the "types", "module" and "main" fields.
+type Resolver struct {
+	root       string
+	conditions map[string]bool
+	packages   map[string]*workspacePackage
+}
+
+// NewResolver discovers the workspace packages under root using DefaultConditions
+func NewResolver(root string) (*Resolver, error) {
+	return NewResolverWithConditions(root, DefaultConditions)
+}
+
+// NewResolverWithConditions discovers the workspace packages under root and matches
+// the given export conditions (e.g. adding "source" for packages exporting sources)
+func NewResolverWithConditions(root string, conditions []string) (*Resolver, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolver{
+		root:       absRoot,
+		conditions: make(map[string]bool, len(conditions)),
+		packages:   make(map[string]*workspacePackage),
+	}
+	for _, c := range conditions {
+		r.conditions[c] = true
+	}
+	if err := r.loadPackages(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Resolver) loadPackages() error {
+	matcher, err := ignore.Load(r.root)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(r.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(r.root, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && matcher.Ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "package.json" {
+			return nil
+		}
+		pkg, name, err := readPackage(p)
+		if err != nil || name == "" {
+			// malformed or private unnamed manifests cannot be imported by name
+			return nil
+		}
+		pkg.dir = filepath.ToSlash(filepath.Dir(rel))
+		if _, dup := r.packages[name]; !dup {
+			r.packages[name] = pkg
+		}
+		return nil
+	})
+}
+
+func readPackage(file string) (*workspacePackage, string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", err
+	}
+	var manifest packageJSON
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", err
+	}
+	pkg := &workspacePackage{
+		types:  manifest.Types,
+		module: manifest.Module,
+		main:   manifest.Main,
+	}
+	if pkg.types == "" {
+		pkg.types = manifest.Typings
+	}
+	if len(manifest.Exports) > 0 {
+		exports, err := parseExports(manifest.Exports)
+		if err != nil {
+			return nil, "", err
+		}
+		pkg.exports = exports
+	}
+	return pkg, manifest.Name, nil
+}
+
+// Resolve returns the file imported by specifier from fromFile, relative to the
+// project root the way the indexer records files. fromFile may be absolute or
+// root-relative. ErrUnresolved is returned for imports outside the project.
+func (r *Resolver) Resolve(fromFile, specifier string) (string, error) {
+	if strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") ||
+		specifier == "." || specifier == ".." {
+		from := fromFile
+		if filepath.IsAbs(from) {
+			rel, err := filepath.Rel(r.root, from)
+			if err != nil {
+				return "", err
+			}
+			from = rel
+		}
+		target := path.Join(path.Dir(filepath.ToSlash(from)), specifier)
+		if resolved, ok := r.probe(target); ok {
+			return resolved, nil
+		}
+		return "", ErrUnresolved
+	}
+
+	name, subpath := splitSpecifier(specifier)
+	pkg, ok := r.packages[name]
+	if !ok {
+		return "", ErrUnresolved
+	}
+	if pkg.exports != nil {
+		// an exports map hides every entry it does not list
+		target, ok := pkg.exports.resolveSubpath(subpath, r.conditions)
+		if !ok {
+			return "", ErrUnresolved
+		}
+		if resolved, ok := r.probe(path.Join(pkg.dir, target)); ok {
+			return resolved, nil
+		}
+		return "", ErrUnresolved
+	}
+	if subpath != "." {
+		if resolved, ok := r.probe(path.Join(pkg.dir, subpath)); ok {
+			return resolved, nil
+		}
+		return "", ErrUnresolved
+	}
+	for _, entry := range []string{pkg.types, pkg.module, pkg.main, "index"} {
+		if entry == "" {
+			continue
+		}
+		if resolved, ok := r.probe(path.Join(pkg.dir, entry)); ok {
+			return resolved, nil
+		}
+	}
+	return "", ErrUnresolved
+}
+
+// EntryPoints returns the project files the workspace packages expose by name:
+// the targets of their exact "exports" subpaths, or the file their "types",
+// "module" or "main" field resolves to. Files are sorted and use forward slashes.
+func (r *Resolver) EntryPoints() []string {
+	seen := make(map[string]bool)
+	for name, pkg := range r.packages {
+		subpaths := []string{"."}
+		if pkg.exports != nil {
+			subpaths = subpaths[:0]
+			for key := range pkg.exports.subpaths() {
+				if !strings.Contains(key, "*") {
+					subpaths = append(subpaths, key)
+				}
+			}
+		}
+		for _, subpath := range subpaths {
+			if file, err := r.Resolve("", name+strings.TrimPrefix(subpath, ".")); err == nil {
+				seen[filepath.ToSlash(file)] = true
+			}
+		}
+	}
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// splitSpecifier splits a bare specifier into the package name and an exports
+// subpath, e.g. "@scope/pkg/feature" into "@scope/pkg" and "./feature"
+func splitSpecifier(specifier string) (string, string) {
+	parts := strings.SplitN(specifier, "/", 3)
+	n := 1
+	if strings.HasPrefix(specifier, "@") && len(parts) > 1 {
+		n = 2
+	}
+	if len(parts) <= n {
+		return specifier, "."
+	}
+	name := strings.Join(parts[:n], "/")
+	return name, "." + specifier[len(name):]
+}
+
+// probe finds the source file for a root-relative import target, trying the
+// target itself, TypeScript counterparts of .js targets, added extensions and
+// index files, in that order
+func (r *Resolver) probe(target string) (string, bool) {
+	target = path.Clean(target)
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "", false
+	}
+	candidates := []string{target}
+	ext := path.Ext(target)
+	base := strings.TrimSuffix(target, ext)
+	switch ext {
+	case ".js", ".jsx":
+		candidates = append(candidates, base+".ts", base+".tsx", base+".d.ts")
+	case ".mjs":
+		candidates = append(candidates, base+".mts", base+".d.mts")
+	case ".cjs":
+		candidates = append(candidates, base+".cts", base+".d.cts")
+	}
+	for _, e := range sourceExtensions {
+		candidates = append(candidates, target+e)
+	}
+	for _, e := range sourceExtensions {
+		candidates = append(candidates, path.Join(target, "index"+e))
+	}
+	for _, c := range candidates {
+		info, err := os.Stat(filepath.Join(r.root, filepath.FromSlash(c)))
+		if err == nil && !info.IsDir() {
+			return filepath.FromSlash(c), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package imports
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"packages/core/package.json": `{
+			"name": "@acme/core",
+			"exports": {
+				".": {"types": "./src/index.ts", "default": "./dist/index.js"},
+				"./utils/*": "./src/utils/*.ts",
+				"./internal/*": null,
+				"./legacy": {"require": "./src/legacy.js", "import": "./src/legacy-esm.js"}
+			}
+		}`,
+		"packages/core/src/index.ts":       "export const core = 1",
+		"packages/core/src/utils/str.ts":   "export const str = 1",
+		"packages/core/src/legacy.ts":      "export const legacy = 1",
+		"packages/core/src/legacy-esm.ts":  "export const legacy = 1",
+		"packages/core/src/internal/x.ts":  "export const x = 1",
+		"packages/ui/package.json":         `{"name": "ui", "main": "./lib/main.js"}`,
+		"packages/ui/lib/main.ts":          "export const ui = 1",
+		"packages/ui/lib/button/index.tsx": "export const Button = 1",
+		"packages/ui/src/app.ts":           "import { core } from '@acme/core'",
+		"node_modules/react/package.json":  `{"name": "react", "main": "index.js"}`,
+		"node_modules/react/index.js":      "",
+	})
+
+	r, err := NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := "packages/ui/src/app.ts"
+	cases := []struct {
+		specifier string
+		want      string
+	}{
+		{"@acme/core", "packages/core/src/index.ts"},
+		{"@acme/core/utils/str", "packages/core/src/utils/str.ts"},
+		// conditions follow the order the package lists them in
+		{"@acme/core/legacy", "packages/core/src/legacy.ts"},
+		{"ui", "packages/ui/lib/main.ts"},
+		{"ui/lib/button", "packages/ui/lib/button/index.tsx"},
+		{"../lib/main.js", "packages/ui/lib/main.ts"},
+		{"../lib/button", "packages/ui/lib/button/index.tsx"},
+	}
+	for _, tc := range cases {
+		got, err := r.Resolve(from, tc.specifier)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tc.specifier, err)
+			continue
+		}
+		if got != filepath.FromSlash(tc.want) {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.specifier, got, tc.want)
+		}
+	}
+
+	// absolute importers resolve the same way
+	got, err := r.Resolve(filepath.Join(root, from), "@acme/core")
+	if err != nil || got != filepath.FromSlash("packages/core/src/index.ts") {
+		t.Errorf("Resolve from absolute path = %q, %v", got, err)
+	}
+
+	for _, specifier := range []string{
+		"react",                   // third-party package in node_modules
+		"@acme/core/internal/x",   // excluded by a null target
+		"@acme/core/src/index.ts", // not listed in exports
+		"./missing",
+	} {
+		if _, err := r.Resolve(from, specifier); !errors.Is(err, ErrUnresolved) {
+			t.Errorf("Resolve(%q): expected ErrUnresolved, got %v", specifier, err)
+		}
+	}
+
+	want := []string{"packages/core/src/index.ts", "packages/core/src/legacy.ts", "packages/ui/lib/main.ts"}
+	if got := r.EntryPoints(); !slices.Equal(got, want) {
+		t.Errorf("EntryPoints() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitSpecifier(t *testing.T) {
+	cases := map[string][2]string{
+		"pkg":                {"pkg", "."},
+		"pkg/a/b":            {"pkg", "./a/b"},
+		"@scope/pkg":         {"@scope/pkg", "."},
+		"@scope/pkg/feature": {"@scope/pkg", "./feature"},
+	}
+	for in, want := range cases {
+		name, subpath := splitSpecifier(in)
+		if name != want[0] || subpath != want[1] {
+			t.Errorf("splitSpecifier(%q) = %q, %q", in, name, subpath)
+		}
+	}
+}
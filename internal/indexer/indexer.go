@@ -11,6 +11,7 @@ type Indexer interface {
 	IndexFile(path string) error
 	IndexFileWithRoot(root, path string) error
 	SearchSymbol(name string) ([]models.SymbolHit, error)
+	GetSymbol(id string) (*models.SymbolDetail, error)
 	SearchSemantic(query string, topK int) ([]models.SemanticHit, error)
 
 	IndexProjectProgress(
@@ -18,3 +19,31 @@ type Indexer interface {
 		path string,
 	) (<-chan models.IndexProgress, <-chan error)
 }
+
+// FileStatusChecker is implemented by indexers that can compare a file on disk
+// with what the index holds for it
+type FileStatusChecker interface {
+	FileIndexStatus(root, path string) (*models.FileIndexStatus, error)
+}
+
+// PositionSymbolFinder is implemented by indexers that can tell which symbols
+// enclose a position in a file without a language server
+type PositionSymbolFinder interface {
+	// SymbolsAt returns the symbols enclosing the 0-based line and UTF-16
+	// character of path, innermost first
+	SymbolsAt(root, path string, line, character int) ([]models.PositionSymbol, error)
+}
+
+// ComponentPropsFinder is implemented by indexers that record the props types
+// of React components
+type ComponentPropsFinder interface {
+	FindComponentProps(name string) ([]models.ComponentProps, error)
+}
+
+// ExportedSymbolLister is implemented by indexers that can list the exported
+// declarations under a directory
+type ExportedSymbolLister interface {
+	// ExportedSymbols returns the exported symbols of the files under the
+	// project-relative dir, or of every file when dir is empty
+	ExportedSymbols(dir string) ([]models.Symbol, error)
+}
@@ -1,6 +1,9 @@
 package indexerfx
 
 import (
+	"regexp"
+
+	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/embeddings"
 	"github.com/0x5457/ts-index/internal/indexer"
 	"github.com/0x5457/ts-index/internal/indexer/pipeline"
@@ -17,17 +20,41 @@ type Params struct {
 	Embedder embeddings.Embedder
 	SymStore storage.SymbolStore
 	VecStore storage.VectorStore
+	Config   *configfx.Config
 }
 
 // NewIndexer creates a new indexer instance
-func NewIndexer(params Params) indexer.Indexer {
+func NewIndexer(params Params) (indexer.Indexer, error) {
+	var markers []*regexp.Regexp
+	if params.Config.SkipGenerated {
+		var err error
+		if markers, err = pipeline.ParseGeneratedMarkers(params.Config.GeneratedMarkers); err != nil {
+			return nil, err
+		}
+	}
+	templates, err := pipeline.ParseEmbedTemplates(params.Config.EmbedTemplates)
+	if err != nil {
+		return nil, err
+	}
 	return pipeline.New(
 		params.Parser,
 		params.Embedder,
 		params.SymStore,
 		params.VecStore,
-		pipeline.Options{},
-	)
+		pipeline.Options{
+			EnrichWithLSP:        params.Config.EnrichWithLSP,
+			SymbolsOnly:          params.Config.SymbolsOnly,
+			NoStoreContent:       params.Config.NoStoreContent,
+			MaxChunkContentBytes: params.Config.MaxChunkContentBytes,
+			FileCommits:          params.Config.FileCommits,
+			Vue:                  params.Config.Vue,
+			GitOnly:              params.Config.GitOnly,
+			IndexCallSites:       params.Config.IndexCallSites,
+			CallSiteCallees:      params.Config.CallSiteCallees,
+			GeneratedMarkers:     markers,
+			EmbedTemplates:       templates,
+		},
+	), nil
 }
 
 // Module provides indexer components
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+var _ indexer.ComponentPropsFinder = (*Indexer)(nil)
+
+// recordComponentProps links the React components of each .tsx file in files
+// to their props types. It is a no-op unless both the parser and the symbol
+// store support component props.
+func (i *Indexer) recordComponentProps(ctx context.Context, root string, files []string) error {
+	cp, ok := i.p.(parser.ComponentPropsParser)
+	if !ok {
+		return nil
+	}
+	store, ok := i.sym.(storage.ComponentPropsStore)
+	if !ok {
+		return nil
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f, ".tsx") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		props, err := cp.ParseComponentPropsWithRoot(root, f)
+		if err != nil {
+			return err
+		}
+		rel, err := relPath(root, f)
+		if err != nil {
+			return err
+		}
+		if err := store.SetComponentProps(rel, props); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindComponentProps returns the props types recorded for the React
+// components named name
+func (i *Indexer) FindComponentProps(name string) ([]models.ComponentProps, error) {
+	store, ok := i.sym.(storage.ComponentPropsStore)
+	if !ok {
+		return nil, errors.New("symbol store cannot hold component props")
+	}
+	return store.FindComponentProps(name)
+}
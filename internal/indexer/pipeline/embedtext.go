@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// AnyLanguage keys the embed template used for languages without their own
+const AnyLanguage = "*"
+
+// embedTextData is what an embed template is executed with. Kind is named as
+// by models.SymbolKindName, and Type is the LSP-resolved signature under
+// Options.EnrichWithLSP.
+type embedTextData struct {
+	Name      string
+	Kind      string
+	NodeType  string
+	File      string
+	Language  string
+	Signature string
+	Type      string
+	Docstring string
+	Content   string
+}
+
+// templateEscapes lets templates written on one command line hold line breaks
+var templateEscapes = strings.NewReplacer(`\n`, "\n", `\t`, "\t")
+
+// ParseEmbedTemplates parses embed text templates written as
+// "language=template" for Options.EmbedTemplates, e.g.
+// `tsx={{.Signature}}\n{{.Content}}`. Languages are the parser's names (ts,
+// tsx, vue), or * for all others. Templates use text/template syntax over the
+// fields Name, Kind, NodeType, File, Language, Signature, Type, Docstring and
+// Content, and \n and \t in them stand for a line break and a tab.
+func ParseEmbedTemplates(specs []string) (map[string]*template.Template, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]*template.Template, len(specs))
+	for _, spec := range specs {
+		language, text, ok := strings.Cut(spec, "=")
+		language = strings.TrimSpace(language)
+		if !ok || language == "" {
+			return nil, fmt.Errorf("invalid embed template %q, want language=template", spec)
+		}
+		tmpl, err := template.New(language).Parse(templateEscapes.Replace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid embed template for %s: %w", language, err)
+		}
+		// unknown fields only fail when executed
+		if err := tmpl.Execute(&strings.Builder{}, embedTextData{}); err != nil {
+			return nil, fmt.Errorf("invalid embed template for %s: %w", language, err)
+		}
+		out[language] = tmpl
+	}
+	return out, nil
+}
+
+// embedText builds the embedding input of ch from the template configured for
+// its language, or the signature, resolved type, docstring and content on
+// separate lines when there is none
+func (i *Indexer) embedText(ch models.CodeChunk, resolvedType string) string {
+	tmpl, ok := i.opt.EmbedTemplates[ch.Language]
+	if !ok {
+		tmpl, ok = i.opt.EmbedTemplates[AnyLanguage]
+	}
+	if !ok {
+		return buildEmbedText(ch, resolvedType)
+	}
+	var b strings.Builder
+	err := tmpl.Execute(&b, embedTextData{
+		Name:      ch.Name,
+		Kind:      models.SymbolKindName(ch.Kind),
+		NodeType:  ch.NodeType,
+		File:      ch.File,
+		Language:  ch.Language,
+		Signature: ch.Signature,
+		Type:      resolvedType,
+		Docstring: ch.Docstring,
+		Content:   ch.Content,
+	})
+	if err != nil {
+		return buildEmbedText(ch, resolvedType)
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+var (
+	_ lsp.EnclosingSymbolFinder    = (*Indexer)(nil)
+	_ indexer.PositionSymbolFinder = (*Indexer)(nil)
+)
+
+// EnclosingSymbol returns the smallest stored symbol of file, relative to root
+// or absolute, whose lines contain line (0-based)
+func (i *Indexer) EnclosingSymbol(root, file string, line int) (*lsp.EnclosingSymbol, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	rel, err := relPath(root, file)
+	if err != nil {
+		return nil, err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+	symbols, err := i.storedEnclosing(filepath.ToSlash(rel), int32(line)+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	best := symbols[0]
+	return &lsp.EnclosingSymbol{
+		Name: best.Name,
+		Kind: best.Kind,
+		Range: lsp.Range{
+			Start: lsp.Position{Line: int(best.StartLine) - 1},
+			End:   lsp.Position{Line: int(best.EndLine) - 1},
+		},
+	}, nil
+}
+
+// storedEnclosing returns the stored symbols of file whose lines contain line
+// (1-based), innermost first
+func (i *Indexer) storedEnclosing(file string, line int32) ([]models.Symbol, error) {
+	if store, ok := i.sym.(storage.EnclosingSymbolStore); ok {
+		return store.FindEnclosing(file, int(line))
+	}
+	store, ok := i.sym.(storage.FileSymbolStore)
+	if !ok {
+		return nil, errors.New("symbol store cannot list symbols by file")
+	}
+	symbols, err := store.SymbolsByFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.Symbol
+	for _, sym := range symbols {
+		if sym.StartLine <= line && sym.EndLine >= line {
+			out = append(out, sym)
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].EndLine-out[a].StartLine < out[b].EndLine-out[b].StartLine
+	})
+	return out, nil
+}
+
+// SymbolsAt returns the symbols enclosing the 0-based line and UTF-16
+// character of path, innermost first. Stored symbols are matched by line.
+// When none is stored there, or there is no symbol store, the file is parsed
+// and its symbols are matched by byte range, which also tells apart
+// declarations sharing a line.
+func (i *Indexer) SymbolsAt(root, path string, line, character int) ([]models.PositionSymbol, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := relPath(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is outside the project", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimPrefix(string(raw), "\ufeff")
+	offset, err := lsp.PositionToByteOffset(content, lsp.Position{Line: line, Character: character})
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(content, "\n")
+	signature := func(sym models.Symbol) string {
+		if sym.StartLine < 1 || int(sym.StartLine) > len(lines) {
+			return ""
+		}
+		return strings.TrimSpace(lines[sym.StartLine-1])
+	}
+
+	if i.sym != nil {
+		stored, err := i.storedEnclosing(filepath.ToSlash(rel), int32(line)+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) > 0 {
+			out := make([]models.PositionSymbol, len(stored))
+			for k, sym := range stored {
+				out[k] = models.PositionSymbol{Symbol: sym, Signature: signature(sym)}
+			}
+			return out, nil
+		}
+	}
+
+	parsed, _, err := i.p.ParseFileWithRoot(root, path)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.PositionSymbol
+	for _, sym := range parsed {
+		if int(sym.StartByte) <= offset && offset < int(sym.EndByte) {
+			out = append(out, models.PositionSymbol{Symbol: sym, Signature: signature(sym), Parsed: true})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].EndByte-out[a].StartByte < out[b].EndByte-out[b].StartByte
+	})
+	return out, nil
+}
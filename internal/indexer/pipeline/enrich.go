@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/0x5457/ts-index/internal/logging"
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser"
+)
+
+// enrichTimeout bounds the hover requests issued for a single file
+const enrichTimeout = 30 * time.Second
+
+// typeEnricher resolves chunk type signatures through a language server.
+// It is best-effort: any failure leaves the affected chunks unenriched.
+type typeEnricher struct {
+	root  string
+	tools *lsp.ClientTools
+}
+
+func newTypeEnricher(root string) *typeEnricher {
+	return &typeEnricher{root: root, tools: lsp.NewClientTools()}
+}
+
+// Close shuts down the language servers started for enrichment
+func (e *typeEnricher) Close() {
+	if err := e.tools.Cleanup(); err != nil {
+		logging.L().Warn("failed to stop enrichment language server", "error", err)
+	}
+}
+
+// Resolve returns resolved type strings keyed by chunk ID for function and method chunks
+func (e *typeEnricher) Resolve(ctx context.Context, chs []models.CodeChunk) map[string]string {
+	byFile := make(map[string][]models.CodeChunk)
+	for _, ch := range chs {
+		if ch.Kind == models.SymbolFunction || ch.Kind == models.SymbolMethod {
+			byFile[ch.File] = append(byFile[ch.File], ch)
+		}
+	}
+
+	types := make(map[string]string)
+	for file, fileChunks := range byFile {
+		absPath := file
+		if !filepath.IsAbs(absPath) {
+			absPath = filepath.Join(e.root, file)
+		}
+		code, err := readSource(absPath)
+		if err != nil {
+			continue
+		}
+
+		var ids []string
+		var positions []lsp.Position
+		for _, ch := range fileChunks {
+			if pos, ok := namePosition(code, ch); ok {
+				ids = append(ids, ch.ID)
+				positions = append(positions, pos)
+			}
+		}
+		if len(positions) == 0 {
+			continue
+		}
+
+		fileCtx, cancel := context.WithTimeout(ctx, enrichTimeout)
+		hovers, err := e.tools.HoverBatch(fileCtx, e.root, absPath, positions)
+		cancel()
+		if err != nil {
+			logging.L().Info("LSP enrichment skipped", "file", file, "error", err)
+		}
+		for idx, hover := range hovers {
+			if hover == nil {
+				continue
+			}
+			if sig := lsp.HoverSignature(hover.Contents); sig != "" {
+				types[ids[idx]] = sig
+			}
+		}
+	}
+	return types
+}
+
+// readSource reads a file decoded as the parser decodes it, so the byte offsets
+// of its chunks index the result
+func readSource(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parser.DecodeSource(path, raw), nil
+}
+
+// namePosition locates the chunk's name inside the file as an LSP position.
+// The name is the first occurrence in the chunk that is a whole identifier,
+// so keywords containing it, like "function" for f, are passed over.
+func namePosition(code []byte, ch models.CodeChunk) (lsp.Position, bool) {
+	if ch.Name == "" || int(ch.EndByte) > len(code) || ch.StartByte < 0 || ch.StartByte > ch.EndByte {
+		return lsp.Position{}, false
+	}
+	text := string(code[ch.StartByte:ch.EndByte])
+	for from := 0; ; {
+		idx := strings.Index(text[from:], ch.Name)
+		if idx < 0 {
+			return lsp.Position{}, false
+		}
+		start, end := from+idx, from+idx+len(ch.Name)
+		if !endsIdentifier(text[:start]) && !startsIdentifier(text[end:]) {
+			position, err := lsp.ByteOffsetToPosition(string(code), int(ch.StartByte)+start)
+			return position, err == nil
+		}
+		from = start + 1
+	}
+}
+
+// endsIdentifier reports whether s ends with an identifier character
+func endsIdentifier(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r != utf8.RuneError && isIdentifierRune(r)
+}
+
+// startsIdentifier reports whether s starts with an identifier character
+func startsIdentifier(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r != utf8.RuneError && isIdentifierRune(r)
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
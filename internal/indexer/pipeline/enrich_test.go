@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser/tsparser"
+)
+
+// fakeLSPAdapter serves TypeScript with the fake language server of the lsp tests
+type fakeLSPAdapter struct {
+	*lsp.TypeScriptLspAdapter
+	bin string
+}
+
+func (a *fakeLSPAdapter) ServerCommand(string) (string, []string, error) { return a.bin, nil, nil }
+
+func (a *fakeLSPAdapter) IsInstalled() bool { return true }
+
+func buildFakeServer(t *testing.T) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available to build the fake language server")
+	}
+	bin := filepath.Join(t.TempDir(), "fakelsp")
+	out, err := exec.Command(goBin, "build", "-o", bin, "../../lsp/testdata/fakelsp").CombinedOutput()
+	if err != nil {
+		t.Fatalf("build fake language server: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// chunkOf returns a function chunk spanning the whole of line in code
+func chunkOf(code, line, name string) models.CodeChunk {
+	start := strings.Index(code, line)
+	return models.CodeChunk{
+		ID:        name,
+		File:      "a.ts",
+		Name:      name,
+		Kind:      models.SymbolFunction,
+		StartByte: int32(start),
+		EndByte:   int32(start + len(line)),
+		Signature: line,
+		Content:   line,
+	}
+}
+
+func TestNamePosition(t *testing.T) {
+	code := "function f() {}\n" +
+		"export const e = () => 1\n" +
+		"export function addAll(add: number) {}\n" +
+		"function $x() {}\n" +
+		"function other() {}"
+	tests := []struct {
+		line, name string
+		want       lsp.Position
+		ok         bool
+	}{
+		{line: "function f() {}", name: "f", want: lsp.Position{Line: 0, Character: 9}, ok: true},
+		{line: "export const e = () => 1", name: "e", want: lsp.Position{Line: 1, Character: 13}, ok: true},
+		{line: "export function addAll(add: number) {}", name: "add", want: lsp.Position{Line: 2, Character: 23},
+			ok: true},
+		{line: "function $x() {}", name: "$x", want: lsp.Position{Line: 3, Character: 9}, ok: true},
+		{line: "function other() {}", name: "missing"},
+	}
+	for _, tt := range tests {
+		got, ok := namePosition([]byte(code), chunkOf(code, tt.line, tt.name))
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s in %q: expected %+v, %v, got %+v, %v", tt.name, tt.line, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestNamePositionEncodings(t *testing.T) {
+	src := "export function add(a: number, b: number) { return a + b }\n"
+	utf16LE := []byte{0xFF, 0xFE}
+	for _, r := range src {
+		utf16LE = append(utf16LE, byte(r), 0)
+	}
+	files := map[string][]byte{
+		"plain.ts": []byte(src),
+		"bom.ts":   append([]byte{0xEF, 0xBB, 0xBF}, src...),
+		"utf16.ts": utf16LE,
+	}
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// chunk offsets count from the text the parser decoded
+		_, chunks, err := tsparser.New().ParseFileWithRoot(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := readSource(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, ch := range chunks {
+			if ch.Name != "add" {
+				continue
+			}
+			found = true
+			pos, ok := namePosition(code, ch)
+			if want := (lsp.Position{Line: 0, Character: 16}); !ok || pos != want {
+				t.Fatalf("%s: expected add at %+v, got %+v, %v", name, want, pos, ok)
+			}
+		}
+		if !found {
+			t.Fatalf("%s: no chunk for add in %+v", name, chunks)
+		}
+	}
+}
+
+func TestTypeEnricher(t *testing.T) {
+	root := t.TempDir()
+	// the fake server hovers line 0 with a signature and line 1 with nothing
+	code := "export function add(a: number, b: number) { return a + b }\n" +
+		"function f() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	enricher := &typeEnricher{root: root, tools: lsp.NewClientTools()}
+	enricher.tools.RegisterAdapter("typescript", &fakeLSPAdapter{
+		TypeScriptLspAdapter: lsp.NewTypeScriptLspAdapter(),
+		bin:                  buildFakeServer(t),
+	})
+	defer enricher.Close()
+
+	add := chunkOf(code, "export function add(a: number, b: number) { return a + b }", "add")
+	f := chunkOf(code, "function f() {}", "f")
+	texts := (&Indexer{}).embedTexts(context.Background(), enricher, []models.CodeChunk{add, f})
+
+	const sig = "function add(a: number, b: number): number"
+	if want := add.Signature + "\n" + sig + "\n" + add.Content; texts[0] != want {
+		t.Fatalf("expected the resolved signature in the embed text:\n%s\ngot:\n%s", want, texts[0])
+	}
+	if want := f.Signature + "\n" + f.Content; texts[1] != want {
+		t.Fatalf("expected no resolved type without a hover:\n%s\ngot:\n%s", want, texts[1])
+	}
+}
@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+var _ indexer.ExportedSymbolLister = (*Indexer)(nil)
+
+// ExportedSymbols returns the exported, non-ambient symbols of the files under
+// dir, ordered by file and line
+func (i *Indexer) ExportedSymbols(dir string) ([]models.Symbol, error) {
+	finder, ok := i.sym.(storage.SymbolFinder)
+	if !ok {
+		return nil, errors.New("symbol store cannot list symbols")
+	}
+	filter := storage.SymbolFilter{ExportedOnly: true}
+	if dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/"); dir != "." && dir != "" {
+		filter.File = dir + "/*"
+	}
+	found, err := finder.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+	out := found[:0]
+	for _, sym := range found {
+		if !sym.Ambient {
+			out = append(out, sym)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"errors"
+
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+var _ indexer.FileStatusChecker = (*Indexer)(nil)
+
+// FileIndexStatus parses path fresh and compares its symbols with the ones
+// stored for it. Symbol IDs encode location, kind and name, so a symbol that
+// moved shows up as missing at its new lines and stale at its old ones.
+// Symbols merged in from a language server sync are reported as stale too.
+func (i *Indexer) FileIndexStatus(root, path string) (*models.FileIndexStatus, error) {
+	store, ok := i.sym.(storage.FileSymbolStore)
+	if !ok {
+		return nil, errors.New("symbol store cannot list symbols by file")
+	}
+	parsed, _, err := i.p.ParseFileWithRoot(root, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := relPath(root, path)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := store.SymbolsByFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.FileIndexStatus{
+		File:    file,
+		Indexed: len(stored) > 0,
+		Parsed:  len(parsed),
+		Stored:  len(stored),
+	}
+	storedIDs := make(map[string]bool, len(stored))
+	for _, sym := range stored {
+		storedIDs[sym.ID] = true
+	}
+	parsedIDs := make(map[string]bool, len(parsed))
+	for _, sym := range parsed {
+		parsedIDs[sym.ID] = true
+		if !storedIDs[sym.ID] {
+			status.Missing = append(status.Missing, sym)
+		}
+	}
+	for _, sym := range stored {
+		if !parsedIDs[sym.ID] {
+			status.Stale = append(status.Stale, sym)
+		}
+	}
+	status.UpToDate = len(status.Missing) == 0 && len(status.Stale) == 0
+	return status, nil
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/0x5457/ts-index/internal/logging"
+)
+
+// generatedHeaderLines is how many leading lines of a file are searched for a
+// generated-file marker
+const generatedHeaderLines = 10
+
+// DefaultGeneratedMarkers match the headers common codegen tools write, such as
+// "// Code generated by protoc-gen-ts. DO NOT EDIT." or "// @generated"
+var DefaultGeneratedMarkers = []string{
+	`Code generated .*DO NOT EDIT`,
+	`@generated\b`,
+	`<auto-generated`,
+}
+
+// ParseGeneratedMarkers compiles generated-file marker patterns for
+// Options.GeneratedMarkers, using DefaultGeneratedMarkers when none are given
+func ParseGeneratedMarkers(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultGeneratedMarkers
+	}
+	markers := make([]*regexp.Regexp, len(patterns))
+	for idx, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generated-file marker %q: %w", pattern, err)
+		}
+		markers[idx] = re
+	}
+	return markers, nil
+}
+
+// skipGenerated drops the generated files from files, returning the rest and
+// the number dropped
+func (i *Indexer) skipGenerated(files []string) ([]string, int) {
+	if len(i.opt.GeneratedMarkers) == 0 {
+		return files, 0
+	}
+	kept := files[:0:0]
+	for _, file := range files {
+		if i.isGenerated(file) {
+			logging.L().Info("skipped generated file", "file", file)
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept, len(files) - len(kept)
+}
+
+// isGenerated reports whether a leading line of path matches a marker. Files
+// that cannot be read are left for the parser to report.
+func (i *Indexer) isGenerated(path string) bool {
+	if len(i.opt.GeneratedMarkers) == 0 {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for line := 0; line < generatedHeaderLines && scanner.Scan(); line++ {
+		for _, marker := range i.opt.GeneratedMarkers {
+			if marker.Match(scanner.Bytes()) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+// chunkKey identifies a chunk by its name and the text its embedding was made
+// from, leaving out its location
+type chunkKey struct {
+	kind      models.SymbolKind
+	name      string
+	nodeType  string
+	signature string
+	docstring string
+	content   string
+}
+
+func keyOf(ch models.CodeChunk) chunkKey {
+	return chunkKey{ch.Kind, ch.Name, ch.NodeType, ch.Signature, ch.Docstring, ch.Content}
+}
+
+// incrementalStore returns the vector store when reindexing a file can keep
+// the embeddings of its unchanged chunks. That needs the stored text to
+// compare with, and embed text that depends only on the chunk, so not under
+// NoStoreContent or EnrichWithLSP.
+func (i *Indexer) incrementalStore() storage.IncrementalVectorStore {
+	if i.opt.SymbolsOnly || i.opt.NoStoreContent || i.opt.EnrichWithLSP {
+		return nil
+	}
+	store, _ := i.vec.(storage.IncrementalVectorStore)
+	return store
+}
+
+// updateFile stores the re-parsed chunks of file against the chunks stored for
+// it, embedding only the new and changed ones. Stored chunks are matched by
+// kind, name and text; a match that moved is rewritten at its new lines with
+// its embedding kept, and stored chunks without a match are deleted. Content
+// cut at MaxChunkContentBytes never matches, so such chunks are re-embedded.
+func (i *Indexer) updateFile(store storage.IncrementalVectorStore, file string, chs []models.CodeChunk) error {
+	stored, err := store.ChunksByFile(file)
+	if err != nil {
+		return err
+	}
+	unmatched := make(map[chunkKey][]models.CodeChunk, len(stored))
+	for _, ch := range stored {
+		unmatched[keyOf(ch)] = append(unmatched[keyOf(ch)], ch)
+	}
+
+	var (
+		moveIDs []string
+		moved   []models.CodeChunk
+		changed []models.CodeChunk
+	)
+	for _, ch := range chs {
+		key := keyOf(ch)
+		olds := unmatched[key]
+		if len(olds) == 0 {
+			changed = append(changed, ch)
+			continue
+		}
+		old := olds[0]
+		unmatched[key] = olds[1:]
+		if old != ch {
+			moveIDs = append(moveIDs, old.ID)
+			moved = append(moved, ch)
+		}
+	}
+	var deleted []string
+	for _, olds := range unmatched {
+		for _, old := range olds {
+			deleted = append(deleted, old.ID)
+		}
+	}
+
+	// embed first so a failing embed request leaves the stored file as it was
+	var vecs [][]float32
+	if len(changed) > 0 {
+		vecs, err = i.e.EmbedTexts(i.embedTexts(context.Background(), nil, changed))
+		if err != nil {
+			return err
+		}
+	}
+	if err := store.DeleteByIDs(deleted); err != nil {
+		return err
+	}
+	if err := store.MoveChunks(moveIDs, moved); err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return i.upsertChunks(changed, vecs)
+}
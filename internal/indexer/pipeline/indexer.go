@@ -2,13 +2,20 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"io/fs"
+	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/gitinfo"
+	"github.com/0x5457/ts-index/internal/ignore"
+	"github.com/0x5457/ts-index/internal/logging"
 	"github.com/0x5457/ts-index/internal/models"
 	"github.com/0x5457/ts-index/internal/parser"
 	"github.com/0x5457/ts-index/internal/storage"
@@ -18,6 +25,46 @@ type Options struct {
 	ParseWorkers   int
 	EmbedBatchSize int
 	EmbedWorkers   int
+	// EnrichWithLSP appends language-server-resolved signatures of functions and
+	// methods to their embed text. Best-effort: LSP failures never fail indexing.
+	EnrichWithLSP bool
+	// SymbolsOnly parses files and stores symbols without embedding chunks.
+	// The embedder and vector store are never used, so no embed server is needed.
+	SymbolsOnly bool
+	// NoStoreContent keeps source code out of the database: chunks are embedded
+	// from their in-memory text but stored without content, signature or
+	// docstring, and symbols without docstrings. Results carry locations only.
+	NoStoreContent bool
+	// MaxChunkContentBytes caps the content stored per chunk; longer content,
+	// such as a huge generated enum, is cut and ends with "…". Chunks are still
+	// embedded from their full text and keep their byte range, so the exact
+	// source can be read from disk. Zero stores content whole.
+	MaxChunkContentBytes int
+	// FileCommits records the commit that last modified each indexed file,
+	// returned with its chunks. The commit the project is checked out at is
+	// recorded regardless when the project is a git repository.
+	FileCommits bool
+	// Vue also indexes the script blocks of .vue single-file components
+	Vue bool
+	// GitOnly indexes only the files git tracks, as listed by git ls-files,
+	// instead of walking the project. Projects outside a git repository, or a
+	// missing git binary, fall back to the walk.
+	GitOnly bool
+	// IndexCallSites also embeds a chunk per notable call expression, of kind
+	// models.SymbolCall, so searches can find API usages. Calls match
+	// CallSiteCallees, or go through an imported binding when it is empty.
+	// Ignored when the parser cannot extract call sites or under SymbolsOnly.
+	IndexCallSites  bool
+	CallSiteCallees []string
+	// GeneratedMarkers skip files with a match in their first lines, such as
+	// "// Code generated ... DO NOT EDIT", reporting them in
+	// IndexProgress.SkippedFiles. See ParseGeneratedMarkers. Empty indexes
+	// generated files like any other.
+	GeneratedMarkers []*regexp.Regexp
+	// EmbedTemplates lay out the embed text of chunks by language; see
+	// ParseEmbedTemplates. Languages without one embed the signature, resolved
+	// type, docstring and content on separate lines.
+	EmbedTemplates map[string]*template.Template
 }
 
 type Indexer struct {
@@ -82,17 +129,25 @@ func (i *Indexer) IndexProjectProgress(
 		defer close(progCh)
 		defer close(errCh)
 
-		files, err := listTSFiles(root)
+		var enricher *typeEnricher
+		if i.opt.EnrichWithLSP && !i.opt.SymbolsOnly {
+			enricher = newTypeEnricher(root)
+			defer enricher.Close()
+		}
+
+		files, err := i.listFiles(ctx, root)
 		if err != nil {
 			errCh <- err
 			return
 		}
+		files, skippedFiles := i.skipGenerated(files)
 		totalFiles := len(files)
 		send(models.IndexProgress{
-			Stage:      models.IndexStageScan,
-			TotalFiles: totalFiles,
-			Message:    "scan complete",
-			Percent:    0,
+			Stage:        models.IndexStageScan,
+			TotalFiles:   totalFiles,
+			SkippedFiles: skippedFiles,
+			Message:      "scan complete",
+			Percent:      0,
 		})
 
 		// Stage 1: parse files concurrently
@@ -111,7 +166,7 @@ func (i *Indexer) IndexProjectProgress(
 			go func() {
 				defer wgParse.Done()
 				for f := range parseCh {
-					syms, chs, err := i.p.ParseFileWithRoot(root, f)
+					syms, chs, err := i.parseFile(root, f)
 					select {
 					case <-ctx.Done():
 						return
@@ -134,17 +189,22 @@ func (i *Indexer) IndexProjectProgress(
 		embeddedChunks := 0
 
 		// Percent policy:
-		// - Parse 60%
-		// - Embed 35%
+		// - Parse 60% (95% when symbols only)
+		// - Embed 35% (skipped when symbols only)
 		// - Symbol upsert 5%
+		parseShare := float32(0.6)
+		if i.opt.SymbolsOnly {
+			parseShare = 0.95
+		}
 		updateParseProgress := func(currentFile string) {
 			pct := float32(0)
 			if totalFiles > 0 {
-				pct = 0.6 * float32(parsedFiles) / float32(totalFiles)
+				pct = parseShare * float32(parsedFiles) / float32(totalFiles)
 			}
 			send(models.IndexProgress{
 				Stage:          models.IndexStageParse,
 				TotalFiles:     totalFiles,
+				SkippedFiles:   skippedFiles,
 				ParsedFiles:    parsedFiles,
 				TotalChunks:    totalChunks,
 				EmbeddedChunks: embeddedChunks,
@@ -160,6 +220,7 @@ func (i *Indexer) IndexProjectProgress(
 			send(models.IndexProgress{
 				Stage:          models.IndexStageEmbed,
 				TotalFiles:     totalFiles,
+				SkippedFiles:   skippedFiles,
 				ParsedFiles:    parsedFiles,
 				TotalChunks:    totalChunks,
 				EmbeddedChunks: embeddedChunks,
@@ -168,18 +229,18 @@ func (i *Indexer) IndexProjectProgress(
 		}
 
 		flush := func(chs []models.CodeChunk) error {
-			if len(chs) == 0 {
+			if len(chs) == 0 || i.opt.SymbolsOnly {
 				return nil
 			}
-			texts := make([]string, len(chs))
-			for idx, ch := range chs {
-				texts[idx] = buildEmbedText(ch)
-			}
-			vecs, err := i.e.EmbedTexts(texts)
+			vecs, err := embeddings.EmbedTexts(ctx, i.e, i.embedTexts(ctx, enricher, chs))
 			if err != nil {
 				return err
 			}
-			if err := i.vec.Upsert(chs, vecs); err != nil {
+			// a batch embedded just before cancellation is not stored
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := i.upsertChunks(chs, vecs); err != nil {
 				return err
 			}
 			embeddedChunks += len(chs)
@@ -188,14 +249,22 @@ func (i *Indexer) IndexProjectProgress(
 		}
 
 		for r := range resCh {
+			if err := ctx.Err(); err != nil {
+				errCh <- err
+				return
+			}
 			if r.err != nil {
 				errCh <- r.err
 				return
 			}
 			allSyms = append(allSyms, r.syms...)
+			parsedFiles++
+			if i.opt.SymbolsOnly {
+				updateParseProgress(r.file)
+				continue
+			}
 			batchChs = append(batchChs, r.chs...)
 			totalChunks += len(r.chs)
-			parsedFiles++
 			updateParseProgress(r.file)
 
 			for len(batchChs) >= i.opt.EmbedBatchSize {
@@ -207,17 +276,26 @@ func (i *Indexer) IndexProjectProgress(
 			}
 		}
 
-		// Parsing finished; switch to embed stage start at 60%
-		send(models.IndexProgress{
-			Stage:          models.IndexStageEmbed,
-			TotalFiles:     totalFiles,
-			ParsedFiles:    parsedFiles,
-			TotalChunks:    totalChunks,
-			EmbeddedChunks: embeddedChunks,
-			Percent:        0.6,
-		})
+		if !i.opt.SymbolsOnly {
+			// Parsing finished; switch to embed stage start at 60%
+			send(models.IndexProgress{
+				Stage:          models.IndexStageEmbed,
+				TotalFiles:     totalFiles,
+				SkippedFiles:   skippedFiles,
+				ParsedFiles:    parsedFiles,
+				TotalChunks:    totalChunks,
+				EmbeddedChunks: embeddedChunks,
+				Percent:        0.6,
+			})
 
-		if err := flush(batchChs); err != nil {
+			if err := flush(batchChs); err != nil {
+				errCh <- err
+				return
+			}
+		}
+
+		// parse workers stop early once ctx is done, so resCh may close short
+		if err := ctx.Err(); err != nil {
 			errCh <- err
 			return
 		}
@@ -228,11 +306,20 @@ func (i *Indexer) IndexProjectProgress(
 			Percent:        0.95,
 			Message:        "upserting symbols",
 			TotalFiles:     totalFiles,
+			SkippedFiles:   skippedFiles,
 			ParsedFiles:    parsedFiles,
 			TotalChunks:    totalChunks,
 			EmbeddedChunks: embeddedChunks,
 		})
-		if err := i.sym.UpsertSymbols(allSyms); err != nil {
+		if err := i.upsertSymbols(allSyms); err != nil {
+			errCh <- err
+			return
+		}
+		if err := i.recordComponentProps(ctx, root, files); err != nil {
+			errCh <- err
+			return
+		}
+		if err := i.recordProvenance(ctx, root, files); err != nil {
 			errCh <- err
 			return
 		}
@@ -241,6 +328,7 @@ func (i *Indexer) IndexProjectProgress(
 		send(models.IndexProgress{
 			Stage:          models.IndexStageDone,
 			TotalFiles:     totalFiles,
+			SkippedFiles:   skippedFiles,
 			ParsedFiles:    parsedFiles,
 			TotalChunks:    totalChunks,
 			EmbeddedChunks: embeddedChunks,
@@ -252,58 +340,203 @@ func (i *Indexer) IndexProjectProgress(
 	return progCh, errCh
 }
 
+// IndexFile reindexes path. The file's previous symbols and chunks are only
+// replaced once it is parsed and embedded, so a failure leaves them intact.
 func (i *Indexer) IndexFile(path string) error {
-	if err := i.sym.DeleteSymbolsByFile(path); err != nil {
-		return err
-	}
-	if err := i.vec.DeleteByFile(path); err != nil {
-		return err
-	}
 	syms, chs, err := i.p.ParseFile(path)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// the file is gone: drop what the index holds for it
+			if delErr := i.deleteFile(path); delErr != nil {
+				return delErr
+			}
+		}
 		return err
 	}
-	texts := make([]string, len(chs))
-	for idx, ch := range chs {
-		texts[idx] = buildEmbedText(ch)
-	}
-	vecs, err := i.e.EmbedTexts(texts)
+	vecs, err := i.embedFile(nil, chs)
 	if err != nil {
 		return err
 	}
-	if err := i.sym.UpsertSymbols(syms); err != nil {
+	if err := i.deleteFile(path); err != nil {
 		return err
 	}
-	return i.vec.Upsert(chs, vecs)
+	return i.storeFile(syms, chs, vecs)
 }
 
-// IndexFileWithRoot indexes a single file using relative paths based on the root path
+// IndexFileWithRoot indexes a single file using relative paths based on the
+// root path. Like IndexFile, it replaces the file's previous index only once
+// the file is parsed and embedded.
 func (i *Indexer) IndexFileWithRoot(root, path string) error {
 	// For deletion, we need to determine what path format is stored
 	// We'll try both the original path and relative path
-	if err := i.sym.DeleteSymbolsByFile(path); err != nil {
+	paths := []string{path}
+	rel, err := relPath(root, path)
+	if err == nil && rel != path {
+		paths = append(paths, rel)
+	}
+	generated := i.isGenerated(path)
+	// The chunks stored under the relative path, as the parser records it,
+	// are updated in place so unchanged ones keep their embeddings
+	store := i.incrementalStore()
+	if err != nil || generated {
+		store = nil
+	}
+	if generated {
+		for _, p := range paths {
+			if err := i.deleteFile(p); err != nil {
+				return err
+			}
+		}
+		logging.L().Info("skipped generated file", "file", path)
+		return nil
+	}
+
+	syms, chs, err := i.parseFile(root, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// the file is gone: drop what the index holds for it
+			for _, p := range paths {
+				if delErr := i.deleteFile(p); delErr != nil {
+					return delErr
+				}
+			}
+		}
 		return err
 	}
-	if err := i.vec.DeleteByFile(path); err != nil {
+	if store != nil {
+		// updateFile embeds the changed chunks before touching stored ones
+		if err := i.updateFile(store, rel, chs); err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if p == rel {
+				err = i.sym.DeleteSymbolsByFile(p)
+			} else {
+				err = i.deleteFile(p)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := i.upsertSymbols(syms); err != nil {
+			return err
+		}
+	} else {
+		var enricher *typeEnricher
+		if i.opt.EnrichWithLSP && !i.opt.SymbolsOnly {
+			enricher = newTypeEnricher(root)
+			defer enricher.Close()
+		}
+		vecs, err := i.embedFile(enricher, chs)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if err := i.deleteFile(p); err != nil {
+				return err
+			}
+		}
+		if err := i.storeFile(syms, chs, vecs); err != nil {
+			return err
+		}
+	}
+	if err := i.recordComponentProps(context.Background(), root, []string{path}); err != nil {
 		return err
 	}
+	if store, ok := i.vec.(storage.ProvenanceStore); ok {
+		return i.recordFileCommits(context.Background(), store, root, []string{path})
+	}
+	return nil
+}
 
+// parseFile parses path relative to root, adding call site chunks when enabled
+func (i *Indexer) parseFile(root, path string) ([]models.Symbol, []models.CodeChunk, error) {
 	syms, chs, err := i.p.ParseFileWithRoot(root, path)
+	if err != nil || !i.opt.IndexCallSites || i.opt.SymbolsOnly {
+		return syms, chs, err
+	}
+	cp, ok := i.p.(parser.CallSiteParser)
+	if !ok {
+		return syms, chs, nil
+	}
+	calls, err := cp.ParseCallSitesWithRoot(root, path, i.opt.CallSiteCallees)
 	if err != nil {
+		return nil, nil, err
+	}
+	return syms, append(chs, calls...), nil
+}
+
+// deleteFile removes the stored symbols and chunks of file. Chunks are removed
+// under SymbolsOnly as well, since an earlier full index may have stored them.
+func (i *Indexer) deleteFile(file string) error {
+	if err := i.sym.DeleteSymbolsByFile(file); err != nil {
 		return err
 	}
-	texts := make([]string, len(chs))
-	for idx, ch := range chs {
-		texts[idx] = buildEmbedText(ch)
+	if i.vec == nil {
+		return nil
 	}
-	vecs, err := i.e.EmbedTexts(texts)
-	if err != nil {
+	return i.vec.DeleteByFile(file)
+}
+
+// embedFile embeds the chunks of a parsed file, or nothing under SymbolsOnly
+func (i *Indexer) embedFile(enricher *typeEnricher, chs []models.CodeChunk) ([][]float32, error) {
+	if i.opt.SymbolsOnly {
+		return nil, nil
+	}
+	return i.e.EmbedTexts(i.embedTexts(context.Background(), enricher, chs))
+}
+
+// storeFile saves the symbols of a parsed file and, unless SymbolsOnly, its
+// chunks with their embeddings
+func (i *Indexer) storeFile(syms []models.Symbol, chs []models.CodeChunk, vecs [][]float32) error {
+	if err := i.upsertSymbols(syms); err != nil {
 		return err
 	}
-	if err := i.sym.UpsertSymbols(syms); err != nil {
+	if i.opt.SymbolsOnly {
+		return nil
+	}
+	return i.upsertChunks(chs, vecs)
+}
+
+// upsertSymbols stores symbols, dropping their docstrings under NoStoreContent
+func (i *Indexer) upsertSymbols(syms []models.Symbol) error {
+	if i.opt.NoStoreContent {
+		redacted := make([]models.Symbol, len(syms))
+		for idx, sym := range syms {
+			sym.Docstring = ""
+			redacted[idx] = sym
+		}
+		syms = redacted
+	}
+	return i.sym.UpsertSymbols(syms)
+}
+
+// upsertChunks stores embedded chunks, dropping their source text under
+// NoStoreContent and capping it at MaxChunkContentBytes, and records the
+// embedding model
+func (i *Indexer) upsertChunks(chs []models.CodeChunk, vecs [][]float32) error {
+	if i.opt.NoStoreContent {
+		redacted := make([]models.CodeChunk, len(chs))
+		for idx, ch := range chs {
+			ch.Content, ch.Signature, ch.Docstring = "", "", ""
+			redacted[idx] = ch
+		}
+		chs = redacted
+	} else if limit := i.opt.MaxChunkContentBytes; limit > 0 {
+		capped := make([]models.CodeChunk, len(chs))
+		for idx, ch := range chs {
+			if len(ch.Content) > limit {
+				// cutting may split a multi-byte character; drop its remains
+				ch.Content = strings.ToValidUTF8(ch.Content[:limit], "") + "…"
+			}
+			capped[idx] = ch
+		}
+		chs = capped
+	}
+	if err := i.vec.Upsert(chs, vecs); err != nil {
 		return err
 	}
-	return i.vec.Upsert(chs, vecs)
+	return i.recordEmbeddingModel(vecs)
 }
 
 func (i *Indexer) SearchSymbol(name string) ([]models.SymbolHit, error) {
@@ -318,6 +551,24 @@ func (i *Indexer) SearchSymbol(name string) ([]models.SymbolHit, error) {
 	return res, nil
 }
 
+// GetSymbol returns the symbol with the given ID and its chunk when the vector
+// store can look chunks up. It returns nil if the symbol is not indexed.
+func (i *Indexer) GetSymbol(id string) (*models.SymbolDetail, error) {
+	sym, err := i.sym.GetByID(id)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	detail := &models.SymbolDetail{Symbol: *sym}
+	if cs, ok := i.vec.(storage.ChunkStore); ok {
+		chunk, err := cs.GetChunk(id)
+		if err != nil {
+			return nil, err
+		}
+		detail.Chunk = chunk
+	}
+	return detail, nil
+}
+
 func (i *Indexer) SearchSemantic(query string, topK int) ([]models.SemanticHit, error) {
 	vec, err := i.e.EmbedQuery(query)
 	if err != nil {
@@ -326,20 +577,85 @@ func (i *Indexer) SearchSemantic(query string, topK int) ([]models.SemanticHit,
 	return i.vec.Query(vec, topK)
 }
 
-func listTSFiles(root string) ([]string, error) {
+// relPath returns path relative to root the same way the parser records it
+func relPath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absRoot, absPath)
+}
+
+// listFiles lists the files of root to index, honoring GitOnly
+func (i *Indexer) listFiles(ctx context.Context, root string) ([]string, error) {
+	if i.opt.GitOnly {
+		if files, err := listTrackedFiles(ctx, root, i.opt.Vue); err == nil {
+			return files, nil
+		}
+	}
+	return listTSFiles(root, i.opt.Vue)
+}
+
+// listTrackedFiles lists the TypeScript files under root that git tracks, and
+// .vue files when vue is set. Ignore rules still apply; tracked files missing
+// from disk, such as those outside a sparse checkout, are skipped.
+func listTrackedFiles(ctx context.Context, root string, vue bool) ([]string, error) {
+	patterns := []string{"*.ts", "*.tsx"}
+	if vue {
+		patterns = append(patterns, "*.vue")
+	}
+	tracked, err := gitinfo.TrackedFiles(ctx, root, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := ignore.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, rel := range tracked {
+		if matcher.Ignored(rel, false) {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// listTSFiles lists the TypeScript files under root, and .vue files when vue is set
+func listTSFiles(root string, vue bool) ([]string, error) {
+	matcher, err := ignore.Load(root)
+	if err != nil {
+		return nil, err
+	}
 	var files []string
 	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
-			name := d.Name()
-			if name == "node_modules" || name == ".git" || name == "dist" || name == "build" {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if matcher.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") {
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") ||
+			(vue && strings.HasSuffix(path, ".vue")) {
 			files = append(files, path)
 		}
 		return nil
@@ -347,10 +663,27 @@ func listTSFiles(root string) ([]string, error) {
 	return files, walkErr
 }
 
-func buildEmbedText(ch models.CodeChunk) string {
+// embedTexts builds embedding inputs, adding LSP-resolved types when an enricher is set
+func (i *Indexer) embedTexts(ctx context.Context, enricher *typeEnricher, chs []models.CodeChunk) []string {
+	var types map[string]string
+	if enricher != nil {
+		types = enricher.Resolve(ctx, chs)
+	}
+	texts := make([]string, len(chs))
+	for idx, ch := range chs {
+		texts[idx] = i.embedText(ch, types[ch.ID])
+	}
+	return texts
+}
+
+func buildEmbedText(ch models.CodeChunk, resolvedType string) string {
 	var b strings.Builder
 	b.WriteString(ch.Signature)
 	b.WriteString("\n")
+	if resolvedType != "" {
+		b.WriteString(resolvedType)
+		b.WriteString("\n")
+	}
 	if ch.Docstring != "" {
 		b.WriteString(ch.Docstring)
 		b.WriteString("\n")
@@ -2,15 +2,28 @@ package pipeline_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/embeddings"
 	"github.com/0x5457/ts-index/internal/indexer"
 	"github.com/0x5457/ts-index/internal/indexer/indexerfx"
+	"github.com/0x5457/ts-index/internal/indexer/pipeline"
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
 	"github.com/0x5457/ts-index/internal/parser/parserfx"
+	"github.com/0x5457/ts-index/internal/parser/tsparser"
+	"github.com/0x5457/ts-index/internal/storage/sqlite"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
 	"github.com/0x5457/ts-index/internal/storage/storagefx"
 	"go.uber.org/fx"
 )
@@ -84,3 +97,1077 @@ func Test_Indexer_E2E_TS(t *testing.T) {
 		t.Fatalf("expected hits")
 	}
 }
+
+// unreachableEmbedder fails every call, standing in for a missing embed server
+type unreachableEmbedder struct{}
+
+func (unreachableEmbedder) EmbedTexts([]string) ([][]float32, error) {
+	return nil, errors.New("embed server unreachable")
+}
+
+func (unreachableEmbedder) EmbedQuery(string) ([]float32, error) {
+	return nil, errors.New("embed server unreachable")
+}
+
+func (unreachableEmbedder) ModelName() string { return "unreachable" }
+
+func Test_Indexer_SymbolsOnly(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "a.ts")
+	src := `export function add(a:number,b:number){return a+b}`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sym, err := sqlite.New(filepath.Join(tmp, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no vector store: symbols-only indexing must never touch it
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+		SymbolsOnly: true,
+	})
+
+	progCh, errCh := idx.IndexProjectProgress(context.Background(), tmp)
+	var last models.IndexProgress
+	for p := range progCh {
+		if p.Stage == models.IndexStageEmbed {
+			t.Fatalf("unexpected embed stage in symbols-only mode")
+		}
+		last = p
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("index project: %v", err)
+	}
+	if last.Stage != models.IndexStageDone || last.TotalChunks != 0 {
+		t.Fatalf("unexpected final progress: %+v", last)
+	}
+
+	if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+		t.Fatalf("index file: %v", err)
+	}
+	syms, err := idx.SearchSymbol("add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 {
+		t.Fatalf("expected one symbol 'add', got %d", len(syms))
+	}
+}
+
+func Test_Indexer_SymbolsOnlyDropsChunks(t *testing.T) {
+	tmp := t.TempDir()
+	a, b := filepath.Join(tmp, "a.ts"), filepath.Join(tmp, "b.ts")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("export function add(a:number,b:number){return a+b}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{})
+	if err := full.IndexProject(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	// a symbols-only run over the same index drops the chunks of the files
+	// it reindexes or finds deleted, so they no longer turn up in searches
+	symbolsOnly := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, vec, pipeline.Options{SymbolsOnly: true})
+	if err := os.WriteFile(a, []byte("export function sub(a:number,b:number){return a-b}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbolsOnly.IndexFileWithRoot(tmp, a); err != nil {
+		t.Fatalf("reindex a.ts: %v", err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbolsOnly.IndexFileWithRoot(tmp, b); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected b.ts to be gone, got %v", err)
+	}
+	for _, file := range []string{"a.ts", "b.ts"} {
+		if stored, err := vec.ChunksByFile(file); err != nil || len(stored) != 0 {
+			t.Fatalf("expected no chunks left for %s, got %+v, %v", file, stored, err)
+		}
+	}
+	if syms, err := symbolsOnly.SearchSymbol("sub"); err != nil || len(syms) != 1 {
+		t.Fatalf("expected the reindexed symbol sub, got %+v, %v", syms, err)
+	}
+}
+
+func Test_Indexer_ExportedSymbols(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"src/a.ts": "export function a() {}\nfunction b() {}\nexport declare const c: number\n",
+		"lib/d.ts": "export const d = 1\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sym, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{SymbolsOnly: true})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatalf("index project: %v", err)
+	}
+
+	for dir, want := range map[string][]string{
+		"":       {"d", "a"},
+		"src":    {"a"},
+		"./src/": {"a"},
+		"none":   nil,
+	} {
+		found, err := idx.ExportedSymbols(dir)
+		if err != nil {
+			t.Fatalf("exported symbols of %q: %v", dir, err)
+		}
+		var names []string
+		for _, s := range found {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Fatalf("expected %v exported under %q, got %v", want, dir, names)
+		}
+	}
+}
+
+func Test_Indexer_FileIndexStatus(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "a.ts")
+	write := func(src string) {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("export function add(a:number,b:number){return a+b}\n")
+
+	sym, err := sqlite.New(filepath.Join(tmp, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+		SymbolsOnly: true,
+	})
+
+	status, err := idx.FileIndexStatus(tmp, path)
+	if err != nil {
+		t.Fatalf("file status: %v", err)
+	}
+	if status.Indexed || status.UpToDate || len(status.Missing) != 1 || status.File != "a.ts" {
+		t.Fatalf("expected unindexed a.ts missing add, got %+v", status)
+	}
+
+	if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+		t.Fatalf("index file: %v", err)
+	}
+	status, err = idx.FileIndexStatus(tmp, path)
+	if err != nil {
+		t.Fatalf("file status: %v", err)
+	}
+	if !status.Indexed || !status.UpToDate || status.Parsed != 1 || status.Stored != 1 {
+		t.Fatalf("expected up-to-date file, got %+v", status)
+	}
+
+	// moving add and adding sub leaves the index behind
+	write("\nexport function add(a:number,b:number){return a+b}\nexport function sub(a:number,b:number){return a-b}\n")
+	status, err = idx.FileIndexStatus(tmp, path)
+	if err != nil {
+		t.Fatalf("file status: %v", err)
+	}
+	if status.UpToDate || len(status.Missing) != 2 || len(status.Stale) != 1 {
+		t.Fatalf("expected two missing and one stale symbol, got %+v", status)
+	}
+	if stale := status.Stale[0]; stale.Name != "add" || stale.StartLine != 1 {
+		t.Fatalf("expected stale add at line 1, got %+v", stale)
+	}
+}
+
+func Test_Indexer_EnclosingSymbol(t *testing.T) {
+	tmp := t.TempDir()
+	src := "export class Store {\n  get(key: string) {\n    return key\n  }\n}\n\nconst x = 1\n"
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sym, err := sqlite.New(filepath.Join(tmp, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{SymbolsOnly: true})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	tests := []struct {
+		file string
+		line int
+		want string
+	}{
+		{"a.ts", 0, "Store"},
+		{"a.ts", 2, "get"},
+		{filepath.Join(tmp, "a.ts"), 6, "x"},
+		{"a.ts", 5, ""},
+		{filepath.Join(t.TempDir(), "b.ts"), 0, ""},
+	}
+	for _, tt := range tests {
+		got, err := idx.EnclosingSymbol(tmp, tt.file, tt.line)
+		if err != nil {
+			t.Fatalf("enclosing symbol of %s:%d: %v", tt.file, tt.line, err)
+		}
+		var name string
+		if got != nil {
+			name = got.Name
+		}
+		if name != tt.want {
+			t.Fatalf("expected %q to enclose %s:%d, got %+v", tt.want, tt.file, tt.line, got)
+		}
+	}
+	got, _ := idx.EnclosingSymbol(tmp, "a.ts", 2)
+	if got.Range.Start.Line != 1 || got.Range.End.Line != 3 {
+		t.Fatalf("expected get to span lines 1-3, got %+v", got.Range)
+	}
+}
+
+func Test_Indexer_SymbolsAt(t *testing.T) {
+	tmp := t.TempDir()
+	src := "export class Store {\n  get(key: string) {\n    return key\n  }\n}\n" +
+		"function a() {}; function b() {}\n"
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names := func(symbols []models.PositionSymbol) []string {
+		var out []string
+		for _, sym := range symbols {
+			out = append(out, sym.Name)
+		}
+		return out
+	}
+
+	// without a symbol store the file is parsed, telling a and b apart
+	parsing := pipeline.New(tsparser.New(), unreachableEmbedder{}, nil, nil, pipeline.Options{SymbolsOnly: true})
+	got, err := parsing.SymbolsAt(tmp, "a.ts", 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(got), []string{"get", "Store"}) || !got[0].Parsed ||
+		got[0].Signature != "get(key: string) {" {
+		t.Fatalf("expected parsed get inside Store, got %+v", got)
+	}
+	if got, _ := parsing.SymbolsAt(tmp, "a.ts", 5, 20); !reflect.DeepEqual(names(got), []string{"b"}) {
+		t.Fatalf("expected b at its character, got %+v", got)
+	}
+
+	sym, err := sqlite.New(filepath.Join(tmp, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{SymbolsOnly: true})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	got, err = idx.SymbolsAt(tmp, filepath.Join(tmp, "a.ts"), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(got), []string{"get", "Store"}) || got[0].Parsed ||
+		got[1].Signature != "export class Store {" {
+		t.Fatalf("expected stored get inside Store, got %+v", got)
+	}
+	if _, err := idx.SymbolsAt(tmp, "a.ts", 9, 0); err == nil {
+		t.Fatal("expected an error for a line past the end of the file")
+	}
+}
+
+func Test_Indexer_Vue(t *testing.T) {
+	tmp := t.TempDir()
+	src := "<template><p/></template>\n<script lang=\"ts\">\nexport function greet() {}\n</script>\n"
+	if err := os.WriteFile(filepath.Join(tmp, "Hello.vue"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vue := range []bool{false, true} {
+		sym, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+			SymbolsOnly: true,
+			Vue:         vue,
+		})
+		if err := idx.IndexProject(tmp); err != nil {
+			t.Fatalf("index project: %v", err)
+		}
+		syms, err := idx.SearchSymbol("greet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[bool]int{false: 0, true: 1}[vue]; len(syms) != want {
+			t.Fatalf("vue=%v: expected %d symbols, got %+v", vue, want, syms)
+		}
+		if vue && (syms[0].Symbol.File != "Hello.vue" || syms[0].Symbol.StartLine != 3) {
+			t.Fatalf("unexpected symbol %+v", syms[0])
+		}
+	}
+}
+
+func Test_Indexer_StoreWorkspaceSymbols(t *testing.T) {
+	tmp := t.TempDir()
+	src := "export class Box {\n  size = 1\n}\n"
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sym, err := sqlite.New(filepath.Join(tmp, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+		SymbolsOnly: true,
+	})
+
+	at := func(name string, kind lsp.SymbolKind, line int) lsp.SymbolInformation {
+		return lsp.SymbolInformation{Name: name, Kind: kind, Location: lsp.Location{
+			URI:   lsp.PathToURI(filepath.Join(tmp, "a.ts")),
+			Range: lsp.Range{Start: lsp.Position{Line: line}, End: lsp.Position{Line: line}},
+		}}
+	}
+	// Box is also parsed by tree-sitter; the property is only known to the server
+	added, err := idx.StoreWorkspaceSymbols(tmp, "a.ts", []lsp.SymbolInformation{
+		at("Box", lsp.SymbolKindClass, 0),
+		at("size", lsp.SymbolKindProperty, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Fatalf("expected 1 added symbol, got %d", added)
+	}
+	for _, name := range []string{"Box", "size"} {
+		hits, err := idx.SearchSymbol(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 1 {
+			t.Fatalf("expected one %s symbol, got %+v", name, hits)
+		}
+	}
+	hits, _ := idx.SearchSymbol("size")
+	if hits[0].Symbol.StartLine != 2 || hits[0].Symbol.Kind != lsp.SymbolKindProperty {
+		t.Fatalf("unexpected LSP symbol %+v", hits[0].Symbol)
+	}
+
+	// a later sync replaces the earlier LSP symbols
+	if _, err := idx.StoreWorkspaceSymbols(tmp, "a.ts", nil); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := idx.SearchSymbol("size"); len(hits) != 0 {
+		t.Fatalf("expected stale LSP symbol to be removed, got %+v", hits)
+	}
+	if hits, _ := idx.SearchSymbol("Box"); len(hits) != 1 {
+		t.Fatalf("expected parsed symbol to remain, got %+v", hits)
+	}
+}
+
+// blockingEmbedder blocks every request until its context is canceled
+type blockingEmbedder struct {
+	started chan struct{}
+}
+
+func (e blockingEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
+	return e.EmbedTextsContext(context.Background(), texts)
+}
+
+func (e blockingEmbedder) EmbedTextsContext(ctx context.Context, _ []string) ([][]float32, error) {
+	select {
+	case e.started <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingEmbedder) EmbedQuery(string) ([]float32, error) {
+	return nil, errors.New("not used")
+}
+
+func (blockingEmbedder) ModelName() string { return "blocking" }
+
+func Test_Indexer_CancelIndexProject(t *testing.T) {
+	tmp := t.TempDir()
+	src := `export function add(a:number,b:number){return a+b}`
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(tmp, "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := blockingEmbedder{started: make(chan struct{}, 1)}
+	idx := pipeline.New(tsparser.New(), embedder, sym, vec, pipeline.Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progCh, errCh := idx.IndexProjectProgress(ctx, tmp)
+	go func() {
+		<-embedder.started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		for range progCh {
+		}
+		var last error
+		for err := range errCh {
+			last = err
+		}
+		done <- last
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline did not stop after cancellation")
+	}
+
+	// nothing was stored for the canceled run
+	hits, err := idx.SearchSymbol("add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("expected no symbols after cancellation, got %+v", hits)
+	}
+}
+
+func Test_Indexer_NoStoreContent(t *testing.T) {
+	tmp := t.TempDir()
+	src := "/** computes the secret */\nexport function secretSauce(a: number) { return a * 42 }\n"
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{
+		NoStoreContent: true,
+	})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := idx.SearchSemantic("secretSauce", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("expected one hit, got %d", len(hits))
+	}
+	ch := hits[0].Chunk
+	if ch.File != "a.ts" || ch.StartLine != 2 || ch.Name != "secretSauce" {
+		t.Fatalf("expected the chunk location, got %+v", ch)
+	}
+	if ch.Content != "" || ch.Signature != "" || ch.Docstring != "" {
+		t.Fatalf("expected no stored source, got %+v", ch)
+	}
+	_ = vec.Close()
+
+	raw, err := os.ReadFile(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a * 42", "computes the secret"} {
+		if strings.Contains(string(raw), s) {
+			t.Fatalf("database contains source text %q", s)
+		}
+	}
+}
+
+// countingEmbedder counts the texts embedded by an embedder
+type countingEmbedder struct {
+	embeddings.Embedder
+	texts []string
+}
+
+func (e *countingEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
+	e.texts = append(e.texts, texts...)
+	return e.Embedder.EmbedTexts(texts)
+}
+
+func Test_Indexer_IncrementalFile(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "a.ts")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("export function one() { return 1 }\n" +
+		"export function two() { return 2 }\n" +
+		"export function three() { return 3 }\n")
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := &countingEmbedder{Embedder: embeddings.NewLocal(8)}
+	idx := pipeline.New(tsparser.New(), embedder, sym, vec, pipeline.Options{})
+	if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if len(embedder.texts) != 3 {
+		t.Fatalf("expected three embedded chunks, got %d", len(embedder.texts))
+	}
+	model, err := vec.EmbeddingModel()
+	if err != nil || model == nil || *model != (models.EmbeddingModel{Name: "local-fixed", Dimension: 8}) {
+		t.Fatalf("expected the local embedding model to be recorded, got %+v, %v", model, err)
+	}
+
+	// a blank first line moves every function; only the edited one is re-embedded
+	embedder.texts = nil
+	write("\n" +
+		"export function one() { return 1 }\n" +
+		"export function two() { return 22 }\n" +
+		"export function three() { return 3 }\n")
+	if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if len(embedder.texts) != 1 || !strings.Contains(embedder.texts[0], "return 22") {
+		t.Fatalf("expected only two to be re-embedded, got %q", embedder.texts)
+	}
+
+	stored, err := vec.ChunksByFile("a.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ch := range stored {
+		got = append(got, fmt.Sprintf("%s:%d", ch.Name, ch.StartLine))
+	}
+	if want := []string{"one:2", "two:3", "three:4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected chunks %v, got %v", want, got)
+	}
+	for _, name := range []string{"one", "two", "three"} {
+		hits, err := idx.SearchSymbol(name)
+		if err != nil || len(hits) != 1 {
+			t.Fatalf("expected one symbol %s, got %v, %v", name, hits, err)
+		}
+		detail, err := idx.GetSymbol(hits[0].Symbol.ID)
+		if err != nil || detail.Chunk == nil {
+			t.Fatalf("expected the chunk of %s under its symbol ID, got %+v, %v", name, detail, err)
+		}
+	}
+	// the local embedder hashes texts, so only the chunk's own embed text matches it
+	hits, err := idx.SearchSemantic("function three() { return 3 }\nfunction three() { return 3 }", 1)
+	if err != nil || len(hits) != 1 || hits[0].Chunk.Name != "three" {
+		t.Fatalf("expected the moved chunk to keep its embedding, got %+v, %v", hits, err)
+	}
+
+	// removing a function deletes its chunk without embedding anything
+	embedder.texts = nil
+	write("\nexport function one() { return 1 }\n")
+	if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := vec.ChunksByFile("a.ts"); err != nil || len(stored) != 1 || len(embedder.texts) != 0 {
+		t.Fatalf("expected only one left and nothing embedded, got %+v, %q, %v", stored, embedder.texts, err)
+	}
+}
+
+// flakyEmbedder fails embed requests while fail is set
+type flakyEmbedder struct {
+	embeddings.Embedder
+	fail bool
+}
+
+func (e *flakyEmbedder) EmbedTexts(texts []string) ([][]float32, error) {
+	if e.fail {
+		return nil, errors.New("embed server unavailable")
+	}
+	return e.Embedder.EmbedTexts(texts)
+}
+
+func Test_Indexer_ReindexFailureKeepsIndex(t *testing.T) {
+	// NoStoreContent reindexes whole files rather than only changed chunks
+	for name, opt := range map[string]pipeline.Options{"incremental": {}, "whole file": {NoStoreContent: true}} {
+		t.Run(name, func(t *testing.T) {
+			tmp := t.TempDir()
+			path := filepath.Join(tmp, "a.ts")
+			write := func(src string) {
+				t.Helper()
+				if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			write("export function one() { return 1 }\n")
+			db := filepath.Join(t.TempDir(), "index.db")
+			sym, err := sqlite.New(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vec, err := sqlvec.New(db, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			embedder := &flakyEmbedder{Embedder: embeddings.NewLocal(8)}
+			idx := pipeline.New(tsparser.New(), embedder, sym, vec, opt)
+			if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+				t.Fatal(err)
+			}
+
+			embedder.fail = true
+			write("export function two() { return 2 }\n")
+			if err := idx.IndexFileWithRoot(tmp, path); err == nil {
+				t.Fatalf("expected the failing embed request to fail reindexing")
+			}
+			if hits, err := idx.SearchSymbol("one"); err != nil || len(hits) != 1 {
+				t.Fatalf("expected the previous symbol to remain, got %v, %v", hits, err)
+			}
+			if hits, err := idx.SearchSymbol("two"); err != nil || len(hits) != 0 {
+				t.Fatalf("expected no symbol of the failed reindex, got %v, %v", hits, err)
+			}
+			if stored, err := vec.ChunksByFile("a.ts"); err != nil || len(stored) != 1 || stored[0].Name != "one" {
+				t.Fatalf("expected the previous chunk to remain, got %+v, %v", stored, err)
+			}
+
+			// a deleted file is dropped without embedding anything
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			if err := idx.IndexFileWithRoot(tmp, path); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("expected a not-exist error for the deleted file, got %v", err)
+			}
+			if hits, err := idx.SearchSymbol("one"); err != nil || len(hits) != 0 {
+				t.Fatalf("expected the deleted file's symbols to be dropped, got %v, %v", hits, err)
+			}
+			if stored, err := vec.ChunksByFile("a.ts"); err != nil || len(stored) != 0 {
+				t.Fatalf("expected the deleted file's chunks to be dropped, got %+v, %v", stored, err)
+			}
+		})
+	}
+}
+
+func Test_Indexer_EmbedTemplates(t *testing.T) {
+	for _, spec := range []string{"{{.Content}}", "ts={{.Content", "ts={{.Missing}}"} {
+		if _, err := pipeline.ParseEmbedTemplates([]string{spec}); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+	templates, err := pipeline.ParseEmbedTemplates([]string{
+		`tsx={{.Kind}} {{.Name}}\n{{.Content}}`,
+		`*={{.Language}}: {{.Signature}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	files := map[string]string{
+		"a.tsx": "export function one() { return 1 }\n",
+		"b.ts":  "export function two() { return 2 }\n",
+	}
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := &countingEmbedder{Embedder: embeddings.NewLocal(8)}
+	idx := pipeline.New(tsparser.New(), embedder, sym, vec, pipeline.Options{EmbedTemplates: templates})
+	for _, name := range []string{"a.tsx", "b.ts"} {
+		path := filepath.Join(tmp, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFileWithRoot(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"function one\nfunction one() { return 1 }",
+		"ts: function two() { return 2 }",
+	}
+	if !reflect.DeepEqual(embedder.texts, want) {
+		t.Fatalf("expected embed texts %q, got %q", want, embedder.texts)
+	}
+}
+
+func Test_Indexer_MaxChunkContentBytes(t *testing.T) {
+	tmp := t.TempDir()
+	var src strings.Builder
+	src.WriteString("export enum Generated {\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&src, "  Value%d = 'é%d',\n", i, i)
+	}
+	src.WriteString("}\nexport function small() { return 1 }\n")
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 30 bytes ends inside the two-byte é of the first member
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{
+		MaxChunkContentBytes: 30,
+	})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	chunk := func(name string) models.CodeChunk {
+		t.Helper()
+		hits, err := idx.SearchSymbol(name)
+		if err != nil || len(hits) != 1 {
+			t.Fatalf("expected one symbol %s, got %v, %v", name, hits, err)
+		}
+		detail, err := idx.GetSymbol(hits[0].Symbol.ID)
+		if err != nil || detail.Chunk == nil {
+			t.Fatalf("expected the chunk of %s, got %+v, %v", name, detail, err)
+		}
+		return *detail.Chunk
+	}
+	enum := chunk("Generated")
+	if enum.Content != "enum Generated {\n  Value0 = '…" {
+		t.Fatalf("expected content cut at a character boundary, got %q", enum.Content)
+	}
+	if int(enum.EndByte-enum.StartByte) != strings.Index(src.String(), "\nexport function")-len("export ") {
+		t.Fatalf("expected the full byte range, got %d-%d", enum.StartByte, enum.EndByte)
+	}
+	if small := chunk("small"); small.Content != "function small() { return 1 }" {
+		t.Fatalf("expected short content stored whole, got %q", small.Content)
+	}
+}
+
+func Test_Indexer_CallSites(t *testing.T) {
+	tmp := t.TempDir()
+	src := "export function save(body: string) {\n  return fetch(\"/api\", { method: \"POST\", body })\n}\n"
+	if err := os.WriteFile(filepath.Join(tmp, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, err := sqlvec.New(db, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = vec.Close() })
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{
+		IndexCallSites:  true,
+		CallSiteCallees: []string{"fetch"},
+	})
+	if err := idx.IndexProject(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := idx.SearchSemantic("fetch", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call *models.CodeChunk
+	for _, hit := range hits {
+		if hit.Chunk.Kind == models.SymbolCall {
+			call = &hit.Chunk
+		}
+	}
+	if len(hits) != 2 || call == nil || call.Name != "fetch" || call.StartLine != 2 {
+		t.Fatalf("expected the declaration and a fetch call chunk, got %+v", hits)
+	}
+	// call sites are chunks only, never symbols
+	if syms, err := idx.SearchSymbol("fetch"); err != nil || len(syms) != 0 {
+		t.Fatalf("expected no fetch symbol, got %+v (%v)", syms, err)
+	}
+}
+
+func Test_Indexer_FileCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmp := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{
+			"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false",
+		}, args...)...)
+		cmd.Dir = tmp
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("a.ts", "export function alpha() { return 1 }\n")
+	git("add", "a.ts")
+	git("commit", "-q", "-m", "add a")
+	first := git("rev-parse", "HEAD")
+	write("b.ts", "export function beta() { return 2 }\n")
+	git("add", "b.ts")
+	git("commit", "-q", "-m", "add b")
+	second := git("rev-parse", "HEAD")
+	write("c.ts", "export function gamma() { return 3 }\n")
+
+	index := func() (*pipeline.Indexer, *sqlvec.Store) {
+		t.Helper()
+		db := filepath.Join(t.TempDir(), "index.db")
+		sym, err := sqlite.New(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vec, err := sqlvec.New(db, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = vec.Close() })
+		idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{
+			FileCommits: true,
+		})
+		if err := idx.IndexProject(tmp); err != nil {
+			t.Fatal(err)
+		}
+		return idx, vec
+	}
+
+	idx, vec := index()
+	prov, err := vec.Provenance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prov == nil || prov.Commit != second || prov.Dirty {
+		t.Fatalf("expected clean provenance at %s, got %+v", second, prov)
+	}
+
+	hits, err := idx.SearchSemantic("function", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.ts": first, "b.ts": second, "c.ts": ""}
+	seen := map[string]bool{}
+	for _, hit := range hits {
+		if commit, ok := want[hit.Chunk.File]; ok {
+			seen[hit.Chunk.File] = true
+			if hit.Chunk.LastCommit != commit {
+				t.Fatalf("%s: expected last commit %q, got %q", hit.Chunk.File, commit, hit.Chunk.LastCommit)
+			}
+		}
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("expected hits in %d files, got %v", len(want), seen)
+	}
+
+	// an index built with uncommitted changes to tracked files is marked dirty
+	write("a.ts", "export function alpha() { return 10 }\n")
+	_, vec = index()
+	if prov, err = vec.Provenance(); err != nil || prov == nil || !prov.Dirty {
+		t.Fatalf("expected dirty provenance, got %+v (%v)", prov, err)
+	}
+}
+
+func Test_Indexer_GitOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmp := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("tracked.ts", "export function tracked() {}\n")
+	write("untracked.ts", "export function untracked() {}\n")
+
+	index := func() []string {
+		t.Helper()
+		sym, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+			SymbolsOnly: true,
+			GitOnly:     true,
+		})
+		if err := idx.IndexProject(tmp); err != nil {
+			t.Fatalf("index project: %v", err)
+		}
+		var found []string
+		for _, name := range []string{"tracked", "untracked"} {
+			if syms, err := idx.SearchSymbol(name); err == nil && len(syms) > 0 {
+				found = append(found, name)
+			}
+		}
+		return found
+	}
+
+	// outside a repository every file is walked
+	if got := index(); len(got) != 2 {
+		t.Fatalf("expected both files outside a repository, got %v", got)
+	}
+
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "add", "tracked.ts")
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	if got := index(); len(got) != 1 || got[0] != "tracked" {
+		t.Fatalf("expected only the tracked file, got %v", got)
+	}
+}
+
+func Test_Indexer_SkipGenerated(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hand.ts", "// DO NOT EDIT lightly\nexport function hand() {}\n")
+	write("api.ts", "/* eslint-disable */\n"+
+		"// Code generated by openapi-generator. DO NOT EDIT.\nexport function api() {}\n")
+	write("schema.ts", "// @generated\nexport function schema() {}\n")
+	write("custom.ts", "// built by gen.sh\nexport function custom() {}\n")
+
+	index := func(patterns []string) ([]string, int) {
+		t.Helper()
+		markers, err := pipeline.ParseGeneratedMarkers(patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sym, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx := pipeline.New(tsparser.New(), unreachableEmbedder{}, sym, nil, pipeline.Options{
+			SymbolsOnly:      true,
+			GeneratedMarkers: markers,
+		})
+		progCh, errCh := idx.IndexProjectProgress(context.Background(), tmp)
+		skipped := 0
+		for p := range progCh {
+			skipped = p.SkippedFiles
+		}
+		if err := <-errCh; err != nil {
+			t.Fatalf("index project: %v", err)
+		}
+		var found []string
+		for _, name := range []string{"hand", "api", "schema", "custom"} {
+			if syms, err := idx.SearchSymbol(name); err == nil && len(syms) > 0 {
+				found = append(found, name)
+			}
+		}
+		return found, skipped
+	}
+
+	if found, skipped := index(nil); !reflect.DeepEqual(found, []string{"hand", "custom"}) || skipped != 2 {
+		t.Fatalf("expected the default markers to skip api and schema, got %v and %d skipped", found, skipped)
+	}
+	found, skipped := index([]string{`^// built by gen\.sh`})
+	if !reflect.DeepEqual(found, []string{"hand", "api", "schema"}) || skipped != 1 {
+		t.Fatalf("expected the custom marker to skip custom only, got %v and %d skipped", found, skipped)
+	}
+	if _, err := pipeline.ParseGeneratedMarkers([]string{"("}); err == nil {
+		t.Fatal("expected an error for an invalid marker")
+	}
+}
+
+func Benchmark_Indexer_IndexProjectProgress(b *testing.B) {
+	tmp := b.TempDir()
+	const files = 50
+	for f := 0; f < files; f++ {
+		dir := filepath.Join(tmp, fmt.Sprintf("pkg%d", f%5))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		var src strings.Builder
+		for d := 0; d < 20; d++ {
+			fmt.Fprintf(&src, "/** adds %d */\nexport function add%d_%d(a: number) { return a + %d }\n\n", d, f, d, d)
+			fmt.Fprintf(&src, "export class Box%d_%d { value = %d }\n\n", f, d, d)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("file%d.ts", f))
+		if err := os.WriteFile(path, []byte(src.String()), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db := filepath.Join(b.TempDir(), "index.db")
+		sym, err := sqlite.New(db)
+		if err != nil {
+			b.Fatal(err)
+		}
+		vec, err := sqlvec.New(db, 8)
+		if err != nil {
+			b.Fatal(err)
+		}
+		idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{})
+		b.StartTimer()
+
+		progCh, errCh := idx.IndexProjectProgress(context.Background(), tmp)
+		for range progCh {
+		}
+		if err := <-errCh; err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		_ = vec.Close()
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(files*b.N)/b.Elapsed().Seconds(), "files/s")
+}
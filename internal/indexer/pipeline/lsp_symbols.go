@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/util"
+)
+
+var _ lsp.WorkspaceSymbolStore = (*Indexer)(nil)
+
+// StoreWorkspaceSymbols merges language server symbols of file into the symbol
+// store. The file is re-parsed so its tree-sitter symbols take precedence: an
+// LSP symbol with the same name and start line as a parsed one is dropped, and
+// LSP symbols from an earlier sync are replaced rather than left stale.
+func (i *Indexer) StoreWorkspaceSymbols(root, file string, symbols []lsp.SymbolInformation) (int, error) {
+	parsed, _, err := i.p.ParseFileWithRoot(root, filepath.Join(root, file))
+	if err != nil {
+		return 0, err
+	}
+	type key struct {
+		name string
+		line int32
+	}
+	seen := make(map[key]bool, len(parsed))
+	for _, sym := range parsed {
+		seen[key{sym.Name, sym.StartLine}] = true
+	}
+	merged := parsed
+	for _, info := range symbols {
+		startLine := int32(info.Location.Range.Start.Line) + 1
+		endLine := int32(info.Location.Range.End.Line) + 1
+		k := key{info.Name, startLine}
+		if seen[k] || info.Name == "" {
+			continue
+		}
+		seen[k] = true
+		merged = append(merged, models.Symbol{
+			ID:        util.GenerateID(file, int(startLine), int(endLine), fmt.Sprint(rune(info.Kind)), info.Name),
+			Name:      info.Name,
+			Kind:      info.Kind,
+			File:      file,
+			StartLine: startLine,
+			EndLine:   endLine,
+		})
+	}
+	if err := i.sym.DeleteSymbolsByFile(file); err != nil {
+		return 0, err
+	}
+	if err := i.upsertSymbols(merged); err != nil {
+		return 0, err
+	}
+	return len(merged) - len(parsed), nil
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/gitinfo"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+// recordProvenance saves the git commit root is checked out at and, with
+// FileCommits, the last commit of each indexed file. Projects outside a git
+// repository, or a missing git binary, are skipped; store errors are returned.
+func (i *Indexer) recordProvenance(ctx context.Context, root string, files []string) error {
+	store, ok := i.vec.(storage.ProvenanceStore)
+	if !ok {
+		return nil
+	}
+	commit, err := gitinfo.HeadCommit(ctx, root)
+	if err != nil {
+		return nil
+	}
+	dirty, _ := gitinfo.IsDirty(ctx, root)
+	if err := store.SetProvenance(models.IndexProvenance{
+		Commit:    commit,
+		Dirty:     dirty,
+		IndexedAt: time.Now(),
+	}); err != nil {
+		return err
+	}
+	return i.recordFileCommits(ctx, store, root, files)
+}
+
+// recordFileCommits saves the last commit of each file under FileCommits.
+// Files git does not track get their entry cleared.
+func (i *Indexer) recordFileCommits(
+	ctx context.Context,
+	store storage.ProvenanceStore,
+	root string,
+	files []string,
+) error {
+	if !i.opt.FileCommits || len(files) == 0 {
+		return nil
+	}
+	rels := make([]string, 0, len(files))
+	for _, f := range files {
+		rel, err := relPath(root, f)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	last, err := gitinfo.LastCommits(ctx, root, rels)
+	if err != nil {
+		// not a repository; there is nothing to record
+		return nil
+	}
+	commits := make(map[string]string, len(rels))
+	for _, rel := range rels {
+		commits[filepath.FromSlash(rel)] = last[rel]
+	}
+	return store.SetFileCommits(commits)
+}
+
+// recordEmbeddingModel saves the embedder's model and the dimension of vecs
+// in vector stores that record it, so searches can tell a model mismatch
+func (i *Indexer) recordEmbeddingModel(vecs [][]float32) error {
+	store, ok := i.vec.(storage.EmbeddingModelStore)
+	if !ok || len(vecs) == 0 {
+		return nil
+	}
+	return store.SetEmbeddingModel(models.EmbeddingModel{Name: i.e.ModelName(), Dimension: len(vecs[0])})
+}
@@ -0,0 +1,60 @@
+// Package logging holds the leveled logger shared by ts-index packages. Log
+// output goes to stderr so it never mixes with an MCP stdio stream.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// LevelEnvVar sets the initial log level, so processes spawned by ts-index,
+// like stdio MCP servers, log at the level of their parent
+const LevelEnvVar = "TS_INDEX_LOG_LEVEL"
+
+// DefaultLevel is used when LevelEnvVar is unset or invalid
+const DefaultLevel = slog.LevelWarn
+
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	level, err := ParseLevel(os.Getenv(LevelEnvVar))
+	if err != nil {
+		level = DefaultLevel
+	}
+	logger.Store(New(os.Stderr, level))
+}
+
+// New returns a text logger writing records at or above level to w
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// L returns the shared logger
+func L() *slog.Logger {
+	return logger.Load()
+}
+
+// SetLogger replaces the shared logger; nil discards all records
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	logger.Store(l)
+}
+
+// ParseLevel parses debug, info, warn or error, case-insensitively. An empty
+// string is DefaultLevel.
+func ParseLevel(s string) (slog.Level, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultLevel, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", s)
+	}
+	return level, nil
+}
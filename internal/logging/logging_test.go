@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"":      DefaultLevel,
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		" warn": slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		got, err := ParseLevel(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestSetLogger(t *testing.T) {
+	prev := L()
+	t.Cleanup(func() { SetLogger(prev) })
+
+	var buf bytes.Buffer
+	SetLogger(New(&buf, slog.LevelWarn))
+	L().Info("hidden")
+	L().Warn("shown", "file", "a.ts")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown file=a.ts")
+
+	SetLogger(nil)
+	L().Error("discarded")
+	assert.NotContains(t, buf.String(), "discarded")
+}
@@ -2,7 +2,10 @@ package lsp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+
+	"github.com/0x5457/ts-index/internal/logging"
 )
 
 // LspAdapter represents a language-specific LSP adapter, inspired by Zed's design
@@ -98,13 +101,31 @@ func (ls *LanguageServer) Start(ctx context.Context) error {
 		return err
 	}
 
+	workspaceConfig, err := ls.adapter.WorkspaceConfiguration(ls.rootPath)
+	if err != nil {
+		return err
+	}
+
+	// The workspace may override both
+	overrides, err := LoadServerSettings(ls.rootPath)
+	if err != nil {
+		return err
+	}
+	initOptions = mergeSettings(initOptions, overrides.InitializationOptions)
+	workspaceConfig = mergeSettings(workspaceConfig, overrides.Settings)
+
 	// Create LSP client configuration
 	config := LanguageServerConfig{
-		Command:               command,
-		Args:                  args,
-		WorkspaceRoot:         ls.rootPath,
-		InitializationOptions: initOptions,
-		Env:                   ls.delegate.ShellEnv(),
+		Command:                command,
+		Args:                   args,
+		WorkspaceRoot:          ls.rootPath,
+		InitializationOptions:  initOptions,
+		WorkspaceConfiguration: workspaceConfig,
+		Env:                    ls.delegate.ShellEnv(),
+	}
+
+	if FindProjectConfig(ls.rootPath) == "" {
+		logging.L().Warn(NoProjectConfigWarning, "workspace", ls.rootPath)
 	}
 
 	// Create and start client
@@ -125,6 +146,19 @@ func (ls *LanguageServer) IsRunning() bool {
 	return ls.client != nil && ls.client.IsRunning()
 }
 
+// RawRequest sends method with params unchanged and returns the raw result
+func (ls *LanguageServer) RawRequest(
+	ctx context.Context,
+	method string,
+	params json.RawMessage,
+) (json.RawMessage, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	return ls.client.RawRequest(ctx, method, params)
+}
+
 // Hover provides hover information
 func (ls *LanguageServer) Hover(
 	ctx context.Context,
@@ -143,19 +177,24 @@ func (ls *LanguageServer) Hover(
 	return ls.client.Hover(ctx, params)
 }
 
-// Completion provides code completion
+// Completion provides code completion; completionContext tells how it was
+// triggered and may be nil
 func (ls *LanguageServer) Completion(
 	ctx context.Context,
 	uri string,
 	position Position,
+	completionContext *CompletionContext,
 ) (*CompletionList, error) {
 	if ls.client == nil {
 		return nil, ErrServerNotRunning
 	}
 
-	params := TextDocumentPositionParams{
-		TextDocument: TextDocumentIdentifier{URI: uri},
-		Position:     position,
+	params := CompletionParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+		Context: completionContext,
 	}
 
 	result, err := ls.client.Completion(ctx, params)
@@ -262,6 +301,26 @@ func (ls *LanguageServer) GotoDeclaration(
 	return ls.client.GotoDeclaration(ctx, params)
 }
 
+// Rename computes the edits that rename the symbol at position to newName
+func (ls *LanguageServer) Rename(
+	ctx context.Context,
+	uri string,
+	position Position,
+	newName string,
+) (*WorkspaceEdit, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	params := RenameParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     position,
+		NewName:      newName,
+	}
+
+	return ls.client.Rename(ctx, params)
+}
+
 // WorkspaceSymbols searches for symbols in the workspace
 func (ls *LanguageServer) WorkspaceSymbols(
 	ctx context.Context,
@@ -287,6 +346,48 @@ func (ls *LanguageServer) DocumentSymbols(
 	return ls.client.DocumentSymbols(ctx, uri)
 }
 
+// PrepareTypeHierarchy gets the type hierarchy items at a position
+func (ls *LanguageServer) PrepareTypeHierarchy(
+	ctx context.Context,
+	uri string,
+	position Position,
+) ([]TypeHierarchyItem, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	params := TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     position,
+	}
+
+	return ls.client.PrepareTypeHierarchy(ctx, params)
+}
+
+// TypeHierarchySupertypes gets the direct supertypes of an item
+func (ls *LanguageServer) TypeHierarchySupertypes(
+	ctx context.Context,
+	item TypeHierarchyItem,
+) ([]TypeHierarchyItem, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	return ls.client.TypeHierarchySupertypes(ctx, item)
+}
+
+// TypeHierarchySubtypes gets the direct subtypes of an item
+func (ls *LanguageServer) TypeHierarchySubtypes(
+	ctx context.Context,
+	item TypeHierarchyItem,
+) ([]TypeHierarchyItem, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	return ls.client.TypeHierarchySubtypes(ctx, item)
+}
+
 // DidOpen notifies the server that a document was opened
 func (ls *LanguageServer) DidOpen(ctx context.Context, uri string, content string) error {
 	if ls.client == nil {
@@ -329,6 +430,55 @@ func (ls *LanguageServer) GetDiagnostics(ctx context.Context, uri string) ([]Dia
 	return ls.adapter.ProcessDiagnostics(diagnostics), nil
 }
 
+// CodeActions returns the code actions of kinds only offered for diagnostics
+// within rng; an empty only asks for every kind
+func (ls *LanguageServer) CodeActions(
+	ctx context.Context,
+	uri string,
+	rng Range,
+	diagnostics []Diagnostic,
+	only ...string,
+) ([]CodeAction, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	params := CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context:      CodeActionContext{Diagnostics: diagnostics, Only: only},
+	}
+	return ls.client.CodeActions(ctx, params)
+}
+
+// ExecuteCommand runs a server command; edits the server applies while it
+// runs are written to the workspace
+func (ls *LanguageServer) ExecuteCommand(
+	ctx context.Context,
+	command string,
+	args ...interface{},
+) (json.RawMessage, error) {
+	if ls.client == nil {
+		return nil, ErrServerNotRunning
+	}
+
+	return ls.client.ExecuteCommand(ctx, command, args)
+}
+
+// ExecuteCommandEdits runs a server command and returns the edits the server
+// asks to apply instead of writing them
+func (ls *LanguageServer) ExecuteCommandEdits(
+	ctx context.Context,
+	command string,
+	args ...interface{},
+) (json.RawMessage, []WorkspaceEdit, error) {
+	if ls.client == nil {
+		return nil, nil, ErrServerNotRunning
+	}
+
+	return ls.client.ExecuteCommandEdits(ctx, command, args)
+}
+
 // Adapter returns the underlying adapter
 func (ls *LanguageServer) Adapter() LspAdapter {
 	return ls.adapter
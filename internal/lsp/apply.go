@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ApplyEditsRequest represents a request to write edits produced by the
+// language server, such as a rename or a quick fix, to the workspace
+type ApplyEditsRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	// Edit is an LSP workspace edit, with file URIs
+	Edit *WorkspaceEdit `json:"edit,omitempty"`
+	// FileEdits are edits grouped by file as in quick fixes, with files
+	// relative to the workspace root or absolute
+	FileEdits []FileEdits `json:"file_edits,omitempty"`
+	// LineBase numbers the lines of all ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+	// DryRun computes the changes and their diffs without writing them
+	DryRun bool `json:"dry_run"`
+}
+
+// ApplyEditsResponse represents the result of applying edits
+type ApplyEditsResponse struct {
+	// Files are the absolute paths of the files rewritten on disk, or that
+	// would be on a dry run
+	Files []string `json:"files"`
+	// Diffs are the unified diffs of the files, set on a dry run
+	Diffs []FileDiff `json:"diffs,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// ApplyEdits writes edits to files inside the workspace. Edits to a file are
+// spliced last first, and every file is edited in memory before any is
+// written, so overlapping edits or a file outside the workspace leave the
+// workspace untouched. Each file is replaced atomically.
+func ApplyEdits(req ApplyEditsRequest) ApplyEditsResponse {
+	if req.WorkspaceRoot == "" {
+		return ApplyEditsResponse{Error: "workspace root is required"}
+	}
+	absRoot, realRoot, err := workspaceRoots(req.WorkspaceRoot)
+	if err != nil {
+		return ApplyEditsResponse{Error: err.Error()}
+	}
+	edit, err := confineEdits(absRoot, req.Edit, req.FileEdits, -req.LineBase)
+	if err != nil {
+		return ApplyEditsResponse{Error: err.Error()}
+	}
+
+	changes, err := PreviewWorkspaceEdit(edit)
+	if err != nil {
+		return ApplyEditsResponse{Error: fmt.Sprintf("failed to apply edits: %v", err)}
+	}
+	files := workspaceFiles(absRoot, realRoot, changes)
+	if req.DryRun {
+		_, diffs, err := fileDiffs(realRoot, changes)
+		if err != nil {
+			return ApplyEditsResponse{Error: err.Error()}
+		}
+		return ApplyEditsResponse{Files: files, Diffs: diffs}
+	}
+	if _, err := WriteFileChanges(changes); err != nil {
+		return ApplyEditsResponse{Error: fmt.Sprintf("failed to apply edits: %v", err)}
+	}
+	return ApplyEditsResponse{Files: files}
+}
+
+// workspaceRoots returns the absolute path of workspaceRoot and the path it
+// resolves to through symlinks
+func workspaceRoots(workspaceRoot string) (string, string, error) {
+	absRoot, err := filepath.Abs(workspaceRoot)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute workspace path: %v", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve workspace path: %v", err)
+	}
+	return absRoot, realRoot, nil
+}
+
+// confineEdits merges edit and fileEdits into one workspace edit keyed by the
+// URI of each file resolved inside absRoot, shifting lines by lineShift. Edits
+// are keyed by resolved path, so a file reached through a symlink is still
+// edited once. It fails on the first file outside the workspace.
+func confineEdits(absRoot string, edit *WorkspaceEdit, fileEdits []FileEdits, lineShift int) (*WorkspaceEdit, error) {
+	confined := &WorkspaceEdit{Changes: make(map[string][]TextEdit)}
+	add := func(path string, edits []TextEdit) error {
+		resolved, err := resolveInWorkspace(absRoot, path)
+		if err != nil {
+			return err
+		}
+		uri := PathToURI(resolved)
+		for _, e := range edits {
+			e.Range = shiftRangeLines(e.Range, lineShift)
+			confined.Changes[uri] = append(confined.Changes[uri], e)
+		}
+		return nil
+	}
+	if edit != nil {
+		for uri, edits := range edit.Changes {
+			if err := add(URIToPath(uri), edits); err != nil {
+				return nil, err
+			}
+		}
+		for _, docEdit := range edit.DocumentChanges {
+			if err := add(URIToPath(docEdit.TextDocument.URI), docEdit.Edits); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, fe := range fileEdits {
+		if err := add(fe.File, fe.Edits); err != nil {
+			return nil, err
+		}
+	}
+	return confined, nil
+}
+
+// workspaceFiles returns the paths of changes, made under realRoot, as paths
+// under absRoot as the workspace was given
+func workspaceFiles(absRoot, realRoot string, changes []FileChange) []string {
+	files := make([]string, len(changes))
+	for i, change := range changes {
+		files[i] = filepath.Join(absRoot, workspaceRelative(realRoot, change.Path))
+	}
+	return files
+}
+
+// fileDiffs returns the paths of changes and their unified diffs, named
+// relative to absRoot when inside it
+func fileDiffs(absRoot string, changes []FileChange) ([]string, []FileDiff, error) {
+	files := make([]string, 0, len(changes))
+	diffs := make([]FileDiff, 0, len(changes))
+	for _, change := range changes {
+		name := workspaceRelative(absRoot, change.Path)
+		diff, err := UnifiedDiff(change, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to diff %s: %v", name, err)
+		}
+		files = append(files, change.Path)
+		diffs = append(diffs, FileDiff{File: name, Diff: diff})
+	}
+	return files, diffs, nil
+}
@@ -0,0 +1,131 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.ts")
+	b := filepath.Join(root, "src", "b.ts")
+	if err := os.MkdirAll(filepath.Dir(b), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(a, []byte("let a = 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("import { a } from '../a'\nconsole.log(a)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// lines are 1-based for both kinds of edits here
+	req := ApplyEditsRequest{
+		WorkspaceRoot: root,
+		Edit: &WorkspaceEdit{Changes: map[string][]TextEdit{
+			PathToURI(a): {textEdit(1, 4, 1, 5, "x")},
+		}},
+		FileEdits: []FileEdits{{File: "src/b.ts", Edits: []TextEdit{
+			textEdit(2, 12, 2, 13, "x"),
+			textEdit(1, 9, 1, 10, "x"),
+		}}},
+		LineBase: 1,
+		DryRun:   true,
+	}
+	res := ApplyEdits(req)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if len(res.Files) != 2 || res.Files[0] != a || res.Files[1] != b {
+		t.Fatalf("unexpected files %v", res.Files)
+	}
+	if len(res.Diffs) != 2 || res.Diffs[1].File != "src/b.ts" || !strings.Contains(res.Diffs[1].Diff, "+console.log(x)") {
+		t.Fatalf("unexpected diffs %+v", res.Diffs)
+	}
+	if content, _ := os.ReadFile(a); string(content) != "let a = 1\n" {
+		t.Fatalf("dry run wrote %q", content)
+	}
+
+	req.DryRun = false
+	if res := ApplyEdits(req); res.Error != "" || res.Diffs != nil {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	for path, want := range map[string]string{
+		a: "let x = 1\n",
+		b: "import { x } from '../a'\nconsole.log(x)\n",
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Fatalf("%s: got %q, want %q", path, content, want)
+		}
+	}
+	info, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("file mode changed to %v", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected no temporary files to remain, got %v", entries)
+	}
+}
+
+func TestApplyEditsRejects(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "project")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(root, "a.ts")
+	outside := filepath.Join(parent, "secret.ts")
+	for _, path := range []string{a, outside} {
+		if err := os.WriteFile(path, []byte("let a = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		files []FileEdits
+		want  string
+	}{
+		{
+			name: "outside the workspace",
+			files: []FileEdits{
+				{File: "a.ts", Edits: []TextEdit{textEdit(0, 4, 0, 5, "x")}},
+				{File: "../secret.ts", Edits: []TextEdit{textEdit(0, 4, 0, 5, "x")}},
+			},
+			want: "outside the workspace",
+		},
+		{
+			name: "overlapping",
+			files: []FileEdits{
+				{File: "a.ts", Edits: []TextEdit{textEdit(0, 0, 0, 5, "x"), textEdit(0, 4, 0, 9, "y")}},
+			},
+			want: "overlap",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := ApplyEdits(ApplyEditsRequest{WorkspaceRoot: root, FileEdits: tt.files})
+			if !strings.Contains(res.Error, tt.want) {
+				t.Fatalf("expected error containing %q, got %+v", tt.want, res)
+			}
+			for _, path := range []string{a, outside} {
+				if content, _ := os.ReadFile(path); string(content) != "let a = 1\n" {
+					t.Fatalf("%s was written: %q", path, content)
+				}
+			}
+		})
+	}
+}
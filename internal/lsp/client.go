@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
 	"strconv"
@@ -14,6 +13,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/0x5457/ts-index/internal/logging"
 )
 
 const (
@@ -21,17 +22,26 @@ const (
 	typescriptLangName = "typescript"
 )
 
+// Waits for pushed diagnostics. Servers such as tsserver publish syntax and
+// semantic diagnostics separately, so a document's diagnostics are only taken
+// once no update has arrived for diagnosticsSettle.
+const (
+	diagnosticsTimeout = 10 * time.Second
+	diagnosticsSettle  = 500 * time.Millisecond
+)
+
 // LSPClient implements a Language Server Protocol client
 type LSPClient struct {
 	cmd       *exec.Cmd
 	stdin     io.WriteCloser
+	writeMu   sync.Mutex // serializes frames written to stdin
 	stdout    io.ReadCloser
 	stderr    io.ReadCloser
 	running   int32
 	requestID int32
 
 	// Channels for handling responses and notifications
-	responses    map[int]chan json.RawMessage
+	responses    map[int]chan LSPResponse
 	responsesMux sync.RWMutex
 
 	// Configuration
@@ -41,6 +51,26 @@ type LSPClient struct {
 	workspaceRoot string
 	openDocuments map[string]bool
 	documentsMux  sync.RWMutex
+
+	// Diagnostics pushed by the server since each document was last opened or
+	// changed; diagnosticsUpdated is closed and replaced on every publish
+	diagnostics        map[string]*publishedDiagnostics
+	diagnosticsUpdated chan struct{}
+	diagnosticsMux     sync.Mutex
+
+	// Edits of workspace/applyEdit requests are appended to bufferedEdits
+	// instead of written while ExecuteCommandEdits runs; bufferMu serializes
+	// those calls
+	bufferMu      sync.Mutex
+	bufferedEdits *[]WorkspaceEdit
+	editsMux      sync.Mutex
+}
+
+// publishedDiagnostics are the latest diagnostics of a document and how often
+// they have been published
+type publishedDiagnostics struct {
+	items []Diagnostic
+	count int
 }
 
 // LSPRequest represents a JSON-RPC 2.0 request
@@ -66,6 +96,11 @@ type LSPError struct {
 	Data    json.RawMessage `json:"data,omitempty"`
 }
 
+// Error implements the error interface so JSON-RPC errors can be returned to callers
+func (e *LSPError) Error() string {
+	return fmt.Sprintf("LSP error %d: %s", e.Code, e.Message)
+}
+
 // LSPNotification represents a JSON-RPC 2.0 notification
 type LSPNotification struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -73,13 +108,23 @@ type LSPNotification struct {
 	Params  interface{} `json:"params"`
 }
 
+// lspReply answers a request the server sent, keeping its ID as sent
+type lspReply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *LSPError       `json:"error,omitempty"`
+}
+
 // NewLSPClient creates a new LSP client
 func NewLSPClient(config LanguageServerConfig) *LSPClient {
 	return &LSPClient{
-		config:        config,
-		responses:     make(map[int]chan json.RawMessage),
-		openDocuments: make(map[string]bool),
-		workspaceRoot: config.WorkspaceRoot,
+		config:             config,
+		responses:          make(map[int]chan LSPResponse),
+		openDocuments:      make(map[string]bool),
+		workspaceRoot:      config.WorkspaceRoot,
+		diagnostics:        make(map[string]*publishedDiagnostics),
+		diagnosticsUpdated: make(chan struct{}),
 	}
 }
 
@@ -121,23 +166,11 @@ func (c *LSPClient) Start(ctx context.Context, workspaceRoot string) error {
 
 	// Start the process
 	if err := c.cmd.Start(); err != nil {
-		errMsg := fmt.Sprintf("failed to start language server %s: %v", c.config.Command, err)
-		fmt.Fprintf(os.Stderr, "[LSP ERROR] %s\n", errMsg)
-		return fmt.Errorf("%s", errMsg)
-	}
-
-	fmt.Printf(
-		"Started language server process: %s %v (PID: %d)\n",
-		c.config.Command,
-		c.config.Args,
-		c.cmd.Process.Pid,
-	)
-	log.Printf(
-		"Started language server process: %s %v (PID: %d)",
-		c.config.Command,
-		c.config.Args,
-		c.cmd.Process.Pid,
-	)
+		return fmt.Errorf("failed to start language server %s: %v", c.config.Command, err)
+	}
+
+	logging.L().Info("started language server",
+		"command", c.config.Command, "args", c.config.Args, "pid", c.cmd.Process.Pid)
 
 	atomic.StoreInt32(&c.running, 1)
 
@@ -148,7 +181,7 @@ func (c *LSPClient) Start(ctx context.Context, workspaceRoot string) error {
 	// Initialize the server
 	if err := c.initialize(ctx); err != nil {
 		if stopErr := c.Stop(); stopErr != nil {
-			log.Printf("Failed to stop language server during cleanup: %v", stopErr)
+			logging.L().Warn("failed to stop language server during cleanup", "error", stopErr)
 		}
 		return fmt.Errorf("failed to initialize language server: %w", err)
 	}
@@ -170,45 +203,48 @@ func (c *LSPClient) Stop() error {
 		return nil
 	}
 
-	atomic.StoreInt32(&c.running, 0)
-
-	// Send shutdown request and wait for response (required by LSP spec)
+	// Send shutdown request and wait for response (required by LSP spec).
+	// The client must still count as running for the request to be sent.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if _, err := c.sendRequest(ctx, "shutdown", nil); err != nil {
-		log.Printf("Failed to send shutdown request: %v", err)
+		logging.L().Warn("failed to send shutdown request", "command", c.config.Command, "error", err)
 		// Continue with exit even if shutdown failed
 	} else {
-		log.Printf("Shutdown request completed successfully")
+		logging.L().Debug("shutdown request completed", "command", c.config.Command)
 	}
 
 	// Send exit notification after shutdown response
 	if err := c.sendNotification("exit", nil); err != nil {
-		log.Printf("Failed to send exit notification: %v", err)
+		logging.L().Warn("failed to send exit notification", "command", c.config.Command, "error", err)
 	}
 
+	atomic.StoreInt32(&c.running, 0)
+
 	// Close pipes
 	if c.stdin != nil {
+		c.writeMu.Lock()
 		if err := c.stdin.Close(); err != nil {
-			log.Printf("Failed to close stdin: %v", err)
+			logging.L().Debug("failed to close language server stdin", "error", err)
 		}
+		c.writeMu.Unlock()
 	}
 	if c.stdout != nil {
 		if err := c.stdout.Close(); err != nil {
-			log.Printf("Failed to close stdout: %v", err)
+			logging.L().Debug("failed to close language server stdout", "error", err)
 		}
 	}
 	if c.stderr != nil {
 		if err := c.stderr.Close(); err != nil {
-			log.Printf("Failed to close stderr: %v", err)
+			logging.L().Debug("failed to close language server stderr", "error", err)
 		}
 	}
 
 	// Wait for process to exit
 	if c.cmd != nil && c.cmd.Process != nil {
 		if err := c.cmd.Wait(); err != nil {
-			log.Printf("Language server process exited with error: %v", err)
+			logging.L().Info("language server exited with error", "command", c.config.Command, "error", err)
 		}
 	}
 
@@ -233,7 +269,7 @@ func (c *LSPClient) sendRequest(
 	id := int(atomic.AddInt32(&c.requestID, 1))
 
 	// Create response channel
-	respChan := make(chan json.RawMessage, 1)
+	respChan := make(chan LSPResponse, 1)
 	c.responsesMux.Lock()
 	c.responses[id] = respChan
 	c.responsesMux.Unlock()
@@ -259,7 +295,10 @@ func (c *LSPClient) sendRequest(
 	// Wait for response
 	select {
 	case response := <-respChan:
-		return response, nil
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		return response.Result, nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
@@ -291,8 +330,12 @@ func (c *LSPClient) sendMessage(message interface{}) error {
 
 	// Log basic message info without exposing sensitive content
 	method := c.extractMethodFromMessage(message)
-	log.Printf("Sending LSP message: method=%s, size=%d bytes", method, len(data))
+	logging.L().Debug("sending LSP message", "method", method, "bytes", len(data))
 
+	// a frame must reach the pipe whole; concurrent requests and notifications
+	// would otherwise interleave their writes
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	_, err = c.stdin.Write([]byte(content))
 	return err
 }
@@ -304,6 +347,8 @@ func (c *LSPClient) extractMethodFromMessage(message interface{}) string {
 		return msg.Method
 	case LSPNotification:
 		return msg.Method
+	case lspReply:
+		return "reply"
 	default:
 		return "unknown"
 	}
@@ -320,7 +365,7 @@ func (c *LSPClient) handleStdout() {
 			line, err := reader.ReadString('\n')
 			if err != nil {
 				if err != io.EOF {
-					log.Printf("Error reading from language server stdout: %v", err)
+					logging.L().Warn("failed to read language server stdout", "command", c.config.Command, "error", err)
 				}
 				return
 			}
@@ -344,29 +389,34 @@ func (c *LSPClient) handleStdout() {
 
 		contentLength, err := strconv.Atoi(contentLengthStr)
 		if err != nil {
-			log.Printf("Invalid Content-Length: %s", contentLengthStr)
+			logging.L().Warn("invalid Content-Length from language server", "value", contentLengthStr)
 			continue
 		}
 
 		content := make([]byte, contentLength)
 		_, err = io.ReadFull(reader, content)
 		if err != nil {
-			log.Printf("Error reading content: %v", err)
+			logging.L().Warn("failed to read language server message", "error", err)
+			continue
+		}
+
+		// Requests from the server carry both an ID and a method; their IDs
+		// are the server's own and must not be mistaken for response IDs
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(content, &request); err == nil && request.Method != "" && len(request.ID) > 0 {
+			go c.handleServerRequest(request.ID, request.Method, request.Params)
 			continue
 		}
 
 		// Parse JSON-RPC message
 		var response LSPResponse
 		if err := json.Unmarshal(content, &response); err != nil {
-			errMsg := fmt.Sprintf(
-				"Error parsing JSON-RPC response from"+
-					"%s: %v\nContent: %s",
-				c.config.Command,
-				err,
-				string(content),
-			)
-			fmt.Fprintf(os.Stderr, "[LSP ERROR] %s\n", errMsg)
-			log.Printf("%s", errMsg)
+			logging.L().Warn("failed to parse JSON-RPC message from language server",
+				"command", c.config.Command, "error", err, "content", string(content))
 			continue
 		}
 
@@ -378,39 +428,151 @@ func (c *LSPClient) handleStdout() {
 
 			if ok && respChan != nil {
 				if response.Error != nil {
-					// Handle error response with detailed output
-					errDetails := fmt.Sprintf("LSP Request Error (ID: %d): Code=%d, Message=%s",
-						*response.ID, response.Error.Code, response.Error.Message)
-					if len(response.Error.Data) > 0 {
-						errDetails += fmt.Sprintf(", Data=%s", string(response.Error.Data))
-					}
-					fmt.Fprintf(os.Stderr, "[LSP ERROR] %s\n", errDetails)
-					log.Printf("%s", errDetails)
-				} else {
-					select {
-					case respChan <- response.Result:
-					default:
-					}
+					logging.L().Warn("language server request failed",
+						"id", *response.ID,
+						"code", response.Error.Code,
+						"message", response.Error.Message,
+						"data", string(response.Error.Data))
+				}
+				select {
+				case respChan <- response:
+				default:
 				}
 			}
+		} else {
+			c.handleNotification(content)
 		}
-		// Note: We could handle notifications here if needed
 	}
 }
 
+// handleNotification records the diagnostics the server publishes; other
+// notifications are ignored
+func (c *LSPClient) handleNotification(content []byte) {
+	var notification struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(content, &notification); err != nil ||
+		notification.Method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(notification.Params, &params); err != nil {
+		logging.L().Warn("failed to parse published diagnostics", "error", err)
+		return
+	}
+
+	c.diagnosticsMux.Lock()
+	defer c.diagnosticsMux.Unlock()
+	doc := c.diagnostics[params.URI]
+	if doc == nil {
+		doc = &publishedDiagnostics{}
+		c.diagnostics[params.URI] = doc
+	}
+	doc.items = params.Diagnostics
+	doc.count++
+	close(c.diagnosticsUpdated)
+	c.diagnosticsUpdated = make(chan struct{})
+}
+
+// handleServerRequest answers the requests the server sends: applyEdit
+// writes the edit to files inside the workspace, or buffers it for
+// ExecuteCommandEdits, and configuration returns the adapter's workspace
+// configuration. Other server requests are left unanswered.
+func (c *LSPClient) handleServerRequest(id json.RawMessage, method string, params json.RawMessage) {
+	var result interface{}
+	switch method {
+	case "workspace/applyEdit":
+		result = c.applyEdit(params)
+	case "workspace/configuration":
+		result = c.configuration(params)
+	default:
+		logging.L().Debug("unhandled language server request", "method", method)
+		return
+	}
+	if err := c.sendMessage(lspReply{JSONRPC: "2.0", ID: id, Result: result}); err != nil {
+		logging.L().Warn("failed to answer language server request", "method", method, "error", err)
+	}
+}
+
+// applyEdit handles a workspace/applyEdit request and returns its result
+func (c *LSPClient) applyEdit(params json.RawMessage) map[string]interface{} {
+	var req struct {
+		Label string        `json:"label"`
+		Edit  WorkspaceEdit `json:"edit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return map[string]interface{}{
+			"applied":       false,
+			"failureReason": fmt.Sprintf("invalid workspace edit: %v", err),
+		}
+	}
+	c.editsMux.Lock()
+	if c.bufferedEdits != nil {
+		*c.bufferedEdits = append(*c.bufferedEdits, req.Edit)
+		c.editsMux.Unlock()
+		logging.L().Debug("buffered workspace edit", "label", req.Label)
+		return map[string]interface{}{"applied": true}
+	}
+	c.editsMux.Unlock()
+
+	res := ApplyEdits(ApplyEditsRequest{WorkspaceRoot: c.workspaceRoot, Edit: &req.Edit})
+	if res.Error != "" {
+		return map[string]interface{}{"applied": false, "failureReason": res.Error}
+	}
+	logging.L().Debug("applied workspace edit", "label", req.Label, "files", len(res.Files))
+	return map[string]interface{}{"applied": true}
+}
+
+// configuration answers a workspace/configuration request with one value per
+// requested item: the section of the workspace configuration it names, the
+// whole configuration for an empty section, or null when it is not set
+func (c *LSPClient) configuration(params json.RawMessage) []interface{} {
+	var req struct {
+		Items []struct {
+			Section string `json:"section"`
+		} `json:"items"`
+	}
+	_ = json.Unmarshal(params, &req)
+	values := make([]interface{}, len(req.Items))
+	for i, item := range req.Items {
+		var value interface{} = c.config.WorkspaceConfiguration
+		for _, key := range strings.Split(item.Section, ".") {
+			if key == "" {
+				continue
+			}
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = section[key]
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// forgetDiagnostics drops the diagnostics of uri, so the next GetDiagnostics
+// waits for the server to publish fresh ones
+func (c *LSPClient) forgetDiagnostics(uri string) {
+	c.diagnosticsMux.Lock()
+	delete(c.diagnostics, uri)
+	c.diagnosticsMux.Unlock()
+}
+
 // handleStderr handles stderr from the language server
 func (c *LSPClient) handleStderr() {
 	scanner := bufio.NewScanner(c.stderr)
 	for scanner.Scan() && c.IsRunning() {
-		line := scanner.Text()
-		// Output stderr with more prominence
-		fmt.Fprintf(os.Stderr, "[LSP ERROR] %s: %s\n", c.config.Command, line)
-		log.Printf("LSP stderr: %s", line)
+		// servers log routine chatter to stderr
+		logging.L().Info("language server stderr", "command", c.config.Command, "line", scanner.Text())
 	}
 	if err := scanner.Err(); err != nil {
-		errMsg := fmt.Sprintf("Error reading stderr from %s: %v", c.config.Command, err)
-		fmt.Fprintf(os.Stderr, "[LSP ERROR] %s\n", errMsg)
-		log.Printf("%s", errMsg)
+		logging.L().Warn("failed to read language server stderr", "command", c.config.Command, "error", err)
 	}
 }
 
@@ -432,11 +594,27 @@ func (c *LSPClient) initialize(ctx context.Context) error {
 				"definition": map[string]interface{}{
 					"linkSupport": true,
 				},
-				"references":     map[string]interface{}{},
-				"documentSymbol": map[string]interface{}{},
+				"references":         map[string]interface{}{},
+				"documentSymbol":     map[string]interface{}{},
+				"rename":             map[string]interface{}{},
+				"publishDiagnostics": map[string]interface{}{},
+				"typeHierarchy":      map[string]interface{}{},
+				"codeAction": map[string]interface{}{
+					"codeActionLiteralSupport": map[string]interface{}{
+						"codeActionKind": map[string]interface{}{
+							"valueSet": []string{CodeActionKindQuickFix},
+						},
+					},
+				},
 			},
 			"workspace": map[string]interface{}{
-				"symbol": map[string]interface{}{},
+				"symbol":         map[string]interface{}{},
+				"executeCommand": map[string]interface{}{},
+				"applyEdit":      true,
+				"configuration":  true,
+				"workspaceEdit": map[string]interface{}{
+					"documentChanges": true,
+				},
 			},
 		},
 		"initializationOptions": c.config.InitializationOptions,
@@ -455,6 +633,12 @@ func (c *LSPClient) initialize(ctx context.Context) error {
 	return c.sendNotification("initialized", map[string]interface{}{})
 }
 
+// RawRequest sends a request for method with params as given and returns the
+// result as the server sent it, for debugging
+func (c *LSPClient) RawRequest(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	return c.sendRequest(ctx, method, params)
+}
+
 // Hover implements LanguageServer.Hover
 func (c *LSPClient) Hover(ctx context.Context, params TextDocumentPositionParams) (*Hover, error) {
 	response, err := c.sendRequest(ctx, "textDocument/hover", params)
@@ -477,7 +661,7 @@ func (c *LSPClient) Hover(ctx context.Context, params TextDocumentPositionParams
 // Completion implements LanguageServer.Completion
 func (c *LSPClient) Completion(
 	ctx context.Context,
-	params TextDocumentPositionParams,
+	params CompletionParams,
 ) (*CompletionList, error) {
 	response, err := c.sendRequest(ctx, "textDocument/completion", params)
 	if err != nil {
@@ -520,19 +704,34 @@ func (c *LSPClient) GotoDefinition(
 		return []Location{}, nil
 	}
 
-	// Try to parse as array of Location first
-	var locations []Location
-	if err := json.Unmarshal(response, &locations); err == nil {
-		return locations, nil
-	}
+	return parseLocations(response)
+}
 
-	// Fallback: try to parse as single Location
-	var location Location
-	if err := json.Unmarshal(response, &location); err != nil {
-		return nil, err
+// parseLocations decodes a goto result: a Location, a Location array or a
+// LocationLink array, the latter converted to Locations
+func parseLocations(response json.RawMessage) ([]Location, error) {
+	// each element may be either shape, so decode both field sets
+	type locationOrLink struct {
+		Location
+		LocationLink
 	}
-
-	return []Location{location}, nil
+	var items []locationOrLink
+	if err := json.Unmarshal(response, &items); err != nil {
+		var single Location
+		if err := json.Unmarshal(response, &single); err != nil {
+			return nil, err
+		}
+		return []Location{single}, nil
+	}
+	locations := make([]Location, 0, len(items))
+	for _, item := range items {
+		if item.URI == "" && item.TargetURI != "" {
+			locations = append(locations, item.LocationLink.ToLocation())
+			continue
+		}
+		locations = append(locations, item.Location)
+	}
+	return locations, nil
 }
 
 // FindReferences implements LanguageServer.FindReferences
@@ -585,19 +784,7 @@ func (c *LSPClient) GotoImplementation(
 		return []Location{}, nil
 	}
 
-	// Try to parse as array of Location first
-	var locations []Location
-	if err := json.Unmarshal(response, &locations); err == nil {
-		return locations, nil
-	}
-
-	// Fallback: try to parse as single Location
-	var location Location
-	if err := json.Unmarshal(response, &location); err != nil {
-		return nil, err
-	}
-
-	return []Location{location}, nil
+	return parseLocations(response)
 }
 
 // GotoTypeDefinition implements LanguageServer.GotoTypeDefinition
@@ -614,19 +801,7 @@ func (c *LSPClient) GotoTypeDefinition(
 		return []Location{}, nil
 	}
 
-	// Try to parse as array of Location first
-	var locations []Location
-	if err := json.Unmarshal(response, &locations); err == nil {
-		return locations, nil
-	}
-
-	// Fallback: try to parse as single Location
-	var location Location
-	if err := json.Unmarshal(response, &location); err != nil {
-		return nil, err
-	}
-
-	return []Location{location}, nil
+	return parseLocations(response)
 }
 
 // GotoDeclaration implements LanguageServer.GotoDeclaration
@@ -643,19 +818,72 @@ func (c *LSPClient) GotoDeclaration(
 		return []Location{}, nil
 	}
 
-	// Try to parse as array of Location first
-	var locations []Location
-	if err := json.Unmarshal(response, &locations); err == nil {
-		return locations, nil
+	return parseLocations(response)
+}
+
+// PrepareTypeHierarchy implements LanguageServer.PrepareTypeHierarchy
+func (c *LSPClient) PrepareTypeHierarchy(
+	ctx context.Context,
+	params TextDocumentPositionParams,
+) ([]TypeHierarchyItem, error) {
+	return c.typeHierarchyRequest(ctx, "textDocument/prepareTypeHierarchy", params)
+}
+
+// TypeHierarchySupertypes implements LanguageServer.TypeHierarchySupertypes
+func (c *LSPClient) TypeHierarchySupertypes(
+	ctx context.Context,
+	item TypeHierarchyItem,
+) ([]TypeHierarchyItem, error) {
+	return c.typeHierarchyRequest(ctx, "typeHierarchy/supertypes", map[string]interface{}{"item": item})
+}
+
+// TypeHierarchySubtypes implements LanguageServer.TypeHierarchySubtypes
+func (c *LSPClient) TypeHierarchySubtypes(
+	ctx context.Context,
+	item TypeHierarchyItem,
+) ([]TypeHierarchyItem, error) {
+	return c.typeHierarchyRequest(ctx, "typeHierarchy/subtypes", map[string]interface{}{"item": item})
+}
+
+func (c *LSPClient) typeHierarchyRequest(
+	ctx context.Context,
+	method string,
+	params interface{},
+) ([]TypeHierarchyItem, error) {
+	response, err := c.sendRequest(ctx, method, params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fallback: try to parse as single Location
-	var location Location
-	if err := json.Unmarshal(response, &location); err != nil {
+	if len(response) == 0 || string(response) == nullResponseString {
+		return []TypeHierarchyItem{}, nil
+	}
+
+	var items []TypeHierarchyItem
+	if err := json.Unmarshal(response, &items); err != nil {
 		return nil, err
 	}
 
-	return []Location{location}, nil
+	return items, nil
+}
+
+// Rename implements LanguageServer.Rename
+func (c *LSPClient) Rename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error) {
+	response, err := c.sendRequest(ctx, "textDocument/rename", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response) == 0 || string(response) == nullResponseString {
+		return &WorkspaceEdit{}, nil
+	}
+
+	var edit WorkspaceEdit
+	if err := json.Unmarshal(response, &edit); err != nil {
+		return nil, err
+	}
+
+	return &edit, nil
 }
 
 // WorkspaceSymbols implements LanguageServer.WorkspaceSymbols
@@ -705,13 +933,123 @@ func (c *LSPClient) DocumentSymbols(ctx context.Context, uri string) ([]SymbolIn
 	return symbols, nil
 }
 
-// GetDiagnostics implements LanguageServer.GetDiagnostics
+// GetDiagnostics implements LanguageServer.GetDiagnostics. Servers push
+// diagnostics, so it waits for them to be published after the document was
+// last opened or changed. A document the server publishes nothing for within
+// diagnosticsTimeout has none.
 func (c *LSPClient) GetDiagnostics(ctx context.Context, uri string) ([]Diagnostic, error) {
-	// Note: LSP doesn't have a direct "get diagnostics" request
-	// Diagnostics are typically sent as notifications from the server
-	// For now, we return an empty slice
-	// In a full implementation, we would store diagnostics from notifications
-	return []Diagnostic{}, nil
+	timeout := time.NewTimer(diagnosticsTimeout)
+	defer timeout.Stop()
+
+	var settle <-chan time.Time
+	seen := 0
+	for {
+		c.diagnosticsMux.Lock()
+		var items []Diagnostic
+		if doc := c.diagnostics[uri]; doc != nil {
+			items = doc.items
+			if doc.count != seen {
+				// wait for the document to settle again
+				seen = doc.count
+				settle = time.After(diagnosticsSettle)
+			}
+		}
+		updated := c.diagnosticsUpdated
+		c.diagnosticsMux.Unlock()
+
+		select {
+		case <-updated:
+		case <-settle:
+			return items, nil
+		case <-timeout.C:
+			return items, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// CodeActions implements LanguageServer.CodeActions
+func (c *LSPClient) CodeActions(ctx context.Context, params CodeActionParams) ([]CodeAction, error) {
+	response, err := c.sendRequest(ctx, "textDocument/codeAction", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response) == 0 || string(response) == nullResponseString {
+		return []CodeAction{}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(response, &items); err != nil {
+		return nil, err
+	}
+	actions := make([]CodeAction, 0, len(items))
+	for _, item := range items {
+		// a bare Command has a string "command" field; a CodeAction an object
+		var shape struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(item, &shape); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimSpace(string(shape.Command)), `"`) {
+			var command Command
+			if err := json.Unmarshal(item, &command); err != nil {
+				return nil, err
+			}
+			actions = append(actions, CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action CodeAction
+		if err := json.Unmarshal(item, &action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// ExecuteCommand runs a server command with workspace/executeCommand and
+// returns its result. Edits the server sends back with workspace/applyEdit
+// while it runs are written to the workspace before the command completes.
+func (c *LSPClient) ExecuteCommand(
+	ctx context.Context,
+	command string,
+	args []interface{},
+) (json.RawMessage, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	params := map[string]interface{}{"command": command, "arguments": args}
+	return c.sendRequest(ctx, "workspace/executeCommand", params)
+}
+
+// ExecuteCommandEdits runs a server command like ExecuteCommand, but returns
+// the edits the server sends with workspace/applyEdit while it runs instead
+// of writing them. The server is told they were applied. Only one such call
+// runs at a time, and edits requested meanwhile by other commands are
+// buffered with it.
+func (c *LSPClient) ExecuteCommandEdits(
+	ctx context.Context,
+	command string,
+	args []interface{},
+) (json.RawMessage, []WorkspaceEdit, error) {
+	c.bufferMu.Lock()
+	defer c.bufferMu.Unlock()
+
+	edits := []WorkspaceEdit{}
+	c.editsMux.Lock()
+	c.bufferedEdits = &edits
+	c.editsMux.Unlock()
+	result, err := c.ExecuteCommand(ctx, command, args)
+	c.editsMux.Lock()
+	c.bufferedEdits = nil
+	c.editsMux.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, edits, nil
 }
 
 // DidOpen implements LanguageServer.DidOpen
@@ -719,6 +1057,7 @@ func (c *LSPClient) DidOpen(ctx context.Context, uri string, content string) err
 	c.documentsMux.Lock()
 	c.openDocuments[uri] = true
 	c.documentsMux.Unlock()
+	c.forgetDiagnostics(uri)
 
 	params := struct {
 		TextDocument struct {
@@ -746,6 +1085,7 @@ func (c *LSPClient) DidOpen(ctx context.Context, uri string, content string) err
 
 // DidChange implements LanguageServer.DidChange
 func (c *LSPClient) DidChange(ctx context.Context, uri string, content string) error {
+	c.forgetDiagnostics(uri)
 	params := struct {
 		TextDocument struct {
 			URI     string `json:"uri"`
@@ -777,6 +1117,7 @@ func (c *LSPClient) DidClose(ctx context.Context, uri string) error {
 	c.documentsMux.Lock()
 	delete(c.openDocuments, uri)
 	c.documentsMux.Unlock()
+	c.forgetDiagnostics(uri)
 
 	params := struct {
 		TextDocument TextDocumentIdentifier `json:"textDocument"`
@@ -802,5 +1143,8 @@ func (c *LSPClient) getLanguageID(uri string) string {
 	if strings.HasSuffix(path, ".jsx") {
 		return "javascriptreact"
 	}
+	if strings.HasSuffix(path, ".vue") {
+		return "vue"
+	}
 	return typescriptLangName // default
 }
@@ -0,0 +1,259 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// buildFakeServer compiles the fake language server in testdata/fakelsp
+func buildFakeServer(t *testing.T) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available to build the fake language server")
+	}
+	bin := filepath.Join(t.TempDir(), "fakelsp")
+	out, err := exec.Command(goBin, "build", "-o", bin, "./testdata/fakelsp").CombinedOutput()
+	if err != nil {
+		t.Fatalf("build fake language server: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func TestLSPClientRoundTrip(t *testing.T) {
+	bin := buildFakeServer(t)
+	root := t.TempDir()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := NewLSPClient(LanguageServerConfig{Command: bin})
+	if err := client.Start(ctx, root); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() { _ = client.Stop() }()
+
+	uri := PathToURI(filepath.Join(root, "a.ts"))
+	if err := client.DidOpen(ctx, uri, "export function add(a: number, b: number) { return a + b }"); err != nil {
+		t.Fatalf("didOpen: %v", err)
+	}
+	at := func(line int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     Position{Line: line, Character: 17},
+		}
+	}
+
+	// hover: markup content
+	hover, err := client.Hover(ctx, at(0))
+	if err != nil {
+		t.Fatalf("hover: %v", err)
+	}
+	if hover == nil {
+		t.Fatalf("expected hover result")
+	}
+	if got := extractHoverContents(hover.Contents); got == "" {
+		t.Fatalf("expected hover contents, got empty string from %s", hover.Contents)
+	}
+
+	// hover: null result
+	hover, err = client.Hover(ctx, at(1))
+	if err != nil || hover != nil {
+		t.Fatalf("expected nil hover for null result, got %v, %v", hover, err)
+	}
+
+	// hover: error response is returned, not dropped
+	_, err = client.Hover(ctx, at(2))
+	var lspErr *LSPError
+	if !errors.As(err, &lspErr) || lspErr.Code != -32603 {
+		t.Fatalf("expected LSP error -32603, got %v", err)
+	}
+
+	// definition: array of locations
+	defs, err := client.GotoDefinition(ctx, at(0))
+	if err != nil || len(defs) != 1 || defs[0].URI != uri {
+		t.Fatalf("unexpected definitions %v, %v", defs, err)
+	}
+	if defs[0].Range.Start.Character != 16 || defs[0].Range.End.Character != 19 {
+		t.Fatalf("unexpected definition range %+v", defs[0].Range)
+	}
+
+	// definition: single location
+	defs, err = client.GotoDefinition(ctx, at(1))
+	if err != nil || len(defs) != 1 {
+		t.Fatalf("expected single location to be wrapped, got %v, %v", defs, err)
+	}
+
+	// definition: null result
+	defs, err = client.GotoDefinition(ctx, at(2))
+	if err != nil || len(defs) != 0 {
+		t.Fatalf("expected no definitions for null result, got %v, %v", defs, err)
+	}
+
+	// definition-like requests: LocationLink array
+	gotos := map[string]func(context.Context, TextDocumentPositionParams) ([]Location, error){
+		"definition":     client.GotoDefinition,
+		"implementation": client.GotoImplementation,
+		"typeDefinition": client.GotoTypeDefinition,
+		"declaration":    client.GotoDeclaration,
+	}
+	for name, gotoFn := range gotos {
+		locs, err := gotoFn(ctx, at(3))
+		if err != nil || len(locs) != 1 || locs[0].URI != uri {
+			t.Fatalf("%s: expected LocationLink to be converted, got %v, %v", name, locs, err)
+		}
+		if locs[0].Range.Start.Character != 16 || locs[0].Range.End.Character != 19 {
+			t.Fatalf("%s: expected the target selection range, got %+v", name, locs[0].Range)
+		}
+	}
+
+	// completion: partial CompletionList
+	list, err := client.Completion(ctx, CompletionParams{TextDocumentPositionParams: at(0)})
+	if err != nil || !list.IsIncomplete || len(list.Items) != 1 || list.Items[0].Label != "add" {
+		t.Fatalf("expected incomplete completion list, got %+v, %v", list, err)
+	}
+
+	// completion: bare item array is a complete list
+	list, err = client.Completion(ctx, CompletionParams{TextDocumentPositionParams: at(1)})
+	if err != nil || list.IsIncomplete || len(list.Items) != 1 {
+		t.Fatalf("expected complete completion list, got %+v, %v", list, err)
+	}
+
+	// completion: null result
+	list, err = client.Completion(ctx, CompletionParams{TextDocumentPositionParams: at(2)})
+	if err != nil || list.IsIncomplete || len(list.Items) != 0 {
+		t.Fatalf("expected empty completion list, got %+v, %v", list, err)
+	}
+
+	// unknown methods surface the server's error
+	if _, err := client.sendRequest(ctx, "textDocument/unknown", nil); err == nil {
+		t.Fatalf("expected error for unknown method")
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if client.IsRunning() {
+		t.Fatalf("client still running after stop")
+	}
+	if client.cmd.ProcessState == nil || !client.cmd.ProcessState.Success() {
+		t.Fatalf("fake server did not exit cleanly: %v", client.cmd.ProcessState)
+	}
+}
+
+// overlapDetector fails writes that start while another write is in flight
+type overlapDetector struct {
+	io.WriteCloser
+	inFlight atomic.Int32
+}
+
+func (w *overlapDetector) Write(p []byte) (int, error) {
+	if w.inFlight.Add(1) > 1 {
+		w.inFlight.Add(-1)
+		return 0, errors.New("concurrent write to stdin")
+	}
+	defer w.inFlight.Add(-1)
+	// widen the window a racing writer would hit
+	time.Sleep(time.Millisecond)
+	return w.WriteCloser.Write(p)
+}
+
+func TestLSPClientConcurrentWrites(t *testing.T) {
+	bin := buildFakeServer(t)
+	root := t.TempDir()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := NewLSPClient(LanguageServerConfig{Command: bin})
+	if err := client.Start(ctx, root); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() { _ = client.Stop() }()
+	client.stdin = &overlapDetector{WriteCloser: client.stdin}
+
+	const callers = 16
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uri := PathToURI(filepath.Join(root, fmt.Sprintf("f%d.ts", i)))
+			if err := client.DidOpen(ctx, uri, "export const x = 1"); err != nil {
+				errs <- err
+				return
+			}
+			_, err := client.Hover(ctx, TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent request: %v", err)
+		}
+	}
+}
+
+func TestLSPClientServerRequests(t *testing.T) {
+	bin := buildFakeServer(t)
+	root := t.TempDir()
+	path := filepath.Join(root, "a.ts")
+	src := "import { unused } from './b'\nexport const a = 1\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := NewLSPClient(LanguageServerConfig{
+		Command: bin,
+		WorkspaceConfiguration: map[string]interface{}{
+			"typescript": map[string]interface{}{
+				"preferences": map[string]interface{}{"includePackageJsonAutoImports": "auto"},
+			},
+		},
+	})
+	if err := client.Start(ctx, root); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() { _ = client.Stop() }()
+
+	for section, want := range map[string]string{
+		"typescript.preferences": `[{"includePackageJsonAutoImports":"auto"}]`,
+		"javascript.preferences": `[null]`,
+	} {
+		got, err := client.ExecuteCommand(ctx, "fake.configuration", []interface{}{section})
+		if err != nil {
+			t.Fatalf("configuration %s: %v", section, err)
+		}
+		if string(got) != want {
+			t.Fatalf("expected %s for %s, got %s", want, section, got)
+		}
+	}
+
+	_, edits, err := client.ExecuteCommandEdits(ctx, "typescript.organizeImports", []interface{}{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 || len(edits[0].Changes[PathToURI(path)]) != 1 {
+		t.Fatalf("expected one buffered edit to a.ts, got %+v", edits)
+	}
+	if got, _ := os.ReadFile(path); string(got) != src {
+		t.Fatalf("buffered edit was written: %q", got)
+	}
+}
@@ -1,19 +1,26 @@
 package lsp
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // ClientTools provides high-level tools for interacting with language servers
 // This is the main interface that applications should use
 type ClientTools struct {
-	manager *LanguageServerManager
+	manager      *LanguageServerManager
+	symbolStore  WorkspaceSymbolStore  // set by NewClientToolsWithSymbolStore
+	symbolFinder EnclosingSymbolFinder // set by SetEnclosingSymbolFinder
 }
 
 // NewClientTools creates a new client tools instance
@@ -39,6 +46,13 @@ type AnalyzeSymbolRequest struct {
 	IncludeImplementations bool   `json:"include_implementations"`
 	IncludeTypeDefinitions bool   `json:"include_type_definitions"`
 	IncludeDeclarations    bool   `json:"include_declarations"`
+	// RelativePaths reports locations inside the workspace as workspace-relative paths
+	RelativePaths bool `json:"relative_paths"`
+	// LineBase numbers Line and returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+	// Enrich adds a preview and the enclosing symbol to every location but
+	// references
+	Enrich bool `json:"enrich"`
 }
 
 // GotoRequest represents a generic goto request (implementation/type definition/declaration)
@@ -47,6 +61,12 @@ type GotoRequest struct {
 	FilePath      string `json:"file_path"`
 	Line          int    `json:"line"`      // 0-based
 	Character     int    `json:"character"` // 0-based
+	// RelativePaths reports locations inside the workspace as workspace-relative paths
+	RelativePaths bool `json:"relative_paths"`
+	// LineBase numbers Line and returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+	// Enrich adds a preview and the enclosing symbol to every location
+	Enrich bool `json:"enrich"`
 }
 
 // GotoResponse represents a goto response
@@ -72,10 +92,16 @@ type HoverResult struct {
 	Range    *Range `json:"range,omitempty"`
 }
 
-// LocationResult represents a location
+// LocationResult represents a location.
+// When relative paths are requested, URI holds the workspace-relative path and
+// AbsoluteURI keeps the original file:// URI. Preview and EnclosingSymbol are
+// set on enriched locations.
 type LocationResult struct {
-	URI   string `json:"uri"`
-	Range Range  `json:"range"`
+	URI             string           `json:"uri"`
+	Range           Range            `json:"range"`
+	AbsoluteURI     string           `json:"absolute_uri,omitempty"`
+	Preview         *LocationPreview `json:"preview,omitempty"`
+	EnclosingSymbol *EnclosingSymbol `json:"enclosing_symbol,omitempty"`
 }
 
 // CompletionRequest represents a request to get completions
@@ -85,12 +111,23 @@ type CompletionRequest struct {
 	Line          int    `json:"line"`      // 0-based
 	Character     int    `json:"character"` // 0-based
 	MaxResults    int    `json:"max_results"`
+	// RetryIncomplete re-requests completions once when the server reports a
+	// partial list, triggered as a completion of that incomplete list
+	RetryIncomplete bool `json:"retry_incomplete"`
+	// GroupByKind returns the items in Groups instead of Items
+	GroupByKind bool `json:"group_by_kind"`
 }
 
 // CompletionResponse represents the response of completion request
 type CompletionResponse struct {
 	Items []CompletionItemResult `json:"items"`
-	Error string                 `json:"error,omitempty"`
+	// Groups holds the items by kind when requested, in the order of each
+	// kind's best item
+	Groups []CompletionGroup `json:"groups,omitempty"`
+	// IsIncomplete reports that the server's list is partial, so it should not be
+	// treated as exhaustive and re-requesting may yield more items
+	IsIncomplete bool   `json:"is_incomplete"`
+	Error        string `json:"error,omitempty"`
 }
 
 // CompletionItemResult represents a completion item
@@ -99,6 +136,13 @@ type CompletionItemResult struct {
 	Kind       int    `json:"kind,omitempty"`
 	Detail     string `json:"detail,omitempty"`
 	InsertText string `json:"insert_text,omitempty"`
+	SortText   string `json:"sort_text,omitempty"`
+}
+
+// CompletionGroup is the completion items of one kind
+type CompletionGroup struct {
+	Kind  int                    `json:"kind"`
+	Items []CompletionItemResult `json:"items"`
 }
 
 // SymbolSearchRequest represents a request to search symbols
@@ -106,6 +150,10 @@ type SymbolSearchRequest struct {
 	WorkspaceRoot string `json:"workspace_root"`
 	Query         string `json:"query"`
 	MaxResults    int    `json:"max_results"`
+	// RelativePaths reports locations inside the workspace as workspace-relative paths
+	RelativePaths bool `json:"relative_paths"`
+	// LineBase numbers returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
 }
 
 // SymbolSearchResponse represents the response of symbol search
@@ -122,6 +170,34 @@ type SymbolResult struct {
 	ContainerName string         `json:"container_name,omitempty"`
 }
 
+// RenameRequest represents a request to rename the symbol at a position
+type RenameRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	FilePath      string `json:"file_path"`
+	Line          int    `json:"line"`      // 0-based
+	Character     int    `json:"character"` // 0-based
+	NewName       string `json:"new_name"`
+	// DryRun computes the edits and their diffs without writing them
+	DryRun bool `json:"dry_run"`
+}
+
+// RenameResponse represents the result of a rename
+type RenameResponse struct {
+	// Files are the absolute paths of the files rewritten on disk, or that
+	// would be on a dry run
+	Files []string `json:"files"`
+	// Diffs are the unified diffs of the files, set on a dry run
+	Diffs []FileDiff `json:"diffs,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// FileDiff is the unified diff of one file, named relative to the workspace
+// root when inside it
+type FileDiff struct {
+	File string `json:"file"`
+	Diff string `json:"diff"`
+}
+
 // AnalyzeSymbol analyzes a symbol at a specific position
 func (ct *ClientTools) AnalyzeSymbol(
 	ctx context.Context,
@@ -147,7 +223,7 @@ func (ct *ClientTools) AnalyzeSymbol(
 	}
 
 	uri := PathToURI(absFilePath)
-	position := Position{Line: req.Line, Character: req.Character}
+	position := Position{Line: req.Line - req.LineBase, Character: req.Character}
 
 	// Ensure document is open
 	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
@@ -221,6 +297,43 @@ func (ct *ClientTools) AnalyzeSymbol(
 		response.Declarations = convertLocationsToResults(declarations)
 	}
 
+	if req.Enrich {
+		for _, locations := range [][]LocationResult{
+			response.Definitions,
+			response.Implementations,
+			response.TypeDefinitions,
+			response.Declarations,
+		} {
+			ct.enrichLocations(locations, req.WorkspaceRoot)
+		}
+	}
+	if req.RelativePaths {
+		for _, locations := range [][]LocationResult{
+			response.Definitions,
+			response.References,
+			response.Implementations,
+			response.TypeDefinitions,
+			response.Declarations,
+		} {
+			relativizeLocations(locations, req.WorkspaceRoot)
+		}
+	}
+	if req.LineBase != 0 {
+		for _, locations := range [][]LocationResult{
+			response.Definitions,
+			response.References,
+			response.Implementations,
+			response.TypeDefinitions,
+			response.Declarations,
+		} {
+			shiftLocationLines(locations, req.LineBase)
+		}
+		if response.Hover != nil && response.Hover.Range != nil {
+			shifted := shiftRangeLines(*response.Hover.Range, req.LineBase)
+			response.Hover.Range = &shifted
+		}
+	}
+
 	return response
 }
 
@@ -261,13 +374,25 @@ func (ct *ClientTools) GetCompletion(
 		req.MaxResults = 20
 	}
 
-	completion, err := server.Completion(ctx, uri, position)
+	completion, err := server.Completion(ctx, uri, position, &CompletionContext{TriggerKind: CompletionTriggerInvoked})
 	if err != nil {
 		return CompletionResponse{Error: fmt.Sprintf("failed to get completion: %v", err)}
 	}
+	if completion.IsIncomplete && req.RetryIncomplete {
+		// re-request the partial list as an editor does once more is typed, so
+		// the server computes the rest instead of answering from the same cache
+		incomplete := &CompletionContext{TriggerKind: CompletionTriggerForIncompleteCompletions}
+		if retry, err := server.Completion(ctx, uri, position, incomplete); err == nil &&
+			(!retry.IsIncomplete || len(retry.Items) >= len(completion.Items)) {
+			completion = retry
+		}
+	}
 
-	items := make([]CompletionItemResult, 0, len(completion.Items))
-	for i, item := range completion.Items {
+	// sort before truncating so the most relevant items are kept
+	sorted := slices.Clone(completion.Items)
+	sortCompletionItems(sorted)
+	items := make([]CompletionItemResult, 0, min(len(sorted), req.MaxResults))
+	for i, item := range sorted {
 		if i >= req.MaxResults {
 			break
 		}
@@ -277,10 +402,48 @@ func (ct *ClientTools) GetCompletion(
 			Kind:       getCompletionKindValue(item.Kind),
 			Detail:     getStringValue(item.Detail),
 			InsertText: getStringValue(item.InsertText),
+			SortText:   getStringValue(item.SortText),
 		})
 	}
 
-	return CompletionResponse{Items: items}
+	if req.GroupByKind {
+		return CompletionResponse{Groups: groupCompletionItems(items), IsIncomplete: completion.IsIncomplete}
+	}
+	return CompletionResponse{Items: items, IsIncomplete: completion.IsIncomplete}
+}
+
+// sortCompletionItems orders items by sortText, falling back to the label as
+// the LSP specification does, then by label
+func sortCompletionItems(items []CompletionItem) {
+	key := func(item CompletionItem) string {
+		if item.SortText != nil && *item.SortText != "" {
+			return *item.SortText
+		}
+		return item.Label
+	}
+	slices.SortStableFunc(items, func(a, b CompletionItem) int {
+		if c := strings.Compare(key(a), key(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Label, b.Label)
+	})
+}
+
+// groupCompletionItems groups sorted items by kind, keeping their order within
+// a group and ordering groups by their first item
+func groupCompletionItems(items []CompletionItemResult) []CompletionGroup {
+	groups := []CompletionGroup{}
+	index := make(map[int]int)
+	for _, item := range items {
+		i, ok := index[item.Kind]
+		if !ok {
+			i = len(groups)
+			index[item.Kind] = i
+			groups = append(groups, CompletionGroup{Kind: item.Kind})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	return groups
 }
 
 // SearchSymbols searches for symbols in the workspace
@@ -324,6 +487,15 @@ func (ct *ClientTools) SearchSymbols(
 		})
 	}
 
+	if req.RelativePaths {
+		for i := range result {
+			result[i].Location = relativeLocation(result[i].Location, req.WorkspaceRoot)
+		}
+	}
+	for i := range result {
+		result[i].Location.Range = shiftRangeLines(result[i].Location.Range, req.LineBase)
+	}
+
 	return SymbolSearchResponse{Symbols: result}
 }
 
@@ -377,7 +549,7 @@ func (ct *ClientTools) performGoto(
 	}
 
 	uri := PathToURI(absFilePath)
-	position := Position{Line: req.Line, Character: req.Character}
+	position := Position{Line: req.Line - req.LineBase, Character: req.Character}
 
 	// Ensure document is open
 	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
@@ -402,7 +574,91 @@ func (ct *ClientTools) performGoto(
 		return GotoResponse{Error: fmt.Sprintf("failed to get %s: %v", gotoType, gotoErr)}
 	}
 
-	return GotoResponse{Locations: convertLocationsToResults(locations)}
+	results := convertLocationsToResults(locations)
+	if req.Enrich {
+		ct.enrichLocations(results, req.WorkspaceRoot)
+	}
+	if req.RelativePaths {
+		relativizeLocations(results, req.WorkspaceRoot)
+	}
+	shiftLocationLines(results, req.LineBase)
+	return GotoResponse{Locations: results}
+}
+
+// Rename renames the symbol at a position and applies the resulting edits to disk.
+// Modified documents are closed so the server re-reads them on next use.
+// A dry run only reports the diffs the edits would make.
+func (ct *ClientTools) Rename(ctx context.Context, req RenameRequest) RenameResponse {
+	if req.NewName == "" {
+		return RenameResponse{Error: "new name is empty"}
+	}
+
+	// Determine language from file extension
+	language := getLanguageFromPath(req.FilePath)
+	if language == "" {
+		return RenameResponse{Error: "unsupported file type"}
+	}
+
+	// Get or create language server
+	server, err := ct.manager.GetLanguageServer(ctx, req.WorkspaceRoot, language)
+	if err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to get language server: %v", err)}
+	}
+
+	// Make file path absolute
+	absFilePath := req.FilePath
+	if !filepath.IsAbs(absFilePath) {
+		absRoot, _ := filepath.Abs(req.WorkspaceRoot)
+		absFilePath = filepath.Join(absRoot, req.FilePath)
+	}
+
+	uri := PathToURI(absFilePath)
+	position := Position{Line: req.Line, Character: req.Character}
+
+	// Ensure document is open
+	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+
+	edit, err := server.Rename(ctx, uri, position, req.NewName)
+	if err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to rename: %v", err)}
+	}
+
+	// the server may answer with any path, so edits are held to the workspace
+	absRoot, realRoot, err := workspaceRoots(req.WorkspaceRoot)
+	if err != nil {
+		return RenameResponse{Error: err.Error()}
+	}
+	edit, err = confineEdits(absRoot, edit, nil, 0)
+	if err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to apply rename edits: %v", err)}
+	}
+	changes, err := PreviewWorkspaceEdit(edit)
+	if err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to apply rename edits: %v", err)}
+	}
+	if req.DryRun {
+		_, diffs, err := fileDiffs(realRoot, changes)
+		if err != nil {
+			return RenameResponse{Error: err.Error()}
+		}
+		return RenameResponse{Files: workspaceFiles(absRoot, realRoot, changes), Diffs: diffs}
+	}
+
+	if _, err := WriteFileChanges(changes); err != nil {
+		return RenameResponse{Error: fmt.Sprintf("failed to apply rename edits: %v", err)}
+	}
+	files := workspaceFiles(absRoot, realRoot, changes)
+
+	_ = server.DidClose(ctx, uri)
+	for _, file := range files {
+		if fileURI := PathToURI(file); fileURI != uri {
+			_ = server.DidClose(ctx, fileURI)
+		}
+	}
+
+	return RenameResponse{Files: files}
 }
 
 // GetDocumentSymbols gets symbols for a specific document
@@ -457,6 +713,110 @@ func (ct *ClientTools) GetDocumentSymbols(
 	return result, nil
 }
 
+// HoverBatch opens a document once and requests hover information for each position.
+// The returned slice is parallel to positions; entries are nil where no hover is available.
+func (ct *ClientTools) HoverBatch(
+	ctx context.Context,
+	workspaceRoot, filePath string,
+	positions []Position,
+) ([]*HoverResult, error) {
+	// Determine language from file extension
+	language := getLanguageFromPath(filePath)
+	if language == "" {
+		return nil, fmt.Errorf("unsupported file type")
+	}
+
+	// Get or create language server
+	server, err := ct.manager.GetLanguageServer(ctx, workspaceRoot, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language server: %v", err)
+	}
+
+	// Make file path absolute
+	absFilePath := filePath
+	if !filepath.IsAbs(absFilePath) {
+		absRoot, _ := filepath.Abs(workspaceRoot)
+		absFilePath = filepath.Join(absRoot, filePath)
+	}
+
+	uri := PathToURI(absFilePath)
+
+	// Ensure document is open
+	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
+		return nil, fmt.Errorf("failed to open document: %v", err)
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	results := make([]*HoverResult, len(positions))
+	for i, position := range positions {
+		hover, err := server.Hover(ctx, uri, position)
+		if err != nil {
+			return results, fmt.Errorf("failed to get hover info: %v", err)
+		}
+		if hover != nil {
+			results[i] = &HoverResult{
+				Contents: extractHoverContents(hover.Contents),
+				Range:    hover.Range,
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// RawRequest opens filePath and sends the language server a method request,
+// returning its result unparsed to diagnose mismatches with what the other
+// methods decode. params must be a JSON object or empty; for textDocument/
+// methods a textDocument naming the file is added unless params have one.
+func (ct *ClientTools) RawRequest(
+	ctx context.Context,
+	workspaceRoot, filePath, method string,
+	params json.RawMessage,
+) (json.RawMessage, error) {
+	// Determine language from file extension
+	language := getLanguageFromPath(filePath)
+	if language == "" {
+		return nil, fmt.Errorf("unsupported file type")
+	}
+
+	fields := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(params)) > 0 {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return nil, fmt.Errorf("params must be a JSON object: %v", err)
+		}
+	}
+
+	// Get or create language server
+	server, err := ct.manager.GetLanguageServer(ctx, workspaceRoot, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language server: %v", err)
+	}
+
+	// Make file path absolute
+	absFilePath := filePath
+	if !filepath.IsAbs(absFilePath) {
+		absRoot, _ := filepath.Abs(workspaceRoot)
+		absFilePath = filepath.Join(absRoot, filePath)
+	}
+
+	uri := PathToURI(absFilePath)
+
+	// Ensure document is open
+	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
+		return nil, fmt.Errorf("failed to open document: %v", err)
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	if _, ok := fields["textDocument"]; !ok && strings.HasPrefix(method, "textDocument/") {
+		fields["textDocument"], _ = json.Marshal(TextDocumentIdentifier{URI: uri})
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return server.RawRequest(ctx, method, body)
+}
+
 // Cleanup shuts down all language servers
 func (ct *ClientTools) Cleanup() error {
 	return ct.manager.StopAllServers()
@@ -467,108 +827,322 @@ func (ct *ClientTools) GetServerInfo() []ServerInfo {
 	return ct.manager.GetRunningServers()
 }
 
+// RegisterAdapter serves language with adapter, e.g. a Vue-aware server for
+// "vue", which has no adapter by default
+func (ct *ClientTools) RegisterAdapter(language string, adapter LspAdapter) {
+	ct.manager.RegisterAdapter(language, adapter)
+}
+
 // GetAdapterInfo returns information about registered adapters
 func (ct *ClientTools) GetAdapterInfo() []AdapterInfo {
 	return ct.manager.GetRegisteredAdapters()
 }
 
+// DefaultReadFileMaxBytes caps the content returned by ReadFile
+const DefaultReadFileMaxBytes = 1 << 20
+
+// DefaultReadFileTimeout bounds a single ReadFile call
+const DefaultReadFileTimeout = 10 * time.Second
+
 // ReadFileRequest represents a request to read file content
 type ReadFileRequest struct {
 	FilePath      string `json:"file_path"`
 	WorkspaceRoot string `json:"workspace_root,omitempty"` // Project root path
 	StartLine     int    `json:"start_line,omitempty"`     // 1-based line number, 0 means from beginning
 	EndLine       int    `json:"end_line,omitempty"`       // 1-based line number, 0 means to end
+	// MaxBytes caps the returned content; 0 means DefaultReadFileMaxBytes, negative means no cap
+	MaxBytes int `json:"max_bytes,omitempty"`
+	// Truncate returns the content up to MaxBytes instead of failing when it is exceeded
+	Truncate bool `json:"truncate,omitempty"`
+	// Timeout bounds the read; 0 means DefaultReadFileTimeout
+	Timeout time.Duration `json:"-"`
 }
 
 // ReadFileResponse represents the response of reading a file
 type ReadFileResponse struct {
 	Content    string `json:"content"`
-	Range      *Range `json:"range,omitempty"` // Range of lines that were read
-	TotalLines int    `json:"total_lines"`     // Total lines in the file
+	Range      *Range `json:"range,omitempty"`       // Range of lines that were read
+	TotalLines int    `json:"total_lines"`           // Total lines in the file
+	LineEnding string `json:"line_ending,omitempty"` // "lf", "crlf" or "mixed"; content keeps them as is
+	Truncated  bool   `json:"truncated,omitempty"`   // Content was cut at the byte cap
 	Error      string `json:"error,omitempty"`
 }
 
-// ReadFile reads file content, optionally within a specified range
+// ReadFile reads file content, optionally within a specified range.
+// Lines are streamed so only the requested range is held in memory.
 func (ct *ClientTools) ReadFile(
 	ctx context.Context,
 	req ReadFileRequest,
 ) ReadFileResponse {
-	// Make file path absolute if needed
-	absFilePath := req.FilePath
-	if !filepath.IsAbs(absFilePath) {
-		if req.WorkspaceRoot != "" {
-			// If workspace root is provided, make path relative to workspace root
-			absWorkspaceRoot, err := filepath.Abs(req.WorkspaceRoot)
-			if err != nil {
-				return ReadFileResponse{
-					Error: fmt.Sprintf("failed to get absolute workspace path: %v", err),
-				}
-			}
-			absFilePath = filepath.Join(absWorkspaceRoot, req.FilePath)
-		} else {
-			// Fallback to current working directory
-			var err error
-			absFilePath, err = filepath.Abs(req.FilePath)
-			if err != nil {
-				return ReadFileResponse{Error: fmt.Sprintf("failed to get absolute path: %v", err)}
-			}
+	filePath := req.FilePath
+	if strings.HasPrefix(filePath, "file://") {
+		filePath = URIToPath(filePath)
+	}
+	var absFilePath string
+	if req.WorkspaceRoot != "" {
+		var err error
+		absFilePath, err = resolveInWorkspace(req.WorkspaceRoot, filePath)
+		if err != nil {
+			return ReadFileResponse{Error: err.Error()}
+		}
+	} else {
+		// Fallback to current working directory
+		var err error
+		absFilePath, err = filepath.Abs(filePath)
+		if err != nil {
+			return ReadFileResponse{Error: fmt.Sprintf("failed to get absolute path: %v", err)}
 		}
 	}
 
-	// Read entire file content
-	content, err := readFileContent(absFilePath)
+	if req.MaxBytes == 0 {
+		req.MaxBytes = DefaultReadFileMaxBytes
+	}
+	if req.Timeout <= 0 {
+		req.Timeout = DefaultReadFileTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	file, err := os.Open(absFilePath)
 	if err != nil {
 		return ReadFileResponse{Error: fmt.Sprintf("failed to read file: %v", err)}
 	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return ReadFileResponse{Error: fmt.Sprintf("failed to read file: %v", err)}
+	}
+	wholeFile := req.StartLine == 0 && req.EndLine == 0
+	if wholeFile && req.MaxBytes > 0 && info.Size() > int64(req.MaxBytes) && !req.Truncate {
+		_ = file.Close()
+		return ReadFileResponse{Error: fmt.Sprintf(
+			"file is %d bytes, over the %d byte read limit; request a line range or truncate",
+			info.Size(), req.MaxBytes,
+		)}
+	}
 
-	lines := strings.Split(content, "\n")
-	totalLines := len(lines)
-
-	// If no range specified, return entire file
-	if req.StartLine == 0 && req.EndLine == 0 {
-		return ReadFileResponse{
-			Content:    content,
-			TotalLines: totalLines,
+	// the read runs aside so a stalled file system cannot outlive the timeout
+	done := make(chan ReadFileResponse, 1)
+	go func() {
+		done <- readLineRange(ctx, bufio.NewReaderSize(file, readFileBufferSize), req)
+	}()
+	select {
+	case resp := <-done:
+		_ = file.Close()
+		if wholeFile {
+			resp.Range = nil
 		}
+		return resp
+	case <-ctx.Done():
+		// closing the file unblocks the pending read
+		_ = file.Close()
+		return ReadFileResponse{Error: fmt.Sprintf("failed to read file: %v", ctx.Err())}
 	}
+}
 
-	// Handle range selection
-	startIdx := 0
-	endIdx := totalLines
+// resolveInWorkspace returns the absolute path of filePath, taken relative to
+// workspaceRoot unless absolute, and rejects paths that resolve outside the
+// workspace through ".." components or symlinks
+func resolveInWorkspace(workspaceRoot, filePath string) (string, error) {
+	absRoot, err := filepath.Abs(workspaceRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute workspace path: %v", err)
+	}
+	absPath := filePath
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(absRoot, filePath)
+	}
+	absPath = filepath.Clean(absPath)
+
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace path: %v", err)
+	}
+	realPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the workspace", filePath)
+	}
+	return realPath, nil
+}
+
+// readFileBufferSize is the most of a line ReadFile holds at once; longer lines
+// are read in fragments so lines outside the range or cap are never buffered whole
+const readFileBufferSize = 64 << 10
 
+// readLineRange streams reader line by line, keeping only the lines requested by req.
+// Lines are read in fragments of at most the reader's buffer size, and bytes
+// outside the range or past the byte cap are discarded as they are read.
+func readLineRange(ctx context.Context, reader *bufio.Reader, req ReadFileRequest) ReadFileResponse {
+	startIdx := 0
 	if req.StartLine > 0 {
 		startIdx = req.StartLine - 1 // Convert to 0-based
-		if startIdx >= totalLines {
-			return ReadFileResponse{Error: "start line exceeds file length"}
-		}
 	}
-
+	endIdx := -1 // exclusive, -1 means to end
 	if req.EndLine > 0 {
 		endIdx = req.EndLine // EndLine is inclusive, so we don't subtract 1
-		if endIdx > totalLines {
-			endIdx = totalLines
-		}
 	}
-
-	if startIdx >= endIdx {
+	if endIdx >= 0 && startIdx >= endIdx {
 		return ReadFileResponse{Error: "invalid range: start line must be less than end line"}
 	}
 
-	// Extract the requested range
-	selectedLines := lines[startIdx:endIdx]
-	selectedContent := strings.Join(selectedLines, "\n")
+	var content strings.Builder
+	totalLines := 0
+	lastIdx, lastLen := -1, 0
+	truncated := false
+	prevEOL := ""     // line ending of the previous line, kept so content round-trips
+	lineStart := true // the next fragment starts a line
+	carry := ""       // a trailing '\r' or partial rune held back for the next fragment
+	lf, crlf := 0, 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return ReadFileResponse{Error: fmt.Sprintf("failed to read file: %v", err)}
+		}
+		fragment, err := reader.ReadSlice('\n')
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return ReadFileResponse{Error: fmt.Sprintf("failed to read file: %v", err)}
+		}
+		lineEnd := err != bufio.ErrBufferFull
+		idx := totalLines
+		selected := idx >= startIdx && (endIdx < 0 || idx < endIdx) && !truncated
+
+		var text, eol string
+		if selected || carry != "" || lineEnd {
+			text = carry + string(fragment)
+			carry = ""
+			if lineEnd {
+				text, eol = splitLineEnding(text)
+			} else {
+				cut := incompleteSuffix(text)
+				text, carry = text[:cut], text[cut:]
+			}
+		} else if n := len(fragment); fragment[n-1] == '\r' {
+			// a skipped line may still end in a "\r\n" split across fragments
+			carry = "\r"
+		}
+		switch eol {
+		case "\n":
+			lf++
+		case "\r\n":
+			crlf++
+		}
 
-	// Create range info
-	rangeInfo := &Range{
-		Start: Position{Line: startIdx, Character: 0},
-		End:   Position{Line: endIdx - 1, Character: len(lines[endIdx-1])},
+		if selected {
+			piece := text
+			if lineStart && idx > startIdx {
+				piece = prevEOL + text
+			}
+			if req.MaxBytes > 0 && content.Len()+len(piece) > req.MaxBytes {
+				if !req.Truncate {
+					return ReadFileResponse{Error: fmt.Sprintf(
+						"requested range is over the %d byte read limit; request fewer lines or truncate",
+						req.MaxBytes,
+					)}
+				}
+				truncated = true
+				piece = truncateUTF8(piece, req.MaxBytes-content.Len())
+				if lineStart && idx > startIdx {
+					if len(piece) < len(prevEOL) {
+						// never end on half a line ending
+						piece = ""
+					}
+					text = strings.TrimPrefix(piece, prevEOL)
+				} else {
+					text = piece
+				}
+			}
+			if piece != "" || idx == startIdx {
+				content.WriteString(piece)
+				if lastIdx != idx {
+					lastIdx, lastLen = idx, 0
+				}
+				lastLen += UTF16Len(text)
+			}
+		}
+		lineStart = lineEnd
+		if lineEnd {
+			prevEOL = eol
+			totalLines++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if startIdx >= totalLines {
+		return ReadFileResponse{Error: "start line exceeds file length"}
 	}
 
 	return ReadFileResponse{
-		Content:    selectedContent,
-		Range:      rangeInfo,
+		Content: content.String(),
+		Range: &Range{
+			Start: Position{Line: startIdx, Character: 0},
+			End:   Position{Line: lastIdx, Character: lastLen},
+		},
 		TotalLines: totalLines,
+		LineEnding: lineEndingStyle(lf, crlf),
+		Truncated:  truncated,
+	}
+}
+
+// splitLineEnding splits a line read up to '\n' into its text and its "\n" or
+// "\r\n" ending, if any
+func splitLineEnding(line string) (string, string) {
+	if !strings.HasSuffix(line, "\n") {
+		return line, ""
+	}
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	return line[:len(line)-1], "\n"
+}
+
+// incompleteSuffix returns where a trailing '\r' or partial UTF-8 rune starts in
+// a fragment cut mid-line, so it can be completed by the next fragment
+func incompleteSuffix(s string) int {
+	if strings.HasSuffix(s, "\r") {
+		return len(s) - 1
+	}
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				return i
+			}
+			break
+		}
 	}
+	return len(s)
+}
+
+// lineEndingStyle names the newline convention from the counts of each ending
+func lineEndingStyle(lf, crlf int) string {
+	switch {
+	case lf > 0 && crlf > 0:
+		return "mixed"
+	case crlf > 0:
+		return "crlf"
+	case lf > 0:
+		return "lf"
+	default:
+		return ""
+	}
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
 
 // Helper functions
@@ -599,6 +1173,9 @@ func getLanguageFromPath(filePath string) string {
 		return "javascript"
 	case ".jsx":
 		return "javascriptreact"
+	case ".vue":
+		// served only when an adapter is registered for "vue"
+		return "vue"
 	default:
 		return ""
 	}
@@ -607,11 +1184,54 @@ func getLanguageFromPath(filePath string) string {
 func convertLocationsToResults(locations []Location) []LocationResult {
 	result := make([]LocationResult, len(locations))
 	for i, loc := range locations {
-		result[i] = LocationResult(loc)
+		result[i] = LocationResult{URI: loc.URI, Range: loc.Range}
 	}
 	return result
 }
 
+// relativizeLocations rewrites locations in place to workspace-relative paths
+func relativizeLocations(locations []LocationResult, workspaceRoot string) {
+	for i := range locations {
+		locations[i] = relativeLocation(locations[i], workspaceRoot)
+	}
+}
+
+// relativeLocation returns loc with its URI replaced by a slash-separated path
+// relative to workspaceRoot. Locations outside the workspace are left unchanged.
+func relativeLocation(loc LocationResult, workspaceRoot string) LocationResult {
+	absRoot, err := filepath.Abs(workspaceRoot)
+	if err != nil || !strings.HasPrefix(loc.URI, "file://") {
+		return loc
+	}
+	rel, err := filepath.Rel(absRoot, URIToPath(loc.URI))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return loc
+	}
+	loc.AbsoluteURI = loc.URI
+	loc.URI = filepath.ToSlash(rel)
+	return loc
+}
+
+// shiftLocationLines adds delta to the line numbers of locations in place
+func shiftLocationLines(locations []LocationResult, delta int) {
+	for i := range locations {
+		loc := &locations[i]
+		loc.Range = shiftRangeLines(loc.Range, delta)
+		if loc.Preview != nil {
+			loc.Preview.StartLine += delta
+		}
+		if loc.EnclosingSymbol != nil {
+			loc.EnclosingSymbol.Range = shiftRangeLines(loc.EnclosingSymbol.Range, delta)
+		}
+	}
+}
+
+func shiftRangeLines(r Range, delta int) Range {
+	r.Start.Line += delta
+	r.End.Line += delta
+	return r
+}
+
 func getStringValue(s *string) string {
 	if s == nil {
 		return ""
@@ -634,17 +1254,11 @@ func (d *SimpleDelegate) ReadTextFile(path string) (string, error) {
 }
 
 func (d *SimpleDelegate) Which(command string) (string, error) {
-	return exec.LookPath(command)
+	return findExecutable(command)
 }
 
 func (d *SimpleDelegate) ShellEnv() map[string]string {
-	env := make(map[string]string)
-	for _, e := range os.Environ() {
-		if i := strings.Index(e, "="); i >= 0 {
-			env[e[:i]] = e[i+1:]
-		}
-	}
-	return env
+	return ShellEnvironment()
 }
 
 func (d *SimpleDelegate) WorkspaceRoot() string {
@@ -677,3 +1291,20 @@ func extractHoverContents(contents json.RawMessage) string {
 	// Fallback: return raw JSON
 	return string(contents)
 }
+
+// HoverSignature extracts the code portion of hover contents, dropping markdown
+// fences and prose
+func HoverSignature(contents string) string {
+	contents = strings.TrimSpace(contents)
+	if start := strings.Index(contents, "```"); start >= 0 {
+		rest := contents[start+3:]
+		if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[nl+1:]
+		}
+		if end := strings.Index(rest, "```"); end >= 0 {
+			rest = rest[:end]
+		}
+		return strings.TrimSpace(rest)
+	}
+	return contents
+}
@@ -0,0 +1,652 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelativeLocation(t *testing.T) {
+	root := t.TempDir()
+	inside := PathToURI(filepath.Join(root, "src", "a.ts"))
+	outside := PathToURI(filepath.Join(filepath.Dir(root), "lib.d.ts"))
+
+	loc := relativeLocation(LocationResult{URI: inside}, root)
+	if loc.URI != "src/a.ts" || loc.AbsoluteURI != inside {
+		t.Fatalf("unexpected relative location: %+v", loc)
+	}
+
+	loc = relativeLocation(LocationResult{URI: outside}, root)
+	if loc.URI != outside || loc.AbsoluteURI != "" {
+		t.Fatalf("location outside workspace should be unchanged: %+v", loc)
+	}
+}
+
+func TestReadFileLimits(t *testing.T) {
+	root := t.TempDir()
+	content := "line1\nline2\nline3\n"
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := &ClientTools{}
+	ctx := context.Background()
+	read := func(req ReadFileRequest) ReadFileResponse {
+		req.FilePath, req.WorkspaceRoot = "a.ts", root
+		return ct.ReadFile(ctx, req)
+	}
+
+	resp := read(ReadFileRequest{})
+	if resp.Error != "" || resp.Content != content || resp.TotalLines != 4 || resp.Range != nil {
+		t.Fatalf("unexpected whole-file read: %+v", resp)
+	}
+
+	resp = read(ReadFileRequest{StartLine: 2, EndLine: 3})
+	if resp.Error != "" || resp.Content != "line2\nline3" || resp.TotalLines != 4 {
+		t.Fatalf("unexpected range read: %+v", resp)
+	}
+	if resp.Range.Start.Line != 1 || resp.Range.End.Line != 2 || resp.Range.End.Character != 5 {
+		t.Fatalf("unexpected range: %+v", resp.Range)
+	}
+
+	resp = read(ReadFileRequest{MaxBytes: 8})
+	if !strings.Contains(resp.Error, "read limit") {
+		t.Fatalf("expected read limit error, got %+v", resp)
+	}
+
+	// only the selected lines count against the cap
+	resp = read(ReadFileRequest{StartLine: 3, EndLine: 3, MaxBytes: 8})
+	if resp.Error != "" || resp.Content != "line3" {
+		t.Fatalf("unexpected capped range read: %+v", resp)
+	}
+
+	resp = read(ReadFileRequest{MaxBytes: 8, Truncate: true})
+	if resp.Error != "" || !resp.Truncated || resp.Content != "line1\nli" || resp.TotalLines != 4 {
+		t.Fatalf("unexpected truncated read: %+v", resp)
+	}
+
+	resp = read(ReadFileRequest{StartLine: 9})
+	if resp.Error != "start line exceeds file length" {
+		t.Fatalf("expected start line error, got %+v", resp)
+	}
+}
+
+func TestReadFileWorkspaceBoundary(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(root, "src dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src dir", "a.ts"), []byte("inside"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("outside"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	ct := &ClientTools{}
+	read := func(path string) ReadFileResponse {
+		return ct.ReadFile(context.Background(), ReadFileRequest{FilePath: path, WorkspaceRoot: root})
+	}
+
+	for _, path := range []string{
+		"src dir/a.ts",
+		"src dir/../src dir/a.ts",
+		filepath.Join(root, "src dir", "a.ts"),
+		PathToURI(filepath.Join(root, "src dir", "a.ts")),
+	} {
+		if resp := read(path); resp.Error != "" || resp.Content != "inside" {
+			t.Fatalf("%s: expected the workspace file, got %+v", path, resp)
+		}
+	}
+	for _, path := range []string{"../secret.txt", "src dir/../../secret.txt", secret, "link.txt"} {
+		if resp := read(path); !strings.Contains(resp.Error, "outside the workspace") {
+			t.Fatalf("%s: expected an outside-workspace error, got %+v", path, resp)
+		}
+	}
+}
+
+func TestReadLineRangeFragments(t *testing.T) {
+	// lines longer than the buffer are read in fragments, which may split a
+	// "\r\n" or a multi-byte rune; the result must not depend on where
+	contents := []string{
+		"0123456789abcde\r\nxyz\r\n",
+		"short\n" + strings.Repeat("é", 20) + "\n" + strings.Repeat("😀", 9) + "\r\nend",
+		strings.Repeat("a", 40) + "\r" + strings.Repeat("b", 40) + "\n\n",
+	}
+	requests := []ReadFileRequest{
+		{},
+		{StartLine: 2},
+		{StartLine: 2, EndLine: 2},
+		{StartLine: 3, EndLine: 3},
+		{MaxBytes: 20, Truncate: true},
+		{StartLine: 2, MaxBytes: 23, Truncate: true},
+		{MaxBytes: 20},
+	}
+	ctx := context.Background()
+	for _, content := range contents {
+		for _, req := range requests {
+			want := readLineRange(ctx, bufio.NewReaderSize(strings.NewReader(content), 4096), req)
+			for size := 16; size <= 24; size++ {
+				got := readLineRange(ctx, bufio.NewReaderSize(strings.NewReader(content), size), req)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%q %+v with a %d byte buffer: expected %+v, got %+v", content, req, size, want, got)
+				}
+			}
+		}
+	}
+}
+
+// longLineReader yields one line of n bytes followed by tail without holding it
+type longLineReader struct {
+	n    int
+	tail *strings.Reader
+}
+
+func (r *longLineReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return r.tail.Read(p)
+	}
+	n := min(len(p), r.n)
+	for i := range n {
+		p[i] = 'a'
+	}
+	r.n -= n
+	return n, nil
+}
+
+func TestReadLineRangeLongLine(t *testing.T) {
+	const lineLen = 64 << 20
+	read := func(req ReadFileRequest) (ReadFileResponse, uint64) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		r := &longLineReader{n: lineLen, tail: strings.NewReader("\nshort\n")}
+		resp := readLineRange(context.Background(), bufio.NewReaderSize(r, readFileBufferSize), req)
+		runtime.ReadMemStats(&after)
+		return resp, after.TotalAlloc - before.TotalAlloc
+	}
+	const limit = 4 << 20
+
+	resp, allocated := read(ReadFileRequest{StartLine: 2, EndLine: 2})
+	if resp.Error != "" || resp.Content != "short" || resp.TotalLines != 3 {
+		t.Fatalf("unexpected read past the long line: %+v", resp)
+	}
+	if allocated > limit {
+		t.Fatalf("expected the skipped line not to be buffered, allocated %d bytes", allocated)
+	}
+
+	resp, allocated = read(ReadFileRequest{MaxBytes: 8, Truncate: true})
+	if resp.Error != "" || resp.Content != "aaaaaaaa" || !resp.Truncated || resp.TotalLines != 3 {
+		t.Fatalf("unexpected truncated long line: %+v", resp)
+	}
+	if resp.Range.End.Character != 8 {
+		t.Fatalf("unexpected truncated range %+v", resp.Range)
+	}
+	if allocated > limit {
+		t.Fatalf("expected reading to stop buffering at the cap, allocated %d bytes", allocated)
+	}
+
+	if resp, _ := read(ReadFileRequest{MaxBytes: 8}); !strings.Contains(resp.Error, "read limit") {
+		t.Fatalf("expected read limit error, got %+v", resp)
+	}
+}
+
+func TestShiftLocationLines(t *testing.T) {
+	locations := []LocationResult{{Range: Range{
+		Start: Position{Line: 0, Character: 4},
+		End:   Position{Line: 2, Character: 1},
+	}}}
+	shiftLocationLines(locations, 1)
+	r := locations[0].Range
+	if r.Start.Line != 1 || r.End.Line != 3 || r.Start.Character != 4 || r.End.Character != 1 {
+		t.Fatalf("unexpected shifted range %+v", r)
+	}
+}
+
+func TestReadFileLineEndings(t *testing.T) {
+	root := t.TempDir()
+	ct := &ClientTools{}
+	ctx := context.Background()
+	read := func(content string, req ReadFileRequest) ReadFileResponse {
+		if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		req.FilePath, req.WorkspaceRoot = "a.ts", root
+		return ct.ReadFile(ctx, req)
+	}
+
+	crlf := "const a = 1\r\nconst bb = 2\r\n"
+	resp := read(crlf, ReadFileRequest{})
+	if resp.Content != crlf || resp.TotalLines != 3 || resp.LineEnding != "crlf" {
+		t.Fatalf("unexpected CRLF whole-file read: %+v", resp)
+	}
+
+	resp = read(crlf, ReadFileRequest{StartLine: 1, EndLine: 2})
+	if resp.Content != "const a = 1\r\nconst bb = 2" {
+		t.Fatalf("unexpected CRLF range content %q", resp.Content)
+	}
+	// carriage returns are not part of the line
+	if resp.Range.End.Line != 1 || resp.Range.End.Character != 12 {
+		t.Fatalf("unexpected CRLF range %+v", resp.Range)
+	}
+
+	resp = read(crlf, ReadFileRequest{MaxBytes: 12, Truncate: true})
+	if resp.Content != "const a = 1" || !resp.Truncated {
+		t.Fatalf("truncation split a line ending: %+v", resp)
+	}
+
+	resp = read("a\nb\r\nc", ReadFileRequest{StartLine: 2})
+	if resp.Content != "b\r\nc" || resp.LineEnding != "mixed" || resp.TotalLines != 3 {
+		t.Fatalf("unexpected mixed line endings read: %+v", resp)
+	}
+
+	resp = read("", ReadFileRequest{StartLine: 1, EndLine: 1})
+	if resp.Error != "" || resp.Content != "" || resp.Range.End.Line != 0 || resp.LineEnding != "" {
+		t.Fatalf("unexpected empty file read: %+v", resp)
+	}
+}
+
+func TestFileOutline(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	src := "export function add(a: number, b: number) { return a + b }\n" +
+		"export class Calc {\n" +
+		"  sub(a: number, b: number) { return a - b }\n" +
+		"}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res := ct.FileOutline(ctx, OutlineRequest{WorkspaceRoot: root, FilePath: "a.ts", LineBase: 1})
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if res.File != "a.ts" || len(res.Symbols) != 2 {
+		t.Fatalf("expected two top-level symbols in a.ts, got %+v", res)
+	}
+
+	add, calc := res.Symbols[0], res.Symbols[1]
+	if add.Name != "add" || add.Signature != "function add(a: number, b: number): number" {
+		t.Fatalf("expected add with its hover signature, got %+v", add)
+	}
+	if add.Range.Start.Line != 1 {
+		t.Fatalf("expected 1-based lines, got %+v", add.Range)
+	}
+	if calc.Name != "Calc" || calc.Signature != "" || len(calc.Children) != 1 {
+		t.Fatalf("expected class Calc with one child, got %+v", calc)
+	}
+	// the server fails hover on line 2; the method is listed without a signature
+	if sub := calc.Children[0]; sub.Name != "sub" || sub.Signature != "" {
+		t.Fatalf("expected method sub without signature, got %+v", sub)
+	}
+}
+
+func TestDiagnoseFix(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	src := "export function ad(a: number, b: number) { return a + b }\n"
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res := ct.DiagnoseFix(ctx, DiagnoseFixRequest{WorkspaceRoot: root, FilePath: "a.ts", LineBase: 1})
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	// the server first publishes no diagnostics; the later semantic pass wins
+	if res.File != "a.ts" || len(res.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic in a.ts, got %+v", res)
+	}
+	d := res.Diagnostics[0]
+	if d.Severity != int(DiagnosticSeverityError) || d.Range.Start.Line != 1 || string(d.Code) != "2552" {
+		t.Fatalf("unexpected diagnostic %+v", d)
+	}
+	if len(d.Fixes) != 2 {
+		t.Fatalf("expected a quick fix and a command, got %+v", d.Fixes)
+	}
+	fix := d.Fixes[0]
+	if !fix.IsPreferred || len(fix.Edits) != 1 || fix.Edits[0].File != "a.ts" ||
+		fix.Edits[0].Edits[0].NewText != "add" || fix.Edits[0].Edits[0].Range.Start.Line != 1 {
+		t.Fatalf("unexpected quick fix %+v", fix)
+	}
+	if command := d.Fixes[1]; command.Command != "_typescript.ignore" || len(command.Edits) != 0 {
+		t.Fatalf("expected a bare command, got %+v", command)
+	}
+	// nothing is applied
+	if got, _ := os.ReadFile(filepath.Join(root, "a.ts")); string(got) != src {
+		t.Fatalf("file was modified: %q", got)
+	}
+}
+
+func TestRenameDryRun(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	path := filepath.Join(root, "a.ts")
+	src := "export function add(a: number, b: number) { return a + b }\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req := RenameRequest{WorkspaceRoot: root, FilePath: "a.ts", Character: 16, NewName: "sum", DryRun: true}
+	res := ct.Rename(ctx, req)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	want := "--- a/a.ts\n+++ b/a.ts\n@@ -1 +1 @@\n" +
+		"-export function add(a: number, b: number) { return a + b }\n" +
+		"+export function sum(a: number, b: number) { return a + b }\n"
+	if len(res.Diffs) != 1 || res.Diffs[0].File != "a.ts" || res.Diffs[0].Diff != want {
+		t.Fatalf("unexpected diffs %+v", res.Diffs)
+	}
+	if got, _ := os.ReadFile(path); string(got) != src {
+		t.Fatalf("dry run modified the file: %q", got)
+	}
+
+	req.DryRun = false
+	if res := ct.Rename(ctx, req); res.Error != "" || len(res.Files) != 1 || res.Diffs != nil {
+		t.Fatalf("unexpected rename result %+v", res)
+	}
+	if got, _ := os.ReadFile(path); !strings.HasPrefix(string(got), "export function sum(") {
+		t.Fatalf("rename was not applied: %q", got)
+	}
+}
+
+func TestRenameOutsideWorkspace(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := "export function add(a: number, b: number) { return a + b }\n"
+	for _, path := range []string{filepath.Join(root, "a.ts"), filepath.Join(dir, "outside.ts")} {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// the server answers with an edit to ../outside.ts as well
+	for _, dryRun := range []bool{true, false} {
+		req := RenameRequest{WorkspaceRoot: root, FilePath: "a.ts", Character: 16, NewName: "escape", DryRun: dryRun}
+		if res := ct.Rename(ctx, req); !strings.Contains(res.Error, "outside the workspace") {
+			t.Fatalf("dry run %v: expected an outside-workspace error, got %+v", dryRun, res)
+		}
+	}
+	for _, path := range []string{filepath.Join(root, "a.ts"), filepath.Join(dir, "outside.ts")} {
+		if got, _ := os.ReadFile(path); string(got) != src {
+			t.Fatalf("%s was modified: %q", path, got)
+		}
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	path := filepath.Join(root, "a.ts")
+	src := "import { unused } from './b'\nexport const a = 1\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	want := "--- a/a.ts\n+++ b/a.ts\n@@ -1,2 +1 @@\n" +
+		"-import { unused } from './b'\n" +
+		" export const a = 1\n"
+	// the server's applyEdit request reuses the id of the pending executeCommand
+	req := OrganizeImportsRequest{WorkspaceRoot: root, FilePath: "a.ts", DryRun: true}
+	res := ct.OrganizeImports(ctx, req)
+	if res.Error != "" || !res.Changed || res.Diff != want {
+		t.Fatalf("unexpected dry run result %+v", res)
+	}
+	if got, _ := os.ReadFile(path); string(got) != src {
+		t.Fatalf("dry run modified the file: %q", got)
+	}
+
+	req.DryRun = false
+	res = ct.OrganizeImports(ctx, req)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if res.File != "a.ts" || !res.Changed || res.Diff != want {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "export const a = 1\n" {
+		t.Fatalf("imports were not organized: %q", got)
+	}
+}
+
+func TestGetCompletionSorted(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte("export const a = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// the server lists zeta first, but it sorts after the items cut to three
+	req := CompletionRequest{WorkspaceRoot: root, FilePath: "a.ts", Line: 3, MaxResults: 3}
+	res := ct.GetCompletion(ctx, req)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	var labels []string
+	for _, item := range res.Items {
+		labels = append(labels, item.Label)
+	}
+	if !slices.Equal(labels, []string{"alpha", "beta", "delta"}) {
+		t.Fatalf("expected the first three items by sortText then label, got %v", labels)
+	}
+
+	req.MaxResults = 5
+	req.GroupByKind = true
+	res = ct.GetCompletion(ctx, req)
+	if res.Error != "" || res.Items != nil || len(res.Groups) != 3 {
+		t.Fatalf("expected three groups, got %+v", res)
+	}
+	// gamma has no sortText and sorts by its label, after the numeric sortTexts
+	for i, want := range []struct {
+		kind   int
+		labels []string
+	}{{3, []string{"alpha", "delta"}}, {6, []string{"beta", "zeta"}}, {14, []string{"gamma"}}} {
+		group := res.Groups[i]
+		labels = nil
+		for _, item := range group.Items {
+			labels = append(labels, item.Label)
+		}
+		if group.Kind != want.kind || !slices.Equal(labels, want.labels) {
+			t.Fatalf("expected group %d to hold kind %d items %v, got %+v", i, want.kind, want.labels, group)
+		}
+	}
+}
+
+func TestGetCompletionRetryIncomplete(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte("export const a = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req := CompletionRequest{WorkspaceRoot: root, FilePath: "a.ts", Line: 0}
+	res := ct.GetCompletion(ctx, req)
+	if res.Error != "" || !res.IsIncomplete || len(res.Items) != 1 {
+		t.Fatalf("expected one item of an incomplete list, got %+v", res)
+	}
+
+	// the retry is sent as a completion of the incomplete list
+	req.RetryIncomplete = true
+	res = ct.GetCompletion(ctx, req)
+	var labels []string
+	for _, item := range res.Items {
+		labels = append(labels, item.Label)
+	}
+	if res.Error != "" || res.IsIncomplete || !slices.Equal(labels, []string{"add", "sub"}) {
+		t.Fatalf("expected the complete list from the retry, got %+v", res)
+	}
+}
+
+func TestRawRequest(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte("export const a = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// the file's textDocument is added to the params
+	raw, err := ct.RawRequest(ctx, root, "a.ts", "textDocument/definition",
+		json.RawMessage(`{"position":{"line":0,"character":0}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locations []Location
+	if err := json.Unmarshal(raw, &locations); err != nil {
+		t.Fatalf("expected a location array, got %s: %v", raw, err)
+	}
+	if len(locations) != 1 || locations[0].URI != PathToURI(filepath.Join(root, "a.ts")) {
+		t.Fatalf("expected the definition in a.ts, got %s", raw)
+	}
+
+	raw, err = ct.RawRequest(ctx, root, "a.ts", "textDocument/hover", json.RawMessage(`{"position":{"line":1}}`))
+	if err != nil || string(raw) != "null" {
+		t.Fatalf("expected a null result, got %s, %v", raw, err)
+	}
+	_, err = ct.RawRequest(ctx, root, "a.ts", "textDocument/hover", json.RawMessage(`{"position":{"line":2}}`))
+	if err == nil {
+		t.Fatal("expected the server's error")
+	}
+	if _, err := ct.RawRequest(ctx, root, "a.ts", "textDocument/hover", json.RawMessage(`[1]`)); err == nil {
+		t.Fatal("expected an error for params that are not an object")
+	}
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte("export class Calc {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ct := NewClientTools()
+	ct.manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = ct.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	names := func(nodes []TypeHierarchyNode) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	res := ct.TypeHierarchy(ctx, TypeHierarchyRequest{
+		WorkspaceRoot: root, FilePath: "a.ts", Line: 2, LineBase: 1, RelativePaths: true,
+	})
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if len(res.Types) != 1 || res.Types[0].Name != "Calc" {
+		t.Fatalf("expected Calc, got %+v", res.Types)
+	}
+	calc := res.Types[0]
+	if calc.Location.URI != "a.ts" || calc.Location.Range.Start.Line != 2 {
+		t.Fatalf("expected Calc at a.ts line 2, got %+v", calc.Location)
+	}
+	// Base extends Root, which the fake server says extends Calc again
+	base := calc.Supertypes
+	if !slices.Equal(names(base), []string{"Base"}) ||
+		!slices.Equal(names(base[0].Supertypes), []string{"Root"}) ||
+		!slices.Equal(names(base[0].Supertypes[0].Supertypes), []string{"Calc"}) ||
+		base[0].Supertypes[0].Supertypes[0].Supertypes != nil {
+		t.Fatalf("expected Calc > Base > Root > Calc without following the cycle, got %+v", base)
+	}
+	if !slices.Equal(names(calc.Subtypes), []string{"SciCalc", "MiniCalc"}) || calc.Subtypes[0].Truncated {
+		t.Fatalf("expected the subtypes SciCalc and MiniCalc, got %+v", calc.Subtypes)
+	}
+
+	res = ct.TypeHierarchy(ctx, TypeHierarchyRequest{
+		WorkspaceRoot: root, FilePath: "a.ts", Line: 1, Direction: TypeHierarchySupertypes, Depth: 1,
+	})
+	if res.Error != "" || len(res.Types) != 1 {
+		t.Fatalf("expected Calc, got %+v", res)
+	}
+	calc = res.Types[0]
+	if calc.Subtypes != nil || len(calc.Supertypes) != 1 || !calc.Supertypes[0].Truncated {
+		t.Fatalf("expected only Base, truncated, got %+v", calc)
+	}
+
+	res = ct.TypeHierarchy(ctx, TypeHierarchyRequest{WorkspaceRoot: root, FilePath: "a.ts", Line: 0})
+	if res.Error != "" || len(res.Types) != 0 {
+		t.Fatalf("expected no types off the class, got %+v", res)
+	}
+	if res = ct.TypeHierarchy(ctx, TypeHierarchyRequest{FilePath: "a.ts", Direction: "sideways"}); res.Error == "" {
+		t.Fatal("expected an error for an unknown direction")
+	}
+}
+
+func TestInfoProjectConfig(t *testing.T) {
+	ct := NewClientTools()
+	root := t.TempDir()
+	info := ct.Info(root)
+	if info.ProjectConfig != "" || len(info.Warnings) != 1 || info.Warnings[0] != NoProjectConfigWarning {
+		t.Fatalf("expected a missing project config warning, got %+v", info)
+	}
+	if len(info.Adapters) == 0 || info.Servers == nil {
+		t.Fatalf("expected adapters and an empty server list, got %+v", info)
+	}
+
+	jsconfig := filepath.Join(root, "jsconfig.json")
+	if err := os.WriteFile(jsconfig, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if info := ct.Info(root); info.ProjectConfig != jsconfig || len(info.Warnings) != 0 {
+		t.Fatalf("expected jsconfig.json without warnings, got %+v", info)
+	}
+}
@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DiagnoseFixRequest represents a request for the diagnostics of a file and
+// their quick fixes
+type DiagnoseFixRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	FilePath      string `json:"file_path"`
+	// LineBase numbers returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+}
+
+// DiagnoseFixResponse lists the diagnostics of a file with their quick fixes
+type DiagnoseFixResponse struct {
+	File        string          `json:"file"`
+	Diagnostics []DiagnosticFix `json:"diagnostics"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// DiagnosticFix is one diagnostic and the quick fixes the server offers for it
+type DiagnosticFix struct {
+	Range    Range           `json:"range"`
+	Severity int             `json:"severity,omitempty"`
+	Code     json.RawMessage `json:"code,omitempty"`
+	Source   string          `json:"source,omitempty"`
+	Message  string          `json:"message"`
+	Fixes    []QuickFix      `json:"fixes"`
+	// FixError is set when the fixes could not be requested
+	FixError string `json:"fix_error,omitempty"`
+}
+
+// QuickFix is a code action resolving a diagnostic. Edits are what applying it
+// would change; fixes that only run a server command have Command instead.
+type QuickFix struct {
+	Title       string      `json:"title"`
+	IsPreferred bool        `json:"is_preferred,omitempty"`
+	Edits       []FileEdits `json:"edits,omitempty"`
+	Command     string      `json:"command,omitempty"`
+}
+
+// FileEdits are the text edits of a quick fix in one file, relative to the
+// workspace root when inside it
+type FileEdits struct {
+	File  string     `json:"file"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// DiagnoseFix returns the diagnostics the language server reports for a file,
+// each with its quick fixes. Nothing is applied.
+func (ct *ClientTools) DiagnoseFix(ctx context.Context, req DiagnoseFixRequest) DiagnoseFixResponse {
+	language := getLanguageFromPath(req.FilePath)
+	if language == "" {
+		return DiagnoseFixResponse{Error: "unsupported file type"}
+	}
+
+	server, err := ct.manager.GetLanguageServer(ctx, req.WorkspaceRoot, language)
+	if err != nil {
+		return DiagnoseFixResponse{Error: fmt.Sprintf("failed to get language server: %v", err)}
+	}
+
+	absRoot, _ := filepath.Abs(req.WorkspaceRoot)
+	absFilePath := req.FilePath
+	if !filepath.IsAbs(absFilePath) {
+		absFilePath = filepath.Join(absRoot, req.FilePath)
+	}
+	file := workspaceRelative(absRoot, absFilePath)
+
+	content, err := readFileContent(absFilePath)
+	if err != nil {
+		return DiagnoseFixResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	uri := PathToURI(absFilePath)
+	if err := server.DidOpen(ctx, uri, content); err != nil {
+		return DiagnoseFixResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	diagnostics, err := server.GetDiagnostics(ctx, uri)
+	if err != nil {
+		return DiagnoseFixResponse{Error: fmt.Sprintf("failed to get diagnostics: %v", err)}
+	}
+
+	items := make([]DiagnosticFix, len(diagnostics))
+	for i, d := range diagnostics {
+		item := DiagnosticFix{
+			Range:   shiftRangeLines(d.Range, req.LineBase),
+			Code:    d.Code,
+			Source:  getStringValue(d.Source),
+			Message: d.Message,
+			Fixes:   []QuickFix{},
+		}
+		if d.Severity != nil {
+			item.Severity = int(*d.Severity)
+		}
+		actions, err := server.CodeActions(ctx, uri, d.Range, []Diagnostic{d}, CodeActionKindQuickFix)
+		if err != nil {
+			if ctx.Err() != nil {
+				return DiagnoseFixResponse{Error: fmt.Sprintf("failed to get code actions: %v", ctx.Err())}
+			}
+			item.FixError = err.Error()
+		}
+		for _, action := range actions {
+			item.Fixes = append(item.Fixes, quickFix(action, absRoot, req.LineBase))
+		}
+		items[i] = item
+	}
+	return DiagnoseFixResponse{File: file, Diagnostics: items}
+}
+
+// quickFix converts a code action, grouping its edits by file
+func quickFix(action CodeAction, absRoot string, lineBase int) QuickFix {
+	fix := QuickFix{Title: action.Title, IsPreferred: action.IsPreferred}
+	if action.Command != nil {
+		fix.Command = action.Command.Command
+	}
+	if action.Edit == nil {
+		return fix
+	}
+
+	byFile := make(map[string][]TextEdit)
+	for uri, edits := range action.Edit.Changes {
+		byFile[uri] = append(byFile[uri], edits...)
+	}
+	for _, docEdit := range action.Edit.DocumentChanges {
+		byFile[docEdit.TextDocument.URI] = append(byFile[docEdit.TextDocument.URI], docEdit.Edits...)
+	}
+	for uri, edits := range byFile {
+		shifted := make([]TextEdit, len(edits))
+		for i, edit := range edits {
+			shifted[i] = TextEdit{Range: shiftRangeLines(edit.Range, lineBase), NewText: edit.NewText}
+		}
+		fix.Edits = append(fix.Edits, FileEdits{File: workspaceRelative(absRoot, URIToPath(uri)), Edits: shifted})
+	}
+	sort.Slice(fix.Edits, func(i, j int) bool { return fix.Edits[i].File < fix.Edits[j].File })
+	return fix
+}
+
+// workspaceRelative returns path relative to absRoot with forward slashes, or
+// path itself when it lies outside
+func workspaceRelative(absRoot, path string) string {
+	rel, err := filepath.Rel(absRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,204 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// FileChange is the content of a file before and after a workspace edit
+type FileChange struct {
+	Path   string // absolute
+	Before string
+	After  string
+}
+
+// PreviewWorkspaceEdit applies the text edits of edit in memory and returns
+// the changed files sorted by path. Nothing is written; it fails without
+// partial results when any file cannot be read or its edits overlap.
+func PreviewWorkspaceEdit(edit *WorkspaceEdit) ([]FileChange, error) {
+	if edit == nil {
+		return nil, nil
+	}
+
+	byFile := make(map[string][]TextEdit)
+	for uri, edits := range edit.Changes {
+		path := URIToPath(uri)
+		byFile[path] = append(byFile[path], edits...)
+	}
+	for _, docEdit := range edit.DocumentChanges {
+		path := URIToPath(docEdit.TextDocument.URI)
+		byFile[path] = append(byFile[path], docEdit.Edits...)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for path, edits := range byFile {
+		if len(edits) > 0 {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+
+	changes := make([]FileChange, 0, len(files))
+	for _, path := range files {
+		content, err := readFileContent(path)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := ApplyTextEdits(content, byFile[path])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		changes = append(changes, FileChange{Path: path, Before: content, After: updated})
+	}
+	return changes, nil
+}
+
+// ApplyWorkspaceEdit writes the text edits of edit to disk and returns the
+// absolute paths of the modified files in sorted order.
+// Files are written only after the edits of every file have been applied in
+// memory, and only when every file is inside workspaceRoot.
+func ApplyWorkspaceEdit(workspaceRoot string, edit *WorkspaceEdit) ([]string, error) {
+	absRoot, realRoot, err := workspaceRoots(workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	confined, err := confineEdits(absRoot, edit, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := PreviewWorkspaceEdit(confined)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := WriteFileChanges(changes); err != nil {
+		return nil, err
+	}
+	return workspaceFiles(absRoot, realRoot, changes), nil
+}
+
+// WriteFileChanges writes the new content of each change, keeping file modes,
+// and returns the written paths. Each file is replaced atomically, so readers
+// never see it half written.
+func WriteFileChanges(changes []FileChange) ([]string, error) {
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if err := writeFileAtomic(change.Path, []byte(change.After)); err != nil {
+			return nil, err
+		}
+		files = append(files, change.Path)
+	}
+	return files, nil
+}
+
+// writeFileAtomic replaces the file at path, or the file a symlink at path
+// points to, with data through a temporary file renamed over it
+func writeFileAtomic(path string, data []byte) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// UnifiedDiff renders change as a unified diff with three lines of context,
+// labelling both sides with name. It is empty when the content is unchanged.
+func UnifiedDiff(change FileChange, name string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(change.Before),
+		B:        diffLines(change.After),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+}
+
+// diffLines splits content after each newline, ending a final unterminated
+// line with one so the diff stays line-oriented
+func diffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// ApplyTextEdits applies edits to content and returns the result. Positions
+// are LSP positions, counting UTF-16 code units. Edits are spliced last first so
+// earlier offsets stay valid; inserts at the same position keep their order,
+// and go before an edit replacing the text starting there whatever their order.
+// Overlapping edits are rejected, since applying them would corrupt the text.
+func ApplyTextEdits(content string, edits []TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		index      int
+		edit       TextEdit
+	}
+	lineStarts := lineOffsets(content)
+	spans := make([]span, len(edits))
+	for i, e := range edits {
+		start, err := positionOffset(content, lineStarts, e.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := positionOffset(content, lineStarts, e.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("invalid edit range %v", e.Range)
+		}
+		spans[i] = span{start: start, end: end, index: i, edit: e}
+	}
+	sort.Slice(spans, func(a, b int) bool {
+		if spans[a].start != spans[b].start {
+			return spans[a].start > spans[b].start
+		}
+		// a replacement is spliced before the inserts at its start, which
+		// then land in front of its new text
+		if emptyA, emptyB := spans[a].start == spans[a].end, spans[b].start == spans[b].end; emptyA != emptyB {
+			return emptyB
+		}
+		return spans[a].index > spans[b].index
+	})
+
+	out := content
+	for i, s := range spans {
+		if i > 0 && s.end > spans[i-1].start {
+			return "", fmt.Errorf("edits at %s and %s overlap",
+				formatRange(s.edit.Range), formatRange(spans[i-1].edit.Range))
+		}
+		out = out[:s.start] + s.edit.NewText + out[s.end:]
+	}
+	return out, nil
+}
+
+// formatRange renders r as 0-based line:character positions
+func formatRange(r Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
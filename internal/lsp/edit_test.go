@@ -0,0 +1,179 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func textEdit(startLine, startChar, endLine, endChar int, text string) TextEdit {
+	return TextEdit{
+		Range: Range{
+			Start: Position{Line: startLine, Character: startChar},
+			End:   Position{Line: endLine, Character: endChar},
+		},
+		NewText: text,
+	}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edits   []TextEdit
+		want    string
+	}{
+		{
+			name:    "out of order",
+			content: "let a = b + c\n",
+			edits:   []TextEdit{textEdit(0, 12, 0, 13, "z"), textEdit(0, 4, 0, 5, "x")},
+			want:    "let x = b + z\n",
+		},
+		{
+			name:    "adjacent",
+			content: "abcdef",
+			edits:   []TextEdit{textEdit(0, 2, 0, 4, "X"), textEdit(0, 0, 0, 2, "Y"), textEdit(0, 4, 0, 6, "Z")},
+			want:    "YXZ",
+		},
+		{
+			name:    "inserts at one position keep their order",
+			content: "f()",
+			edits:   []TextEdit{textEdit(0, 2, 0, 2, "a"), textEdit(0, 2, 0, 2, ", b")},
+			want:    "f(a, b)",
+		},
+		{
+			name:    "insert before a replacement at the same position",
+			content: "foo bar",
+			edits:   []TextEdit{textEdit(0, 0, 0, 0, "X"), textEdit(0, 0, 0, 3, "baz")},
+			want:    "Xbaz bar",
+		},
+		{
+			name:    "replacement before an insert at the same position",
+			content: "foo bar",
+			edits:   []TextEdit{textEdit(0, 0, 0, 3, "baz"), textEdit(0, 0, 0, 0, "X")},
+			want:    "Xbaz bar",
+		},
+		{
+			name:    "inserts around a replacement",
+			content: "foo bar",
+			edits: []TextEdit{
+				textEdit(0, 0, 0, 0, "<"), textEdit(0, 0, 0, 3, "baz"), textEdit(0, 3, 0, 3, ">"),
+				textEdit(0, 0, 0, 0, "!"),
+			},
+			want: "<!baz> bar",
+		},
+		{
+			name:    "multi-line",
+			content: "function f() {\n  return 1\n}\nf()\n",
+			edits:   []TextEdit{textEdit(0, 13, 2, 1, "{ return 2 }"), textEdit(3, 0, 3, 1, "g")},
+			want:    "function f() { return 2 }\ng()\n",
+		},
+		{
+			name: "utf-16 columns",
+			// 😀 is two UTF-16 code units, é one
+			content: "const s = '😀é' + x\n",
+			edits:   []TextEdit{textEdit(0, 18, 0, 19, "y")},
+			want:    "const s = '😀é' + y\n",
+		},
+		{
+			name:    "past the end of a crlf line",
+			content: "ab\r\ncd\r\n",
+			edits:   []TextEdit{textEdit(0, 99, 0, 99, ";")},
+			want:    "ab;\r\ncd\r\n",
+		},
+		{
+			name:    "append after the last line",
+			content: "a\n",
+			edits:   []TextEdit{textEdit(1, 0, 1, 0, "b\n")},
+			want:    "a\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyTextEdits(tt.content, tt.edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTextEditsRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		edits []TextEdit
+		err   string
+	}{
+		{"overlapping", []TextEdit{textEdit(0, 0, 0, 3, "x"), textEdit(0, 2, 0, 5, "y")}, "overlap"},
+		{"overlapping lines", []TextEdit{textEdit(0, 4, 1, 2, "x"), textEdit(1, 0, 1, 1, "y")}, "overlap"},
+		{"same range twice", []TextEdit{textEdit(0, 1, 0, 2, "x"), textEdit(0, 1, 0, 2, "y")}, "overlap"},
+		{"insert inside a replacement", []TextEdit{textEdit(0, 0, 0, 3, "x"), textEdit(0, 1, 0, 1, "y")}, "overlap"},
+		{"reversed range", []TextEdit{textEdit(0, 3, 0, 1, "x")}, "invalid edit range"},
+		{"line out of range", []TextEdit{textEdit(5, 0, 5, 1, "x")}, "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyTextEdits("hello\nworld\n", tt.edits)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestApplyWorkspaceEditOverlap(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a.ts"), filepath.Join(root, "b.ts")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("const value = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rename := TextEdit{Range: Range{Start: Position{Character: 6}, End: Position{Character: 11}}, NewText: "v"}
+	overlap := TextEdit{Range: Range{Start: Position{Character: 8}, End: Position{Character: 13}}, NewText: "x"}
+	edit := &WorkspaceEdit{Changes: map[string][]TextEdit{
+		PathToURI(a): {rename},
+		PathToURI(b): {rename, overlap},
+	}}
+	if _, err := ApplyWorkspaceEdit(root, edit); err == nil {
+		t.Fatal("expected overlapping edits to fail")
+	}
+	// a.ts sorts first but is left alone as well
+	if got, _ := os.ReadFile(a); string(got) != "const value = 1\n" {
+		t.Fatalf("a.ts was written: %q", got)
+	}
+}
+
+func TestApplyWorkspaceEditOutsideWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	inside, outside := filepath.Join(root, "a.ts"), filepath.Join(dir, "b.ts")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("const value = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rename := TextEdit{Range: Range{Start: Position{Character: 6}, End: Position{Character: 11}}, NewText: "v"}
+
+	edit := &WorkspaceEdit{Changes: map[string][]TextEdit{PathToURI(inside): {rename}, PathToURI(outside): {rename}}}
+	if _, err := ApplyWorkspaceEdit(root, edit); err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Fatalf("expected an outside-workspace error, got %v", err)
+	}
+	for _, path := range []string{inside, outside} {
+		if got, _ := os.ReadFile(path); string(got) != "const value = 1\n" {
+			t.Fatalf("%s was written: %q", path, got)
+		}
+	}
+
+	files, err := ApplyWorkspaceEdit(root, &WorkspaceEdit{Changes: map[string][]TextEdit{PathToURI(inside): {rename}}})
+	if err != nil || len(files) != 1 || files[0] != inside {
+		t.Fatalf("expected a.ts to be edited, got %v, %v", files, err)
+	}
+}
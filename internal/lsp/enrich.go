@@ -0,0 +1,88 @@
+package lsp
+
+import (
+	"os"
+	"strings"
+)
+
+// previewContextLines are the lines shown before and after a location in its
+// preview, and previewMaxLines caps the lines of the location itself
+const (
+	previewContextLines = 2
+	previewMaxLines     = 10
+)
+
+// EnclosingSymbolFinder looks up indexed declarations by position
+type EnclosingSymbolFinder interface {
+	// EnclosingSymbol returns the innermost indexed symbol of file whose
+	// lines contain line (0-based), or nil if there is none. Files outside
+	// root are not indexed.
+	EnclosingSymbol(root, file string, line int) (*EnclosingSymbol, error)
+}
+
+// EnclosingSymbol is the indexed declaration containing a location. Its range
+// spans whole lines.
+type EnclosingSymbol struct {
+	Name  string     `json:"name"`
+	Kind  SymbolKind `json:"kind"`
+	Range Range      `json:"range"`
+}
+
+// LocationPreview is the source around a location, starting at StartLine
+type LocationPreview struct {
+	StartLine int    `json:"start_line"`
+	Text      string `json:"text"`
+}
+
+// SetEnclosingSymbolFinder makes enriched locations report the indexed symbol
+// containing them
+func (ct *ClientTools) SetEnclosingSymbolFinder(finder EnclosingSymbolFinder) {
+	ct.symbolFinder = finder
+}
+
+// enrichLocations adds a preview and, with a symbol finder, the enclosing
+// symbol to locations in place. Locations must still carry file URIs and
+// 0-based lines. Enrichment is best effort: locations whose file cannot be
+// read, or that lie past its end, are left as they are.
+func (ct *ClientTools) enrichLocations(locations []LocationResult, workspaceRoot string) {
+	files := make(map[string][]string)
+	for i := range locations {
+		loc := &locations[i]
+		if !strings.HasPrefix(loc.URI, "file://") {
+			continue
+		}
+		path := URIToPath(loc.URI)
+		lines, ok := files[path]
+		if !ok {
+			if content, err := os.ReadFile(path); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[path] = lines
+		}
+		loc.Preview = previewLines(lines, loc.Range)
+		if loc.Preview == nil {
+			continue
+		}
+		if ct.symbolFinder != nil {
+			if sym, err := ct.symbolFinder.EnclosingSymbol(workspaceRoot, path, loc.Range.Start.Line); err == nil {
+				loc.EnclosingSymbol = sym
+			}
+		}
+	}
+}
+
+// previewLines returns the lines of r, at most previewMaxLines of them, with
+// previewContextLines around
+func previewLines(lines []string, r Range) *LocationPreview {
+	if r.Start.Line < 0 || r.Start.Line >= len(lines) {
+		return nil
+	}
+	start := max(r.Start.Line-previewContextLines, 0)
+	end := min(r.End.Line, r.Start.Line+previewMaxLines-1) + previewContextLines
+	end = min(max(end, r.Start.Line), len(lines)-1)
+	text := make([]string, 0, end-start+1)
+	for _, line := range lines[start : end+1] {
+		text = append(text, strings.TrimRight(line, "\r"))
+	}
+	return &LocationPreview{StartLine: start, Text: strings.Join(text, "\n")}
+}
@@ -0,0 +1,78 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type stubSymbolFinder struct {
+	root, file string
+	line       int
+}
+
+func (f *stubSymbolFinder) EnclosingSymbol(root, file string, line int) (*EnclosingSymbol, error) {
+	f.root, f.file, f.line = root, file, line
+	return &EnclosingSymbol{Name: "load", Kind: SymbolKindFunction, Range: Range{End: Position{Line: 4}}}, nil
+}
+
+func TestEnrichLocations(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.ts")
+	src := "// 0\n// 1\n// 2\nfunction load() {\n  return 1\n}\n// 6\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	finder := &stubSymbolFinder{}
+	ct := &ClientTools{}
+	ct.SetEnclosingSymbolFinder(finder)
+	locations := []LocationResult{
+		{URI: PathToURI(path), Range: Range{Start: Position{Line: 3, Character: 9}, End: Position{Line: 3, Character: 13}}},
+		{URI: PathToURI(filepath.Join(root, "missing.ts"))},
+	}
+	ct.enrichLocations(locations, root)
+
+	preview := locations[0].Preview
+	if preview == nil || preview.StartLine != 1 || preview.Text != "// 1\n// 2\nfunction load() {\n  return 1\n}" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if locations[0].EnclosingSymbol == nil || locations[0].EnclosingSymbol.Name != "load" {
+		t.Fatalf("expected enclosing symbol load, got %+v", locations[0].EnclosingSymbol)
+	}
+	if finder.root != root || finder.file != path || finder.line != 3 {
+		t.Fatalf("unexpected lookup %+v", finder)
+	}
+	if locations[1].Preview != nil {
+		t.Fatalf("expected no preview of a missing file, got %+v", locations[1].Preview)
+	}
+
+	// relative paths and line bases keep the enrichment, renumbered
+	relativizeLocations(locations, root)
+	shiftLocationLines(locations, 1)
+	if locations[0].URI != "a.ts" || locations[0].Preview.StartLine != 2 ||
+		locations[0].EnclosingSymbol.Range.End.Line != 5 {
+		t.Fatalf("unexpected relative 1-based location %+v", locations[0])
+	}
+}
+
+func TestPreviewLines(t *testing.T) {
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = string(rune('a' + i%26))
+	}
+	// long ranges are cut to previewMaxLines
+	preview := previewLines(lines, Range{Start: Position{Line: 5}, End: Position{Line: 25}})
+	if preview.StartLine != 3 || len(strings.Split(preview.Text, "\n")) != previewMaxLines+2*previewContextLines {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	// context stops at the end of the file
+	preview = previewLines(lines, Range{Start: Position{Line: 29}, End: Position{Line: 29}})
+	if preview.StartLine != 27 || preview.Text != "b\nc\nd" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if previewLines(lines, Range{Start: Position{Line: 30}}) != nil {
+		t.Fatal("expected no preview past the end of the file")
+	}
+}
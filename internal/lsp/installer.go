@@ -4,12 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+
+	"github.com/0x5457/ts-index/internal/logging"
 )
 
 const (
@@ -141,7 +142,7 @@ func (i *TypeScriptLspInstaller) FetchLatestServerVersion(
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			log.Printf("Failed to close response body: %v", err)
+			logging.L().Debug("failed to close response body", "error", err)
 		}
 	}()
 
@@ -344,8 +345,13 @@ func (i *TypeScriptLspInstaller) installToDirectory(
 	args := []string{"install"}
 	args = append(args, packages...)
 	args = append(args, "--prefix", ".")
-	cmd := exec.CommandContext(ctx, "npm", args...)
+	npm, err := findExecutable("npm")
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, npm, args...)
 	cmd.Dir = installDir
+	cmd.Env = environList(ShellEnvironment())
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -514,7 +520,7 @@ func (m *InstallationManager) CleanupServer(serverName string, keepVersions int)
 	for i := 0; i < len(versions)-keepVersions; i++ {
 		versionDir := filepath.Join(serverDir, versions[i])
 		if err := os.RemoveAll(versionDir); err != nil {
-			log.Printf("Failed to remove old version directory %s: %v", versionDir, err)
+			logging.L().Warn("failed to remove old language server version", "dir", versionDir, "error", err)
 		}
 	}
 
@@ -2,7 +2,9 @@ package lsp
 
 import (
 	"context"
+	"net/url"
 	"path/filepath"
+	"strings"
 )
 
 // LanguageServerInterface represents the interface for language server implementations
@@ -21,7 +23,7 @@ type LanguageServerInterface interface {
 	Hover(ctx context.Context, params TextDocumentPositionParams) (*Hover, error)
 
 	// Completion provides completion items for a position in a document
-	Completion(ctx context.Context, params TextDocumentPositionParams) (*CompletionList, error)
+	Completion(ctx context.Context, params CompletionParams) (*CompletionList, error)
 
 	// GotoDefinition provides goto definition information
 	GotoDefinition(ctx context.Context, params TextDocumentPositionParams) ([]Location, error)
@@ -38,6 +40,9 @@ type LanguageServerInterface interface {
 	// GotoDeclaration provides goto declaration information
 	GotoDeclaration(ctx context.Context, params TextDocumentPositionParams) ([]Location, error)
 
+	// Rename computes the workspace edit that renames the symbol at the given position
+	Rename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error)
+
 	// WorkspaceSymbols returns workspace symbols matching the query
 	WorkspaceSymbols(ctx context.Context, params WorkspaceSymbolParams) ([]SymbolInformation, error)
 
@@ -47,6 +52,18 @@ type LanguageServerInterface interface {
 	// GetDiagnostics returns diagnostics for the given document
 	GetDiagnostics(ctx context.Context, uri string) ([]Diagnostic, error)
 
+	// CodeActions returns the code actions, such as quick fixes, for a range
+	CodeActions(ctx context.Context, params CodeActionParams) ([]CodeAction, error)
+
+	// PrepareTypeHierarchy returns the type hierarchy items at a position
+	PrepareTypeHierarchy(ctx context.Context, params TextDocumentPositionParams) ([]TypeHierarchyItem, error)
+
+	// TypeHierarchySupertypes returns the types an item directly extends or implements
+	TypeHierarchySupertypes(ctx context.Context, item TypeHierarchyItem) ([]TypeHierarchyItem, error)
+
+	// TypeHierarchySubtypes returns the types directly extending or implementing an item
+	TypeHierarchySubtypes(ctx context.Context, item TypeHierarchyItem) ([]TypeHierarchyItem, error)
+
 	// DidOpen notifies the server that a document was opened
 	DidOpen(ctx context.Context, uri string, content string) error
 
@@ -71,6 +88,10 @@ type LanguageServerConfig struct {
 	// InitializationOptions are server-specific initialization options
 	InitializationOptions map[string]interface{}
 
+	// WorkspaceConfiguration answers the server's workspace/configuration
+	// requests
+	WorkspaceConfiguration map[string]interface{}
+
 	// Environment variables to set for the server process
 	Env map[string]string
 }
@@ -87,16 +108,61 @@ type LanguageServerFactory interface {
 	GetDefaultConfig(language string, workspaceRoot string) LanguageServerConfig
 }
 
-// Helper function to convert file paths to URIs
+// PathToURI converts a file path to a file URI, percent-encoding characters
+// such as spaces that are not allowed in URIs. Windows drive paths become
+// file:///C:/... and UNC paths file://server/share/....
 func PathToURI(path string) string {
 	abs, _ := filepath.Abs(path)
-	return "file://" + abs
+	return slashPathToURI(filepath.ToSlash(abs))
 }
 
-// Helper function to convert URIs to file paths
+// slashPathToURI converts an absolute, forward-slashed path to a file URI
+func slashPathToURI(path string) string {
+	u := &url.URL{Scheme: "file", Path: path}
+	switch {
+	case strings.HasPrefix(path, "//"):
+		// UNC path: the server is the URI authority
+		host, rest, _ := strings.Cut(path[2:], "/")
+		u.Host, u.Path = host, "/"+rest
+	case !strings.HasPrefix(path, "/"):
+		// drive letter path
+		u.Path = "/" + path
+	}
+	return u.String()
+}
+
+// URIToPath converts a file URI to a file path, decoding percent-encoded
+// characters and the Windows drive letter and UNC forms. Other strings are
+// returned unchanged.
 func URIToPath(uri string) string {
-	if len(uri) > 7 && uri[:7] == "file://" {
-		return uri[7:]
+	rest, ok := strings.CutPrefix(uri, "file://")
+	if !ok {
+		return uri
+	}
+	host, path := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	}
+	if decoded, err := url.PathUnescape(path); err == nil {
+		path = decoded
+	}
+	switch {
+	case isDriveLetter(host):
+		// file://C:/... written without the empty authority
+		path = host + path
+	case host != "" && host != "localhost":
+		path = "//" + host + path
+	case len(path) >= 3 && path[0] == '/' && isDriveLetter(path[1:3]):
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// isDriveLetter reports whether s is a Windows drive such as "C:"
+func isDriveLetter(s string) bool {
+	if len(s) != 2 || s[1] != ':' {
+		return false
 	}
-	return uri
+	c := s[0] | 0x20 // lower case
+	return c >= 'a' && c <= 'z'
 }
@@ -0,0 +1,59 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathURIRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.ts", "src dir/a b.ts", "ünïcode/日本.ts", "100%/#hash?.ts"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		uri := PathToURI(path)
+		for _, c := range []string{" ", "#", "?", "ü"} {
+			if strings.Contains(uri, c) {
+				t.Fatalf("%s: %q left unencoded in %s", name, c, uri)
+			}
+		}
+		if got := URIToPath(uri); got != path {
+			t.Fatalf("%s: round trip gave %q via %s", name, got, uri)
+		}
+	}
+}
+
+func TestSlashPathToURI(t *testing.T) {
+	cases := map[string]string{
+		"/home/me/a b.ts":       "file:///home/me/a%20b.ts",
+		"/tmp/caf\u00e9.ts":     "file:///tmp/caf%C3%A9.ts",
+		"C:/Users/me/a b.ts":    "file:///C:/Users/me/a%20b.ts",
+		"//server/share/src.ts": "file://server/share/src.ts",
+	}
+	for path, want := range cases {
+		if got := slashPathToURI(path); got != want {
+			t.Errorf("slashPathToURI(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	cases := map[string]string{
+		"file:///home/me/a%20b.ts":      "/home/me/a b.ts",
+		"file:///tmp/caf%C3%A9.ts":      "/tmp/café.ts",
+		"file:///tmp/unencoded path.ts": "/tmp/unencoded path.ts",
+		"file:///C:/Users/me/a%20b.ts":  "C:/Users/me/a b.ts",
+		"file:///c%3A/Users/me/a.ts":    "c:/Users/me/a.ts",
+		"file://C:/Users/me/a.ts":       "C:/Users/me/a.ts",
+		"file://localhost/home/me/a.ts": "/home/me/a.ts",
+		"file://server/share/src.ts":    "//server/share/src.ts",
+		"untitled:Untitled-1":           "untitled:Untitled-1",
+		"file:///tmp/bad%zzescape.ts":   "/tmp/bad%zzescape.ts",
+		"file:///tmp/with%23hash%3F.ts": "/tmp/with#hash?.ts",
+		"file:///home/me/%E6%97%A5.ts":  "/home/me/日.ts",
+	}
+	for uri, want := range cases {
+		if got := URIToPath(uri); got != filepath.FromSlash(want) {
+			t.Errorf("URIToPath(%q) = %q, want %q", uri, got, filepath.FromSlash(want))
+		}
+	}
+}
@@ -3,11 +3,10 @@ package lsp
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // LanguageServerManager manages multiple language servers across different workspaces
@@ -15,6 +14,7 @@ import (
 type LanguageServerManager struct {
 	adapters map[string]LspAdapter      // language name -> adapter
 	servers  map[string]*LanguageServer // workspace_root:language -> server
+	starting map[string]*serverStart    // servers being started, by the same key
 	delegate LanguageServerDelegate
 	mu       sync.RWMutex
 }
@@ -24,6 +24,7 @@ func NewLanguageServerManager(delegate LanguageServerDelegate) *LanguageServerMa
 	manager := &LanguageServerManager{
 		adapters: make(map[string]LspAdapter),
 		servers:  make(map[string]*LanguageServer),
+		starting: make(map[string]*serverStart),
 		delegate: delegate,
 	}
 
@@ -43,35 +44,90 @@ func (m *LanguageServerManager) RegisterAdapter(language string, adapter LspAdap
 	m.adapters[language] = adapter
 }
 
-// GetLanguageServer gets or creates a language server for the given workspace and language
+// serverStartTimeout bounds a language server start, which outlives the
+// callers waiting for it
+const serverStartTimeout = 2 * time.Minute
+
+// GetLanguageServer gets or creates a language server for the given workspace and language.
+// Concurrent callers for the same workspace and language share one start; the
+// manager lock is not held while a server starts, so other workspaces are not blocked.
+// The start is not tied to any caller's ctx: a caller that gives up stops waiting,
+// and the others still get the server.
 func (m *LanguageServerManager) GetLanguageServer(
 	ctx context.Context,
 	workspaceRoot, language string,
 ) (*LanguageServer, error) {
 	key := m.serverKey(workspaceRoot, language)
 
-	m.mu.RLock()
+	m.mu.Lock()
 	if server, exists := m.servers[key]; exists && server.IsRunning() {
-		m.mu.RUnlock()
+		m.mu.Unlock()
 		return server, nil
 	}
-	m.mu.RUnlock()
+	start, exists := m.starting[key]
+	if !exists {
+		adapter, ok := m.adapters[language]
+		if !ok {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("no adapter registered for language: %s", language)
+		}
+		start = &serverStart{done: make(chan struct{})}
+		m.starting[key] = start
+		go m.runStart(context.WithoutCancel(ctx), start, key, adapter, workspaceRoot, language)
+	}
+	m.mu.Unlock()
 
-	// Need to create a new server
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	select {
+	case <-start.done:
+		return start.server, start.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	// Double-check after acquiring write lock
-	if server, exists := m.servers[key]; exists && server.IsRunning() {
-		return server, nil
+// serverStart is a language server start in progress; done is closed once
+// server or err is set
+type serverStart struct {
+	done   chan struct{}
+	server *LanguageServer
+	err    error
+}
+
+// runStart starts the server for key and records the outcome in start. The
+// server process lives as long as ctx, so the timeout cancels ctx only when it
+// fires before the server is up.
+func (m *LanguageServerManager) runStart(
+	ctx context.Context,
+	start *serverStart,
+	key string,
+	adapter LspAdapter,
+	workspaceRoot, language string,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(serverStartTimeout, cancel)
+	start.server, start.err = m.startServer(ctx, adapter, workspaceRoot, language)
+	if !timer.Stop() {
+		if start.err == nil {
+			_ = start.server.Stop()
+		}
+		start.server, start.err = nil, fmt.Errorf("language server did not start within %s", serverStartTimeout)
 	}
 
-	// Get adapter for this language
-	adapter, exists := m.adapters[language]
-	if !exists {
-		return nil, fmt.Errorf("no adapter registered for language: %s", language)
+	m.mu.Lock()
+	delete(m.starting, key)
+	if start.err == nil {
+		m.servers[key] = start.server
 	}
+	m.mu.Unlock()
+	close(start.done)
+}
 
+// startServer creates and starts a language server for the workspace
+func (m *LanguageServerManager) startServer(
+	ctx context.Context,
+	adapter LspAdapter,
+	workspaceRoot, language string,
+) (*LanguageServer, error) {
 	// Check if the adapter's language server is installed
 	if !adapter.IsInstalled() {
 		return nil, fmt.Errorf(
@@ -94,10 +150,6 @@ func (m *LanguageServerManager) GetLanguageServer(
 	if err := server.Start(ctx); err != nil {
 		return nil, fmt.Errorf("failed to start language server: %w", err)
 	}
-
-	// Store the server
-	m.servers[key] = server
-
 	return server, nil
 }
 
@@ -218,13 +270,12 @@ func (m *LanguageServerManager) serverKey(workspaceRoot, language string) string
 	return fmt.Sprintf("%s:%s", absWorkspace, language)
 }
 
+// matchesWorkspace reports whether a server key belongs to workspaceRoot.
+// Keys are "workspace:language"; the workspace may itself contain colons,
+// but the language does not, so the key is split at its last colon.
 func (m *LanguageServerManager) matchesWorkspace(key, workspaceRoot string) bool {
-	// Key format is "workspace:language"
-	if len(key) > len(workspaceRoot) && key[:len(workspaceRoot)] == workspaceRoot {
-		// Check if it's followed by a colon (not just a prefix match)
-		return len(key) > len(workspaceRoot) && key[len(workspaceRoot)] == ':'
-	}
-	return false
+	i := strings.LastIndex(key, ":")
+	return i >= 0 && key[:i] == workspaceRoot
 }
 
 // Information types
@@ -265,19 +316,12 @@ func (d *DefaultDelegate) ReadTextFile(path string) (string, error) {
 
 // Which implements LanguageServerDelegate.Which
 func (d *DefaultDelegate) Which(command string) (string, error) {
-	path, err := exec.LookPath(command)
-	return path, err
+	return findExecutable(command)
 }
 
 // ShellEnv implements LanguageServerDelegate.ShellEnv
 func (d *DefaultDelegate) ShellEnv() map[string]string {
-	env := make(map[string]string)
-	for _, e := range os.Environ() {
-		if i := strings.Index(e, "="); i >= 0 {
-			env[e[:i]] = e[i+1:]
-		}
-	}
-	return env
+	return ShellEnvironment()
 }
 
 // WorkspaceRoot implements LanguageServerDelegate.WorkspaceRoot
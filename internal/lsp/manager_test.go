@@ -0,0 +1,165 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAdapter runs the fake language server and counts how often it is launched
+type fakeAdapter struct {
+	*TypeScriptLspAdapter
+	bin    string
+	starts atomic.Int32
+}
+
+func (a *fakeAdapter) ServerCommand(string) (string, []string, error) {
+	a.starts.Add(1)
+	return a.bin, nil, nil
+}
+
+func (a *fakeAdapter) IsInstalled() bool { return true }
+
+func TestGetLanguageServerConcurrent(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	manager := NewLanguageServerManager(NewDefaultDelegate(root))
+	manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = manager.StopAllServers() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const callers = 8
+	servers := make([]*LanguageServer, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			servers[i], errs[i] = manager.GetLanguageServer(ctx, root, "typescript")
+		}()
+	}
+	wg.Wait()
+
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: %v", i, errs[i])
+		}
+		if servers[i] != servers[0] {
+			t.Fatalf("caller %d got a different server instance", i)
+		}
+	}
+	if n := adapter.starts.Load(); n != 1 {
+		t.Fatalf("expected one server process, started %d", n)
+	}
+	if running := manager.GetRunningServers(); len(running) != 1 {
+		t.Fatalf("expected one running server, got %d", len(running))
+	}
+}
+
+// gatedAdapter holds every server start until release is closed
+type gatedAdapter struct {
+	*fakeAdapter
+	release chan struct{}
+}
+
+func (a *gatedAdapter) ServerCommand(root string) (string, []string, error) {
+	<-a.release
+	return a.fakeAdapter.ServerCommand(root)
+}
+
+func TestGetLanguageServerCallerCancel(t *testing.T) {
+	adapter := &gatedAdapter{
+		fakeAdapter: &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)},
+		release:     make(chan struct{}),
+	}
+	root := t.TempDir()
+	manager := NewLanguageServerManager(NewDefaultDelegate(root))
+	manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = manager.StopAllServers() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// the first caller gives up while the server is starting
+	first, cancelFirst := context.WithCancel(ctx)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := manager.GetLanguageServer(first, root, "typescript")
+		firstErr <- err
+	}()
+	waiting := make(chan error, 1)
+	go func() {
+		for {
+			manager.mu.RLock()
+			n := len(manager.starting)
+			manager.mu.RUnlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		_, err := manager.GetLanguageServer(ctx, root, "typescript")
+		waiting <- err
+	}()
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first caller to be canceled, got %v", err)
+	}
+
+	close(adapter.release)
+	if err := <-waiting; err != nil {
+		t.Fatalf("expected the other caller to get the server, got %v", err)
+	}
+	server, err := manager.GetLanguageServer(ctx, root, "typescript")
+	if err != nil || !server.IsRunning() {
+		t.Fatalf("expected the started server to be cached, got %v", err)
+	}
+	if n := adapter.starts.Load(); n != 1 {
+		t.Fatalf("expected one server process, started %d", n)
+	}
+}
+
+func TestStopWorkspaceServersSiblings(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	parent := t.TempDir()
+	manager := NewLanguageServerManager(NewDefaultDelegate(parent))
+	manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = manager.StopAllServers() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// siblings sharing the prefix, one continuing it with a colon like the key separator
+	roots := []string{"proj", "proj2", "proj:typescript"}
+	for i, name := range roots {
+		roots[i] = filepath.Join(parent, name)
+		if err := os.Mkdir(roots[i], 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := manager.GetLanguageServer(ctx, roots[i], "typescript"); err != nil {
+			t.Fatalf("start %s: %v", name, err)
+		}
+	}
+
+	if err := manager.StopWorkspaceServers(roots[0]); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	var remaining []string
+	for _, info := range manager.GetRunningServers() {
+		remaining = append(remaining, info.WorkspaceRoot)
+	}
+	sort.Strings(remaining)
+	if want := roots[1:]; !slices.Equal(remaining, want) {
+		t.Fatalf("expected %v to keep running, got %v", want, remaining)
+	}
+}
@@ -0,0 +1,111 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// organizeImportsCommands are the organize-imports commands of the supported
+// servers by name. Each takes the absolute path of the file to organize.
+var organizeImportsCommands = map[string]string{
+	"vtsls":                      "typescript.organizeImports",
+	"typescript-language-server": "_typescript.organizeImports",
+}
+
+// OrganizeImportsRequest represents a request to organize the imports of a file
+type OrganizeImportsRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	FilePath      string `json:"file_path"`
+	// DryRun returns the diff without writing the file
+	DryRun bool `json:"dry_run"`
+}
+
+// OrganizeImportsResponse represents the result of organizing imports
+type OrganizeImportsResponse struct {
+	// File is relative to the workspace root
+	File string `json:"file"`
+	// Changed is set when the file was rewritten, or would be on a dry run;
+	// Diff is then its unified diff
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// OrganizeImports runs the language server's organize-imports command on a
+// file inside the workspace. The server sends the edits back as
+// workspace/applyEdit requests, which are buffered and then written like
+// ApplyEdits, so a failing edit is reported instead of dropped.
+func (ct *ClientTools) OrganizeImports(ctx context.Context, req OrganizeImportsRequest) OrganizeImportsResponse {
+	language := getLanguageFromPath(req.FilePath)
+	if language == "" {
+		return OrganizeImportsResponse{Error: "unsupported file type"}
+	}
+	path, err := resolveInWorkspace(req.WorkspaceRoot, req.FilePath)
+	if err != nil {
+		return OrganizeImportsResponse{Error: err.Error()}
+	}
+	absRoot, _ := filepath.Abs(req.WorkspaceRoot)
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to resolve workspace path: %v", err)}
+	}
+	file := workspaceRelative(realRoot, path)
+
+	server, err := ct.manager.GetLanguageServer(ctx, req.WorkspaceRoot, language)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to get language server: %v", err)}
+	}
+	command, ok := organizeImportsCommands[server.Name()]
+	if !ok {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("%s has no organize imports command", server.Name())}
+	}
+
+	before, err := readFileContent(path)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	uri := PathToURI(path)
+	if err := server.DidOpen(ctx, uri, before); err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	// closed so the server re-reads the organized file on next use
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	_, edits, err := server.ExecuteCommandEdits(ctx, command, path)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to organize imports: %v", err)}
+	}
+	if req.DryRun {
+		var diff strings.Builder
+		for _, edit := range edits {
+			res := ApplyEdits(ApplyEditsRequest{WorkspaceRoot: req.WorkspaceRoot, Edit: &edit, DryRun: true})
+			if res.Error != "" {
+				return OrganizeImportsResponse{Error: res.Error}
+			}
+			for _, d := range res.Diffs {
+				diff.WriteString(d.Diff)
+			}
+		}
+		return OrganizeImportsResponse{File: file, Changed: diff.Len() > 0, Diff: diff.String()}
+	}
+	for _, edit := range edits {
+		if res := ApplyEdits(ApplyEditsRequest{WorkspaceRoot: req.WorkspaceRoot, Edit: &edit}); res.Error != "" {
+			return OrganizeImportsResponse{Error: res.Error}
+		}
+	}
+
+	after, err := readFileContent(path)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to read organized file: %v", err)}
+	}
+	if after == before {
+		return OrganizeImportsResponse{File: file}
+	}
+	diff, err := UnifiedDiff(FileChange{Path: path, Before: before, After: after}, file)
+	if err != nil {
+		return OrganizeImportsResponse{Error: fmt.Sprintf("failed to diff %s: %v", file, err)}
+	}
+	return OrganizeImportsResponse{File: file, Changed: true, Diff: diff}
+}
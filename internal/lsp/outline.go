@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OutlineRequest represents a request for the typed outline of a file
+type OutlineRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	FilePath      string `json:"file_path"`
+	// LineBase numbers returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+}
+
+// OutlineResponse is the symbol tree of a file
+type OutlineResponse struct {
+	File    string          `json:"file"`
+	Symbols []OutlineSymbol `json:"symbols"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// OutlineSymbol is one node of a file outline. Signature is the hover type of
+// functions and methods, omitted when the server has none.
+type OutlineSymbol struct {
+	Name      string          `json:"name"`
+	Kind      int             `json:"kind"`
+	Range     Range           `json:"range"`
+	Signature string          `json:"signature,omitempty"`
+	Children  []OutlineSymbol `json:"children,omitempty"`
+}
+
+// FileOutline returns the document symbols of a file nested by range, with the
+// resolved signature of each function and method taken from hover. Hover
+// failures only leave the affected signatures out.
+func (ct *ClientTools) FileOutline(ctx context.Context, req OutlineRequest) OutlineResponse {
+	language := getLanguageFromPath(req.FilePath)
+	if language == "" {
+		return OutlineResponse{Error: "unsupported file type"}
+	}
+
+	server, err := ct.manager.GetLanguageServer(ctx, req.WorkspaceRoot, language)
+	if err != nil {
+		return OutlineResponse{Error: fmt.Sprintf("failed to get language server: %v", err)}
+	}
+
+	absRoot, _ := filepath.Abs(req.WorkspaceRoot)
+	absFilePath := req.FilePath
+	if !filepath.IsAbs(absFilePath) {
+		absFilePath = filepath.Join(absRoot, req.FilePath)
+	}
+	file := absFilePath
+	if rel, err := filepath.Rel(absRoot, absFilePath); err == nil && !strings.HasPrefix(rel, "..") {
+		file = rel
+	}
+
+	content, err := readFileContent(absFilePath)
+	if err != nil {
+		return OutlineResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	uri := PathToURI(absFilePath)
+	if err := server.DidOpen(ctx, uri, content); err != nil {
+		return OutlineResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	symbols, err := server.DocumentSymbols(ctx, uri)
+	if err != nil {
+		return OutlineResponse{Error: fmt.Sprintf("failed to get document symbols: %v", err)}
+	}
+
+	lines := strings.Split(content, "\n")
+	nodes := make([]OutlineSymbol, len(symbols))
+	for i, symbol := range symbols {
+		nodes[i] = OutlineSymbol{
+			Name:  symbol.Name,
+			Kind:  int(symbol.Kind),
+			Range: symbol.Location.Range,
+		}
+		if !hasSignature(symbol.Kind) {
+			continue
+		}
+		position, ok := symbolNamePosition(lines, symbol)
+		if !ok {
+			continue
+		}
+		hover, err := server.Hover(ctx, uri, position)
+		if err != nil {
+			if ctx.Err() != nil {
+				return OutlineResponse{Error: fmt.Sprintf("failed to get hover info: %v", ctx.Err())}
+			}
+			continue
+		}
+		if hover != nil {
+			nodes[i].Signature = HoverSignature(extractHoverContents(hover.Contents))
+		}
+	}
+
+	outline := nestSymbols(nodes)
+	shiftOutlineLines(outline, req.LineBase)
+	return OutlineResponse{File: file, Symbols: outline}
+}
+
+// hasSignature reports whether hover on a symbol of kind yields a useful signature
+func hasSignature(kind SymbolKind) bool {
+	return kind == SymbolKindFunction || kind == SymbolKindMethod || kind == SymbolKindConstructor
+}
+
+// symbolNamePosition locates the symbol's name inside its range; servers
+// returning flat SymbolInformation give only the full declaration range
+func symbolNamePosition(lines []string, symbol SymbolInformation) (Position, bool) {
+	r := symbol.Location.Range
+	for line := r.Start.Line; line <= r.End.Line && line < len(lines); line++ {
+		text := lines[line]
+		from := 0
+		if line == r.Start.Line {
+			from = utf16ByteOffset(text, r.Start.Character)
+		}
+		if idx := strings.Index(text[from:], symbol.Name); idx >= 0 {
+			return Position{Line: line, Character: UTF16Len(text[:from+idx])}, true
+		}
+	}
+	return Position{}, false
+}
+
+// nestSymbols turns a flat symbol list into a tree, placing each symbol under
+// the smallest symbol whose range contains it
+func nestSymbols(symbols []OutlineSymbol) []OutlineSymbol {
+	order := make([]int, len(symbols))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := symbols[order[a]].Range, symbols[order[b]].Range
+		if c := comparePositions(ra.Start, rb.Start); c != 0 {
+			return c < 0
+		}
+		// enclosing symbols first
+		return comparePositions(ra.End, rb.End) > 0
+	})
+
+	children := make(map[int][]int)
+	var roots, stack []int
+	for _, idx := range order {
+		for len(stack) > 0 && !rangeContains(symbols[stack[len(stack)-1]].Range, symbols[idx].Range) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, idx)
+		} else {
+			parent := stack[len(stack)-1]
+			children[parent] = append(children[parent], idx)
+		}
+		stack = append(stack, idx)
+	}
+
+	var build func(indices []int) []OutlineSymbol
+	build = func(indices []int) []OutlineSymbol {
+		if len(indices) == 0 {
+			return nil
+		}
+		out := make([]OutlineSymbol, len(indices))
+		for i, idx := range indices {
+			out[i] = symbols[idx]
+			out[i].Children = build(children[idx])
+		}
+		return out
+	}
+	return build(roots)
+}
+
+func comparePositions(a, b Position) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+	return a.Character - b.Character
+}
+
+func rangeContains(outer, inner Range) bool {
+	return comparePositions(outer.Start, inner.Start) <= 0 && comparePositions(inner.End, outer.End) <= 0
+}
+
+func shiftOutlineLines(symbols []OutlineSymbol, delta int) {
+	for i := range symbols {
+		symbols[i].Range = shiftRangeLines(symbols[i].Range, delta)
+		shiftOutlineLines(symbols[i].Children, delta)
+	}
+}
@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// LSP positions count characters in UTF-16 code units: a character outside the
+// Basic Multilingual Plane, such as most emoji, is two units and several UTF-8
+// bytes. These helpers translate between positions and byte offsets of content.
+
+// PositionToByteOffset converts an LSP position to a byte offset into content.
+// A character past the end of its line stops before the line break; the line
+// after the last one is only valid at character 0, the end of content.
+func PositionToByteOffset(content string, pos Position) (int, error) {
+	return positionOffset(content, lineOffsets(content), pos)
+}
+
+// ByteOffsetToPosition converts a byte offset into content to an LSP position
+func ByteOffsetToPosition(content string, offset int) (Position, error) {
+	if offset < 0 || offset > len(content) {
+		return Position{}, fmt.Errorf("offset %d out of range", offset)
+	}
+	prefix := content[:offset]
+	lineStart := strings.LastIndexByte(prefix, '\n') + 1
+	return Position{
+		Line:      strings.Count(prefix, "\n"),
+		Character: UTF16Len(prefix[lineStart:]),
+	}, nil
+}
+
+// UTF16Len returns the length of s in UTF-16 code units
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// lineOffsets returns the byte offset at which each line of content starts
+func lineOffsets(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// positionOffset is PositionToByteOffset with the line starts of content
+// computed once for many positions
+func positionOffset(content string, lineStarts []int, pos Position) (int, error) {
+	if pos.Line < 0 || pos.Line >= len(lineStarts) {
+		if pos.Line == len(lineStarts) && pos.Character == 0 {
+			return len(content), nil
+		}
+		return 0, fmt.Errorf("line %d out of range", pos.Line)
+	}
+	offset := lineStarts[pos.Line]
+	line := content[offset:]
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = strings.TrimSuffix(line[:end], "\r")
+	}
+	return offset + utf16ByteOffset(line, pos.Character), nil
+}
+
+// utf16ByteOffset converts a UTF-16 column of line to a byte offset, clamped to
+// the end of line. A column inside a surrogate pair moves past the pair.
+func utf16ByteOffset(line string, character int) int {
+	units := 0
+	for offset, r := range line {
+		if units >= character {
+			return offset
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import "testing"
+
+func TestPositionByteOffset(t *testing.T) {
+	// 😀 is 4 bytes and a surrogate pair (2 units); é is 2 bytes and 1 unit
+	content := "a😀é = x\r\nnext\n"
+	tests := []struct {
+		pos    Position
+		offset int
+	}{
+		{Position{Line: 0, Character: 0}, 0},
+		{Position{Line: 0, Character: 1}, 1},
+		{Position{Line: 0, Character: 3}, 5},
+		{Position{Line: 0, Character: 4}, 7},
+		{Position{Line: 0, Character: 8}, 11},
+		{Position{Line: 1, Character: 2}, 15},
+		{Position{Line: 2, Character: 0}, 18},
+	}
+	for _, tt := range tests {
+		offset, err := PositionToByteOffset(content, tt.pos)
+		if err != nil || offset != tt.offset {
+			t.Fatalf("PositionToByteOffset(%+v) = %d, %v; want %d", tt.pos, offset, err, tt.offset)
+		}
+		pos, err := ByteOffsetToPosition(content, tt.offset)
+		if err != nil || pos != tt.pos {
+			t.Fatalf("ByteOffsetToPosition(%d) = %+v, %v; want %+v", tt.offset, pos, err, tt.pos)
+		}
+	}
+
+	// past the end of a line stops before its CRLF; inside a pair moves past it
+	if offset, _ := PositionToByteOffset(content, Position{Line: 0, Character: 50}); offset != 11 {
+		t.Fatalf("expected the end of line 0 before \\r\\n, got %d", offset)
+	}
+	if offset, _ := PositionToByteOffset(content, Position{Line: 0, Character: 2}); offset != 5 {
+		t.Fatalf("expected a column inside the surrogate pair to move past it, got %d", offset)
+	}
+	if _, err := PositionToByteOffset(content, Position{Line: 4}); err == nil {
+		t.Fatal("expected an error for a line past the end")
+	}
+	if _, err := ByteOffsetToPosition(content, len(content)+1); err == nil {
+		t.Fatal("expected an error for an offset past the end")
+	}
+}
+
+func TestUTF16Len(t *testing.T) {
+	if n := UTF16Len("a😀é"); n != 4 {
+		t.Fatalf("expected 4 UTF-16 units, got %d", n)
+	}
+}
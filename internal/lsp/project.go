@@ -0,0 +1,55 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProjectConfigFiles are the files that make a folder a TypeScript or
+// JavaScript project for the language server
+var ProjectConfigFiles = []string{"tsconfig.json", "jsconfig.json"}
+
+// NoProjectConfigWarning explains the limited results of a workspace without
+// a project config
+const NoProjectConfigWarning = "no tsconfig.json or jsconfig.json in the workspace root: the language server " +
+	"treats each file as a loose script, so cross-file results such as definitions and references may be empty"
+
+// FindProjectConfig returns the project config in workspaceRoot, or "" when
+// there is none
+func FindProjectConfig(workspaceRoot string) string {
+	for _, name := range ProjectConfigFiles {
+		path := filepath.Join(workspaceRoot, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// InfoResponse describes the language servers available to a workspace
+type InfoResponse struct {
+	Adapters []AdapterInfo `json:"adapters"`
+	Servers  []ServerInfo  `json:"servers"`
+	// ProjectConfig is the workspace's tsconfig.json or jsconfig.json
+	ProjectConfig string   `json:"project_config,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// Info reports the registered adapters, the running servers and, for a
+// workspace, its project config, with a warning when there is none
+func (ct *ClientTools) Info(workspaceRoot string) InfoResponse {
+	info := InfoResponse{
+		Adapters: ct.GetAdapterInfo(),
+		Servers:  ct.GetServerInfo(),
+	}
+	if info.Servers == nil {
+		info.Servers = []ServerInfo{}
+	}
+	if workspaceRoot != "" {
+		info.ProjectConfig = FindProjectConfig(workspaceRoot)
+		if info.ProjectConfig == "" {
+			info.Warnings = append(info.Warnings, NoProjectConfigWarning)
+		}
+	}
+	return info
+}
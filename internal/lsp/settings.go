@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SettingsFile in a workspace root overrides the language server settings
+// the adapter sends, e.g.
+//
+//	{"lsp": {"initializationOptions": {...}, "settings": {"typescript": {...}}}}
+const SettingsFile = ".ts-index.json"
+
+// ServerSettings are a workspace's overrides of adapter defaults. Objects are
+// merged key by key into the defaults; other values replace them, and null
+// removes a default.
+type ServerSettings struct {
+	// InitializationOptions are sent with the initialize request
+	InitializationOptions map[string]interface{} `json:"initializationOptions"`
+	// Settings answer the server's workspace/configuration requests
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// LoadServerSettings reads the lsp section of workspaceRoot's SettingsFile. A
+// missing file has no overrides.
+func LoadServerSettings(workspaceRoot string) (ServerSettings, error) {
+	path := filepath.Join(workspaceRoot, SettingsFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ServerSettings{}, nil
+	}
+	if err != nil {
+		return ServerSettings{}, err
+	}
+	var file struct {
+		LSP ServerSettings `json:"lsp"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return ServerSettings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return file.LSP, nil
+}
+
+// mergeSettings returns defaults with overrides merged over them, leaving
+// both unchanged
+func mergeSettings(defaults, overrides map[string]interface{}) map[string]interface{} {
+	if overrides == nil {
+		return defaults
+	}
+	merged := make(map[string]interface{}, len(defaults)+len(overrides))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		base, baseIsMap := merged[key].(map[string]interface{})
+		override, isMap := value.(map[string]interface{})
+		if baseIsMap && isMap {
+			merged[key] = mergeSettings(base, override)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeSettings(t *testing.T) {
+	defaults := map[string]interface{}{
+		"typescript": map[string]interface{}{
+			"suggest":    map[string]interface{}{"autoImports": true},
+			"inlayHints": map[string]interface{}{"includeInlayVariableTypeHints": false},
+		},
+		"vtsls": map[string]interface{}{"experimental": true},
+	}
+	overrides := map[string]interface{}{
+		"typescript": map[string]interface{}{
+			"suggest":    map[string]interface{}{"autoImports": false, "paths": true},
+			"tsserver":   map[string]interface{}{"maxTsServerMemory": 8192},
+			"inlayHints": "off",
+		},
+		"vtsls": nil,
+	}
+	want := map[string]interface{}{
+		"typescript": map[string]interface{}{
+			"suggest":    map[string]interface{}{"autoImports": false, "paths": true},
+			"tsserver":   map[string]interface{}{"maxTsServerMemory": 8192},
+			"inlayHints": "off",
+		},
+	}
+	if got := mergeSettings(defaults, overrides); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if _, ok := defaults["vtsls"]; !ok {
+		t.Fatal("merging changed the defaults")
+	}
+	if got := mergeSettings(defaults, nil); !reflect.DeepEqual(got, defaults) {
+		t.Fatalf("expected the defaults without overrides, got %v", got)
+	}
+}
+
+func TestServerSettingsFile(t *testing.T) {
+	adapter := &fakeAdapter{TypeScriptLspAdapter: NewTypeScriptLspAdapter(), bin: buildFakeServer(t)}
+	root := t.TempDir()
+	if settings, err := LoadServerSettings(root); err != nil || settings.Settings != nil {
+		t.Fatalf("expected no overrides without %s, got %+v, %v", SettingsFile, settings, err)
+	}
+	settings := `{"lsp": {"settings": {"typescript": {"preferences": {"quoteStyle": "single"}}}}}`
+	if err := os.WriteFile(filepath.Join(root, SettingsFile), []byte(settings), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manager := NewLanguageServerManager(NewDefaultDelegate(root))
+	manager.RegisterAdapter("typescript", adapter)
+	defer func() { _ = manager.StopAllServers() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	server, err := manager.GetLanguageServer(ctx, root, "typescript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// merged over the adapter's javascript defaults, as the root has no tsconfig.json
+	for section, want := range map[string]string{
+		"typescript.preferences": `[{"quoteStyle":"single"}]`,
+		"javascript.preferences": `[{"includePackageJsonAutoImports":"auto"}]`,
+	} {
+		got, err := server.client.ExecuteCommand(ctx, "fake.configuration", []interface{}{section})
+		if err != nil {
+			t.Fatalf("configuration %s: %v", section, err)
+		}
+		if string(got) != want {
+			t.Fatalf("expected %s for %s, got %s", want, section, got)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, SettingsFile), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadServerSettings(root); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
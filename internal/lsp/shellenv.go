@@ -0,0 +1,158 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/logging"
+)
+
+// ShellEnvVar enables sourcing the user's login shell environment when set to a true
+// value. Apps launched from a GUI do not inherit the PATH set up in shell profiles, so
+// node-based servers such as vtsls are otherwise not found.
+const ShellEnvVar = "TS_INDEX_SHELL_ENV"
+
+const shellEnvTimeout = 5 * time.Second
+
+var (
+	shellEnvOnce sync.Once
+	shellEnv     map[string]string
+)
+
+// ShellEnvironment returns the process environment, with the variables of the user's
+// login shell ($SHELL -lc env) merged over it when ShellEnvVar is enabled.
+// The login shell is run at most once per process.
+func ShellEnvironment() map[string]string {
+	env := environMap(os.Environ())
+	if !shellEnvEnabled() {
+		return env
+	}
+	shellEnvOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shellEnvTimeout)
+		defer cancel()
+		loaded, err := loadLoginShellEnv(ctx, os.Getenv("SHELL"))
+		if err != nil {
+			logging.L().Warn("failed to load login shell environment", "error", err)
+			return
+		}
+		shellEnv = loaded
+	})
+	for key, value := range shellEnv {
+		env[key] = value
+	}
+	return env
+}
+
+// findExecutable resolves command against the process PATH and then against the
+// PATH of ShellEnvironment
+func findExecutable(command string) (string, error) {
+	path, err := exec.LookPath(command)
+	if err == nil || !shellEnvEnabled() {
+		return path, err
+	}
+	if shellPath, lookErr := lookPathIn(command, ShellEnvironment()["PATH"]); lookErr == nil {
+		return shellPath, nil
+	}
+	return "", err
+}
+
+func shellEnvEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(ShellEnvVar))
+	return enabled
+}
+
+// loadLoginShellEnv runs shell as a login shell and returns the environment it prints
+func loadLoginShellEnv(ctx context.Context, shell string) (map[string]string, error) {
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.CommandContext(ctx, shell, "-lc", "env")
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	env := parseEnvOutput(string(out))
+	if len(env) == 0 {
+		return nil, errors.New("login shell printed no environment")
+	}
+	return env, nil
+}
+
+// parseEnvOutput parses the output of env. Lines that do not start with a valid
+// variable name continue the value of the previous variable.
+func parseEnvOutput(out string) map[string]string {
+	env := make(map[string]string)
+	last := ""
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok && isEnvName(key) {
+			env[key] = value
+			last = key
+			continue
+		}
+		if last != "" {
+			env[last] += "\n" + line
+		}
+	}
+	return env
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// lookPathIn searches the directories of pathList for an executable named command
+func lookPathIn(command, pathList string) (string, error) {
+	if strings.Contains(command, string(filepath.Separator)) {
+		return exec.LookPath(command)
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, command)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: command, Err: exec.ErrNotFound}
+}
+
+func environMap(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, e := range environ {
+		if i := strings.Index(e, "="); i >= 0 {
+			env[e[:i]] = e[i+1:]
+		}
+	}
+	return env
+}
+
+func environList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for key, value := range env {
+		list = append(list, key+"="+value)
+	}
+	return list
+}
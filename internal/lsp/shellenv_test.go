@@ -0,0 +1,60 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestParseEnvOutput(t *testing.T) {
+	env := parseEnvOutput("HOME=/home/me\nMULTI=first\nsecond\nPATH=/usr/bin:/opt/node/bin\n")
+	if env["HOME"] != "/home/me" || env["PATH"] != "/usr/bin:/opt/node/bin" {
+		t.Fatalf("unexpected env %v", env)
+	}
+	if env["MULTI"] != "first\nsecond" {
+		t.Fatalf("expected multi-line value to be joined, got %q", env["MULTI"])
+	}
+}
+
+func TestShellEnvironmentFromLoginShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("login shells are not used on windows")
+	}
+	dir := t.TempDir()
+	nodeDir := filepath.Join(dir, "node", "bin")
+	if err := os.MkdirAll(nodeDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	server := filepath.Join(nodeDir, "fake-language-server")
+	if err := os.WriteFile(server, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// a "shell" whose profile puts the node bin directory on PATH
+	shell := filepath.Join(dir, "shell")
+	script := "#!/bin/sh\necho \"PATH=" + nodeDir + "\"\necho \"FROM_PROFILE=1\"\n"
+	if err := os.WriteFile(shell, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	shellEnvOnce, shellEnv = sync.Once{}, nil
+	t.Cleanup(func() { shellEnvOnce, shellEnv = sync.Once{}, nil })
+	t.Setenv("SHELL", shell)
+	t.Setenv("PATH", filepath.Join(dir, "empty"))
+
+	t.Setenv(ShellEnvVar, "")
+	if _, err := findExecutable("fake-language-server"); err == nil {
+		t.Fatalf("expected lookup to fail without the login shell environment")
+	}
+
+	t.Setenv(ShellEnvVar, "1")
+	env := ShellEnvironment()
+	if env["FROM_PROFILE"] != "1" || env["PATH"] != nodeDir {
+		t.Fatalf("login shell variables not merged: %v", env)
+	}
+	path, err := findExecutable("fake-language-server")
+	if err != nil || path != server {
+		t.Fatalf("expected %s from login shell PATH, got %q, %v", server, path, err)
+	}
+}
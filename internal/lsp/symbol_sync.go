@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/ignore"
+)
+
+// WorkspaceSymbolStore persists symbols crawled from a language server
+type WorkspaceSymbolStore interface {
+	// StoreWorkspaceSymbols merges the language server's symbols for file, relative
+	// to root, into the store and returns how many were not already indexed
+	StoreWorkspaceSymbols(root, file string, symbols []SymbolInformation) (int, error)
+}
+
+// SymbolSyncResult summarizes a SyncWorkspaceSymbols crawl
+type SymbolSyncResult struct {
+	Files   int `json:"files"`
+	Symbols int `json:"symbols"`
+	Added   int `json:"added"`
+	Failed  int `json:"failed"`
+}
+
+// NewClientToolsWithSymbolStore creates client tools that can persist workspace
+// symbols into store with SyncWorkspaceSymbols
+func NewClientToolsWithSymbolStore(store WorkspaceSymbolStore) *ClientTools {
+	ct := NewClientTools()
+	ct.symbolStore = store
+	return ct
+}
+
+// HasSymbolStore reports whether the tools were created with a symbol store
+func (ct *ClientTools) HasSymbolStore() bool {
+	return ct.symbolStore != nil
+}
+
+// SyncWorkspaceSymbols crawls the TypeScript files under root once and stores
+// each file's language server symbols, so later symbol lookups can be answered
+// from the store without a warm server. workspace/symbol cannot enumerate a
+// project (servers require a query), so documents are walked one by one.
+// Files the server fails on are counted and skipped.
+func (ct *ClientTools) SyncWorkspaceSymbols(ctx context.Context, root string) (SymbolSyncResult, error) {
+	var res SymbolSyncResult
+	if ct.symbolStore == nil {
+		return res, errors.New("no symbol store configured")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return res, err
+	}
+	server, err := ct.manager.GetLanguageServer(ctx, absRoot, "typescript")
+	if err != nil {
+		return res, fmt.Errorf("failed to get language server: %v", err)
+	}
+	matcher, err := ignore.Load(absRoot)
+	if err != nil {
+		return res, err
+	}
+
+	err = filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(absRoot, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && matcher.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".tsx")) {
+			return nil
+		}
+
+		symbols, err := ct.documentSymbols(ctx, server, path)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			res.Failed++
+			return nil
+		}
+		added, err := ct.symbolStore.StoreWorkspaceSymbols(absRoot, rel, symbols)
+		if err != nil {
+			return fmt.Errorf("store symbols of %s: %w", rel, err)
+		}
+		res.Files++
+		res.Symbols += len(symbols)
+		res.Added += added
+		return nil
+	})
+	return res, err
+}
+
+// documentSymbols requests the symbols of one file, closing it afterwards so a
+// crawl does not keep every project file open in the server
+func (ct *ClientTools) documentSymbols(
+	ctx context.Context,
+	server *LanguageServer,
+	path string,
+) ([]SymbolInformation, error) {
+	uri := PathToURI(path)
+	if err := ct.ensureDocumentOpen(ctx, server, uri, path); err != nil {
+		return nil, err
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+	return server.DocumentSymbols(ctx, uri)
+}
@@ -0,0 +1,403 @@
+// Command fakelsp is a minimal stdio language server used by the lsp package tests.
+// It speaks the LSP base protocol (Content-Length framing + JSON-RPC 2.0) and answers
+// a few requests with canned data selected by the requested line:
+//
+//	line 0: a regular result
+//	line 1: a null result (definition: a single Location instead of an array;
+//	        completion: a bare item array instead of a CompletionList)
+//	line 2: a JSON-RPC error response
+//
+// completion on line 3 returns items out of sortText order, one without a sortText.
+// Completion on line 0 is incomplete unless it is triggered for incomplete
+// completions; then the list is complete and also holds sub.
+//
+// documentSymbol returns a flat list for a fixed document: function add on line 0
+// and class Calc on lines 1-3 with method sub on line 2.
+//
+// prepareTypeHierarchy on line 1 returns class Calc. Its supertypes are Base,
+// whose supertype is Root, whose supertype is Calc again; its subtypes are
+// SciCalc and MiniCalc. Every type is declared in the requested document, on
+// a line of its own.
+//
+// Opening a document publishes no diagnostics and then, as tsserver does for
+// its semantic pass, an error on line 0 characters 16-19. codeAction answers
+// with a quick fix replacing that range by "add" and a bare command. rename
+// replaces the same range by the new name; renaming to "escape" also edits
+// outside.ts in the parent of the document's directory.
+//
+// executeCommand of typescript.organizeImports asks the client to delete line 0
+// of the file given as argument with a workspace/applyEdit request, reusing the
+// command's request id, and answers the command once the edit is applied.
+// fake.configuration requests the workspace configuration section given as
+// argument and answers with the client's reply.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+type positionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	} `json:"position"`
+	Context struct {
+		TriggerKind int `json:"triggerKind"`
+	} `json:"context"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func main() {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			// stdin closed by the client
+			os.Exit(0)
+		}
+		if msg.Method == "exit" {
+			os.Exit(0)
+		}
+		if msg.ID == nil {
+			// notifications (initialized, didOpen, ...) need no answer
+			if msg.Method == "textDocument/didOpen" {
+				publishDiagnostics(msg.Params)
+			}
+			continue
+		}
+		if msg.Method == "workspace/executeCommand" {
+			result, rpcErr := executeCommand(reader, msg)
+			reply(*msg.ID, result, rpcErr)
+			continue
+		}
+		result, rpcErr := handle(msg)
+		reply(*msg.ID, result, rpcErr)
+	}
+}
+
+func handle(msg message) (any, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"hoverProvider":      true,
+				"definitionProvider": true,
+				"completionProvider": map[string]any{},
+			},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/hover":
+		params := decodePosition(msg.Params)
+		switch params.Position.Line {
+		case 0:
+			return map[string]any{
+				"contents": map[string]any{
+					"kind":  "markdown",
+					"value": "```typescript\nfunction add(a: number, b: number): number\n```",
+				},
+			}, nil
+		case 1:
+			return nil, nil
+		default:
+			return nil, &rpcError{Code: -32603, Message: "hover failed"}
+		}
+	case "textDocument/definition", "textDocument/implementation",
+		"textDocument/typeDefinition", "textDocument/declaration":
+		params := decodePosition(msg.Params)
+		nameRange := map[string]any{
+			"start": map[string]int{"line": 0, "character": 16},
+			"end":   map[string]int{"line": 0, "character": 19},
+		}
+		location := map[string]any{"uri": params.TextDocument.URI, "range": nameRange}
+		switch params.Position.Line {
+		case 0:
+			return []any{location}, nil
+		case 1:
+			return location, nil
+		case 3:
+			return []any{map[string]any{
+				"targetUri": params.TextDocument.URI,
+				"targetRange": map[string]any{
+					"start": map[string]int{"line": 0, "character": 0},
+					"end":   map[string]int{"line": 0, "character": 58},
+				},
+				"targetSelectionRange": nameRange,
+			}}, nil
+		default:
+			return nil, nil
+		}
+	case "textDocument/documentSymbol":
+		params := decodePosition(msg.Params)
+		symbol := func(name string, kind, startLine, startChar, endLine, endChar int) map[string]any {
+			return map[string]any{
+				"name": name,
+				"kind": kind,
+				"location": map[string]any{
+					"uri": params.TextDocument.URI,
+					"range": map[string]any{
+						"start": map[string]int{"line": startLine, "character": startChar},
+						"end":   map[string]int{"line": endLine, "character": endChar},
+					},
+				},
+			}
+		}
+		return []any{
+			symbol("Calc", 5, 1, 0, 3, 1),
+			symbol("sub", 6, 2, 2, 2, 44),
+			symbol("add", 12, 0, 0, 0, 58),
+		}, nil
+	case "textDocument/completion":
+		params := decodePosition(msg.Params)
+		items := []any{map[string]any{"label": "add", "kind": 3}}
+		switch params.Position.Line {
+		case 0:
+			if params.Context.TriggerKind == 3 {
+				items = append(items, map[string]any{"label": "sub", "kind": 3})
+				return map[string]any{"isIncomplete": false, "items": items}, nil
+			}
+			return map[string]any{"isIncomplete": true, "items": items}, nil
+		case 1:
+			return items, nil
+		case 3:
+			return []any{
+				map[string]any{"label": "zeta", "kind": 6, "sortText": "15"},
+				map[string]any{"label": "alpha", "kind": 3, "sortText": "11"},
+				map[string]any{"label": "gamma", "kind": 14},
+				map[string]any{"label": "delta", "kind": 3, "sortText": "11"},
+				map[string]any{"label": "beta", "kind": 6, "sortText": "11"},
+			}, nil
+		default:
+			return nil, nil
+		}
+	case "textDocument/prepareTypeHierarchy":
+		params := decodePosition(msg.Params)
+		if params.Position.Line != 1 {
+			return nil, nil
+		}
+		return []any{typeItem(params.TextDocument.URI, "Calc")}, nil
+	case "typeHierarchy/supertypes", "typeHierarchy/subtypes":
+		var params struct {
+			Item struct {
+				Name string `json:"name"`
+				URI  string `json:"uri"`
+			} `json:"item"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		related := map[string][]string{"Calc": {"Base"}, "Base": {"Root"}, "Root": {"Calc"}}
+		if msg.Method == "typeHierarchy/subtypes" {
+			related = map[string][]string{"Calc": {"SciCalc", "MiniCalc"}}
+		}
+		items := []any{}
+		for _, name := range related[params.Item.Name] {
+			items = append(items, typeItem(params.Item.URI, name))
+		}
+		return items, nil
+	case "textDocument/rename":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			NewName string `json:"newName"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		edit := map[string]any{"range": errorRange(), "newText": params.NewName}
+		changes := map[string]any{params.TextDocument.URI: []any{edit}}
+		if params.NewName == "escape" {
+			dir := params.TextDocument.URI[:strings.LastIndex(params.TextDocument.URI, "/")]
+			changes[dir[:strings.LastIndex(dir, "/")]+"/outside.ts"] = []any{edit}
+		}
+		return map[string]any{"changes": changes}, nil
+	case "textDocument/codeAction":
+		params := decodePosition(msg.Params)
+		edit := map[string]any{"range": errorRange(), "newText": "add"}
+		return []any{
+			map[string]any{
+				"title":       "Change spelling to 'add'",
+				"kind":        "quickfix",
+				"isPreferred": true,
+				"edit": map[string]any{
+					"changes": map[string]any{params.TextDocument.URI: []any{edit}},
+				},
+			},
+			map[string]any{"title": "Ignore this error", "command": "_typescript.ignore"},
+		}, nil
+	default:
+		return nil, &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+}
+
+// typeItem returns the type hierarchy item of a type named name in uri
+func typeItem(uri, name string) map[string]any {
+	line := map[string]int{"Calc": 1, "Base": 5, "Root": 6, "SciCalc": 7, "MiniCalc": 8}[name]
+	nameRange := map[string]any{
+		"start": map[string]int{"line": line, "character": 6},
+		"end":   map[string]int{"line": line, "character": 6 + len(name)},
+	}
+	return map[string]any{
+		"name":           name,
+		"kind":           5,
+		"uri":            uri,
+		"range":          nameRange,
+		"selectionRange": nameRange,
+		"data":           map[string]any{"name": name},
+	}
+}
+
+// executeCommand runs a command through a request to the client
+func executeCommand(reader *bufio.Reader, msg message) (any, *rpcError) {
+	var params struct {
+		Command   string   `json:"command"`
+		Arguments []string `json:"arguments"`
+	}
+	_ = json.Unmarshal(msg.Params, &params)
+	if len(params.Arguments) != 1 {
+		return nil, &rpcError{Code: -32602, Message: "expected one argument"}
+	}
+	switch params.Command {
+	case "typescript.organizeImports":
+		edit := map[string]any{
+			"range": map[string]any{
+				"start": map[string]int{"line": 0, "character": 0},
+				"end":   map[string]int{"line": 1, "character": 0},
+			},
+			"newText": "",
+		}
+		uri := "file://" + params.Arguments[0]
+		answer := call(reader, *msg.ID, "workspace/applyEdit", map[string]any{
+			"label": "Organize Imports",
+			"edit":  map[string]any{"changes": map[string]any{uri: []any{edit}}},
+		})
+		var result struct {
+			Applied bool `json:"applied"`
+		}
+		_ = json.Unmarshal(answer, &result)
+		if !result.Applied {
+			return nil, &rpcError{Code: -32603, Message: "edit not applied"}
+		}
+		return nil, nil
+	case "fake.configuration":
+		return call(reader, *msg.ID, "workspace/configuration", map[string]any{
+			"items": []any{map[string]any{"section": params.Arguments[0]}},
+		}), nil
+	default:
+		return nil, &rpcError{Code: -32602, Message: "unknown command: " + params.Command}
+	}
+}
+
+// call sends a request to the client and returns the result of its reply,
+// skipping other messages until it arrives
+func call(reader *bufio.Reader, id int, method string, params any) json.RawMessage {
+	request(id, method, params)
+	for {
+		answer, err := readMessage(reader)
+		if err != nil {
+			os.Exit(0)
+		}
+		if answer.Method == "" && answer.ID != nil && *answer.ID == id {
+			return answer.Result
+		}
+	}
+}
+
+func errorRange() map[string]any {
+	return map[string]any{
+		"start": map[string]int{"line": 0, "character": 16},
+		"end":   map[string]int{"line": 0, "character": 19},
+	}
+}
+
+// publishDiagnostics reports the diagnostics of a document opened with params
+func publishDiagnostics(raw json.RawMessage) {
+	params := decodePosition(raw)
+	notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         params.TextDocument.URI,
+		"diagnostics": []any{},
+	})
+	notify("textDocument/publishDiagnostics", map[string]any{
+		"uri": params.TextDocument.URI,
+		"diagnostics": []any{map[string]any{
+			"range":    errorRange(),
+			"severity": 1,
+			"code":     2552,
+			"source":   "typescript",
+			"message":  "Cannot find name 'ad'. Did you mean 'add'?",
+		}},
+	})
+}
+
+func decodePosition(raw json.RawMessage) positionParams {
+	var params positionParams
+	_ = json.Unmarshal(raw, &params)
+	return params
+}
+
+func readMessage(reader *bufio.Reader) (message, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return message{}, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok &&
+			strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	if length < 0 {
+		return message{}, fmt.Errorf("missing Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return message{}, err
+	}
+	var msg message
+	err := json.Unmarshal(body, &msg)
+	return msg, err
+}
+
+func notify(method string, params any) {
+	data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+	fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+func request(id int, method string, params any) {
+	data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+func reply(id int, result any, rpcErr *rpcError) {
+	resp := map[string]any{"jsonrpc": "2.0", "id": id}
+	if rpcErr != nil {
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	data, _ := json.Marshal(resp)
+	fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
@@ -0,0 +1,195 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// DefaultTypeHierarchyDepth is how many levels above and below a type
+// TypeHierarchy follows by default
+const DefaultTypeHierarchyDepth = 5
+
+// Type hierarchy directions
+const (
+	TypeHierarchySupertypes = "supertypes"
+	TypeHierarchySubtypes   = "subtypes"
+	TypeHierarchyBoth       = "both"
+)
+
+// TypeHierarchyRequest asks for the types above and below the class or
+// interface at a position
+type TypeHierarchyRequest struct {
+	WorkspaceRoot string `json:"workspace_root"`
+	FilePath      string `json:"file_path"`
+	Line          int    `json:"line"`      // 0-based
+	Character     int    `json:"character"` // 0-based
+	// Direction is TypeHierarchySupertypes, TypeHierarchySubtypes or
+	// TypeHierarchyBoth, the default
+	Direction string `json:"direction"`
+	// Depth caps the levels followed in each direction, DefaultTypeHierarchyDepth when 0
+	Depth int `json:"depth"`
+	// RelativePaths reports locations inside the workspace as workspace-relative paths
+	RelativePaths bool `json:"relative_paths"`
+	// LineBase numbers Line and returned ranges from 0 (LSP, the default) or 1
+	LineBase int `json:"line_base"`
+}
+
+// TypeHierarchyResponse holds the hierarchy of each type at the position
+type TypeHierarchyResponse struct {
+	Types []TypeHierarchyNode `json:"types"`
+	Error string              `json:"error,omitempty"`
+}
+
+// TypeHierarchyNode is a type with the types it extends or implements and
+// the types extending or implementing it, each a level further out. Location
+// ranges over the type's name.
+type TypeHierarchyNode struct {
+	Name       string              `json:"name"`
+	Kind       int                 `json:"kind"`
+	Detail     string              `json:"detail,omitempty"`
+	Location   LocationResult      `json:"location"`
+	Supertypes []TypeHierarchyNode `json:"supertypes,omitempty"`
+	Subtypes   []TypeHierarchyNode `json:"subtypes,omitempty"`
+	// Truncated is set when the depth limit left this type's further
+	// supertypes or subtypes unrequested
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TypeHierarchy walks the type hierarchy from the type at a position, up
+// through its supertypes and down through its subtypes. A type already on the
+// path from the starting type is not expanded again.
+func (ct *ClientTools) TypeHierarchy(ctx context.Context, req TypeHierarchyRequest) TypeHierarchyResponse {
+	up, down := true, true
+	switch req.Direction {
+	case "", TypeHierarchyBoth:
+	case TypeHierarchySupertypes:
+		down = false
+	case TypeHierarchySubtypes:
+		up = false
+	default:
+		return TypeHierarchyResponse{Error: fmt.Sprintf("unknown direction %q", req.Direction)}
+	}
+	if req.Depth <= 0 {
+		req.Depth = DefaultTypeHierarchyDepth
+	}
+
+	language := getLanguageFromPath(req.FilePath)
+	if language == "" {
+		return TypeHierarchyResponse{Error: "unsupported file type"}
+	}
+
+	server, err := ct.manager.GetLanguageServer(ctx, req.WorkspaceRoot, language)
+	if err != nil {
+		return TypeHierarchyResponse{Error: fmt.Sprintf("failed to get language server: %v", err)}
+	}
+
+	absFilePath := req.FilePath
+	if !filepath.IsAbs(absFilePath) {
+		absRoot, _ := filepath.Abs(req.WorkspaceRoot)
+		absFilePath = filepath.Join(absRoot, req.FilePath)
+	}
+
+	uri := PathToURI(absFilePath)
+	position := Position{Line: req.Line - req.LineBase, Character: req.Character}
+
+	if err := ct.ensureDocumentOpen(ctx, server, uri, absFilePath); err != nil {
+		return TypeHierarchyResponse{Error: fmt.Sprintf("failed to open document: %v", err)}
+	}
+	defer func() { _ = server.DidClose(ctx, uri) }()
+
+	items, err := server.PrepareTypeHierarchy(ctx, uri, position)
+	if err != nil {
+		return TypeHierarchyResponse{Error: fmt.Sprintf("failed to prepare type hierarchy: %v", err)}
+	}
+
+	walker := typeHierarchyWalker{server: server, req: req, onPath: make(map[string]bool)}
+	types := make([]TypeHierarchyNode, 0, len(items))
+	for _, item := range items {
+		node := walker.node(item)
+		walker.onPath[typeHierarchyKey(item)] = true
+		if up {
+			if node.Supertypes, err = walker.expand(ctx, item, TypeHierarchySupertypes, 1); err != nil {
+				return TypeHierarchyResponse{Error: err.Error()}
+			}
+		}
+		if down {
+			if node.Subtypes, err = walker.expand(ctx, item, TypeHierarchySubtypes, 1); err != nil {
+				return TypeHierarchyResponse{Error: err.Error()}
+			}
+		}
+		delete(walker.onPath, typeHierarchyKey(item))
+		types = append(types, node)
+	}
+	return TypeHierarchyResponse{Types: types}
+}
+
+type typeHierarchyWalker struct {
+	server *LanguageServer
+	req    TypeHierarchyRequest
+	onPath map[string]bool // types between the starting type and the current one
+}
+
+// expand returns the supertypes or subtypes of item, each expanded the same
+// way while level is within the request's depth
+func (w *typeHierarchyWalker) expand(
+	ctx context.Context,
+	item TypeHierarchyItem,
+	direction string,
+	level int,
+) ([]TypeHierarchyNode, error) {
+	var (
+		next []TypeHierarchyItem
+		err  error
+	)
+	if direction == TypeHierarchySupertypes {
+		next, err = w.server.TypeHierarchySupertypes(ctx, item)
+	} else {
+		next, err = w.server.TypeHierarchySubtypes(ctx, item)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s of %s: %v", direction, item.Name, err)
+	}
+
+	nodes := make([]TypeHierarchyNode, 0, len(next))
+	for _, n := range next {
+		node := w.node(n)
+		key := typeHierarchyKey(n)
+		switch {
+		case w.onPath[key]:
+			// a cycle, reported once without following it
+		case level == w.req.Depth:
+			node.Truncated = true
+		default:
+			w.onPath[key] = true
+			children, err := w.expand(ctx, n, direction, level+1)
+			delete(w.onPath, key)
+			if err != nil {
+				return nil, err
+			}
+			if direction == TypeHierarchySupertypes {
+				node.Supertypes = children
+			} else {
+				node.Subtypes = children
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// node returns item without its relatives, located as the request asks
+func (w *typeHierarchyWalker) node(item TypeHierarchyItem) TypeHierarchyNode {
+	loc := LocationResult{URI: item.URI, Range: item.SelectionRange}
+	if w.req.RelativePaths {
+		loc = relativeLocation(loc, w.req.WorkspaceRoot)
+	}
+	loc.Range = shiftRangeLines(loc.Range, w.req.LineBase)
+	return TypeHierarchyNode{Name: item.Name, Kind: int(item.Kind), Detail: item.Detail, Location: loc}
+}
+
+// typeHierarchyKey identifies an item by where its name is declared
+func typeHierarchyKey(item TypeHierarchyItem) string {
+	start := item.SelectionRange.Start
+	return fmt.Sprintf("%s:%d:%d", item.URI, start.Line, start.Character)
+}
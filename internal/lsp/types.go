@@ -22,6 +22,21 @@ type Location struct {
 	Range Range  `json:"range"`
 }
 
+// LocationLink is the richer definition result servers may send to clients that
+// advertise linkSupport
+type LocationLink struct {
+	OriginSelectionRange *Range `json:"originSelectionRange,omitempty"`
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// ToLocation returns the link's target as a Location, ranged over the target's
+// selection (e.g. the symbol name) like a plain Location result would be
+func (l LocationLink) ToLocation() Location {
+	return Location{URI: l.TargetURI, Range: l.TargetSelectionRange}
+}
+
 // TextDocumentIdentifier represents a reference to a text document
 type TextDocumentIdentifier struct {
 	URI string `json:"uri"`
@@ -47,6 +62,8 @@ type CompletionItem struct {
 	Documentation json.RawMessage `json:"documentation,omitempty"`
 	InsertText    *string         `json:"insertText,omitempty"`
 	TextEdit      *TextEdit       `json:"textEdit,omitempty"`
+	// SortText orders the item among the others; the label is used when empty
+	SortText *string `json:"sortText,omitempty"`
 }
 
 // CompletionKind represents the kind of a completion item
@@ -86,6 +103,30 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+// CompletionParams are the parameters of a completion request
+type CompletionParams struct {
+	TextDocumentPositionParams
+	// Context tells how completion was triggered; nil when unknown
+	Context *CompletionContext `json:"context,omitempty"`
+}
+
+// CompletionContext describes how a completion request was triggered
+type CompletionContext struct {
+	TriggerKind      CompletionTriggerKind `json:"triggerKind"`
+	TriggerCharacter *string               `json:"triggerCharacter,omitempty"`
+}
+
+// CompletionTriggerKind tells how completion was triggered
+type CompletionTriggerKind int
+
+const (
+	CompletionTriggerInvoked   CompletionTriggerKind = 1
+	CompletionTriggerCharacter CompletionTriggerKind = 2
+	// CompletionTriggerForIncompleteCompletions re-requests a list the server
+	// reported as incomplete
+	CompletionTriggerForIncompleteCompletions CompletionTriggerKind = 3
+)
+
 // CompletionList represents a list of completion items
 type CompletionList struct {
 	IsIncomplete bool             `json:"isIncomplete"`
@@ -99,6 +140,8 @@ type Diagnostic struct {
 	Code     json.RawMessage     `json:"code,omitempty"`
 	Source   *string             `json:"source,omitempty"`
 	Message  string              `json:"message"`
+	// Data is kept so the diagnostic can be sent back in a code action request
+	Data json.RawMessage `json:"data,omitempty"`
 }
 
 // DiagnosticSeverity represents the severity of a diagnostic
@@ -156,3 +199,72 @@ const (
 type WorkspaceSymbolParams struct {
 	Query string `json:"query"`
 }
+
+// TypeHierarchyItem is a class or interface in a type hierarchy. Data is kept
+// as the server sent it, to be passed back with the item in supertypes and
+// subtypes requests.
+type TypeHierarchyItem struct {
+	Name           string          `json:"name"`
+	Kind           SymbolKind      `json:"kind"`
+	Detail         string          `json:"detail,omitempty"`
+	URI            string          `json:"uri"`
+	Range          Range           `json:"range"`
+	SelectionRange Range           `json:"selectionRange"`
+	Data           json.RawMessage `json:"data,omitempty"`
+}
+
+// RenameParams represents the parameters of a rename request
+type RenameParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	NewName      string                 `json:"newName"`
+}
+
+// TextDocumentEdit represents edits to a single text document
+type TextDocumentEdit struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit             `json:"edits"`
+}
+
+// WorkspaceEdit represents changes to many resources managed in the workspace.
+// Resource operations (create/rename/delete file) in DocumentChanges are not supported.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []TextDocumentEdit    `json:"documentChanges,omitempty"`
+}
+
+// Code action kinds requested by ts-index
+const (
+	CodeActionKindQuickFix = "quickfix"
+)
+
+// CodeActionParams represents the parameters of a code action request
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+// CodeActionContext carries the diagnostics a code action request is about
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Only        []string     `json:"only,omitempty"`
+}
+
+// Command is a server command a code action may run instead of, or after, its edit
+type Command struct {
+	Title     string            `json:"title"`
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+// CodeAction is a change the server offers for a range, such as a quick fix
+// for a diagnostic. Bare commands returned by the server are wrapped in one.
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
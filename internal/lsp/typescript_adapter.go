@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 )
 
@@ -15,7 +14,7 @@ const (
 
 // IsVTSLSInstalled checks if vtsls is installed and available
 func IsVTSLSInstalled() bool {
-	_, err := exec.LookPath("vtsls")
+	_, err := findExecutable("vtsls")
 	return err == nil
 }
 
@@ -26,7 +25,7 @@ func InstallVTSLSCommand() string {
 
 // IsTypeScriptLanguageServerInstalled checks if typescript-language-server is installed and available
 func IsTypeScriptLanguageServerInstalled() bool {
-	_, err := exec.LookPath("typescript-language-server")
+	_, err := findExecutable("typescript-language-server")
 	return err == nil
 }
 
@@ -117,8 +116,8 @@ func (a *TypeScriptLspAdapter) ServerCommand(workspaceRoot string) (string, []st
 	// Fallback to system-wide installation
 	switch a.serverType {
 	case ServerTypeVTSLS:
-		if IsVTSLSInstalled() {
-			return "vtsls", []string{"--stdio"}, nil
+		if path, err := findExecutable("vtsls"); err == nil {
+			return path, []string{"--stdio"}, nil
 		}
 		return "", nil, fmt.Errorf(
 			"vtsls is not installed. Use 'ts-index lsp install vtsls' or install globally with: %s",
@@ -126,8 +125,8 @@ func (a *TypeScriptLspAdapter) ServerCommand(workspaceRoot string) (string, []st
 		)
 
 	case ServerTypeTypeScriptLanguageServer:
-		if IsTypeScriptLanguageServerInstalled() {
-			return "typescript-language-server", []string{"--stdio"}, nil
+		if path, err := findExecutable("typescript-language-server"); err == nil {
+			return path, []string{"--stdio"}, nil
 		}
 		return "", nil, fmt.Errorf(
 			"%s or install globally with: %s",
@@ -239,7 +238,7 @@ func (a *TypeScriptLspAdapter) ProcessCompletions(items []CompletionItem) []Comp
 // CanInstall implements LspAdapter.CanInstall
 func (a *TypeScriptLspAdapter) CanInstall() bool {
 	// Check if npm is available for installation
-	_, err := exec.LookPath("npm")
+	_, err := findExecutable("npm")
 	return err == nil
 }
 
@@ -25,6 +25,21 @@ type ServerConfig struct {
 	Project  string
 	DB       string
 	EmbedURL string
+	// EmbedModel is sent as the "model" field of embed requests when set
+	EmbedModel string
+	// SearchDBs are additional index databases searched together with DB
+	SearchDBs []string
+	// SyncLSPSymbols stores the language server's symbols of every project file
+	// in the index once the server has started
+	SyncLSPSymbols bool
+	// AstGrepConfig is the sgconfig.yml passed to ast-grep; empty uses the
+	// one in the project root, if any
+	AstGrepConfig string
+	// Rerank reorders semantic search hits with search.HeuristicReranker
+	Rerank bool
+	// ReadOnly opens DB without writing to it, so it can be searched while
+	// another process indexes it
+	ReadOnly bool
 }
 
 // NewStdioClient creates and initializes an MCP client that launches this binary with mcp.
@@ -48,9 +63,24 @@ func NewStdioClientWithConfig(ctx context.Context, config ServerConfig) (*Client
 	if config.DB != "" {
 		args = append(args, "--db", config.DB)
 	}
+	for _, db := range config.SearchDBs {
+		args = append(args, "--db", db)
+	}
 	if config.EmbedURL != "" {
 		args = append(args, "--embed-url", config.EmbedURL)
 	}
+	if config.EmbedModel != "" {
+		args = append(args, "--embed-model", config.EmbedModel)
+	}
+	if config.AstGrepConfig != "" {
+		args = append(args, "--ast-grep-config", config.AstGrepConfig)
+	}
+	if config.Rerank {
+		args = append(args, "--rerank")
+	}
+	if config.ReadOnly {
+		args = append(args, "--read-only")
+	}
 
 	// First, test if the server can start properly by running it briefly
 	testCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var typeNamePattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// componentPropsHit is a component with the indexed declarations of its props type
+type componentPropsHit struct {
+	models.ComponentProps
+	PropsSymbols []models.Symbol `json:"props_symbols"`
+}
+
+func newComponentPropsTool() mcp.Tool {
+	return mcp.NewTool(
+		"component_props",
+		mcp.WithDescription(
+			"Find the props type of an exported React component in .tsx files, with the indexed "+
+				"interfaces and type aliases declaring it",
+		),
+		mcp.WithString("name", mcp.Description("Component name"), mcp.Required()),
+		withLineBase(indexLineBase),
+	)
+}
+
+func (srv *Server) handleComponentProps(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	name, err := req.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	finder, ok := srv.indexer.(indexer.ComponentPropsFinder)
+	if !ok {
+		return mcp.NewToolResultError("indexer cannot find component props"), nil
+	}
+
+	props, err := finder.FindComponentProps(name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	shift := int32(lineBase - indexLineBase)
+	components := make([]componentPropsHit, len(props))
+	for i, p := range props {
+		p.StartLine += shift
+		p.EndLine += shift
+		hit := componentPropsHit{ComponentProps: p, PropsSymbols: []models.Symbol{}}
+		if typeName := propsTypeName(p.PropsType); typeName != "" {
+			syms, err := srv.indexer.SearchSymbol(typeName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			for _, s := range syms {
+				if s.Symbol.Kind != models.SymbolInterface && s.Symbol.Kind != models.SymbolType {
+					continue
+				}
+				s.Symbol.StartLine += shift
+				s.Symbol.EndLine += shift
+				hit.PropsSymbols = append(hit.PropsSymbols, s.Symbol)
+			}
+		}
+		components[i] = hit
+	}
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+		"name":       name,
+		"total":      len(components),
+		"components": components,
+	}), nil
+}
+
+// propsTypeName returns the name to look a props type up by: "Props" for
+// "Props<T>" or "ui.Props", and "" for unions, object literals and the like
+func propsTypeName(propsType string) string {
+	name, _, _ := strings.Cut(propsType, "<")
+	name = strings.TrimSpace(name)
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	if !typeNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/search"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxTestedDefinitions caps the declarations of one name find_tests asks the
+// language server about
+const maxTestedDefinitions = 10
+
+var (
+	// testCallPattern matches the line opening a test or suite, e.g.
+	// `it('adds', () => {`, `describe.only("math", function () {` or
+	// `test.each(cases)('adds %i', ...`
+	testCallPattern = regexp.MustCompile("^(\\s*)(describe|it|test)\\b[\\w.]*(?:\\([^)]*\\))?\\(\\s*['\"`]([^'\"`]*)")
+	// importLinePattern matches import statements, whose references locate no test
+	importLinePattern = regexp.MustCompile(`^\s*import\b|\brequire\(`)
+)
+
+// testReference is a reference to a symbol from a test file, with the test and
+// suites enclosing it
+type testReference struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Character int    `json:"character"`
+	// Test names the innermost it or test call around the reference
+	Test     string `json:"test,omitempty"`
+	TestLine *int   `json:"test_line,omitempty"`
+	// Suites names the enclosing describe blocks, outermost first
+	Suites []string `json:"suites,omitempty"`
+}
+
+func newFindTestsTool() mcp.Tool {
+	return mcp.NewTool(
+		"find_tests",
+		mcp.WithDescription(
+			"Find where an indexed symbol is tested: its references from test files (*.test.*, *.spec.*, "+
+				"__tests__, test and tests directories) found with the language server, each with the "+
+				"nearest enclosing it/test call and describe blocks",
+		),
+		mcp.WithString("name", mcp.Description("Symbol name"), mcp.Required()),
+		withLineBase(indexLineBase),
+	)
+}
+
+func (srv *Server) handleFindTests(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	name, err := req.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if srv.indexer == nil {
+		return mcp.NewToolResultError("indexer not initialized"), nil
+	}
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+	absRoot, err := filepath.Abs(project)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	hits, err := srv.indexer.SearchSymbol(name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	sources := newSourceCache(absRoot)
+	seen := make(map[string]bool)
+	tests := []testReference{}
+	checked := 0
+	for _, hit := range hits {
+		sym := hit.Symbol
+		if search.IsTestFile(sym.File) {
+			continue
+		}
+		if checked == maxTestedDefinitions {
+			break
+		}
+		pos, ok := sources.namePosition(sym)
+		if !ok {
+			continue
+		}
+		checked++
+		analyzed := clientTools.AnalyzeSymbol(ctx, lsp.AnalyzeSymbolRequest{
+			WorkspaceRoot: absRoot,
+			FilePath:      sym.File,
+			Line:          pos.Line,
+			Character:     pos.Character,
+			IncludeRefs:   true,
+			RelativePaths: true,
+		})
+		if analyzed.Error != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("%s in %s: %s", sym.Name, sym.File, analyzed.Error)), nil
+		}
+		for _, ref := range analyzed.References {
+			file := filepath.ToSlash(ref.URI)
+			start := ref.Range.Start
+			key := fmt.Sprintf("%s:%d:%d", file, start.Line, start.Character)
+			if !search.IsTestFile(file) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if importLinePattern.MatchString(sources.line(file, start.Line)) {
+				continue
+			}
+			tests = append(tests, enclosingTest(sources, file, start, lineBase))
+		}
+	}
+	if checked == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no indexed symbol named %s outside test files", name)), nil
+	}
+	sort.SliceStable(tests, func(a, b int) bool {
+		if tests[a].File != tests[b].File {
+			return tests[a].File < tests[b].File
+		}
+		return tests[a].Line < tests[b].Line
+	})
+
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+		"name":        name,
+		"definitions": checked,
+		"tests":       tests,
+	}), nil
+}
+
+// enclosingTest locates the 0-based pos of file in the tests around it: the
+// nearest it or test call at or above its line and the describe calls around
+// that, each indented less than the one it encloses
+func enclosingTest(sources *sourceCache, file string, pos lsp.Position, lineBase int) testReference {
+	ref := testReference{File: file, Line: pos.Line + lineBase, Character: pos.Character}
+	text := sources.line(file, pos.Line)
+	bound := len(text) - len(strings.TrimLeft(text, " \t"))
+	for n := pos.Line; n >= 0; n-- {
+		m := testCallPattern.FindStringSubmatch(sources.line(file, n))
+		if m == nil {
+			continue
+		}
+		// the reference's own line may open its test; above it, calls indented
+		// as much as what they would enclose are siblings that ended before
+		indent := len(m[1])
+		if n < pos.Line && indent >= bound {
+			continue
+		}
+		if m[2] == "describe" {
+			ref.Suites = append([]string{m[3]}, ref.Suites...)
+		} else if ref.Test == "" && len(ref.Suites) == 0 {
+			line := n + lineBase
+			ref.Test, ref.TestLine = m[3], &line
+		} else {
+			continue
+		}
+		if bound = indent; bound == 0 {
+			break
+		}
+	}
+	return ref
+}
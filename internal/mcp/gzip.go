@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GzipHandler compresses responses with gzip when the client accepts it. Event
+// streams and responses without a body are passed through untouched.
+func GzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// gzip;q=0 explicitly refuses it
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+// gzipResponseWriter delays the status line until the first write or flush, so
+// it can decide from the final headers whether to compress
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		// informational responses go out as they are
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if w.Header().Get("Content-Type") == "" {
+			// sniff the plain bytes; net/http would sniff the compressed ones
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.decide(true)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+// Flush sends what has been compressed so far, keeping streamed responses live
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the delayed status line, switching to gzip when the response
+// has a body that is neither encoded already nor an event stream
+func (w *gzipResponseWriter) decide(hasBody bool) {
+	w.decided = true
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	h := w.Header()
+	contentType, _, _ := strings.Cut(h.Get("Content-Type"), ";")
+	if hasBody && bodyAllowed(status) && h.Get("Content-Encoding") == "" &&
+		strings.TrimSpace(contentType) != "text/event-stream" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// close finishes the gzip stream, or sends the status of an empty response
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		if w.status == 0 {
+			// nothing was written; let net/http send its implicit 200
+			return
+		}
+		w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// maxFinishedJobs bounds how many finished indexing jobs stay queryable
+const maxFinishedJobs = 16
+
+// Indexing job states
+const (
+	jobRunning  = "running"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+)
+
+// IndexJobStatus is a snapshot of an indexing job reported by index_status
+type IndexJobStatus struct {
+	ID             string            `json:"job_id"`
+	Project        string            `json:"project"`
+	State          string            `json:"state"`
+	Stage          models.IndexStage `json:"stage,omitempty"`
+	Percent        float32           `json:"percent"`
+	TotalFiles     int               `json:"total_files"`
+	SkippedFiles   int               `json:"skipped_files,omitempty"`
+	ParsedFiles    int               `json:"parsed_files"`
+	TotalChunks    int               `json:"total_chunks"`
+	EmbeddedChunks int               `json:"embedded_chunks"`
+	Error          string            `json:"error,omitempty"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+}
+
+// indexJob is one IndexProjectProgress run that can be polled and canceled
+type indexJob struct {
+	seq    int // start order
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the run has finished
+
+	mu     sync.Mutex
+	status IndexJobStatus
+}
+
+func (j *indexJob) snapshot() IndexJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	if status.FinishedAt != nil {
+		finished := *status.FinishedAt
+		status.FinishedAt = &finished
+	}
+	return status
+}
+
+// jobRegistry tracks indexing jobs by ID. The zero value is ready to use.
+type jobRegistry struct {
+	mu   sync.Mutex
+	next int
+	jobs map[string]*indexJob
+}
+
+// start runs index in the background under a cancelable child of ctx and
+// registers it as a job. Only one job may run per project at a time.
+func (r *jobRegistry) start(
+	ctx context.Context,
+	project string,
+	index func(context.Context) (<-chan models.IndexProgress, <-chan error),
+) (*indexJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if status := job.snapshot(); status.State == jobRunning && status.Project == project {
+			return nil, fmt.Errorf("project is already being indexed by job %s", status.ID)
+		}
+	}
+	if r.jobs == nil {
+		r.jobs = make(map[string]*indexJob)
+	}
+	r.next++
+	ctx, cancel := context.WithCancel(ctx)
+	job := &indexJob{
+		seq:    r.next,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: IndexJobStatus{
+			ID:        fmt.Sprint(r.next),
+			Project:   project,
+			State:     jobRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	r.jobs[job.status.ID] = job
+	r.prune()
+
+	progCh, errCh := index(ctx)
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		for p := range progCh {
+			job.mu.Lock()
+			job.status.Stage = p.Stage
+			job.status.Percent = p.Percent
+			job.status.TotalFiles = p.TotalFiles
+			job.status.SkippedFiles = p.SkippedFiles
+			job.status.ParsedFiles = p.ParsedFiles
+			job.status.TotalChunks = p.TotalChunks
+			job.status.EmbeddedChunks = p.EmbeddedChunks
+			job.mu.Unlock()
+		}
+		var runErr error
+		for err := range errCh {
+			if err != nil {
+				runErr = err
+			}
+		}
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		now := time.Now()
+		job.status.FinishedAt = &now
+		switch {
+		case runErr == nil:
+			job.status.State = jobDone
+		case errors.Is(runErr, context.Canceled):
+			job.status.State = jobCanceled
+		default:
+			job.status.State = jobFailed
+			job.status.Error = runErr.Error()
+		}
+	}()
+	return job, nil
+}
+
+// get returns the job with the given ID, or nil
+func (r *jobRegistry) get(id string) *indexJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id]
+}
+
+// list returns a snapshot of every tracked job, oldest first
+func (r *jobRegistry) list() []IndexJobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := r.ordered()
+	out := make([]IndexJobStatus, len(jobs))
+	for i, job := range jobs {
+		out[i] = job.snapshot()
+	}
+	return out
+}
+
+// ordered returns the tracked jobs oldest first; r.mu must be held
+func (r *jobRegistry) ordered() []*indexJob {
+	jobs := make([]*indexJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].seq < jobs[j].seq })
+	return jobs
+}
+
+// cancelAll cancels every running job and waits until they have finished or
+// ctx is done
+func (r *jobRegistry) cancelAll(ctx context.Context) error {
+	r.mu.Lock()
+	jobs := r.ordered()
+	r.mu.Unlock()
+	for _, job := range jobs {
+		job.cancel()
+	}
+	for _, job := range jobs {
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// prune drops the oldest finished jobs beyond maxFinishedJobs; r.mu must be held
+func (r *jobRegistry) prune() {
+	var finished []*indexJob
+	for _, job := range r.ordered() {
+		if job.snapshot().State != jobRunning {
+			finished = append(finished, job)
+		}
+	}
+	for len(finished) > maxFinishedJobs {
+		delete(r.jobs, finished[0].snapshot().ID)
+		finished = finished[1:]
+	}
+}
@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Line numbering conventions. The index stores 1-based, inclusive start and end
+// lines while LSP positions are 0-based. Every tool that reports lines accepts
+// line_base to pick either numbering; the defaults keep each surface's native one.
+const (
+	indexLineBase = 1
+	lspLineBase   = 0
+)
+
+// withLineBase declares the line_base parameter with the tool's native default
+func withLineBase(defaultBase int) mcp.ToolOption {
+	return mcp.WithNumber(
+		"line_base",
+		mcp.Description("Number lines in the request and results from 0 or 1"),
+		mcp.DefaultNumber(float64(defaultBase)),
+	)
+}
+
+func getLineBase(req mcp.CallToolRequest, defaultBase int) (int, error) {
+	base := req.GetInt("line_base", defaultBase)
+	if base != 0 && base != 1 {
+		return 0, fmt.Errorf("line_base must be 0 or 1, got %d", base)
+	}
+	return base, nil
+}
+
+// rebaseSemanticHits returns hits with chunk lines renumbered from base
+func rebaseSemanticHits(hits []models.SemanticHit, base int) []models.SemanticHit {
+	if base == indexLineBase {
+		return hits
+	}
+	out := make([]models.SemanticHit, len(hits))
+	for i, hit := range hits {
+		hit.Chunk.StartLine += int32(base - indexLineBase)
+		hit.Chunk.EndLine += int32(base - indexLineBase)
+		out[i] = hit
+	}
+	return out
+}
+
+// rebaseSymbolHits returns hits with symbol lines renumbered from base
+func rebaseSymbolHits(hits []models.SymbolHit, base int) []models.SymbolHit {
+	if base == indexLineBase {
+		return hits
+	}
+	out := make([]models.SymbolHit, len(hits))
+	for i, hit := range hits {
+		hit.Symbol.StartLine += int32(base - indexLineBase)
+		hit.Symbol.EndLine += int32(base - indexLineBase)
+		out[i] = hit
+	}
+	return out
+}
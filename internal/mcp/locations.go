@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Result formats of the search tools. formatLSP replaces the hits with LSP
+// Locations, so editors can hand results to their existing LSP plumbing.
+const (
+	formatHits = "hits"
+	formatLSP  = "lsp"
+)
+
+// withFormat declares the format parameter of a search tool
+func withFormat() mcp.ToolOption {
+	return mcp.WithString(
+		"format",
+		mcp.Description(
+			`"hits" (default) or "lsp" for LSP Location objects under "locations": `+
+				"file URIs and 0-based ranges spanning the whole lines; line_base is ignored",
+		),
+		mcp.Enum(formatHits, formatLSP),
+	)
+}
+
+// withEnrich declares the enrich parameter of the tools returning definitions
+func withEnrich() mcp.ToolOption {
+	return mcp.WithBoolean(
+		"enrich",
+		mcp.Description(
+			"Add to each definition location a preview of the lines around it and "+
+				"the enclosing indexed symbol, saving follow-up reads",
+		),
+		mcp.DefaultBool(false),
+	)
+}
+
+func getFormat(req mcp.CallToolRequest) (string, error) {
+	format := req.GetString("format", formatHits)
+	if format != formatHits && format != formatLSP {
+		return "", fmt.Errorf("format must be %s or %s, got %q", formatHits, formatLSP, format)
+	}
+	return format, nil
+}
+
+// semanticResult builds the result of semantic_search for hits
+func (srv *Server) semanticResult(
+	query string,
+	hits []models.SemanticHit,
+	lineBase int,
+	format string,
+) map[string]interface{} {
+	result := map[string]interface{}{
+		"query": query,
+		"total": len(hits),
+	}
+	if format != formatLSP {
+		result["hits"] = rebaseSemanticHits(hits, lineBase)
+		return result
+	}
+	locations := make([]lsp.Location, len(hits))
+	for i, hit := range hits {
+		locations[i] = srv.indexLocation(hit.Chunk.File, hit.Chunk.StartLine, hit.Chunk.EndLine)
+	}
+	result["locations"] = locations
+	return result
+}
+
+// symbolResult builds the result of symbol_search for hits
+func (srv *Server) symbolResult(
+	name string,
+	hits []models.SymbolHit,
+	lineBase int,
+	format string,
+) map[string]interface{} {
+	result := map[string]interface{}{
+		"name":  name,
+		"total": len(hits),
+	}
+	if format != formatLSP {
+		result["hits"] = rebaseSymbolHits(hits, lineBase)
+		return result
+	}
+	locations := make([]lsp.Location, len(hits))
+	for i, hit := range hits {
+		locations[i] = srv.indexLocation(hit.Symbol.File, hit.Symbol.StartLine, hit.Symbol.EndLine)
+	}
+	result["locations"] = locations
+	return result
+}
+
+// indexLocation converts an indexed span to an LSP Location. Index lines are
+// 1-based and inclusive, so the range runs from the start of the first line to
+// the start of the line after the last. Relative files are resolved against
+// the project.
+func (srv *Server) indexLocation(file string, startLine, endLine int32) lsp.Location {
+	path := filepath.FromSlash(file)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(srv.config.Project, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return lsp.Location{
+		URI: lsp.PathToURI(path),
+		Range: lsp.Range{
+			Start: lsp.Position{Line: int(startLine) - indexLineBase},
+			End:   lsp.Position{Line: int(endLine) - indexLineBase + 1},
+		},
+	}
+}
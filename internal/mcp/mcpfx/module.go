@@ -19,16 +19,28 @@ type Params struct {
 	SearchService *search.Service
 	Indexer       indexer.Indexer
 	Config        *configfx.Config
+	Lifecycle     fx.Lifecycle
 }
 
-// NewMCPServer creates a new MCP server instance
-func NewMCPServer(params Params) *server.MCPServer {
+// NewServer creates the MCP server wrapper, shut down when the app stops
+func NewServer(params Params) *appmcp.Server {
 	config := appmcp.ServerConfig{
 		Project:  params.Config.Project,
 		DB:       params.Config.DBPath,
 		EmbedURL: params.Config.EmbedURL,
+
+		SearchDBs:      params.Config.SearchDBPaths,
+		SyncLSPSymbols: params.Config.SyncLSPSymbols,
+		AstGrepConfig:  params.Config.AstGrepConfig,
 	}
-	return appmcp.New(params.SearchService, params.Indexer, config)
+	srv := appmcp.NewServer(params.SearchService, params.Indexer, config)
+	params.Lifecycle.Append(fx.Hook{OnStop: srv.Shutdown})
+	return srv
+}
+
+// NewMCPServer creates a new MCP server instance
+func NewMCPServer(srv *appmcp.Server) *server.MCPServer {
+	return srv.MCPServer()
 }
 
 // Lifecycle manages MCP server lifecycle
@@ -64,13 +76,14 @@ func (m *Lifecycle) Start(ctx context.Context) error {
 
 // Stop handles graceful shutdown
 func (m *Lifecycle) Stop(ctx context.Context) error {
-	// MCP server cleanup is handled by the framework
+	// the server's own stop hook, appended by NewServer, releases its resources
 	return nil
 }
 
 // Module provides MCP server components
 var Module = fx.Module("mcp",
 	fx.Provide(
+		NewServer,
 		NewMCPServer,
 		NewLifecycle,
 	),
@@ -2,25 +2,46 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/0x5457/ts-index/internal/astgrep"
+	"github.com/0x5457/ts-index/internal/ignore"
 	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/logging"
 	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser/tsparser"
 	"github.com/0x5457/ts-index/internal/search"
+	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/util"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// lspWarmupTimeout bounds the background language server warm-up request
+const lspWarmupTimeout = 60 * time.Second
+
 // Server wraps an MCP server with direct interface dependencies
 type Server struct {
-	server         *server.MCPServer
-	searchService  *search.Service  // Search service (can be nil)
-	indexer        indexer.Indexer  // Indexer (can be nil)
-	config         ServerConfig     // Server configuration
-	lspClientTools *lsp.ClientTools // Pre-initialized LSP client tools
+	server        *server.MCPServer
+	searchService *search.Service // Search service (can be nil)
+	indexer       indexer.Indexer // Indexer (can be nil)
+	config        ServerConfig    // Server configuration
+	jobs          jobRegistry     // Indexing jobs started by index_project
+
+	// lspClientTools are shared by every handler for the server's lifetime, so
+	// each language server is started once. lspMu guards creating them lazily
+	// when no project was configured.
+	lspMu          sync.Mutex
+	lspClientTools *lsp.ClientTools
 }
 
 // New returns an MCP server with the given services and configuration.
@@ -29,6 +50,16 @@ func New(
 	indexer indexer.Indexer,
 	config ServerConfig,
 ) *server.MCPServer {
+	return NewServer(searchService, indexer, config).MCPServer()
+}
+
+// NewServer is like New but returns the wrapper, whose Shutdown releases the
+// language servers and indexing jobs started on behalf of clients.
+func NewServer(
+	searchService *search.Service,
+	indexer indexer.Indexer,
+	config ServerConfig,
+) *Server {
 	srv := &Server{
 		searchService: searchService,
 		indexer:       indexer,
@@ -47,77 +78,189 @@ func New(
 
 	// Search tools
 	srv.server.AddTool(newSemanticSearchTool(), srv.handleSemanticSearch)
+	srv.server.AddTool(newSearchStatsTool(), srv.handleSearchStats)
+	srv.server.AddTool(newSymbolSearchTool(), srv.handleSymbolSearch)
+	srv.server.AddTool(newGetSymbolTool(), srv.handleGetSymbol)
+	srv.server.AddTool(newComponentPropsTool(), srv.handleComponentProps)
+	srv.server.AddTool(newUnusedExportsTool(), srv.handleUnusedExports)
+	srv.server.AddTool(newFindTestsTool(), srv.handleFindTests)
+	srv.server.AddTool(newIndexProjectTool(), srv.handleIndexProject)
+	srv.server.AddTool(newIndexStatusTool(), srv.handleIndexStatus)
+	srv.server.AddTool(newIndexCancelTool(), srv.handleIndexCancel)
 
 	// LSP tools
+	srv.server.AddTool(newLSPInfoTool(), srv.handleLSPInfo)
+	srv.server.AddTool(newLSPRawTool(), srv.handleLSPRaw)
 	srv.server.AddTool(newLSPAnalyzeTool(), srv.handleLSPAnalyze)
+	srv.server.AddTool(newLSPCompletionTool(), srv.handleLSPCompletion)
 	srv.server.AddTool(newLSPSymbolsTool(), srv.handleLSPSymbols)
+	srv.server.AddTool(newFileOutlineTool(), srv.handleFileOutline)
+	srv.server.AddTool(newDiagnoseFixTool(), srv.handleDiagnoseFix)
 	srv.server.AddTool(newLSPImplementationTool(), srv.handleLSPImplementation)
 	srv.server.AddTool(newLSPTypeDefinitionTool(), srv.handleLSPTypeDefinition)
 	srv.server.AddTool(newLSPDeclarationTool(), srv.handleLSPDeclaration)
+	srv.server.AddTool(newTypeHierarchyTool(), srv.handleTypeHierarchy)
+	srv.server.AddTool(newLSPRenameTool(), srv.handleLSPRename)
+	srv.server.AddTool(newLSPOrganizeImportsTool(), srv.handleLSPOrganizeImports)
+	srv.server.AddTool(newApplyEditsTool(), srv.handleApplyEdits)
 
 	// AST-grep tools
 	srv.server.AddTool(newAstGrepSearchTool(), srv.handleAstGrepSearch)
+	srv.server.AddTool(newAstGrepLintTool(), srv.handleAstGrepLint)
+	srv.server.AddTool(newAstGrepDumpTreeTool(), srv.handleAstGrepDumpTree)
+	srv.server.AddTool(newAstGrepPresetTool(), srv.handleAstGrepPreset)
+	srv.server.AddTool(newListPresetsTool(), srv.handleListPresets)
 
 	// File tools
 	srv.server.AddTool(newReadFileTool(), srv.handleReadFile)
+	srv.server.AddTool(newFileSummaryTool(), srv.handleFileSummary)
+	srv.server.AddTool(newFileIndexStatusTool(), srv.handleFileIndexStatus)
+
+	return srv
+}
 
+// MCPServer returns the underlying MCP server to serve over a transport
+func (srv *Server) MCPServer() *server.MCPServer {
 	return srv.server
 }
 
+// Shutdown cancels running indexing jobs, waiting for them until ctx is done,
+// and stops the language servers. Call it once the transport has stopped.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	jobsErr := srv.jobs.cancelAll(ctx)
+
+	srv.lspMu.Lock()
+	defer srv.lspMu.Unlock()
+	var lspErr error
+	if srv.lspClientTools != nil {
+		lspErr = srv.lspClientTools.Cleanup()
+		srv.lspClientTools = nil
+	}
+	return errors.Join(jobsErr, lspErr)
+}
+
 // initializeLSPClient pre-initializes the LSP client to catch errors early
 func (srv *Server) initializeLSPClient() {
-	fmt.Printf("Initializing LSP client for project: %s\n", srv.config.Project)
+	logging.L().Info("initializing LSP client", "project", srv.config.Project)
 
 	srv.lspClientTools = lsp.NewClientTools()
-
-	// Test LSP connection by trying to create a language server
-	ctx := context.Background()
+	if store, ok := srv.indexer.(lsp.WorkspaceSymbolStore); ok && srv.config.SyncLSPSymbols {
+		srv.lspClientTools = lsp.NewClientToolsWithSymbolStore(store)
+	}
+	if finder, ok := srv.indexer.(lsp.EnclosingSymbolFinder); ok {
+		srv.lspClientTools.SetEnclosingSymbolFinder(finder)
+	}
 
 	// Try to get adapter info to validate the setup
 	adapters := srv.lspClientTools.GetAdapterInfo()
 	if len(adapters) == 0 {
-		fmt.Fprintf(os.Stderr, "[LSP WARNING] No LSP adapters available\n")
+		logging.L().Warn("no LSP adapters available")
 		return
 	}
 
-	// Try to test the server startup with a simple operation
-	// We'll test this by checking if we can start a language server
+	// Warm up the language server with a harmless real operation so startup
+	// problems surface early without blocking server construction
 	go func() {
-		// Create a test request to warm up the language server
-		result := srv.lspClientTools.SearchSymbols(ctx, lsp.SymbolSearchRequest{
-			WorkspaceRoot: srv.config.Project,
-			Query:         "", // Empty query to test connection
-			MaxResults:    1,
-		})
-
-		if result.Error != "" {
-			fmt.Fprintf(
-				os.Stderr,
-				"[LSP ERROR] Language server initialization failed: %s\n",
-				result.Error,
-			)
-			fmt.Fprintf(
-				os.Stderr,
-				"[LSP ERROR] This may cause LSP tools to fail during operation\n",
-			)
-		} else {
-			fmt.Printf("LSP client initialized successfully\n")
+		ctx, cancel := context.WithTimeout(context.Background(), lspWarmupTimeout)
+		defer cancel()
+		if err := srv.warmUpLSP(ctx); err != nil {
+			logging.L().Error("language server initialization failed; LSP tools may fail", "error", err)
+			return
+		}
+		logging.L().Info("LSP client initialized")
+		if srv.lspClientTools.HasSymbolStore() {
+			srv.syncLSPSymbols()
 		}
 	}()
 }
 
-// getLSPClientTools returns the pre-initialized LSP client tools or creates new ones as fallback
-func (srv *Server) getLSPClientTools() *lsp.ClientTools {
-	if srv.lspClientTools != nil {
-		return srv.lspClientTools
+// syncLSPSymbols persists the language server's symbols of every project file
+// into the symbol store so symbol_search can answer them from the index
+func (srv *Server) syncLSPSymbols() {
+	res, err := srv.lspClientTools.SyncWorkspaceSymbols(context.Background(), srv.config.Project)
+	if err != nil {
+		logging.L().Error("workspace symbol sync failed", "error", err)
+		return
 	}
+	logging.L().Info("synced workspace symbols",
+		"files", res.Files, "symbols", res.Symbols, "added", res.Added, "failed", res.Failed)
+}
 
-	// Fallback: create new client tools if pre-initialization failed
-	fmt.Fprintf(
-		os.Stderr,
-		"[LSP WARNING] Using fallback LSP client tools (pre-initialization may have failed)\n",
-	)
-	return lsp.NewClientTools()
+// warmUpLSP starts the language server by requesting document symbols for a project
+// file, falling back to a single-character workspace symbol query. Servers that
+// reject the query itself are still considered started.
+func (srv *Server) warmUpLSP(ctx context.Context) error {
+	if file := findWarmupFile(srv.config.Project); file != "" {
+		_, err := srv.lspClientTools.GetDocumentSymbols(ctx, srv.config.Project, file)
+		if err == nil || isQueryRejection(err.Error()) {
+			return nil
+		}
+		return err
+	}
+
+	result := srv.lspClientTools.SearchSymbols(ctx, lsp.SymbolSearchRequest{
+		WorkspaceRoot: srv.config.Project,
+		Query:         "a",
+		MaxResults:    1,
+	})
+	if result.Error == "" || isQueryRejection(result.Error) {
+		return nil
+	}
+	return fmt.Errorf("%s", result.Error)
+}
+
+// findWarmupFile returns the first indexable TypeScript file under root, or ""
+func findWarmupFile(root string) string {
+	matcher, err := ignore.Load(root)
+	if err != nil {
+		return ""
+	}
+	var found string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil || matcher.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && (strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx")) {
+			found = path
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// isQueryRejection reports whether an LSP error only means the server refused the
+// request parameters (e.g. empty workspace/symbol queries) while itself running fine
+func isQueryRejection(msg string) bool {
+	msg = strings.ToLower(msg)
+	if strings.Contains(msg, "failed to get language server") {
+		return false
+	}
+	for _, marker := range []string{"empty query", "query is empty", "invalid params", "-32602"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// getLSPClientTools returns the shared LSP client tools, creating them on first
+// use when they were not pre-initialized. Handlers must not clean them up.
+func (srv *Server) getLSPClientTools() *lsp.ClientTools {
+	srv.lspMu.Lock()
+	defer srv.lspMu.Unlock()
+	if srv.lspClientTools == nil {
+		logging.L().Info("creating LSP client tools on first use")
+		srv.lspClientTools = lsp.NewClientTools()
+	}
+	return srv.lspClientTools
 }
 
 // Tool definitions
@@ -127,6 +270,86 @@ func newSemanticSearchTool() mcp.Tool {
 		mcp.WithDescription("Semantic code search by natural language query"),
 		mcp.WithString("query", mcp.Description("Natural language query"), mcp.Required()),
 		mcp.WithNumber("top_k", mcp.Description("Top K results"), mcp.DefaultNumber(5)),
+		mcp.WithString(
+			"refine",
+			mcp.Description("Optional keyword to narrow results by name/content substring"),
+		),
+		withLineBase(indexLineBase),
+		withFormat(),
+	)
+}
+
+func newSearchStatsTool() mcp.Tool {
+	return mcp.NewTool(
+		"search_stats",
+		mcp.WithDescription(
+			"Cumulative semantic search latency histograms for the embed and vector-query steps, "+
+				"and the git commit the index was built from",
+		),
+		mcp.WithBoolean(
+			"reset",
+			mcp.Description("Reset the counters after returning them"),
+			mcp.DefaultBool(false),
+		),
+	)
+}
+
+func newSymbolSearchTool() mcp.Tool {
+	return mcp.NewTool(
+		"symbol_search",
+		mcp.WithDescription("Exact symbol name search in the index"),
+		mcp.WithString("name", mcp.Description("Symbol name"), mcp.Required()),
+		withLineBase(indexLineBase),
+		withFormat(),
+	)
+}
+
+func newGetSymbolTool() mcp.Tool {
+	return mcp.NewTool(
+		"get_symbol",
+		mcp.WithDescription(
+			"Fetch an indexed symbol by ID with its code, docstring, signature and location",
+		),
+		mcp.WithString(
+			"id",
+			mcp.Description("Symbol ID as returned by symbol_search or semantic_search"),
+			mcp.Required(),
+		),
+		withLineBase(indexLineBase),
+	)
+}
+
+func newIndexProjectTool() mcp.Tool {
+	return mcp.NewTool(
+		"index_project",
+		mcp.WithDescription(
+			"Re-index the configured project as a job that index_cancel can stop. "+
+				"In the foreground indexing also stops if the request is canceled, e.g. by a disconnect",
+		),
+		mcp.WithBoolean(
+			"background",
+			mcp.Description("Return the job ID at once instead of waiting; poll it with index_status"),
+			mcp.DefaultBool(false),
+		),
+	)
+}
+
+func newIndexStatusTool() mcp.Tool {
+	return mcp.NewTool(
+		"index_status",
+		mcp.WithDescription("Progress and state of indexing jobs: running, done, failed or canceled"),
+		mcp.WithString("job_id", mcp.Description("Job to report; omit to list recent jobs")),
+	)
+}
+
+func newIndexCancelTool() mcp.Tool {
+	return mcp.NewTool(
+		"index_cancel",
+		mcp.WithDescription(
+			"Cancel a running indexing job. Batches already embedded stay stored; "+
+				"the rest of the project is left as it was",
+		),
+		mcp.WithString("job_id", mcp.Description("Job to cancel"), mcp.Required()),
 	)
 }
 
@@ -135,11 +358,41 @@ func newLSPAnalyzeTool() mcp.Tool {
 		"lsp_analyze",
 		mcp.WithDescription("Analyze symbol at position using LSP"),
 		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
-		mcp.WithNumber("line", mcp.Description("0-based line"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line, 0-based unless line_base is 1"), mcp.Required()),
 		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
 		mcp.WithBoolean("hover", mcp.Description("Include hover"), mcp.DefaultBool(true)),
 		mcp.WithBoolean("refs", mcp.Description("Include references"), mcp.DefaultBool(false)),
 		mcp.WithBoolean("defs", mcp.Description("Include definitions"), mcp.DefaultBool(true)),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+		withEnrich(),
+	)
+}
+
+func newLSPCompletionTool() mcp.Tool {
+	return mcp.NewTool(
+		"lsp_completion",
+		mcp.WithDescription(
+			"Get completion items at position via LSP, most relevant first; is_incomplete marks a partial list",
+		),
+		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("0-based line"), mcp.Required()),
+		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithNumber("max_results", mcp.Description("Max results"), mcp.DefaultNumber(20)),
+		mcp.WithBoolean(
+			"retry_incomplete",
+			mcp.Description("Re-request once, as a completion of the incomplete list, when the server reports one"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean(
+			"group_by_kind",
+			mcp.Description("Return the items grouped by completion kind in groups instead of items"),
+			mcp.DefaultBool(false),
+		),
 	)
 }
 
@@ -149,6 +402,44 @@ func newLSPSymbolsTool() mcp.Tool {
 		mcp.WithDescription("Search workspace symbols via LSP"),
 		mcp.WithString("query", mcp.Description("Symbol query"), mcp.Required()),
 		mcp.WithNumber("max_results", mcp.Description("Max results"), mcp.DefaultNumber(50)),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+	)
+}
+
+func newFileOutlineTool() mcp.Tool {
+	return mcp.NewTool(
+		"file_outline",
+		mcp.WithDescription(
+			"Typed table of contents of a file via LSP: its symbols nested by range, "+
+				"with the resolved signature of each function and method when hover provides one",
+		),
+		mcp.WithString(
+			"file",
+			mcp.Description("File path, absolute or relative to the project"),
+			mcp.Required(),
+		),
+		withLineBase(lspLineBase),
+	)
+}
+
+func newDiagnoseFixTool() mcp.Tool {
+	return mcp.NewTool(
+		"diagnose_fix",
+		mcp.WithDescription(
+			"Diagnostics of a file via LSP, each with the quick fixes the language server offers "+
+				"and the edits they would make. Nothing is applied",
+		),
+		mcp.WithString(
+			"file",
+			mcp.Description("File path, absolute or relative to the project"),
+			mcp.Required(),
+		),
+		withLineBase(lspLineBase),
 	)
 }
 
@@ -157,8 +448,15 @@ func newLSPImplementationTool() mcp.Tool {
 		"lsp_implementation",
 		mcp.WithDescription("Find implementations of symbol at position"),
 		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
-		mcp.WithNumber("line", mcp.Description("0-based line"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line, 0-based unless line_base is 1"), mcp.Required()),
 		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+		withEnrich(),
 	)
 }
 
@@ -167,8 +465,15 @@ func newLSPTypeDefinitionTool() mcp.Tool {
 		"lsp_type_definition",
 		mcp.WithDescription("Find type definitions of symbol at position"),
 		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
-		mcp.WithNumber("line", mcp.Description("0-based line"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line, 0-based unless line_base is 1"), mcp.Required()),
 		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+		withEnrich(),
 	)
 }
 
@@ -177,8 +482,103 @@ func newLSPDeclarationTool() mcp.Tool {
 		"lsp_declaration",
 		mcp.WithDescription("Find declarations of symbol at position"),
 		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line, 0-based unless line_base is 1"), mcp.Required()),
+		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+		withEnrich(),
+	)
+}
+
+func newLSPInfoTool() mcp.Tool {
+	return mcp.NewTool(
+		"lsp_info",
+		mcp.WithDescription(
+			"Show the language server adapters, the running servers and the project's tsconfig.json or "+
+				"jsconfig.json, with warnings about setups that limit LSP results",
+		),
+	)
+}
+
+func newLSPRawTool() mcp.Tool {
+	return mcp.NewTool(
+		"lsp_raw",
+		mcp.WithDescription(
+			"Debugging aid: send any request to the language server for a file and return its result "+
+				"exactly as the server sent it, to compare with what the other LSP tools report",
+		),
+		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
+		mcp.WithString("method", mcp.Description("LSP method, e.g. textDocument/hover"), mcp.Required()),
+		mcp.WithString(
+			"params",
+			mcp.Description("Request params as a JSON object. For textDocument/ methods the file's "+
+				"textDocument is added unless given; positions are 0-based"),
+		),
+	)
+}
+
+func newLSPRenameTool() mcp.Tool {
+	return mcp.NewTool(
+		"lsp_rename",
+		mcp.WithDescription(
+			"Rename symbol at position via LSP, apply the edits and reindex the modified files",
+		),
+		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
 		mcp.WithNumber("line", mcp.Description("0-based line"), mcp.Required()),
 		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithString("new_name", mcp.Description("New symbol name"), mcp.Required()),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("Only return a unified diff per file that would change (default: false)"),
+		),
+	)
+}
+
+func newLSPOrganizeImportsTool() mcp.Tool {
+	return mcp.NewTool(
+		"lsp_organize_imports",
+		mcp.WithDescription(
+			"Sort imports and remove unused ones in a file via the language server, "+
+				"then reindex it. Returns a unified diff of the change",
+		),
+		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("Only return the unified diff without writing the file (default: false)"),
+		),
+	)
+}
+
+func newApplyEditsTool() mcp.Tool {
+	return mcp.NewTool(
+		"apply_edits",
+		mcp.WithDescription(
+			"Write edits from the language server, such as the fixes of diagnose_fix, to project files "+
+				"and reindex them. Every file is edited in memory first, so overlapping edits or files "+
+				"outside the project write nothing",
+		),
+		mcp.WithObject(
+			"edits",
+			mcp.Description(`LSP WorkspaceEdit: {"changes": {uri: [TextEdit]}} and/or "documentChanges"`),
+		),
+		mcp.WithArray(
+			"file_edits",
+			mcp.Description(
+				`Edits grouped by file as in diagnose_fix fixes: [{"file": path, "edits": [TextEdit]}], `+
+					"paths relative to the project or absolute",
+			),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		withLineBase(lspLineBase),
+		mcp.WithBoolean("reindex", mcp.Description("Reindex the modified files (default: true)")),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("Only return a unified diff per file that would change (default: false)"),
+		),
 	)
 }
 
@@ -193,112 +593,795 @@ func (srv *Server) handleSemanticSearch(
 	}
 
 	topK := req.GetInt("top_k", 5)
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	format, err := getFormat(req)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Use default search service
 	if srv.searchService == nil {
 		return mcp.NewToolResultError("search service not initialized"), nil
 	}
 
-	hits, err := srv.searchService.Search(ctx, query, topK)
+	refine := req.GetString("refine", "")
+	hits, err := srv.searchWithProgress(ctx, req, query, topK, lineBase, refine)
+	if errors.Is(err, storage.ErrNoEmbeddings) {
+		return srv.symbolFallback(query, topK, lineBase, format, err)
+	}
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	if refine != "" {
+		hits = srv.searchService.Refine(hits, refine)
+	}
 
 	// Wrap the hits array in an object to satisfy MCP protocol expectations
-	result := map[string]interface{}{
-		"hits":  hits,
-		"query": query,
-		"total": len(hits),
+	return mcp.NewToolResultStructuredOnly(srv.semanticResult(query, hits, lineBase, format)), nil
+}
+
+// searchWithProgress runs a semantic search. When the request carries a
+// progress token, each hit matching refine is also sent to the client in a
+// progress notification, under "hit" in its _meta, as soon as the vector store
+// reads it, so the client can render the first results before the search
+// completes.
+func (srv *Server) searchWithProgress(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+	query string,
+	topK, lineBase int,
+	refine string,
+) ([]models.SemanticHit, error) {
+	mcpServer := server.ServerFromContext(ctx)
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil || mcpServer == nil {
+		return srv.searchService.Search(ctx, query, topK)
+	}
+
+	hitCh, errCh := srv.searchService.SearchStream(ctx, query, topK)
+	var hits []models.SemanticHit
+	for hit := range hitCh {
+		hits = append(hits, hit)
+		if len(srv.searchService.Refine([]models.SemanticHit{hit}, refine)) == 0 {
+			continue
+		}
+		hit = rebaseSemanticHits([]models.SemanticHit{hit}, lineBase)[0]
+		err := mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": req.Params.Meta.ProgressToken,
+			"progress":      len(hits),
+			"total":         topK,
+			"message":       fmt.Sprintf("%s:%d %s", hit.Chunk.File, hit.Chunk.StartLine, hit.Chunk.Name),
+			"_meta":         map[string]any{"hit": hit},
+		})
+		if err != nil {
+			logging.L().Debug("failed to send search progress", "error", err)
+		}
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// queryIdentifiers matches the words of a query that could be symbol names
+var queryIdentifiers = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+// symbolFallback answers a semantic search on an index without embeddings with
+// the symbols named by words of the query, flagged with a warning. It returns
+// noEmbeddings as the tool error when nothing matches.
+func (srv *Server) symbolFallback(
+	query string,
+	topK, lineBase int,
+	format string,
+	noEmbeddings error,
+) (*mcp.CallToolResult, error) {
+	if srv.indexer == nil {
+		return mcp.NewToolResultError(noEmbeddings.Error()), nil
+	}
+	if topK <= 0 {
+		topK = 5
+	}
+	var hits []models.SemanticHit
+	seen := map[string]bool{}
+	for _, word := range queryIdentifiers.FindAllString(query, -1) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		syms, err := srv.indexer.SearchSymbol(word)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		for _, sym := range syms {
+			hits = append(hits, models.SemanticHit{Chunk: models.CodeChunk{
+				ID:        sym.Symbol.ID,
+				File:      sym.Symbol.File,
+				Language:  sym.Symbol.Language,
+				NodeType:  sym.Symbol.NodeType,
+				StartLine: sym.Symbol.StartLine,
+				EndLine:   sym.Symbol.EndLine,
+				StartByte: sym.Symbol.StartByte,
+				EndByte:   sym.Symbol.EndByte,
+				Docstring: sym.Symbol.Docstring,
+				Kind:      sym.Symbol.Kind,
+				Name:      sym.Symbol.Name,
+			}})
+		}
+	}
+	if len(hits) == 0 {
+		return mcp.NewToolResultError(noEmbeddings.Error()), nil
+	}
+	if len(hits) > topK {
+		hits = hits[:topK]
 	}
+	result := srv.semanticResult(query, hits, lineBase, format)
+	result["source"] = "symbols"
+	result["warning"] = noEmbeddings.Error() + "; showing exact symbol name matches instead"
 	return mcp.NewToolResultStructuredOnly(result), nil
 }
 
-func (srv *Server) handleLSPAnalyze(
+func (srv *Server) handleSearchStats(
 	ctx context.Context,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	// Use server config project
-	project := srv.config.Project
-	if project == "" {
-		return mcp.NewToolResultError(
-			"workspace path must be specified in server configuration",
-		), nil
+	if srv.searchService == nil {
+		return mcp.NewToolResultError("search service not initialized"), nil
 	}
-	file, err := req.RequireString("file")
+
+	if req.GetBool("reset", false) {
+		return mcp.NewToolResultStructuredOnly(srv.searchService.ResetStats()), nil
+	}
+	return mcp.NewToolResultStructuredOnly(srv.searchService.Stats()), nil
+}
+
+func (srv *Server) handleSymbolSearch(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	name, err := req.RequireString("name")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	line, err := req.RequireInt("line")
+	lineBase, err := getLineBase(req, indexLineBase)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	ch, err := req.RequireInt("character")
+	format, err := getFormat(req)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	hover := req.GetBool("hover", true)
-	refs := req.GetBool("refs", false)
-	defs := req.GetBool("defs", true)
 
-	// Use pre-initialized client tools or create new ones
-	clientTools := srv.getLSPClientTools()
-	if clientTools == nil {
-		return mcp.NewToolResultError("LSP client not available"), nil
+	if srv.indexer == nil {
+		return mcp.NewToolResultError("indexer not initialized"), nil
 	}
 
-	result := clientTools.AnalyzeSymbol(ctx, lsp.AnalyzeSymbolRequest{
-		WorkspaceRoot: project,
-		FilePath:      file,
-		Line:          line,
-		Character:     ch,
-		IncludeHover:  hover,
-		IncludeRefs:   refs,
-		IncludeDefs:   defs,
-	})
+	hits, err := srv.indexer.SearchSymbol(name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	source := "index"
+	if len(hits) == 0 {
+		// symbols added since the last index or sync are only known to the server
+		if lspHits := srv.lspSymbolHits(ctx, name); len(lspHits) > 0 {
+			hits, source = lspHits, "lsp"
+		}
+	}
+
+	result := srv.symbolResult(name, hits, lineBase, format)
+	result["source"] = source
 	return mcp.NewToolResultStructuredOnly(result), nil
 }
 
-func (srv *Server) handleReadFile(
+// lspSymbolHits looks name up through the running language server, returning
+// exact matches inside the project numbered like index hits. It returns nil
+// when no language server was set up for the project.
+func (srv *Server) lspSymbolHits(ctx context.Context, name string) []models.SymbolHit {
+	// with a project the tools were set up in New and are never replaced
+	if srv.config.Project == "" || srv.lspClientTools == nil {
+		return nil
+	}
+	resp := srv.lspClientTools.SearchSymbols(ctx, lsp.SymbolSearchRequest{
+		WorkspaceRoot: srv.config.Project,
+		Query:         name,
+		RelativePaths: true,
+	})
+	if resp.Error != "" {
+		return nil
+	}
+	var hits []models.SymbolHit
+	for _, sym := range resp.Symbols {
+		if sym.Name != name || sym.Location.AbsoluteURI == "" {
+			// other names or files outside the project, e.g. lib.d.ts
+			continue
+		}
+		file := filepath.FromSlash(sym.Location.URI)
+		startLine := int32(sym.Location.Range.Start.Line) + indexLineBase
+		endLine := int32(sym.Location.Range.End.Line) + indexLineBase
+		hits = append(hits, models.SymbolHit{Symbol: models.Symbol{
+			ID:        util.GenerateID(file, int(startLine), int(endLine), fmt.Sprint(sym.Kind), name),
+			Name:      name,
+			Kind:      lsp.SymbolKind(sym.Kind),
+			File:      file,
+			StartLine: startLine,
+			EndLine:   endLine,
+		}})
+	}
+	return hits
+}
+
+func (srv *Server) handleGetSymbol(
 	ctx context.Context,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	// Use server config project
-	project := srv.config.Project
-	if project == "" {
-		return mcp.NewToolResultError(
-			"workspace path must be specified in server configuration",
-		), nil
+	id, err := req.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-
-	filePath, err := req.RequireString("file_path")
+	lineBase, err := getLineBase(req, indexLineBase)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	startLine := req.GetInt("start_line", 0)
-	endLine := req.GetInt("end_line", 0)
+	if srv.indexer == nil {
+		return mcp.NewToolResultError("indexer not initialized"), nil
+	}
 
-	// Use pre-initialized client tools or create new ones
-	clientTools := srv.getLSPClientTools()
-	if clientTools == nil {
-		return mcp.NewToolResultError("LSP client not available"), nil
+	detail, err := srv.indexer.GetSymbol(id)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if detail == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("symbol %q not found", id)), nil
 	}
 
-	result := clientTools.ReadFile(ctx, lsp.ReadFileRequest{
-		FilePath:      filePath,
-		WorkspaceRoot: project,
+	sym := detail.Symbol
+	result := map[string]interface{}{
+		"id":         sym.ID,
+		"name":       sym.Name,
+		"kind":       sym.Kind,
+		"file":       sym.File,
+		"start_line": sym.StartLine + int32(lineBase-indexLineBase),
+		"end_line":   sym.EndLine + int32(lineBase-indexLineBase),
+		"docstring":  sym.Docstring,
+	}
+	if ch := detail.Chunk; ch != nil {
+		result["language"] = ch.Language
+		result["node_type"] = ch.NodeType
+		result["start_byte"] = ch.StartByte
+		result["end_byte"] = ch.EndByte
+		result["signature"] = ch.Signature
+		result["content"] = ch.Content
+	}
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleIndexProject(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	if srv.indexer == nil {
+		return mcp.NewToolResultError("indexer not initialized"), nil
+	}
+
+	// a foreground job also stops when the client cancels or disconnects
+	background := req.GetBool("background", false)
+	jobCtx := ctx
+	if background {
+		jobCtx = context.Background()
+	}
+	job, err := srv.jobs.start(
+		jobCtx,
+		project,
+		func(ctx context.Context) (<-chan models.IndexProgress, <-chan error) {
+			return srv.indexer.IndexProjectProgress(ctx, project)
+		},
+	)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if background {
+		return mcp.NewToolResultStructuredOnly(job.snapshot()), nil
+	}
+
+	<-job.done
+	status := job.snapshot()
+	switch status.State {
+	case jobCanceled:
+		return mcp.NewToolResultError("indexing stopped: job canceled"), nil
+	case jobFailed:
+		return mcp.NewToolResultError(fmt.Sprintf("indexing stopped: %s", status.Error)), nil
+	}
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+		"job_id": status.ID,
+		"files":  status.TotalFiles,
+		"chunks": status.TotalChunks,
+		"stage":  status.Stage,
+	}), nil
+}
+
+func (srv *Server) handleIndexStatus(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	id := req.GetString("job_id", "")
+	if id == "" {
+		return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+			"jobs": srv.jobs.list(),
+		}), nil
+	}
+	job := srv.jobs.get(id)
+	if job == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no indexing job %s", id)), nil
+	}
+	return mcp.NewToolResultStructuredOnly(job.snapshot()), nil
+}
+
+func (srv *Server) handleIndexCancel(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	id, err := req.RequireString("job_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	job := srv.jobs.get(id)
+	if job == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no indexing job %s", id)), nil
+	}
+	job.cancel()
+	// the pipeline stops between batches; report the final state once it has
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+	}
+	return mcp.NewToolResultStructuredOnly(job.snapshot()), nil
+}
+
+func (srv *Server) handleLSPAnalyze(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line, err := req.RequireInt("line")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ch, err := req.RequireInt("character")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	hover := req.GetBool("hover", true)
+	refs := req.GetBool("refs", false)
+	defs := req.GetBool("defs", true)
+
+	// Use pre-initialized client tools or create new ones
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := clientTools.AnalyzeSymbol(ctx, lsp.AnalyzeSymbolRequest{
+		WorkspaceRoot: project,
+		FilePath:      file,
+		Line:          line,
+		Character:     ch,
+		IncludeHover:  hover,
+		IncludeRefs:   refs,
+		IncludeDefs:   defs,
+		RelativePaths: req.GetBool("relative_paths", false),
+		LineBase:      lineBase,
+		Enrich:        req.GetBool("enrich", false),
+	})
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleReadFile(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+
+	filePath, err := req.RequireString("file_path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	startLine := req.GetInt("start_line", 0)
+	endLine := req.GetInt("end_line", 0)
+	maxBytes := req.GetInt("max_bytes", 0)
+	if maxBytes <= 0 || maxBytes > lsp.DefaultReadFileMaxBytes {
+		// the byte cap cannot be lifted or raised by clients
+		maxBytes = lsp.DefaultReadFileMaxBytes
+	}
+	timeout := time.Duration(req.GetInt("timeout_seconds", 0)) * time.Second
+
+	// Use pre-initialized client tools or create new ones
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := clientTools.ReadFile(ctx, lsp.ReadFileRequest{
+		FilePath:      filePath,
+		WorkspaceRoot: project,
 		StartLine:     startLine,
 		EndLine:       endLine,
+		MaxBytes:      maxBytes,
+		Truncate:      req.GetBool("truncate", false),
+		Timeout:       timeout,
 	})
 
-	if result.Error != "" {
-		return mcp.NewToolResultError(result.Error), nil
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleFileSummary(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(project, file)
+	}
+
+	summary, err := tsparser.New().SummarizeFileWithRoot(project, file)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for i := range summary.Exports {
+		summary.Exports[i].StartLine += int32(lineBase - indexLineBase)
+		summary.Exports[i].EndLine += int32(lineBase - indexLineBase)
+	}
+	return mcp.NewToolResultStructuredOnly(summary), nil
+}
+
+func (srv *Server) handleFileIndexStatus(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	checker, ok := srv.indexer.(indexer.FileStatusChecker)
+	if !ok {
+		return mcp.NewToolResultError("indexer cannot check file status"), nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(project, file)
+	}
+
+	status, err := checker.FileIndexStatus(project, file)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for _, syms := range [][]models.Symbol{status.Missing, status.Stale} {
+		for i := range syms {
+			syms[i].StartLine += int32(lineBase - indexLineBase)
+			syms[i].EndLine += int32(lineBase - indexLineBase)
+		}
+	}
+	return mcp.NewToolResultStructuredOnly(status), nil
+}
+
+func (srv *Server) handleLSPCompletion(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line, err := req.RequireInt("line")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ch, err := req.RequireInt("character")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	max := req.GetInt("max_results", 20)
+	retry := req.GetBool("retry_incomplete", false)
+
+	clientTools := srv.getLSPClientTools()
+	result := clientTools.GetCompletion(ctx, lsp.CompletionRequest{
+		WorkspaceRoot:   project,
+		FilePath:        file,
+		Line:            line,
+		Character:       ch,
+		MaxResults:      max,
+		RetryIncomplete: retry,
+		GroupByKind:     req.GetBool("group_by_kind", false),
+	})
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleLSPSymbols(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	query, err := req.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	max := req.GetInt("max_results", 50)
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Use pre-initialized client tools or create new ones
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := clientTools.SearchSymbols(ctx, lsp.SymbolSearchRequest{
+		WorkspaceRoot: project,
+		Query:         query,
+		MaxResults:    max,
+		RelativePaths: req.GetBool("relative_paths", false),
+		LineBase:      lineBase,
+	})
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleFileOutline(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	outline := clientTools.FileOutline(ctx, lsp.OutlineRequest{
+		WorkspaceRoot: project,
+		FilePath:      file,
+		LineBase:      lineBase,
+	})
+	if outline.Error != "" {
+		return mcp.NewToolResultError(outline.Error), nil
+	}
+	return mcp.NewToolResultStructuredOnly(outline), nil
+}
+
+func (srv *Server) handleDiagnoseFix(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := clientTools.DiagnoseFix(ctx, lsp.DiagnoseFixRequest{
+		WorkspaceRoot: project,
+		FilePath:      file,
+		LineBase:      lineBase,
+	})
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+// handleLSPGoto is a generic handler for goto operations
+func (srv *Server) handleLSPGoto(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+	gotoFunc func(*lsp.ClientTools, context.Context, lsp.GotoRequest) lsp.GotoResponse,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line, err := req.RequireInt("line")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ch, err := req.RequireInt("character")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Use pre-initialized client tools or create new ones
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := gotoFunc(clientTools, ctx, lsp.GotoRequest{
+		WorkspaceRoot: project,
+		FilePath:      file,
+		Line:          line,
+		Character:     ch,
+		RelativePaths: req.GetBool("relative_paths", false),
+		LineBase:      lineBase,
+		Enrich:        req.GetBool("enrich", false),
+	})
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleLSPImplementation(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoImplementation)
+}
+
+func (srv *Server) handleLSPTypeDefinition(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoTypeDefinition)
+}
+
+func (srv *Server) handleLSPDeclaration(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoDeclaration)
+}
+
+func (srv *Server) handleLSPInfo(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+	return mcp.NewToolResultStructuredOnly(clientTools.Info(srv.config.Project)), nil
+}
+
+func (srv *Server) handleLSPRaw(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	method, err := req.RequireString("method")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
 	}
 
-	return mcp.NewToolResultStructuredOnly(result), nil
+	raw, err := clientTools.RawRequest(ctx, project, file, method, json.RawMessage(req.GetString("params", "")))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(raw) == 0 {
+		raw = json.RawMessage("null")
+	}
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+		"method": method,
+		"result": raw,
+	}), nil
 }
 
-func (srv *Server) handleLSPCompletion(
+func (srv *Server) handleLSPRename(
 	ctx context.Context,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
@@ -321,111 +1404,188 @@ func (srv *Server) handleLSPCompletion(
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	max := req.GetInt("max_results", 20)
+	newName, err := req.RequireString("new_name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	clientTools := lsp.NewClientTools()
-	defer func() { _ = clientTools.Cleanup() }()
-	result := clientTools.GetCompletion(ctx, lsp.CompletionRequest{
+	// Use pre-initialized client tools or create new ones
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	rename := clientTools.Rename(ctx, lsp.RenameRequest{
 		WorkspaceRoot: project,
 		FilePath:      file,
 		Line:          line,
 		Character:     ch,
-		MaxResults:    max,
+		NewName:       newName,
+		DryRun:        req.GetBool("dry_run", false),
 	})
+	if rename.Error != "" {
+		return mcp.NewToolResultError(rename.Error), nil
+	}
+	if rename.Diffs != nil {
+		return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+			"files": rename.Files,
+			"diffs": rename.Diffs,
+		}), nil
+	}
+
+	result := map[string]interface{}{
+		"files": rename.Files,
+	}
+	reindexed, err := srv.reindexFiles(rename.Files)
+	result["reindexed"] = reindexed
+	if err != nil {
+		// The edits are already on disk; report the stale index instead of failing
+		result["reindex_error"] = err.Error()
+	}
 	return mcp.NewToolResultStructuredOnly(result), nil
 }
 
-func (srv *Server) handleLSPSymbols(
+func (srv *Server) handleLSPOrganizeImports(
 	ctx context.Context,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	// Use server config project
 	project := srv.config.Project
 	if project == "" {
 		return mcp.NewToolResultError(
 			"workspace path must be specified in server configuration",
 		), nil
 	}
-	query, err := req.RequireString("query")
+	file, err := req.RequireString("file")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	max := req.GetInt("max_results", 50)
 
-	// Use pre-initialized client tools or create new ones
 	clientTools := srv.getLSPClientTools()
 	if clientTools == nil {
 		return mcp.NewToolResultError("LSP client not available"), nil
 	}
 
-	result := clientTools.SearchSymbols(ctx, lsp.SymbolSearchRequest{
+	organized := clientTools.OrganizeImports(ctx, lsp.OrganizeImportsRequest{
 		WorkspaceRoot: project,
-		Query:         query,
-		MaxResults:    max,
+		FilePath:      file,
+		DryRun:        req.GetBool("dry_run", false),
 	})
+	if organized.Error != "" {
+		return mcp.NewToolResultError(organized.Error), nil
+	}
+	result := map[string]interface{}{
+		"file":    organized.File,
+		"changed": organized.Changed,
+	}
+	if !organized.Changed {
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+	result["diff"] = organized.Diff
+	if req.GetBool("dry_run", false) {
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+	absRoot, err := filepath.Abs(project)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	reindexed, err := srv.reindexFiles([]string{filepath.Join(absRoot, organized.File)})
+	result["reindexed"] = reindexed
+	if err != nil {
+		// The edits are already on disk; report the stale index instead of failing
+		result["reindex_error"] = err.Error()
+	}
 	return mcp.NewToolResultStructuredOnly(result), nil
 }
 
-// handleLSPGoto is a generic handler for goto operations
-func (srv *Server) handleLSPGoto(
+func (srv *Server) handleApplyEdits(
 	ctx context.Context,
 	req mcp.CallToolRequest,
-	gotoFunc func(*lsp.ClientTools, context.Context, lsp.GotoRequest) lsp.GotoResponse,
 ) (*mcp.CallToolResult, error) {
-	// Use server config project
 	project := srv.config.Project
 	if project == "" {
 		return mcp.NewToolResultError(
 			"workspace path must be specified in server configuration",
 		), nil
 	}
-	file, err := req.RequireString("file")
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
+	var args struct {
+		Edits     *lsp.WorkspaceEdit `json:"edits"`
+		FileEdits []lsp.FileEdits    `json:"file_edits"`
 	}
-	line, err := req.RequireInt("line")
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid edits: %v", err)), nil
 	}
-	ch, err := req.RequireInt("character")
+	if args.Edits == nil && len(args.FileEdits) == 0 {
+		return mcp.NewToolResultError("edits or file_edits is required"), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Use pre-initialized client tools or create new ones
-	clientTools := srv.getLSPClientTools()
-	if clientTools == nil {
-		return mcp.NewToolResultError("LSP client not available"), nil
-	}
-
-	result := gotoFunc(clientTools, ctx, lsp.GotoRequest{
+	applied := lsp.ApplyEdits(lsp.ApplyEditsRequest{
 		WorkspaceRoot: project,
-		FilePath:      file,
-		Line:          line,
-		Character:     ch,
+		Edit:          args.Edits,
+		FileEdits:     args.FileEdits,
+		LineBase:      lineBase,
+		DryRun:        req.GetBool("dry_run", false),
 	})
-	return mcp.NewToolResultStructuredOnly(result), nil
-}
+	if applied.Error != "" {
+		return mcp.NewToolResultError(applied.Error), nil
+	}
+	if applied.Diffs != nil {
+		return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+			"files": applied.Files,
+			"diffs": applied.Diffs,
+		}), nil
+	}
 
-func (srv *Server) handleLSPImplementation(
-	ctx context.Context,
-	req mcp.CallToolRequest,
-) (*mcp.CallToolResult, error) {
-	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoImplementation)
+	result := map[string]interface{}{
+		"files": applied.Files,
+	}
+	if !req.GetBool("reindex", true) {
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+	reindexed, err := srv.reindexFiles(applied.Files)
+	result["reindexed"] = reindexed
+	if err != nil {
+		// The edits are already on disk; report the stale index instead of failing
+		result["reindex_error"] = err.Error()
+	}
+	return mcp.NewToolResultStructuredOnly(result), nil
 }
 
-func (srv *Server) handleLSPTypeDefinition(
-	ctx context.Context,
-	req mcp.CallToolRequest,
-) (*mcp.CallToolResult, error) {
-	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoTypeDefinition)
-}
+// reindexFiles refreshes the index for files modified outside the indexer so
+// stored symbols and chunks match the new content. Files outside the project
+// or not indexable are skipped.
+func (srv *Server) reindexFiles(files []string) ([]string, error) {
+	if srv.indexer == nil {
+		return nil, nil
+	}
+	matcher, err := ignore.Load(srv.config.Project)
+	if err != nil {
+		return nil, err
+	}
+	absRoot, err := filepath.Abs(srv.config.Project)
+	if err != nil {
+		return nil, err
+	}
 
-func (srv *Server) handleLSPDeclaration(
-	ctx context.Context,
-	req mcp.CallToolRequest,
-) (*mcp.CallToolResult, error) {
-	return srv.handleLSPGoto(ctx, req, (*lsp.ClientTools).GotoDeclaration)
+	var reindexed []string
+	for _, file := range files {
+		ext := filepath.Ext(file)
+		if ext != ".ts" && ext != ".tsx" {
+			continue
+		}
+		rel, err := filepath.Rel(absRoot, file)
+		if err != nil || strings.HasPrefix(rel, "..") || matcher.Ignored(rel, false) {
+			continue
+		}
+		if err := srv.indexer.IndexFileWithRoot(absRoot, file); err != nil {
+			return reindexed, fmt.Errorf("reindex %s: %w", rel, err)
+		}
+		reindexed = append(reindexed, rel)
+	}
+	return reindexed, nil
 }
 
 // AST-grep tool definitions
@@ -455,6 +1615,111 @@ func newAstGrepSearchTool() mcp.Tool {
 				"Comma-separated glob patterns for file inclusion/exclusion. Patterns starting with ! are exclusions.",
 			),
 		),
+		mcp.WithNumber(
+			"timeout_seconds",
+			mcp.Description("Abort ast-grep after this many seconds (default 60)"),
+		),
+	)
+}
+
+func newAstGrepLintTool() mcp.Tool {
+	return mcp.NewTool(
+		"ast_grep_lint",
+		mcp.WithDescription(
+			"Run all ast-grep rules of a lint profile against the project, grouped by rule id",
+		),
+		mcp.WithString(
+			"profile",
+			mcp.Description(
+				"Profile name under .ts-index/lint, or a project directory of ast-grep YAML rules",
+			),
+			mcp.Required(),
+		),
+		mcp.WithNumber(
+			"max_results",
+			mcp.Description("Maximum number of matches per rule"),
+			mcp.DefaultNumber(50),
+		),
+	)
+}
+
+func newAstGrepPresetTool() mcp.Tool {
+	presets := astgrep.Presets()
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		names[i] = preset.Name
+	}
+	return mcp.NewTool(
+		"ast_grep_preset",
+		mcp.WithDescription(
+			"Run a bundled ast-grep rule for a common TypeScript scan; list_presets describes them",
+		),
+		mcp.WithString("preset", mcp.Description("Preset name"), mcp.Enum(names...), mcp.Required()),
+		mcp.WithNumber(
+			"max_results",
+			mcp.Description("Maximum number of matches"),
+			mcp.DefaultNumber(50),
+		),
+	)
+}
+
+func newListPresetsTool() mcp.Tool {
+	return mcp.NewTool(
+		"list_presets",
+		mcp.WithDescription("List the bundled ast-grep presets runnable with ast_grep_preset"),
+	)
+}
+
+func newAstGrepDumpTreeTool() mcp.Tool {
+	return mcp.NewTool(
+		"ast_grep_dump_tree",
+		mcp.WithDescription(
+			"Dump the tree-sitter syntax tree of a code snippet to see node kinds for writing ast-grep patterns",
+		),
+		mcp.WithString("code", mcp.Description("Code snippet to parse"), mcp.Required()),
+		mcp.WithString(
+			"language",
+			mcp.Description("Programming language (typescript, tsx, javascript, etc.)"),
+			mcp.DefaultString("typescript"),
+		),
+		mcp.WithString(
+			"format",
+			mcp.Description("Tree format: ast (named nodes), cst (all nodes), sexp or pattern"),
+			mcp.DefaultString(astgrep.DefaultDumpFormat),
+			mcp.Enum("ast", "cst", "sexp", "pattern"),
+		),
+	)
+}
+
+func newFileSummaryTool() mcp.Tool {
+	return mcp.NewTool(
+		"file_summary",
+		mcp.WithDescription(
+			"Outline a file without reading it: exported symbols with kinds, signatures and IDs, "+
+				"import count and total lines",
+		),
+		mcp.WithString(
+			"file",
+			mcp.Description("File path, absolute or relative to the project"),
+			mcp.Required(),
+		),
+		withLineBase(indexLineBase),
+	)
+}
+
+func newFileIndexStatusTool() mcp.Tool {
+	return mcp.NewTool(
+		"file_index_status",
+		mcp.WithDescription(
+			"Diagnose why a file's symbols are not found: parses the file fresh and compares "+
+				"its symbols with the index, listing missing and stale ones",
+		),
+		mcp.WithString(
+			"file",
+			mcp.Description("File path, absolute or relative to the project"),
+			mcp.Required(),
+		),
+		withLineBase(indexLineBase),
 	)
 }
 
@@ -473,10 +1738,35 @@ func newReadFileTool() mcp.Tool {
 			mcp.Description("End line (1-based, optional)"),
 			mcp.DefaultNumber(0),
 		),
+		mcp.WithNumber(
+			"max_bytes",
+			mcp.Description("Maximum bytes of content to return, at most the 1 MiB default (0 = default)"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithBoolean(
+			"truncate",
+			mcp.Description("Cut content at max_bytes and set truncated instead of failing"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber(
+			"timeout_seconds",
+			mcp.Description("Read timeout in seconds (0 = 10s default)"),
+			mcp.DefaultNumber(0),
+		),
 	)
 }
 
 // AST-grep handlers
+
+// astGrepClient returns an ast-grep client for the project using the
+// configured sgconfig.yml; a non-positive timeout uses the default
+func (srv *Server) astGrepClient(timeout time.Duration) *astgrep.Client {
+	return astgrep.NewClientWithOptions(srv.config.Project, astgrep.Options{
+		Timeout: timeout,
+		Config:  srv.config.AstGrepConfig,
+	})
+}
+
 func (srv *Server) handleAstGrepSearch(
 	ctx context.Context,
 	req mcp.CallToolRequest,
@@ -509,7 +1799,11 @@ func (srv *Server) handleAstGrepSearch(
 		}
 	}
 
-	client := astgrep.NewClient(project)
+	timeout := time.Duration(req.GetInt("timeout_seconds", 0)) * time.Second
+	client := srv.astGrepClient(timeout)
+	if err := client.ValidatePattern(ctx, pattern, language); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	result := client.Search(ctx, astgrep.SearchRequest{
 		Pattern:        pattern,
 		Language:       language,
@@ -524,3 +1818,93 @@ func (srv *Server) handleAstGrepSearch(
 
 	return mcp.NewToolResultStructuredOnly(result), nil
 }
+
+func (srv *Server) handleAstGrepLint(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+
+	profile, err := req.RequireString("profile")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client := srv.astGrepClient(0)
+	result := client.Lint(ctx, astgrep.LintRequest{
+		Profile:    profile,
+		MaxResults: req.GetInt("max_results", 50),
+	})
+
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleAstGrepPreset(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	// Use server config project
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+
+	preset, err := req.RequireString("preset")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client := srv.astGrepClient(0)
+	result := client.SearchPreset(ctx, astgrep.PresetRequest{
+		Preset:     preset,
+		MaxResults: req.GetInt("max_results", 50),
+	})
+
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
+
+func (srv *Server) handleListPresets(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{"presets": astgrep.Presets()}), nil
+}
+
+func (srv *Server) handleAstGrepDumpTree(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	code, err := req.RequireString("code")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client := srv.astGrepClient(0)
+	result := client.DumpSyntaxTree(ctx, astgrep.SyntaxTreeRequest{
+		Code:     code,
+		Language: req.GetString("language", "typescript"),
+		Format:   req.GetString("format", astgrep.DefaultDumpFormat),
+	})
+
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
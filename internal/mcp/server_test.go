@@ -2,9 +2,24 @@ package mcp
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
-
+	"time"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/indexer/pipeline"
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser/tsparser"
+	"github.com/0x5457/ts-index/internal/search"
+	"github.com/0x5457/ts-index/internal/storage/sqlite"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,11 +36,35 @@ func TestToolDefinitions(t *testing.T) {
 		toolName string
 	}{
 		{"semantic_search", newSemanticSearchTool, "semantic_search"},
+		{"search_stats", newSearchStatsTool, "search_stats"},
+		{"symbol_search", newSymbolSearchTool, "symbol_search"},
+		{"get_symbol", newGetSymbolTool, "get_symbol"},
+		{"component_props", newComponentPropsTool, "component_props"},
+		{"unused_exports", newUnusedExportsTool, "unused_exports"},
+		{"find_tests", newFindTestsTool, "find_tests"},
+		{"index_project", newIndexProjectTool, "index_project"},
+		{"index_status", newIndexStatusTool, "index_status"},
+		{"index_cancel", newIndexCancelTool, "index_cancel"},
+		{"file_summary", newFileSummaryTool, "file_summary"},
+		{"file_index_status", newFileIndexStatusTool, "file_index_status"},
+		{"lsp_completion", newLSPCompletionTool, "lsp_completion"},
+		{"lsp_info", newLSPInfoTool, "lsp_info"},
+		{"lsp_raw", newLSPRawTool, "lsp_raw"},
+		{"type_hierarchy", newTypeHierarchyTool, "type_hierarchy"},
 		{"lsp_analyze", newLSPAnalyzeTool, "lsp_analyze"},
 		{"lsp_symbols", newLSPSymbolsTool, "lsp_symbols"},
+		{"file_outline", newFileOutlineTool, "file_outline"},
+		{"diagnose_fix", newDiagnoseFixTool, "diagnose_fix"},
 		{"lsp_implementation", newLSPImplementationTool, "lsp_implementation"},
 		{"lsp_type_definition", newLSPTypeDefinitionTool, "lsp_type_definition"},
 		{"lsp_declaration", newLSPDeclarationTool, "lsp_declaration"},
+		{"lsp_rename", newLSPRenameTool, "lsp_rename"},
+		{"lsp_organize_imports", newLSPOrganizeImportsTool, "lsp_organize_imports"},
+		{"apply_edits", newApplyEditsTool, "apply_edits"},
+		{"ast_grep_lint", newAstGrepLintTool, "ast_grep_lint"},
+		{"ast_grep_dump_tree", newAstGrepDumpTreeTool, "ast_grep_dump_tree"},
+		{"ast_grep_preset", newAstGrepPresetTool, "ast_grep_preset"},
+		{"list_presets", newListPresetsTool, "list_presets"},
 	}
 
 	for _, tt := range tests {
@@ -123,6 +162,24 @@ func TestHandleLSPCompletionError(t *testing.T) {
 	assert.NotEmpty(t, result.Content) // check error content
 }
 
+func TestGetLSPClientToolsShared(t *testing.T) {
+	srv := &Server{}
+	tools := make([]*lsp.ClientTools, 8)
+	var wg sync.WaitGroup
+	for i := range tools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tools[i] = srv.getLSPClientTools()
+		}()
+	}
+	wg.Wait()
+	for _, ct := range tools {
+		require.NotNil(t, ct)
+		assert.Same(t, tools[0], ct, "every handler must get the same client tools")
+	}
+}
+
 func TestHandleLSPSymbolsError(t *testing.T) {
 	ctx := context.Background()
 
@@ -194,3 +251,719 @@ func TestHandleLSPDeclarationError(t *testing.T) {
 	assert.True(t, result.IsError)
 	assert.NotEmpty(t, result.Content) // check error content
 }
+
+func TestHandleLSPRenameError(t *testing.T) {
+	ctx := context.Background()
+
+	// test missing required params
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "lsp_rename",
+			Arguments: map[string]any{},
+		},
+	}
+
+	srv := &Server{searchService: nil, indexer: nil}
+	result, err := srv.handleLSPRename(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.NotEmpty(t, result.Content) // check error content
+}
+
+func TestRenameReindexesModifiedFiles(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	file := filepath.Join(project, "a.ts")
+	src := "export function oldName() {}\nexport const x = oldName()\n"
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 8)
+	require.NoError(t, err)
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{})
+	require.NoError(t, idx.IndexProject(project))
+
+	srv := &Server{indexer: idx, config: ServerConfig{Project: project}}
+	symbolTotal := func(name string) int {
+		result, err := srv.handleSymbolSearch(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "symbol_search",
+				Arguments: map[string]any{"name": name},
+			},
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.StructuredContent.(map[string]interface{})["total"].(int)
+	}
+	require.Equal(t, 1, symbolTotal("oldName"))
+
+	// the workspace edit a language server returns for renaming oldName
+	edit := &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{
+		lsp.PathToURI(file): {
+			{Range: lsp.Range{
+				Start: lsp.Position{Line: 0, Character: 16},
+				End:   lsp.Position{Line: 0, Character: 23},
+			}, NewText: "newName"},
+			{Range: lsp.Range{
+				Start: lsp.Position{Line: 1, Character: 17},
+				End:   lsp.Position{Line: 1, Character: 24},
+			}, NewText: "newName"},
+		},
+	}}
+	files, err := lsp.ApplyWorkspaceEdit(project, edit)
+	require.NoError(t, err)
+	reindexed, err := srv.reindexFiles(files)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.ts"}, reindexed)
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "export function newName() {}\nexport const x = newName()\n", string(content))
+	assert.Equal(t, 1, symbolTotal("newName"))
+	assert.Equal(t, 0, symbolTotal("oldName"))
+}
+
+func TestHandleApplyEdits(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	file := filepath.Join(project, "a.ts")
+	src := "export function oldName() {}\nexport const x = oldName()\n"
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 0)
+	require.NoError(t, err)
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{SymbolsOnly: true})
+	require.NoError(t, idx.IndexProject(project))
+
+	srv := &Server{indexer: idx, config: ServerConfig{Project: project}}
+	applyEdits := func(args map[string]any) *mcp.CallToolResult {
+		result, err := srv.handleApplyEdits(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "apply_edits", Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+	rename := func(line, start, end int) map[string]any {
+		return map[string]any{
+			"range": map[string]any{
+				"start": map[string]any{"line": line, "character": start},
+				"end":   map[string]any{"line": line, "character": end},
+			},
+			"newText": "newName",
+		}
+	}
+
+	// decoded JSON as a client sends it: a WorkspaceEdit plus edits by file
+	result := applyEdits(map[string]any{
+		"edits": map[string]any{
+			"changes": map[string]any{lsp.PathToURI(file): []any{rename(0, 16, 23)}},
+		},
+		"file_edits": []any{map[string]any{"file": "a.ts", "edits": []any{rename(1, 17, 24)}}},
+	})
+	require.False(t, result.IsError, "%v", result.Content)
+	got := result.StructuredContent.(map[string]interface{})
+	assert.Equal(t, []string{file}, got["files"])
+	assert.Equal(t, []string{"a.ts"}, got["reindexed"])
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "export function newName() {}\nexport const x = newName()\n", string(content))
+	hits, err := idx.SearchSymbol("newName")
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	result = applyEdits(map[string]any{})
+	assert.True(t, result.IsError)
+}
+
+func TestHandleGetSymbol(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	src := "/** Adds two numbers. */\nexport function add(a: number, b: number) {\n  return a + b\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "a.ts"), []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 8)
+	require.NoError(t, err)
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{})
+	require.NoError(t, idx.IndexProject(project))
+
+	hits, err := idx.SearchSymbol("add")
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	srv := &Server{indexer: idx}
+	getSymbol := func(id string) *mcp.CallToolResult {
+		result, err := srv.handleGetSymbol(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "get_symbol",
+				Arguments: map[string]any{"id": id},
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := getSymbol(hits[0].Symbol.ID)
+	require.False(t, result.IsError)
+	got := result.StructuredContent.(map[string]interface{})
+	assert.Equal(t, "add", got["name"])
+	assert.Equal(t, models.SymbolFunction, got["kind"])
+	assert.Equal(t, "a.ts", got["file"])
+	assert.Equal(t, int32(2), got["start_line"])
+	assert.Equal(t, int32(4), got["end_line"])
+	assert.Equal(t, "function add(a: number, b: number) {", got["signature"])
+	assert.Contains(t, got["content"], "return a + b")
+	assert.Contains(t, got["docstring"], "Adds two numbers.")
+
+	assert.True(t, getSymbol("missing").IsError)
+
+	// index lines are 1-based by default; line_base 0 matches LSP numbering
+	result, err = srv.handleGetSymbol(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "get_symbol",
+			Arguments: map[string]any{"id": hits[0].Symbol.ID, "line_base": 0},
+		},
+	})
+	require.NoError(t, err)
+	got = result.StructuredContent.(map[string]interface{})
+	assert.Equal(t, int32(1), got["start_line"])
+	assert.Equal(t, int32(3), got["end_line"])
+
+	symbolSearch := func(args map[string]any) *mcp.CallToolResult {
+		result, err := srv.handleSymbolSearch(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "symbol_search", Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+	result = symbolSearch(map[string]any{"name": "add"})
+	symHits := result.StructuredContent.(map[string]interface{})["hits"].([]models.SymbolHit)
+	assert.Equal(t, int32(2), symHits[0].Symbol.StartLine)
+	result = symbolSearch(map[string]any{"name": "add", "line_base": 0})
+	symHits = result.StructuredContent.(map[string]interface{})["hits"].([]models.SymbolHit)
+	assert.Equal(t, int32(1), symHits[0].Symbol.StartLine)
+	assert.True(t, symbolSearch(map[string]any{"name": "add", "line_base": 2}).IsError)
+
+	// rebasing copies, leaving the indexer's results untouched
+	assert.Equal(t, int32(2), hits[0].Symbol.StartLine)
+}
+
+func TestSemanticSearchSymbolFallback(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	src := "export function add(a: number, b: number) {\n  return a + b\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "a.ts"), []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 0)
+	require.NoError(t, err)
+	emb := embeddings.NewLocal(8)
+	idx := pipeline.New(tsparser.New(), emb, sym, vec, pipeline.Options{SymbolsOnly: true})
+	require.NoError(t, idx.IndexProject(project))
+
+	srv := &Server{indexer: idx, searchService: &search.Service{Embedder: emb, Vector: vec}}
+	semanticSearch := func(query string) *mcp.CallToolResult {
+		result, err := srv.handleSemanticSearch(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "semantic_search",
+				Arguments: map[string]any{"query": query},
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := semanticSearch("where is add defined")
+	require.False(t, result.IsError)
+	got := result.StructuredContent.(map[string]interface{})
+	assert.Equal(t, "symbols", got["source"])
+	assert.Contains(t, got["warning"], "no embeddings")
+	hits := got["hits"].([]models.SemanticHit)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "add", hits[0].Chunk.Name)
+
+	// without matching symbols the error explains what is missing
+	result = semanticSearch("multiply")
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no embeddings")
+}
+
+// notifySession is an initialized client session collecting the notifications
+// sent to it
+type notifySession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *notifySession) Initialize()                                         {}
+func (s *notifySession) Initialized() bool                                   { return true }
+func (s *notifySession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *notifySession) SessionID() string                                   { return "test" }
+
+func TestSemanticSearchProgress(t *testing.T) {
+	project := t.TempDir()
+	src := "export function add(a: number, b: number) {\n  return a + b\n}\n\n" +
+		"export function sub(a: number, b: number) {\n  return a - b\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "a.ts"), []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 0)
+	require.NoError(t, err)
+	emb := embeddings.NewLocal(8)
+	idx := pipeline.New(tsparser.New(), emb, sym, vec, pipeline.Options{})
+	require.NoError(t, idx.IndexProject(project))
+	srv := &Server{indexer: idx, searchService: &search.Service{Embedder: emb, Vector: vec}}
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(newSemanticSearchTool(), srv.handleSemanticSearch)
+	session := &notifySession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	require.NoError(t, mcpServer.RegisterSession(context.Background(), session))
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"semantic_search",` +
+		`"arguments":{"query":"add","top_k":2,"line_base":0},"_meta":{"progressToken":"search-1"}}}`
+	response, ok := mcpServer.HandleMessage(ctx, []byte(request)).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result := response.Result.(mcp.CallToolResult)
+	require.False(t, result.IsError)
+	hits := result.StructuredContent.(map[string]interface{})["hits"].([]models.SemanticHit)
+	require.Len(t, hits, 2)
+
+	// every hit was sent ahead of the result, in order and with the same lines
+	for i, hit := range hits {
+		var n mcp.JSONRPCNotification
+		select {
+		case n = <-session.notifications:
+		default:
+			t.Fatalf("missing progress notification %d", i+1)
+		}
+		assert.Equal(t, "notifications/progress", n.Method)
+		fields := n.Params.AdditionalFields
+		assert.Equal(t, "search-1", fields["progressToken"])
+		assert.Equal(t, i+1, fields["progress"])
+		assert.Equal(t, hit, fields["_meta"].(map[string]any)["hit"])
+	}
+}
+
+func TestSearchLSPFormat(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	src := "const x = 1\n\nexport function add(a: number, b: number) {\n  return a + b\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "a.ts"), []byte(src), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 0)
+	require.NoError(t, err)
+	emb := embeddings.NewLocal(8)
+	idx := pipeline.New(tsparser.New(), emb, sym, vec, pipeline.Options{SymbolsOnly: true})
+	require.NoError(t, idx.IndexProject(project))
+
+	srv := &Server{
+		indexer:       idx,
+		searchService: &search.Service{Embedder: emb, Vector: vec},
+		config:        ServerConfig{Project: project},
+	}
+	want := []lsp.Location{{
+		URI: lsp.PathToURI(filepath.Join(project, "a.ts")),
+		Range: lsp.Range{
+			Start: lsp.Position{Line: 2},
+			End:   lsp.Position{Line: 5},
+		},
+	}}
+
+	result, err := srv.handleSymbolSearch(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "symbol_search",
+			Arguments: map[string]any{"name": "add", "format": "lsp", "line_base": 1},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	got := result.StructuredContent.(map[string]interface{})
+	assert.Equal(t, want, got["locations"])
+	assert.NotContains(t, got, "hits")
+
+	// the symbol fallback of semantic_search is formatted the same way
+	result, err = srv.handleSemanticSearch(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "semantic_search",
+			Arguments: map[string]any{"query": "add", "format": "lsp"},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, want, result.StructuredContent.(map[string]interface{})["locations"])
+
+	result, err = srv.handleSymbolSearch(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "symbol_search",
+			Arguments: map[string]any{"name": "add", "format": "vim"},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandleComponentProps(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	types := "export interface ButtonProps {\n  label: string\n}\n"
+	ui := "import { ButtonProps } from './types'\n\n" +
+		"export function Button({ label }: ButtonProps) {\n  return <button>{label}</button>\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "types.ts"), []byte(types), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "ui.tsx"), []byte(ui), 0o644))
+
+	db := filepath.Join(t.TempDir(), "index.db")
+	sym, err := sqlite.New(db)
+	require.NoError(t, err)
+	vec, err := sqlvec.New(db, 0)
+	require.NoError(t, err)
+	idx := pipeline.New(tsparser.New(), embeddings.NewLocal(8), sym, vec, pipeline.Options{SymbolsOnly: true})
+	require.NoError(t, idx.IndexProject(project))
+
+	srv := &Server{indexer: idx, config: ServerConfig{Project: project}}
+	componentProps := func(args map[string]any) []componentPropsHit {
+		result, err := srv.handleComponentProps(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "component_props", Arguments: args},
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.StructuredContent.(map[string]interface{})["components"].([]componentPropsHit)
+	}
+
+	hits := componentProps(map[string]any{"name": "Button", "line_base": 0})
+	require.Len(t, hits, 1)
+	assert.Equal(t, models.ComponentProps{
+		Component: "Button",
+		File:      "ui.tsx",
+		StartLine: 2,
+		EndLine:   4,
+		PropsType: "ButtonProps",
+	}, hits[0].ComponentProps)
+	require.Len(t, hits[0].PropsSymbols, 1)
+	assert.Equal(t, "types.ts", hits[0].PropsSymbols[0].File)
+	assert.Equal(t, int32(0), hits[0].PropsSymbols[0].StartLine)
+
+	// reindexing a file replaces its components
+	ui = "export const Button = (props: ButtonProps & { icon: string }) => null\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "ui.tsx"), []byte(ui), 0o644))
+	require.NoError(t, idx.IndexFileWithRoot(project, filepath.Join(project, "ui.tsx")))
+	hits = componentProps(map[string]any{"name": "Button"})
+	require.Len(t, hits, 1)
+	assert.Equal(t, int32(1), hits[0].StartLine)
+	assert.Equal(t, "ButtonProps & { icon: string }", hits[0].PropsType)
+	assert.Empty(t, hits[0].PropsSymbols)
+}
+
+func TestClassifyReferences(t *testing.T) {
+	project := t.TempDir()
+	files := map[string]string{
+		"a.ts":     "export function a(n: number): number {\n  return n ? a(n - 1) : 0\n}\nconst b = a(1)\n",
+		"index.ts": "export { a } from './a'\n",
+		"use.ts":   "import { a } from './a'\n",
+	}
+	for name, src := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(project, name), []byte(src), 0o644))
+	}
+	sources := newSourceCache(project)
+	sym := models.Symbol{Name: "a", File: "a.ts", StartLine: 1, EndLine: 3, Exported: true}
+
+	pos, ok := sources.namePosition(sym)
+	require.True(t, ok)
+	assert.Equal(t, lsp.Position{Line: 0, Character: 16}, pos)
+
+	ref := func(file string, line int) lsp.LocationResult {
+		return lsp.LocationResult{URI: file, Range: lsp.Range{Start: lsp.Position{Line: line}}}
+	}
+	// the declaration, a recursive call, a use in the file and a re-export
+	refs := []lsp.LocationResult{ref("a.ts", 0), ref("a.ts", 1), ref("a.ts", 3), ref("index.ts", 0)}
+	u, used := classifyReferences(sym, refs, sources.line)
+	require.False(t, used)
+	assert.True(t, u.UsedInFile)
+	assert.Equal(t, []string{"index.ts"}, u.ReExportedIn)
+
+	u, used = classifyReferences(sym, refs[:2], sources.line)
+	require.False(t, used)
+	assert.False(t, u.UsedInFile)
+	assert.Empty(t, u.ReExportedIn)
+
+	_, used = classifyReferences(sym, append(refs, ref("use.ts", 0)), sources.line)
+	assert.True(t, used)
+
+	assert.Equal(t, 6, nameOffset("const ab = 1, a = ab", "ab"))
+	assert.Equal(t, 14, nameOffset("const ab = 1, a = ab", "a"))
+	assert.Equal(t, -1, nameOffset("const $a = 1", "a"))
+}
+
+func TestEnclosingTest(t *testing.T) {
+	project := t.TempDir()
+	src := "import { add } from '../src/add'\n" +
+		"\n" +
+		"describe('math', () => {\n" +
+		"  it('adds', () => {\n" +
+		"    expect(add(1, 2)).toBe(3)\n" +
+		"  })\n" +
+		"  describe.each([1])('nested', () => {\n" +
+		"    test('one', () => expect(add(1, 0)).toBe(1))\n" +
+		"    const x = add(0, 0)\n" +
+		"  })\n" +
+		"})\n" +
+		"const top = add(2, 2)\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "add.test.ts"), []byte(src), 0o644))
+	sources := newSourceCache(project)
+	line := func(n int) *int { return &n }
+
+	ref := enclosingTest(sources, "add.test.ts", lsp.Position{Line: 4, Character: 11}, 1)
+	assert.Equal(t, testReference{
+		File: "add.test.ts", Line: 5, Character: 11, Test: "adds", TestLine: line(4), Suites: []string{"math"},
+	}, ref)
+
+	ref = enclosingTest(sources, "add.test.ts", lsp.Position{Line: 7, Character: 29}, 0)
+	assert.Equal(t, "one", ref.Test)
+	assert.Equal(t, line(7), ref.TestLine)
+	assert.Equal(t, []string{"math", "nested"}, ref.Suites)
+
+	// outside any test, after a sibling test ended
+	ref = enclosingTest(sources, "add.test.ts", lsp.Position{Line: 8, Character: 14}, 0)
+	assert.Empty(t, ref.Test)
+	assert.Equal(t, []string{"math", "nested"}, ref.Suites)
+
+	ref = enclosingTest(sources, "add.test.ts", lsp.Position{Line: 11, Character: 12}, 0)
+	assert.Empty(t, ref.Test)
+	assert.Empty(t, ref.Suites)
+
+	assert.True(t, importLinePattern.MatchString(sources.line("add.test.ts", 0)))
+}
+
+func TestHandleFileSummary(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	src := "import { b } from './b'\n\nexport function add(a: number, b: number) {\n  return a + b\n}\n" +
+		"const hidden = 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project, "a.ts"), []byte(src), 0o644))
+
+	srv := &Server{config: ServerConfig{Project: project}}
+	summarize := func(args map[string]any) *mcp.CallToolResult {
+		result, err := srv.handleFileSummary(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "file_summary", Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := summarize(map[string]any{"file": "a.ts"})
+	require.False(t, result.IsError)
+	summary := result.StructuredContent.(*models.FileSummary)
+	assert.Equal(t, "a.ts", summary.File)
+	assert.Equal(t, 6, summary.Lines)
+	assert.Equal(t, 1, summary.Imports)
+	require.Len(t, summary.Exports, 1)
+	assert.Equal(t, "add", summary.Exports[0].Name)
+	assert.Equal(t, "function add(a: number, b: number) {", summary.Exports[0].Signature)
+	assert.Equal(t, int32(3), summary.Exports[0].StartLine)
+	assert.NotEmpty(t, summary.Exports[0].ID)
+
+	result = summarize(map[string]any{"file": "a.ts", "line_base": 0})
+	summary = result.StructuredContent.(*models.FileSummary)
+	assert.Equal(t, int32(2), summary.Exports[0].StartLine)
+
+	assert.True(t, summarize(map[string]any{"file": "missing.ts"}).IsError)
+}
+
+// blockingIndexer reports one parse step, then blocks until its context ends
+type blockingIndexer struct {
+	indexer.Indexer
+}
+
+func (blockingIndexer) IndexProjectProgress(
+	ctx context.Context,
+	_ string,
+) (<-chan models.IndexProgress, <-chan error) {
+	progCh := make(chan models.IndexProgress, 1)
+	errCh := make(chan error, 1)
+	progCh <- models.IndexProgress{Stage: models.IndexStageParse, TotalFiles: 3, ParsedFiles: 1}
+	go func() {
+		defer close(progCh)
+		defer close(errCh)
+		<-ctx.Done()
+		errCh <- ctx.Err()
+	}()
+	return progCh, errCh
+}
+
+func TestIndexJobs(t *testing.T) {
+	ctx := context.Background()
+	srv := &Server{indexer: blockingIndexer{}, config: ServerConfig{Project: t.TempDir()}}
+	call := func(handler server.ToolHandlerFunc, args map[string]any) *mcp.CallToolResult {
+		result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call(srv.handleIndexProject, map[string]any{"background": true})
+	require.False(t, result.IsError)
+	started := result.StructuredContent.(IndexJobStatus)
+	assert.Equal(t, jobRunning, started.State)
+
+	// one job per project at a time
+	assert.True(t, call(srv.handleIndexProject, map[string]any{"background": true}).IsError)
+
+	require.Eventually(t, func() bool {
+		status := call(srv.handleIndexStatus, map[string]any{"job_id": started.ID})
+		return status.StructuredContent.(IndexJobStatus).ParsedFiles == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	result = call(srv.handleIndexCancel, map[string]any{"job_id": started.ID})
+	require.False(t, result.IsError)
+	canceled := result.StructuredContent.(IndexJobStatus)
+	assert.Equal(t, jobCanceled, canceled.State)
+	assert.NotNil(t, canceled.FinishedAt)
+
+	list := call(srv.handleIndexStatus, nil).StructuredContent.(map[string]interface{})
+	assert.Len(t, list["jobs"], 1)
+	assert.True(t, call(srv.handleIndexStatus, map[string]any{"job_id": "missing"}).IsError)
+
+	// a foreground run canceled from another call reports the cancellation
+	done := make(chan *mcp.CallToolResult)
+	go func() { done <- call(srv.handleIndexProject, nil) }()
+	require.Eventually(t, func() bool { return srv.jobs.get("2") != nil }, 5*time.Second, 10*time.Millisecond)
+	call(srv.handleIndexCancel, map[string]any{"job_id": "2"})
+	assert.True(t, (<-done).IsError)
+}
+
+func TestServerShutdown(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(nil, blockingIndexer{}, ServerConfig{})
+	srv.config.Project = t.TempDir()
+	require.NotNil(t, srv.getLSPClientTools())
+
+	result, err := srv.handleIndexProject(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]any{"background": true}},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	id := result.StructuredContent.(IndexJobStatus).ID
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(shutdownCtx))
+	assert.Equal(t, jobCanceled, srv.jobs.get(id).snapshot().State, "running jobs must stop with the server")
+	assert.Nil(t, srv.lspClientTools, "language servers must be released")
+}
+
+func TestRebaseSemanticHits(t *testing.T) {
+	hits := []models.SemanticHit{{Chunk: models.CodeChunk{StartLine: 1, EndLine: 4}}}
+	assert.Equal(t, hits, rebaseSemanticHits(hits, indexLineBase))
+	rebased := rebaseSemanticHits(hits, lspLineBase)
+	assert.Equal(t, int32(0), rebased[0].Chunk.StartLine)
+	assert.Equal(t, int32(3), rebased[0].Chunk.EndLine)
+	assert.Equal(t, int32(1), hits[0].Chunk.StartLine)
+}
+
+func TestIsQueryRejection(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{msg: "jsonrpc2: code -32602 message: Invalid params", want: true},
+		{msg: "request failed: invalid params: query must not be empty", want: true},
+		{msg: "workspace/symbol: Empty query", want: true},
+		{msg: "the query is empty", want: true},
+		{msg: "failed to get language server: exec: \"typescript-language-server\": not found"},
+		{msg: "failed to get language server: initialize: invalid params"},
+		{msg: "jsonrpc2: code -32603 message: internal error"},
+		{msg: "context deadline exceeded"},
+		{msg: ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isQueryRejection(tt.msg), "%q", tt.msg)
+	}
+}
+
+func TestFindWarmupFile(t *testing.T) {
+	root := t.TempDir()
+	assert.Empty(t, findWarmupFile(root))
+
+	for _, file := range []string{
+		"node_modules/pkg/index.ts",
+		"dist/index.ts",
+		"generated/api.ts",
+		"src/README.md",
+		"src/app/main.tsx",
+	} {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("export {}\n"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("generated/\n"), 0o644))
+
+	assert.Equal(t, filepath.Join(root, "src", "app", "main.tsx"), findWarmupFile(root),
+		"ignored directories must be skipped")
+}
+
+func TestWarmUpLSPServerFailure(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.ts"), []byte("export {}\n"), 0o644))
+	srv := &Server{config: ServerConfig{Project: root}, lspClientTools: lsp.NewClientTools()}
+	srv.lspClientTools.RegisterAdapter("typescript", &missingLSPAdapter{lsp.NewTypeScriptLspAdapter()})
+	defer func() { _ = srv.lspClientTools.Cleanup() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := srv.warmUpLSP(ctx)
+	require.Error(t, err, "a server that cannot start must fail the warm-up")
+	assert.Contains(t, err.Error(), "failed to get language server")
+}
+
+// missingLSPAdapter points at a language server binary that does not exist
+type missingLSPAdapter struct {
+	*lsp.TypeScriptLspAdapter
+}
+
+func (a *missingLSPAdapter) ServerCommand(string) (string, []string, error) {
+	return "/nonexistent/typescript-language-server", nil, nil
+}
+
+func (a *missingLSPAdapter) IsInstalled() bool { return true }
+
+func TestHandleReadFileByteCap(t *testing.T) {
+	project := t.TempDir()
+	content := strings.Repeat("a", lsp.DefaultReadFileMaxBytes+10)
+	require.NoError(t, os.WriteFile(filepath.Join(project, "big.js"), []byte(content), 0o644))
+	srv := &Server{config: ServerConfig{Project: project}}
+	read := func(args map[string]any) *mcp.CallToolResult {
+		args["file_path"] = "big.js"
+		result, err := srv.handleReadFile(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "read_file", Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// clients can lower the cap but neither lift nor raise it
+	for _, maxBytes := range []int{-1, 0, 1 << 30} {
+		result := read(map[string]any{"max_bytes": maxBytes})
+		require.True(t, result.IsError, "max_bytes %d", maxBytes)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "read limit")
+	}
+	result := read(map[string]any{"max_bytes": 1 << 30, "truncate": true})
+	require.False(t, result.IsError, "%v", result.Content)
+	got := result.StructuredContent.(lsp.ReadFileResponse)
+	assert.Len(t, got.Content, lsp.DefaultReadFileMaxBytes)
+	assert.True(t, got.Truncated)
+	result = read(map[string]any{"max_bytes": 4, "truncate": true})
+	assert.Equal(t, "aaaa", result.StructuredContent.(lsp.ReadFileResponse).Content)
+}
@@ -1,9 +1,13 @@
 package mcp
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -125,3 +129,89 @@ func TestInProcessTransport(t *testing.T) {
 		t.Fatalf("expected some tools")
 	}
 }
+
+// TestGzipStreamableHTTP verifies compressed responses and a full client
+// session through GzipHandler
+func TestGzipStreamableHTTP(t *testing.T) {
+	s := New(nil, nil, ServerConfig{})
+	ts := httptest.NewServer(GzipHandler(server.NewStreamableHTTPServer(s)))
+	t.Cleanup(ts.Close)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"` +
+		mcp.LATEST_PROTOCOL_VERSION + `","clientInfo":{"name":"test","version":"0.0.1"},"capabilities":{}}}`
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	// set explicitly so the transport does not decompress transparently
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post initialize: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	var decoded struct {
+		Result struct {
+			ServerInfo mcp.Implementation `json:"serverInfo"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded.Result.ServerInfo.Name == "" {
+		t.Fatalf("unexpected initialize response %s: %v", raw, err)
+	}
+
+	cliTr, err := transport.NewStreamableHTTP(ts.URL)
+	if err != nil {
+		t.Fatalf("new streamable http: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	cli := client.NewClient(cliTr)
+	if err := cli.Start(ctx); err != nil {
+		t.Fatalf("start client: %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "0.0.1"}
+	if _, err := cli.Initialize(ctx, initReq); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	res, err := cli.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	if len(res.Tools) == 0 {
+		t.Fatalf("expected some tools")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	cases := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"GZIP;q=0.5":        true,
+		"gzip;q=0":          false,
+		"br, gzip; q=0.000": false,
+		"identity":          false,
+	}
+	for header, want := range cases {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTypeHierarchyTool() mcp.Tool {
+	return mcp.NewTool(
+		"type_hierarchy",
+		mcp.WithDescription(
+			"Show the type hierarchy of the class or interface at a position via LSP: the types it extends "+
+				"or implements and the types extending or implementing it, over several levels",
+		),
+		mcp.WithString("file", mcp.Description("File path"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line, 0-based unless line_base is 1"), mcp.Required()),
+		mcp.WithNumber("character", mcp.Description("0-based character"), mcp.Required()),
+		mcp.WithString(
+			"direction",
+			mcp.Description("Which relatives to follow (default: both)"),
+			mcp.Enum(lsp.TypeHierarchyBoth, lsp.TypeHierarchySupertypes, lsp.TypeHierarchySubtypes),
+		),
+		mcp.WithNumber(
+			"depth",
+			mcp.Description(fmt.Sprintf("Levels to follow in each direction (default: %d)",
+				lsp.DefaultTypeHierarchyDepth)),
+		),
+		mcp.WithBoolean(
+			"relative_paths",
+			mcp.Description("Report locations as project-relative paths (absolute URI kept)"),
+			mcp.DefaultBool(false),
+		),
+		withLineBase(lspLineBase),
+	)
+}
+
+func (srv *Server) handleTypeHierarchy(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	file, err := req.RequireString("file")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line, err := req.RequireInt("line")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ch, err := req.RequireInt("character")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lineBase, err := getLineBase(req, lspLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+
+	result := clientTools.TypeHierarchy(ctx, lsp.TypeHierarchyRequest{
+		WorkspaceRoot: project,
+		FilePath:      file,
+		Line:          line,
+		Character:     ch,
+		Direction:     req.GetString("direction", lsp.TypeHierarchyBoth),
+		Depth:         req.GetInt("depth", lsp.DefaultTypeHierarchyDepth),
+		RelativePaths: req.GetBool("relative_paths", false),
+		LineBase:      lineBase,
+	})
+	if result.Error != "" {
+		return mcp.NewToolResultError(result.Error), nil
+	}
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
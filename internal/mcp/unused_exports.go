@@ -0,0 +1,242 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/imports"
+	"github.com/0x5457/ts-index/internal/indexer"
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultUnusedExportsMax caps the symbols unused_exports asks the language
+// server about, one references request each
+const defaultUnusedExportsMax = 200
+
+// exportClausePattern matches lines that only export a name declared
+// elsewhere: export lists, re-exports and export default of an identifier
+var exportClausePattern = regexp.MustCompile(`^export\s+(type\s+)?(\{|\*)|^export\s+default\s+[A-Za-z_$][\w$]*\s*;?$`)
+
+// unusedExport is an exported symbol nothing else in the workspace uses
+type unusedExport struct {
+	models.Symbol
+	// UsedInFile is set when the declaring file uses the symbol, so only its
+	// export can be removed
+	UsedInFile bool `json:"used_in_file"`
+	// EntryPoint is set when the declaring file is a workspace package entry
+	// point, whose exports are used outside the workspace
+	EntryPoint bool `json:"entry_point"`
+	// ReExportedIn lists the files re-exporting the symbol
+	ReExportedIn []string `json:"re_exported_in,omitempty"`
+}
+
+func newUnusedExportsTool() mcp.Tool {
+	return mcp.NewTool(
+		"unused_exports",
+		mcp.WithDescription(
+			"List indexed exported symbols that no other file references, as candidates for removal. "+
+				"References come from the language server; results in package entry points or "+
+				"re-exported by other files may still be used outside the workspace and are flagged.",
+		),
+		mcp.WithString("dir", mcp.Description("Directory relative to the project (default: whole project)")),
+		mcp.WithNumber(
+			"max_symbols",
+			mcp.Description(fmt.Sprintf("Maximum number of exported symbols to check (default: %d)",
+				defaultUnusedExportsMax)),
+		),
+		withLineBase(indexLineBase),
+	)
+}
+
+func (srv *Server) handleUnusedExports(
+	ctx context.Context,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	project := srv.config.Project
+	if project == "" {
+		return mcp.NewToolResultError(
+			"workspace path must be specified in server configuration",
+		), nil
+	}
+	lineBase, err := getLineBase(req, indexLineBase)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lister, ok := srv.indexer.(indexer.ExportedSymbolLister)
+	if !ok {
+		return mcp.NewToolResultError("indexer cannot list exported symbols"), nil
+	}
+	clientTools := srv.getLSPClientTools()
+	if clientTools == nil {
+		return mcp.NewToolResultError("LSP client not available"), nil
+	}
+	absRoot, err := filepath.Abs(project)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	dir := req.GetString("dir", "")
+	symbols, err := lister.ExportedSymbols(dir)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	checked := symbols
+	if limit := req.GetInt("max_symbols", defaultUnusedExportsMax); limit > 0 && len(checked) > limit {
+		checked = checked[:limit]
+	}
+	resolver, err := imports.NewResolver(absRoot)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	entryPoints := make(map[string]bool)
+	for _, file := range resolver.EntryPoints() {
+		entryPoints[file] = true
+	}
+
+	sources := newSourceCache(absRoot)
+	shift := int32(lineBase - indexLineBase)
+	unused := []unusedExport{}
+	for _, sym := range checked {
+		if err := ctx.Err(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		pos, ok := sources.namePosition(sym)
+		if !ok {
+			continue
+		}
+		analyzed := clientTools.AnalyzeSymbol(ctx, lsp.AnalyzeSymbolRequest{
+			WorkspaceRoot: absRoot,
+			FilePath:      sym.File,
+			Line:          pos.Line,
+			Character:     pos.Character,
+			IncludeRefs:   true,
+			RelativePaths: true,
+		})
+		if analyzed.Error != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("%s in %s: %s", sym.Name, sym.File, analyzed.Error)), nil
+		}
+		u, used := classifyReferences(sym, analyzed.References, sources.line)
+		if used {
+			continue
+		}
+		u.EntryPoint = entryPoints[sym.File]
+		u.StartLine += shift
+		u.EndLine += shift
+		unused = append(unused, u)
+	}
+
+	return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+		"dir":       dir,
+		"exported":  len(symbols),
+		"checked":   len(checked),
+		"truncated": len(checked) < len(symbols),
+		"unused":    unused,
+	}), nil
+}
+
+// classifyReferences sorts the 0-based references to the exported sym. used
+// is set by any reference from another file other than a re-export; otherwise
+// the returned unusedExport records re-exports and uses in the declaring file.
+// References inside the declaration itself, such as recursive calls, are skipped.
+func classifyReferences(
+	sym models.Symbol,
+	refs []lsp.LocationResult,
+	line func(file string, line int) string,
+) (unusedExport, bool) {
+	u := unusedExport{Symbol: sym}
+	for _, ref := range refs {
+		file := filepath.ToSlash(ref.URI)
+		refLine := ref.Range.Start.Line
+		if file == sym.File && refLine+1 >= int(sym.StartLine) && refLine+1 <= int(sym.EndLine) {
+			continue
+		}
+		if exportClausePattern.MatchString(strings.TrimSpace(line(file, refLine))) {
+			if file != sym.File && !slices.Contains(u.ReExportedIn, file) {
+				u.ReExportedIn = append(u.ReExportedIn, file)
+			}
+			continue
+		}
+		if file != sym.File {
+			return unusedExport{}, true
+		}
+		u.UsedInFile = true
+	}
+	return u, false
+}
+
+// sourceCache reads project files once for the lines unused_exports looks up
+type sourceCache struct {
+	root  string
+	lines map[string][]string
+}
+
+func newSourceCache(root string) *sourceCache {
+	return &sourceCache{root: root, lines: make(map[string][]string)}
+}
+
+// line returns the 0-based line n of file, or "" if there is none
+func (c *sourceCache) line(file string, n int) string {
+	lines, ok := c.lines[file]
+	if !ok {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.root, filepath.FromSlash(file))
+		}
+		data, _ := os.ReadFile(path)
+		lines = strings.Split(string(data), "\n")
+		c.lines[file] = lines
+	}
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return lines[n]
+}
+
+// namePosition returns the 0-based position of the first whole-word
+// occurrence of sym's name in its declaration lines
+func (c *sourceCache) namePosition(sym models.Symbol) (lsp.Position, bool) {
+	for n := int(sym.StartLine) - 1; n < int(sym.EndLine); n++ {
+		text := c.line(sym.File, n)
+		offset := nameOffset(text, sym.Name)
+		if offset < 0 {
+			continue
+		}
+		pos, err := lsp.ByteOffsetToPosition(text, offset)
+		if err != nil {
+			return lsp.Position{}, false
+		}
+		return lsp.Position{Line: n, Character: pos.Character}, true
+	}
+	return lsp.Position{}, false
+}
+
+// nameOffset returns the byte offset of the first occurrence of name in
+// content that is not part of a longer identifier, or -1
+func nameOffset(content, name string) int {
+	if name == "" {
+		return -1
+	}
+	for from := 0; ; {
+		i := strings.Index(content[from:], name)
+		if i < 0 {
+			return -1
+		}
+		at := from + i
+		end := at + len(name)
+		if (at == 0 || !isIdentByte(content[at-1])) && (end == len(content) || !isIdentByte(content[end])) {
+			return at
+		}
+		from = at + 1
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
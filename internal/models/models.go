@@ -1,6 +1,11 @@
 package models
 
-import "github.com/0x5457/ts-index/internal/lsp"
+import (
+	"strconv"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+)
 
 // Use SymbolKind from lsp package
 type SymbolKind = lsp.SymbolKind
@@ -14,8 +19,42 @@ const (
 	SymbolType      = lsp.SymbolKindStruct // Using struct for type
 	SymbolEnum      = lsp.SymbolKindEnum
 	SymbolVariable  = lsp.SymbolKindVariable
+	SymbolModule    = lsp.SymbolKindModule    // declare module "name"
+	SymbolNamespace = lsp.SymbolKindNamespace // namespace N
+
+	// SymbolEnumMember symbols are named after their enum, e.g. "Status.Active"
+	SymbolEnumMember = lsp.SymbolKindEnumMember
+	// SymbolCall marks call site chunks; it is outside the LSP kind range
+	SymbolCall SymbolKind = 100
 )
 
+// SymbolKindName names kind as StringToSymbolKind spells it, or by its
+// numeric LSP value for kinds without a name
+func SymbolKindName(kind SymbolKind) string {
+	switch kind {
+	case SymbolFunction:
+		return "function"
+	case SymbolMethod:
+		return "method"
+	case SymbolClass:
+		return "class"
+	case SymbolInterface:
+		return "interface"
+	case SymbolType:
+		return "type"
+	case SymbolEnum:
+		return "enum"
+	case SymbolEnumMember:
+		return "enum_member"
+	case SymbolVariable:
+		return "variable"
+	case SymbolCall:
+		return "call"
+	default:
+		return strconv.Itoa(int(kind))
+	}
+}
+
 // StringToSymbolKind converts string to SymbolKind
 func StringToSymbolKind(s string) SymbolKind {
 	switch s {
@@ -31,9 +70,17 @@ func StringToSymbolKind(s string) SymbolKind {
 		return SymbolType
 	case "enum":
 		return SymbolEnum
+	case "enum_member":
+		return SymbolEnumMember
 	case "variable":
 		return SymbolVariable
+	case "call":
+		return SymbolCall
 	default:
+		// stores persist kinds as their numeric LSP value
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return SymbolKind(n)
+		}
 		return lsp.SymbolKindVariable // default fallback
 	}
 }
@@ -45,11 +92,28 @@ type Symbol struct {
 	File      string
 	Language  string
 	NodeType  string
-	StartLine int32
-	EndLine   int32
+	StartLine int32 // 1-based
+	EndLine   int32 // 1-based, inclusive
 	StartByte int32
 	EndByte   int32
 	Docstring string
+	// Exported is set on declarations made in an export statement
+	Exported bool
+	// Ambient is set on declarations made with declare, inside a declare
+	// block, or in a .d.ts file
+	Ambient bool
+	// ConstEnum is set on const enums, whose members are inlined at use sites
+	ConstEnum bool
+}
+
+// PositionSymbol is a symbol enclosing a position in a file
+type PositionSymbol struct {
+	Symbol
+	// Signature is the first line of the declaration
+	Signature string
+	// Parsed is set when the symbol comes from parsing the file instead of
+	// from the index
+	Parsed bool
 }
 
 type CodeChunk struct {
@@ -57,8 +121,8 @@ type CodeChunk struct {
 	File      string
 	Language  string
 	NodeType  string
-	StartLine int32
-	EndLine   int32
+	StartLine int32 // 1-based
+	EndLine   int32 // 1-based, inclusive
 	StartByte int32
 	EndByte   int32
 	Content   string
@@ -66,17 +130,87 @@ type CodeChunk struct {
 	Signature string
 	Kind      SymbolKind
 	Name      string
+	// LastCommit is the commit that last modified File, when recorded at index time
+	LastCommit string `json:"LastCommit,omitempty"`
 }
 
 type SemanticHit struct {
 	Chunk CodeChunk
+	// Score is higher for more similar chunks; an identical chunk scores 1
 	Score float32
+	// Source names the index a hit came from in federated searches
+	Source string `json:"Source,omitempty"`
 }
 
 type SymbolHit struct {
 	Symbol Symbol
 }
 
+// SymbolDetail is a stored symbol together with its indexed chunk.
+// Chunk is nil when the symbol was indexed without embeddings.
+type SymbolDetail struct {
+	Symbol Symbol
+	Chunk  *CodeChunk
+}
+
+// FileSummary is a structural outline of a source file built by the parser
+type FileSummary struct {
+	File     string
+	Language string
+	Lines    int
+	Imports  int // import statements
+	Exports  []ExportedSymbol
+}
+
+// ExportedSymbol is a declaration a file exports. ID matches the indexed symbol
+// for local declarations; re-exports from other modules set From instead.
+type ExportedSymbol struct {
+	ID        string `json:"ID,omitempty"`
+	Name      string
+	Kind      SymbolKind
+	Signature string
+	StartLine int32  // 1-based
+	EndLine   int32  // 1-based, inclusive
+	Default   bool   `json:"Default,omitempty"`
+	From      string `json:"From,omitempty"`
+}
+
+// ComponentProps links an exported React component to the type of its props,
+// as written in the source, e.g. "ButtonProps" or "Props<T>"
+type ComponentProps struct {
+	Component string `json:"component"`
+	File      string `json:"file"`
+	StartLine int32  `json:"start_line"` // 1-based
+	EndLine   int32  `json:"end_line"`   // 1-based, inclusive
+	PropsType string `json:"props_type"`
+}
+
+// IndexProvenance records the version of the source an index was built from
+type IndexProvenance struct {
+	Commit    string    `json:"commit"`
+	Dirty     bool      `json:"dirty"` // tracked files had uncommitted changes
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// EmbeddingModel records the embedder an index was built with
+type EmbeddingModel struct {
+	Name      string `json:"name"`
+	Dimension int    `json:"dimension"` // of the embeddings, before any reduction
+}
+
+// FileIndexStatus compares a freshly parsed file with its symbols in the index.
+// Missing symbols are in the file but not stored; stale ones are stored but no
+// longer match the file, e.g. after the declaration moved or was removed.
+type FileIndexStatus struct {
+	File     string   `json:"file"`
+	Indexed  bool     `json:"indexed"` // the index has symbols for the file
+	UpToDate bool     `json:"up_to_date"`
+	Parsed   int      `json:"parsed"`
+	Stored   int      `json:"stored"`
+	Missing  []Symbol `json:"missing,omitempty"`
+	Stale    []Symbol `json:"stale,omitempty"`
+}
+
 // Index progress and stages
 type IndexStage string
 
@@ -98,6 +232,8 @@ type IndexProgress struct {
 	CurrentFile    string
 	Message        string
 	Percent        float32
+	// SkippedFiles counts the generated files left out of TotalFiles
+	SkippedFiles int
 }
 
 // LSPHoverInfo represents hover information from LSP
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/0x5457/ts-index/internal/logging"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DecodeSource converts file bytes to the UTF-8 text tree-sitter expects.
+// A UTF-8 BOM is stripped, BOM-marked UTF-16 is transcoded, and other invalid
+// UTF-8 is read as Latin-1 so every byte maps to one character. Byte offsets
+// reported for the file are relative to the decoded text.
+func DecodeSource(path string, code []byte) []byte {
+	switch {
+	case bytes.HasPrefix(code, bomUTF8):
+		return code[len(bomUTF8):]
+	case bytes.HasPrefix(code, bomUTF16LE):
+		return decodeUTF16(code[len(bomUTF16LE):], false)
+	case bytes.HasPrefix(code, bomUTF16BE):
+		return decodeUTF16(code[len(bomUTF16BE):], true)
+	case utf8.Valid(code):
+		return code
+	}
+	logging.L().Warn("file is not valid UTF-8; decoding it as Latin-1", "file", path)
+	out := make([]byte, 0, len(code)+len(code)/4)
+	for _, b := range code {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return out
+}
+
+// decodeUTF16 transcodes UTF-16 text to UTF-8; a trailing odd byte is dropped
+func decodeUTF16(code []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(code)/2)
+	for i := range units {
+		lo, hi := code[2*i], code[2*i+1]
+		if bigEndian {
+			lo, hi = hi, lo
+		}
+		units[i] = uint16(lo) | uint16(hi)<<8
+	}
+	out := make([]byte, 0, len(units))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
@@ -7,3 +7,17 @@ type Parser interface {
 	ParseFileWithRoot(root, path string) ([]models.Symbol, []models.CodeChunk, error)
 	ParseProject(root string) ([]models.Symbol, []models.CodeChunk, error)
 }
+
+// CallSiteParser is implemented by parsers that can extract call sites as
+// chunks of kind models.SymbolCall, with paths relative to root like
+// ParseFileWithRoot. Calls match when their callee is in callees; an empty
+// list matches calls to imported bindings.
+type CallSiteParser interface {
+	ParseCallSitesWithRoot(root, path string, callees []string) ([]models.CodeChunk, error)
+}
+
+// ComponentPropsParser is implemented by parsers that can link the React
+// components a file exports to their props types
+type ComponentPropsParser interface {
+	ParseComponentPropsWithRoot(root, path string) ([]models.ComponentProps, error)
+}
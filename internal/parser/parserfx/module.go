@@ -1,14 +1,30 @@
 package parserfx
 
 import (
+	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/parser"
 	"github.com/0x5457/ts-index/internal/parser/tsparser"
 	"go.uber.org/fx"
 )
 
-// NewParser creates a new TypeScript parser instance
-func NewParser() parser.Parser {
-	return tsparser.New()
+// Params represents dependencies for the parser
+type Params struct {
+	fx.In
+
+	Config *configfx.Config `optional:"true"`
+}
+
+// NewParser creates a new TypeScript parser instance, indexing the extra node
+// kinds configured in Config.NodeKinds
+func NewParser(params Params) (parser.Parser, error) {
+	if params.Config == nil || len(params.Config.NodeKinds) == 0 {
+		return tsparser.New(), nil
+	}
+	nodeKinds, err := tsparser.ParseNodeKinds(params.Config.NodeKinds)
+	if err != nil {
+		return nil, err
+	}
+	return tsparser.NewWithNodeKinds(nodeKinds), nil
 }
 
 // Module provides parser components
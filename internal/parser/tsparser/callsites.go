@@ -0,0 +1,163 @@
+package tsparser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser"
+	"github.com/0x5457/ts-index/internal/util"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// maxCallSnippet caps the stored text of a call site; long argument lists
+// such as inline callbacks are cut
+const maxCallSnippet = 512
+
+var _ parser.CallSiteParser = (*TSParser)(nil)
+
+// ParseCallSitesWithRoot returns a chunk for each call in path whose callee
+// matches callees. An entry matches the callee expression as written, such as
+// "axios.post", or its last name, so "fetch" matches window.fetch(...). With
+// no callees, calls through an imported binding are returned instead.
+func (p *TSParser) ParseCallSitesWithRoot(
+	root, path string,
+	callees []string,
+) ([]models.CodeChunk, error) {
+	absPath, relPath, err := resolveRelative(root, path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	tree, code, languageName, err := parseSource(relPath, parser.DecodeSource(relPath, raw))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	program := tree.RootNode()
+
+	match := func(callee string) bool {
+		for _, c := range callees {
+			if callee == c || strings.HasSuffix(callee, "."+c) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(callees) == 0 {
+		imported := importedBindings(program, code)
+		match = func(callee string) bool {
+			head, _, _ := strings.Cut(callee, ".")
+			return imported[head]
+		}
+	}
+
+	var chunks []models.CodeChunk
+	var walk func(n *tree_sitter.Node)
+	walk = func(n *tree_sitter.Node) {
+		if n.Kind() == "call_expression" {
+			if fn := n.ChildByFieldName("function"); fn != nil {
+				callee := calleeName(fn, code)
+				if callee != "" && match(callee) {
+					chunks = append(chunks, callSiteChunk(n, relPath, languageName, callee, code))
+				}
+			}
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(program)
+	return chunks, nil
+}
+
+// calleeName returns a dotted callee such as "api.client.get", or "" for
+// computed callees like f()() or obj[key]()
+func calleeName(n *tree_sitter.Node, code []byte) string {
+	switch n.Kind() {
+	case "identifier", "this", "super":
+		return string(code[n.StartByte():n.EndByte()])
+	case "member_expression":
+		object := n.ChildByFieldName("object")
+		property := n.ChildByFieldName("property")
+		if object == nil || property == nil {
+			return ""
+		}
+		head := calleeName(object, code)
+		if head == "" {
+			return ""
+		}
+		return head + "." + string(code[property.StartByte():property.EndByte()])
+	case "non_null_expression", "parenthesized_expression":
+		if n.NamedChildCount() == 1 {
+			return calleeName(n.NamedChild(0), code)
+		}
+	}
+	return ""
+}
+
+// importedBindings returns the local names bound by the file's import statements
+func importedBindings(program *tree_sitter.Node, code []byte) map[string]bool {
+	names := map[string]bool{}
+	var collect func(n *tree_sitter.Node)
+	collect = func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "import_specifier":
+			name := n.ChildByFieldName("alias")
+			if name == nil {
+				name = n.ChildByFieldName("name")
+			}
+			if name != nil {
+				names[string(code[name.StartByte():name.EndByte()])] = true
+			}
+			return
+		case "identifier":
+			// default and namespace imports
+			names[string(code[n.StartByte():n.EndByte()])] = true
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			collect(n.NamedChild(i))
+		}
+	}
+	for i := uint(0); i < program.NamedChildCount(); i++ {
+		if n := program.NamedChild(i); n.Kind() == "import_statement" {
+			for j := uint(0); j < n.NamedChildCount(); j++ {
+				if c := n.NamedChild(j); c.Kind() == "import_clause" {
+					collect(c)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func callSiteChunk(n *tree_sitter.Node, path, language, callee string, code []byte) models.CodeChunk {
+	startLine := int32(n.StartPosition().Row) + 1
+	endLine := int32(n.EndPosition().Row) + 1
+	content := string(code[n.StartByte():n.EndByte()])
+	if len(content) > maxCallSnippet {
+		content = strings.ToValidUTF8(content[:maxCallSnippet], "") + "…"
+	}
+	kind := fmt.Sprint(rune(models.SymbolCall))
+	// several calls of one callee can share a line; the offset tells them apart
+	id := util.GenerateID(path, int(startLine), int(endLine), kind, fmt.Sprintf("%s@%d", callee, n.StartByte()))
+	return models.CodeChunk{
+		ID:        id,
+		File:      path,
+		Language:  language,
+		NodeType:  n.Kind(),
+		StartLine: startLine,
+		EndLine:   endLine,
+		StartByte: int32(n.StartByte()),
+		EndByte:   int32(n.EndByte()),
+		Content:   content,
+		Signature: firstLine(content),
+		Kind:      models.SymbolCall,
+		Name:      callee,
+	}
+}
@@ -0,0 +1,183 @@
+package tsparser
+
+import (
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+var _ parser.ComponentPropsParser = (*TSParser)(nil)
+
+// fcTypes are the React types annotating function components, whose first
+// type argument is the props type
+var fcTypes = map[string]bool{
+	"FC":                    true,
+	"FunctionComponent":     true,
+	"VFC":                   true,
+	"VoidFunctionComponent": true,
+}
+
+// ParseComponentPropsWithRoot returns the props type of each exported React
+// component in a .tsx file. Components are exported functions, arrow functions
+// and classes with a capitalized name; the props type is the type of the
+// first parameter, the argument of an FC annotation or of the Component base
+// class, or the second argument of forwardRef. Components without a declared
+// props type are left out, and files other than .tsx have none.
+func (p *TSParser) ParseComponentPropsWithRoot(root, path string) ([]models.ComponentProps, error) {
+	absPath, relPath, err := resolveRelative(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(relPath, ".tsx") {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	tree, code, _, err := parseSource(relPath, parser.DecodeSource(relPath, raw))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	program := tree.RootNode()
+
+	var props []models.ComponentProps
+	add := func(n *tree_sitter.Node, name, propsType string) {
+		if !isComponentName(name) || propsType == "" {
+			return
+		}
+		props = append(props, models.ComponentProps{
+			Component: name,
+			File:      relPath,
+			StartLine: int32(n.StartPosition().Row) + 1,
+			EndLine:   int32(n.EndPosition().Row) + 1,
+			PropsType: propsType,
+		})
+	}
+	for i := uint(0); i < program.NamedChildCount(); i++ {
+		n := program.NamedChild(i)
+		if n.Kind() != "export_statement" {
+			continue
+		}
+		decl := n.ChildByFieldName("declaration")
+		if decl == nil {
+			continue
+		}
+		switch decl.Kind() {
+		case "function_declaration":
+			add(decl, nodeText(decl.ChildByFieldName("name"), code), paramsType(decl, code))
+		case "class_declaration":
+			add(decl, nodeText(decl.ChildByFieldName("name"), code), classPropsType(decl, code))
+		case "lexical_declaration":
+			for j := uint(0); j < decl.NamedChildCount(); j++ {
+				d := decl.NamedChild(j)
+				if d.Kind() != "variable_declarator" {
+					continue
+				}
+				add(d, nodeText(d.ChildByFieldName("name"), code), declaratorPropsType(d, code))
+			}
+		}
+	}
+	return props, nil
+}
+
+// isComponentName reports whether name follows the React convention of
+// capitalized component names
+func isComponentName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// declaratorPropsType returns the props type of a component assigned to a
+// variable: from an FC annotation, a function value, or a memo or forwardRef
+// call wrapping one
+func declaratorPropsType(d *tree_sitter.Node, code []byte) string {
+	if annotation := d.ChildByFieldName("type"); annotation != nil && annotation.NamedChildCount() > 0 {
+		if t := annotation.NamedChild(0); t.Kind() == "generic_type" {
+			name := nodeText(t.ChildByFieldName("name"), code)
+			if fcTypes[name[strings.LastIndexByte(name, '.')+1:]] {
+				return typeArgument(t.ChildByFieldName("type_arguments"), 0, code)
+			}
+		}
+	}
+	value := d.ChildByFieldName("value")
+	for value != nil && value.Kind() == "call_expression" {
+		callee := nodeText(value.ChildByFieldName("function"), code)
+		if strings.HasSuffix(callee, "forwardRef") {
+			if t := typeArgument(value.ChildByFieldName("type_arguments"), 1, code); t != "" {
+				return t
+			}
+		}
+		args := value.ChildByFieldName("arguments")
+		if args == nil || args.NamedChildCount() == 0 {
+			return ""
+		}
+		value = args.NamedChild(0)
+	}
+	if value == nil {
+		return ""
+	}
+	switch value.Kind() {
+	case "arrow_function", "function_expression", "function":
+		return paramsType(value, code)
+	}
+	return ""
+}
+
+// paramsType returns the declared type of the first parameter of a function
+func paramsType(fn *tree_sitter.Node, code []byte) string {
+	params := fn.ChildByFieldName("parameters")
+	if params == nil || params.NamedChildCount() == 0 {
+		return ""
+	}
+	first := params.NamedChild(0)
+	annotation := first.ChildByFieldName("type")
+	if annotation == nil || annotation.NamedChildCount() == 0 {
+		return ""
+	}
+	return nodeText(annotation.NamedChild(0), code)
+}
+
+// classPropsType returns the first type argument of a class extending
+// Component or PureComponent
+func classPropsType(class *tree_sitter.Node, code []byte) string {
+	for i := uint(0); i < class.NamedChildCount(); i++ {
+		heritage := class.NamedChild(i)
+		if heritage.Kind() != "class_heritage" {
+			continue
+		}
+		for j := uint(0); j < heritage.NamedChildCount(); j++ {
+			extends := heritage.NamedChild(j)
+			if extends.Kind() != "extends_clause" {
+				continue
+			}
+			base := nodeText(extends.ChildByFieldName("value"), code)
+			switch base[strings.LastIndexByte(base, '.')+1:] {
+			case "Component", "PureComponent":
+				return typeArgument(extends.ChildByFieldName("type_arguments"), 0, code)
+			}
+		}
+	}
+	return ""
+}
+
+// typeArgument returns the i-th type in a type_arguments node
+func typeArgument(args *tree_sitter.Node, i uint, code []byte) string {
+	if args == nil || args.NamedChildCount() <= i {
+		return ""
+	}
+	return nodeText(args.NamedChild(i), code)
+}
+
+func nodeText(n *tree_sitter.Node, code []byte) string {
+	if n == nil {
+		return ""
+	}
+	return string(code[n.StartByte():n.EndByte()])
+}
@@ -0,0 +1,69 @@
+package tsparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/lsp"
+	"github.com/0x5457/ts-index/internal/models"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// nodeKindSymbols names the symbol kinds extra node kinds can be indexed as
+var nodeKindSymbols = map[string]models.SymbolKind{
+	"function":    models.SymbolFunction,
+	"method":      models.SymbolMethod,
+	"class":       models.SymbolClass,
+	"interface":   models.SymbolInterface,
+	"type":        models.SymbolType,
+	"enum":        models.SymbolEnum,
+	"enum_member": models.SymbolEnumMember,
+	"variable":    models.SymbolVariable,
+	"constant":    lsp.SymbolKindConstant,
+	"property":    lsp.SymbolKindProperty,
+	"field":       lsp.SymbolKindField,
+	"constructor": lsp.SymbolKindConstructor,
+	"namespace":   lsp.SymbolKindNamespace,
+}
+
+// ParseNodeKinds parses node kind mappings written as "node_kind=symbol_kind",
+// e.g. "public_field_definition=property", for NewWithNodeKinds
+func ParseNodeKinds(specs []string) (map[string]models.SymbolKind, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]models.SymbolKind, len(specs))
+	for _, spec := range specs {
+		node, kind, ok := strings.Cut(spec, "=")
+		node, kind = strings.TrimSpace(node), strings.ToLower(strings.TrimSpace(kind))
+		if !ok || node == "" {
+			return nil, fmt.Errorf("invalid node kind %q, want node_kind=symbol_kind", spec)
+		}
+		if !isNodeKind(node) {
+			return nil, fmt.Errorf("unknown tree-sitter node kind %q", node)
+		}
+		symbolKind, known := nodeKindSymbols[kind]
+		if !known {
+			names := make([]string, 0, len(nodeKindSymbols))
+			for name := range nodeKindSymbols {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown symbol kind %q for %s, want one of %s",
+				kind, node, strings.Join(names, ", "))
+		}
+		out[node] = symbolKind
+	}
+	return out, nil
+}
+
+// isNodeKind reports whether the TypeScript or TSX grammar has a named node kind
+func isNodeKind(kind string) bool {
+	for _, lang := range []*tree_sitter.Language{tsParsers.language, tsxParsers.language} {
+		if lang.IdForNodeKind(kind, true) != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package tsparser
+
+import (
+	"runtime"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+	tstypes "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
+)
+
+// Parser pools per grammar. Pooled parsers keep their language, so a parse
+// worker of the indexer ends up reusing one parser per grammar instead of
+// allocating and configuring one per file.
+var (
+	tsParsers  = newParserPool(tree_sitter.NewLanguage(tstypes.LanguageTypescript()))
+	tsxParsers = newParserPool(tree_sitter.NewLanguage(tstypes.LanguageTSX()))
+)
+
+// parserPool hands out tree-sitter parsers set to one language. A parser is
+// not safe for concurrent use, so each is owned by a single parse until it is
+// returned. Parsers hold C memory; one dropped by the pool is closed by its
+// finalizer.
+type parserPool struct {
+	language *tree_sitter.Language
+	pool     sync.Pool
+}
+
+func newParserPool(language *tree_sitter.Language) *parserPool {
+	return &parserPool{language: language}
+}
+
+// parse parses code with a parser from the pool
+func (p *parserPool) parse(code []byte) (*tree_sitter.Tree, error) {
+	parser, ok := p.pool.Get().(*tree_sitter.Parser)
+	if !ok {
+		parser = tree_sitter.NewParser()
+		if err := parser.SetLanguage(p.language); err != nil {
+			parser.Close()
+			return nil, err
+		}
+		runtime.SetFinalizer(parser, (*tree_sitter.Parser).Close)
+	}
+	defer func() {
+		// drop state left by the parse so the next file starts clean
+		parser.Reset()
+		p.pool.Put(parser)
+	}()
+	return parser.Parse(code, nil), nil
+}
@@ -0,0 +1,234 @@
+package tsparser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/parser"
+	"github.com/0x5457/ts-index/internal/util"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// SummarizeFileWithRoot outlines a file without returning its contents: the
+// symbols it exports with their signatures, its import count and line count.
+// Paths in the summary are relative to root like parsed symbols.
+func (p *TSParser) SummarizeFileWithRoot(root, path string) (*models.FileSummary, error) {
+	absPath, relPath, err := resolveRelative(root, path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	tree, code, languageName, err := parseSource(relPath, parser.DecodeSource(relPath, raw))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	program := tree.RootNode()
+
+	summary := &models.FileSummary{
+		File:     relPath,
+		Language: languageName,
+		Lines:    countLines(code),
+	}
+
+	// top-level declarations, so export clauses can refer to them by name
+	locals := map[string]models.ExportedSymbol{}
+	for i := uint(0); i < program.NamedChildCount(); i++ {
+		n := program.NamedChild(i)
+		decl := n
+		if n.Kind() == "export_statement" {
+			if decl = n.ChildByFieldName("declaration"); decl == nil {
+				continue
+			}
+		}
+		for _, sym := range declaredSymbols(decl, relPath, code) {
+			locals[sym.Name] = sym
+		}
+	}
+
+	for i := uint(0); i < program.NamedChildCount(); i++ {
+		n := program.NamedChild(i)
+		switch n.Kind() {
+		case "import_statement":
+			summary.Imports++
+		case "export_statement":
+			summary.Exports = append(summary.Exports, exportedSymbols(n, relPath, code, locals)...)
+		}
+	}
+	return summary, nil
+}
+
+// exportedSymbols lists the symbols one export statement exports
+func exportedSymbols(
+	n *tree_sitter.Node,
+	path string,
+	code []byte,
+	locals map[string]models.ExportedSymbol,
+) []models.ExportedSymbol {
+	isDefault := false
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if n.Child(i).Kind() == "default" {
+			isDefault = true
+		}
+	}
+	// statement-level entry for exports without a local declaration
+	statement := func(name string, kind models.SymbolKind) models.ExportedSymbol {
+		return models.ExportedSymbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: firstLine(string(code[n.StartByte():n.EndByte()])),
+			StartLine: int32(n.StartPosition().Row) + 1,
+			EndLine:   int32(n.EndPosition().Row) + 1,
+		}
+	}
+
+	if decl := n.ChildByFieldName("declaration"); decl != nil {
+		syms := declaredSymbols(decl, path, code)
+		for i := range syms {
+			syms[i].Default = isDefault
+		}
+		return syms
+	}
+	if value := n.ChildByFieldName("value"); value != nil {
+		sym, ok := locals[string(code[value.StartByte():value.EndByte()])]
+		if !ok || value.Kind() != "identifier" {
+			kind := models.SymbolVariable
+			switch value.Kind() {
+			case "arrow_function", "function_expression", "function":
+				kind = models.SymbolFunction
+			case "class":
+				kind = models.SymbolClass
+			}
+			sym = statement("default", kind)
+		}
+		sym.Default = true
+		return []models.ExportedSymbol{sym}
+	}
+
+	var from string
+	if source := n.ChildByFieldName("source"); source != nil {
+		from = strings.Trim(string(code[source.StartByte():source.EndByte()]), "\"'`")
+	}
+	var out []models.ExportedSymbol
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		c := n.NamedChild(i)
+		switch c.Kind() {
+		case "export_clause":
+			for j := uint(0); j < c.NamedChildCount(); j++ {
+				spec := c.NamedChild(j)
+				if spec.Kind() != "export_specifier" {
+					continue
+				}
+				nameNode := spec.ChildByFieldName("name")
+				if nameNode == nil {
+					continue
+				}
+				local := string(code[nameNode.StartByte():nameNode.EndByte()])
+				exported := local
+				if alias := spec.ChildByFieldName("alias"); alias != nil {
+					exported = string(code[alias.StartByte():alias.EndByte()])
+				}
+				sym, ok := locals[local]
+				if from != "" || !ok {
+					sym = statement(exported, models.SymbolVariable)
+				}
+				sym.Name = exported
+				sym.Default = exported == "default"
+				sym.From = from
+				out = append(out, sym)
+			}
+		case "namespace_export":
+			// export * as ns from "mod"
+			sym := statement(childIdentifier(c, code), models.SymbolVariable)
+			sym.From = from
+			out = append(out, sym)
+		}
+	}
+	if len(out) == 0 && from != "" {
+		// export * from "mod"
+		sym := statement("*", models.SymbolVariable)
+		sym.From = from
+		out = append(out, sym)
+	}
+	return out
+}
+
+// declaredSymbols returns the symbols a top-level declaration introduces. IDs
+// are set for the declarations the index records, matching their symbol IDs.
+func declaredSymbols(decl *tree_sitter.Node, path string, code []byte) []models.ExportedSymbol {
+	symbol := func(n *tree_sitter.Node, kind models.SymbolKind, indexed bool) models.ExportedSymbol {
+		name := childIdentifier(n, code)
+		if kind == models.SymbolModule {
+			name = moduleName(n, code)
+		}
+		startLine := int32(n.StartPosition().Row) + 1
+		endLine := int32(n.EndPosition().Row) + 1
+		sym := models.ExportedSymbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: firstLine(string(code[decl.StartByte():decl.EndByte()])),
+			StartLine: startLine,
+			EndLine:   endLine,
+		}
+		if indexed {
+			sym.ID = util.GenerateID(path, int(startLine), int(endLine), fmt.Sprint(rune(kind)), name)
+		}
+		return sym
+	}
+
+	switch decl.Kind() {
+	case "function_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolFunction, true)}
+	case "generator_function_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolFunction, false)}
+	case "function_signature":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolFunction, isAmbient(decl, path))}
+	case "class_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolClass, true)}
+	case "abstract_class_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolClass, false)}
+	case "interface_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolInterface, true)}
+	case "type_alias_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolType, true)}
+	case "enum_declaration":
+		return []models.ExportedSymbol{symbol(decl, models.SymbolEnum, true)}
+	case "module", "internal_module":
+		return []models.ExportedSymbol{symbol(decl, moduleKind(decl.Kind()), true)}
+	case "lexical_declaration", "variable_declaration":
+		var out []models.ExportedSymbol
+		for i := uint(0); i < decl.NamedChildCount(); i++ {
+			if c := decl.NamedChild(i); c.Kind() == "variable_declarator" {
+				out = append(out, symbol(c, declaratorKind(c), true))
+			}
+		}
+		return out
+	case "ambient_declaration":
+		// declare function f(): void
+		for i := uint(0); i < decl.NamedChildCount(); i++ {
+			if syms := declaredSymbols(decl.NamedChild(i), path, code); len(syms) > 0 {
+				return syms
+			}
+		}
+	}
+	return nil
+}
+
+// countLines counts lines the way editors number them; a trailing newline does
+// not start another line
+func countLines(code []byte) int {
+	if len(code) == 0 {
+		return 0
+	}
+	lines := bytes.Count(code, []byte("\n"))
+	if code[len(code)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
@@ -8,17 +8,27 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/0x5457/ts-index/internal/ignore"
 	"github.com/0x5457/ts-index/internal/models"
 	"github.com/0x5457/ts-index/internal/parser"
 	"github.com/0x5457/ts-index/internal/util"
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
-	tstypes "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
 )
 
-type TSParser struct{}
+type TSParser struct {
+	// nodeKinds indexes extra tree-sitter node kinds as the given symbol kinds
+	nodeKinds map[string]models.SymbolKind
+}
 
 func New() *TSParser { return &TSParser{} }
 
+// NewWithNodeKinds returns a parser that also indexes the named node kinds,
+// e.g. "abstract_class_declaration" as a class. An entry for a kind the parser
+// already handles replaces the built-in symbol kind.
+func NewWithNodeKinds(nodeKinds map[string]models.SymbolKind) *TSParser {
+	return &TSParser{nodeKinds: nodeKinds}
+}
+
 func (p *TSParser) ParseProject(root string) ([]models.Symbol, []models.CodeChunk, error) {
 	var symbols []models.Symbol
 	var chunks []models.CodeChunk
@@ -29,25 +39,33 @@ func (p *TSParser) ParseProject(root string) ([]models.Symbol, []models.CodeChun
 		return nil, nil, fmt.Errorf("failed to get absolute path for root: %w", err)
 	}
 
+	matcher, err := ignore.Load(absRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+
 	walkErr := filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
-			if d.Name() == "node_modules" || d.Name() == ".git" || d.Name() == "dist" ||
-				d.Name() == "build" {
+
+		// Convert absolute path to relative path
+		relPath, err := filepath.Rel(absRoot, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		}
+
+		if matcher.Ignored(relPath, d.IsDir()) {
+			if d.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if !strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".tsx") {
+		if d.IsDir() {
 			return nil
 		}
-
-		// Convert absolute path to relative path
-		relPath, err := filepath.Rel(absRoot, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		if !strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".tsx") {
+			return nil
 		}
 
 		syms, chs, perr := p.parseFileWithRelativePath(path, relPath)
@@ -72,49 +90,48 @@ func (p *TSParser) ParseFile(path string) ([]models.Symbol, []models.CodeChunk,
 func (p *TSParser) ParseFileWithRoot(
 	root, path string,
 ) ([]models.Symbol, []models.CodeChunk, error) {
+	absPath, relPath, err := resolveRelative(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.parseFileWithRelativePath(absPath, relPath)
+}
+
+// resolveRelative returns the absolute path of path and its path relative to root
+func resolveRelative(root, path string) (string, string, error) {
 	// Convert root to absolute path for consistent comparison
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get absolute path for root: %w", err)
+		return "", "", fmt.Errorf("failed to get absolute path for root: %w", err)
 	}
 
 	// Convert file path to absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get absolute path for file: %w", err)
+		return "", "", fmt.Errorf("failed to get absolute path for file: %w", err)
 	}
 
 	// Calculate relative path
 	relPath, err := filepath.Rel(absRoot, absPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		return "", "", fmt.Errorf("failed to get relative path for %s: %w", path, err)
 	}
 
-	return p.parseFileWithRelativePath(absPath, relPath)
+	return absPath, relPath, nil
 }
 
 // parseFileWithRelativePath parses a file using absPath for reading but relPath for symbol/chunk metadata
 func (p *TSParser) parseFileWithRelativePath(
 	absPath, relPath string,
 ) ([]models.Symbol, []models.CodeChunk, error) {
-	code, err := os.ReadFile(absPath)
+	raw, err := os.ReadFile(absPath)
 	if err != nil {
 		return nil, nil, err
 	}
-	parser := tree_sitter.NewParser()
-	defer parser.Close()
-
-	lang := tree_sitter.NewLanguage(tstypes.LanguageTypescript())
-	languageName := "ts"
-	if strings.HasSuffix(relPath, ".tsx") {
-		lang = tree_sitter.NewLanguage(tstypes.LanguageTSX())
-		languageName = "tsx"
-	}
-	if err := parser.SetLanguage(lang); err != nil {
+	tree, code, languageName, err := parseSource(relPath, parser.DecodeSource(relPath, raw))
+	if err != nil {
 		return nil, nil, err
 	}
-
-	tree := parser.Parse(code, nil)
 	defer tree.Close()
 	root := tree.RootNode()
 
@@ -124,6 +141,15 @@ func (p *TSParser) parseFileWithRelativePath(
 	var walk func(n *tree_sitter.Node)
 	walk = func(n *tree_sitter.Node) {
 		nt := n.Kind()
+		if kind, ok := p.nodeKinds[nt]; ok {
+			if name := childIdentifier(n, code); name != "" {
+				appendDecl(&symbols, &chunks, relPath, languageName, nt, code, n, kind, name)
+			}
+			for i := uint(0); i < n.ChildCount(); i++ {
+				walk(n.Child(i))
+			}
+			return
+		}
 		switch nt {
 		case "function_declaration":
 			name := childIdentifier(n, code)
@@ -203,6 +229,18 @@ func (p *TSParser) parseFileWithRelativePath(
 				models.SymbolEnum,
 				name,
 			)
+			appendEnumMembers(&symbols, &chunks, relPath, languageName, code, n, name)
+		case "function_signature":
+			// a bodiless declaration is an overload unless it is ambient
+			if isAmbient(n, relPath) {
+				name := childIdentifier(n, code)
+				appendDecl(&symbols, &chunks, relPath, languageName, nt, code, n, models.SymbolFunction, name)
+			}
+		case "module", "internal_module":
+			// declare global has no name
+			if name := moduleName(n, code); name != "" {
+				appendDecl(&symbols, &chunks, relPath, languageName, nt, code, n, moduleKind(nt), name)
+			}
 		case "lexical_declaration",
 			"variable_statement",
 			"variable_declaration",
@@ -218,6 +256,32 @@ func (p *TSParser) parseFileWithRelativePath(
 	return symbols, chunks, nil
 }
 
+// parseSource parses code with the TypeScript or TSX grammar picked by the file
+// extension, returning the tree, the source its nodes index into and the
+// language name recorded on chunks. Vue components are reduced to their
+// script blocks first.
+func parseSource(relPath string, code []byte) (*tree_sitter.Tree, []byte, string, error) {
+	parsers := tsParsers
+	languageName := "ts"
+	switch {
+	case strings.HasSuffix(relPath, ".tsx"):
+		parsers = tsxParsers
+		languageName = "tsx"
+	case strings.HasSuffix(relPath, ".vue"):
+		var tsx bool
+		code, tsx = vueScript(code)
+		if tsx {
+			parsers = tsxParsers
+		}
+		languageName = "vue"
+	}
+	tree, err := parsers.parse(code)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return tree, code, languageName, nil
+}
+
 func childIdentifier(n *tree_sitter.Node, code []byte) string {
 	// Prefer named field `name` if available
 	if c := n.ChildByFieldName("name"); c != nil {
@@ -253,7 +317,79 @@ func collectVariables(
 		return
 	}
 	name := childIdentifier(n, code)
-	appendDecl(symbols, chunks, path, language, n.Kind(), code, n, models.SymbolVariable, name)
+	kind := declaratorKind(n)
+	appendDecl(symbols, chunks, path, language, n.Kind(), code, n, kind, name)
+	if kind == models.SymbolFunction {
+		// the first line may stop inside a long parameter list
+		(*chunks)[len(*chunks)-1].Signature = functionValueSignature(n, code)
+	}
+}
+
+// appendEnumMembers adds each member of an enum declaration as a symbol
+// qualified by the enum name, e.g. "Status.Active" for enum Status { Active }
+func appendEnumMembers(
+	symbols *[]models.Symbol,
+	chunks *[]models.CodeChunk,
+	path, language string,
+	code []byte,
+	n *tree_sitter.Node,
+	enumName string,
+) {
+	body := n.ChildByFieldName("body")
+	if body == nil || enumName == "" {
+		return
+	}
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		member := body.NamedChild(i)
+		nameNode := member
+		if member.Kind() == "enum_assignment" {
+			nameNode = member.ChildByFieldName("name")
+		}
+		if nameNode == nil {
+			continue
+		}
+		var name string
+		switch nameNode.Kind() {
+		case "property_identifier", "number":
+			name = string(code[nameNode.StartByte():nameNode.EndByte()])
+		case "string":
+			// enum E { 'not-an-identifier' }
+			name = strings.Trim(string(code[nameNode.StartByte():nameNode.EndByte()]), `"'`)
+		default:
+			// comments
+			continue
+		}
+		qualified := enumName + "." + name
+		appendDecl(symbols, chunks, path, language, member.Kind(), code, member, models.SymbolEnumMember, qualified)
+		// "Active = 'active'" alone does not say which enum it belongs to
+		text := firstLine(string(code[member.StartByte():member.EndByte()]))
+		(*chunks)[len(*chunks)-1].Signature = fmt.Sprintf("enum %s { %s }", enumName, text)
+	}
+}
+
+// declaratorKind classifies a variable declarator: consts holding an arrow
+// function or function expression are functions
+func declaratorKind(n *tree_sitter.Node) models.SymbolKind {
+	if value := n.ChildByFieldName("value"); value != nil {
+		switch value.Kind() {
+		case "arrow_function", "function_expression", "function", "generator_function":
+			return models.SymbolFunction
+		}
+	}
+	return models.SymbolVariable
+}
+
+// functionValueSignature returns a declarator holding a function up to its
+// body, e.g. "handler = async (req: Request): Promise<void> =>", with
+// whitespace collapsed
+func functionValueSignature(n *tree_sitter.Node, code []byte) string {
+	end := n.EndByte()
+	if value := n.ChildByFieldName("value"); value != nil {
+		if body := value.ChildByFieldName("body"); body != nil {
+			end = body.StartByte()
+		}
+	}
+	return strings.Join(strings.Fields(string(code[n.StartByte():end])), " ")
 }
 
 func appendDecl(
@@ -287,6 +423,9 @@ func appendDecl(
 			StartByte: startByte,
 			EndByte:   endByte,
 			Docstring: doc,
+			Exported:  isExported(n),
+			Ambient:   isAmbient(n, path),
+			ConstEnum: isConstEnum(n),
 		},
 	)
 	*chunks = append(
@@ -309,6 +448,57 @@ func appendDecl(
 	)
 }
 
+// isExported reports whether declaration n is made in an export statement, as
+// in export function f() {} or, for a variable declarator, export const x = 1.
+// A declare between the export and the declaration is skipped.
+func isExported(n *tree_sitter.Node) bool {
+	parent := n.Parent()
+	if parent != nil && n.Kind() == "variable_declarator" {
+		parent = parent.Parent()
+	}
+	if parent != nil && parent.Kind() == "ambient_declaration" {
+		parent = parent.Parent()
+	}
+	return parent != nil && parent.Kind() == "export_statement"
+}
+
+// isAmbient reports whether declaration n only describes a shape: it is in a
+// .d.ts file, or made with declare or inside a declare block
+func isAmbient(n *tree_sitter.Node, path string) bool {
+	if strings.HasSuffix(path, ".d.ts") {
+		return true
+	}
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == "ambient_declaration" {
+			return true
+		}
+	}
+	return false
+}
+
+// isConstEnum reports whether n is a const enum declaration
+func isConstEnum(n *tree_sitter.Node) bool {
+	if n.Kind() != "enum_declaration" {
+		return false
+	}
+	first := n.Child(0)
+	return first != nil && first.Kind() == "const"
+}
+
+// moduleName returns the name of a module or namespace declaration, without
+// the quotes of declare module "name"
+func moduleName(n *tree_sitter.Node, code []byte) string {
+	return strings.Trim(childIdentifier(n, code), `"'`)
+}
+
+// moduleKind returns the symbol kind of a module or internal_module node
+func moduleKind(nodeType string) models.SymbolKind {
+	if nodeType == "module" {
+		return models.SymbolModule
+	}
+	return models.SymbolNamespace
+}
+
 func firstLine(s string) string {
 	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
 		return strings.TrimSpace(s[:idx])
@@ -339,26 +529,24 @@ func extractDocstring(code []byte, n *tree_sitter.Node) string {
 		pre := code[:lineStart]
 		pre = trimRightWhitespace(pre)
 		if len(pre) > 0 {
-			// Find the nearest preceding block comment that begins at line start and is JSDoc
+			// Use the nearest preceding block comment if it begins at line start
+			// and is JSDoc. Earlier comments never qualify, as the text after
+			// them holds this one, so only the nearest is looked at; walking
+			// back through all of them made parsing quadratic in file size.
 			closeIdx := bytes.LastIndex(pre, []byte("*/"))
-			for closeIdx >= 0 {
-				openIdx := bytes.LastIndex(pre[:closeIdx], []byte("/*"))
-				if openIdx < 0 {
-					break
-				}
-				openLineStart := bytes.LastIndexByte(pre[:openIdx], '\n') + 1
-				beginsAtLine := len(bytes.TrimSpace(pre[openLineStart:openIdx])) == 0
-				raw := pre[openIdx : closeIdx+2]
-				isJSDoc := bytes.HasPrefix(bytes.TrimLeft(raw, " \t\r\n"), []byte("/**"))
-				tail := bytes.TrimSpace(pre[closeIdx+2:])
-				if beginsAtLine && isJSDoc && (len(tail) == 0 || isOnlyTSModifiers(tail)) {
-					if s := cleanBlockComment(raw); s != "" {
-						parts = append(parts, s)
+			if closeIdx >= 0 {
+				if openIdx := bytes.LastIndex(pre[:closeIdx], []byte("/*")); openIdx >= 0 {
+					openLineStart := bytes.LastIndexByte(pre[:openIdx], '\n') + 1
+					beginsAtLine := len(bytes.TrimSpace(pre[openLineStart:openIdx])) == 0
+					raw := pre[openIdx : closeIdx+2]
+					isJSDoc := bytes.HasPrefix(bytes.TrimLeft(raw, " \t\r\n"), []byte("/**"))
+					tail := bytes.TrimSpace(pre[closeIdx+2:])
+					if beginsAtLine && isJSDoc && (len(tail) == 0 || isOnlyTSModifiers(tail)) {
+						if s := cleanBlockComment(raw); s != "" {
+							parts = append(parts, s)
+						}
 					}
-					break
 				}
-				// move to previous block (before this one's open)
-				closeIdx = bytes.LastIndex(pre[:openIdx], []byte("*/"))
 			}
 
 			// Try consecutive //-style lines immediately preceding
@@ -455,6 +643,19 @@ func collectLineCommentsBeforeLine(code []byte, lineStart int) []string {
 	return lines
 }
 
+var tsModifiers = map[string]struct{}{
+	"export":    {},
+	"default":   {},
+	"async":     {},
+	"declare":   {},
+	"abstract":  {},
+	"readonly":  {},
+	"public":    {},
+	"private":   {},
+	"protected": {},
+	"static":    {},
+}
+
 // isOnlyTSModifiers reports whether b contains only TypeScript declaration modifiers
 // and whitespace, e.g., export, default, async, declare, abstract, readonly.
 func isOnlyTSModifiers(b []byte) bool {
@@ -467,20 +668,8 @@ func isOnlyTSModifiers(b []byte) bool {
 	if len(tokens) == 0 {
 		return true
 	}
-	allowed := map[string]struct{}{
-		"export":    {},
-		"default":   {},
-		"async":     {},
-		"declare":   {},
-		"abstract":  {},
-		"readonly":  {},
-		"public":    {},
-		"private":   {},
-		"protected": {},
-		"static":    {},
-	}
 	for _, t := range tokens {
-		if _, ok := allowed[t]; !ok {
+		if _, ok := tsModifiers[t]; !ok {
 			return false
 		}
 	}
@@ -1,10 +1,15 @@
 package tsparser_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/0x5457/ts-index/internal/lsp"
 	"github.com/0x5457/ts-index/internal/models"
 	p "github.com/0x5457/ts-index/internal/parser/tsparser"
 )
@@ -173,3 +178,558 @@ export function paramDoc(a: number /* a is value */): number { return a } // end
 		}
 	}
 }
+
+func Test_TSParser_Encodings(t *testing.T) {
+	tmp := t.TempDir()
+	src := "export function f(x: number): number { return x }\n"
+
+	// UTF-8 with BOM
+	writeFile(t, tmp, "bom.ts", "\xEF\xBB\xBF"+src)
+
+	// UTF-16LE with BOM
+	utf16 := []byte{0xFF, 0xFE}
+	for _, r := range src {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "utf16.ts"), utf16, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	parser := p.New()
+	for _, name := range []string{"bom.ts", "utf16.ts"} {
+		symbols, chunks, err := parser.ParseFileWithRoot(tmp, filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("%s: parse error: %v", name, err)
+		}
+		if len(symbols) != 1 || symbols[0].Name != "f" {
+			t.Fatalf("%s: expected function f, got %+v", name, symbols)
+		}
+		// offsets must match the BOM-free source
+		start := int32(strings.Index(src, "function"))
+		if symbols[0].StartLine != 1 || chunks[0].StartByte != start {
+			t.Fatalf("%s: expected f at line 1 byte %d, got line %d byte %d",
+				name, start, symbols[0].StartLine, chunks[0].StartByte)
+		}
+		if chunks[0].Content != strings.TrimSpace(src[start:]) {
+			t.Fatalf("%s: unexpected content %q", name, chunks[0].Content)
+		}
+	}
+}
+
+func Test_TSParser_Vue(t *testing.T) {
+	tmp := t.TempDir()
+	src := `<template>
+  <div>{{ greet("é") }}</div>
+</template>
+
+<script setup lang="ts">
+/** Greets someone */
+function greet(name: string): string {
+  return "hi " + name
+}
+</script>
+
+<style scoped>
+.x { color: red }
+</style>
+`
+	writeFile(t, tmp, "Hello.vue", src)
+
+	symbols, chunks, err := p.New().ParseFileWithRoot(tmp, filepath.Join(tmp, "Hello.vue"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].Name != "greet" {
+		t.Fatalf("expected function greet, got %+v", symbols)
+	}
+	// lines and bytes point into the component, not the script block
+	sym, ch := symbols[0], chunks[0]
+	start := int32(strings.Index(src, "function greet"))
+	if sym.StartLine != 7 || sym.EndLine != 9 || ch.StartByte != start || ch.Language != "vue" {
+		t.Fatalf("unexpected symbol %+v, chunk starts at byte %d (want %d)", sym, ch.StartByte, start)
+	}
+	if !strings.HasPrefix(ch.Content, "function greet") || sym.Docstring != "Greets someone" {
+		t.Fatalf("unexpected content %q or docstring %q", ch.Content, sym.Docstring)
+	}
+}
+
+func Test_TSParser_CallSites(t *testing.T) {
+	tmp := t.TempDir()
+	src := `import axios from "axios"
+import { track as log } from "./analytics"
+
+export async function save(body: string) {
+  await fetch("/api", { method: "POST", body }); window.fetch("/ping")
+  await axios.post("/items", body)
+  log("saved")
+  console.log("done")
+}
+`
+	writeFile(t, tmp, "save.ts", src)
+	parser := p.New()
+	path := filepath.Join(tmp, "save.ts")
+
+	names := func(callees []string) []string {
+		chunks, err := parser.ParseCallSitesWithRoot(tmp, path, callees)
+		if err != nil {
+			t.Fatalf("parse call sites: %v", err)
+		}
+		var out []string
+		for _, ch := range chunks {
+			if ch.Kind != models.SymbolCall || ch.File != "save.ts" {
+				t.Fatalf("unexpected call chunk %+v", ch)
+			}
+			out = append(out, ch.Name)
+		}
+		return out
+	}
+
+	got := names([]string{"fetch", "axios.post"})
+	if !reflect.DeepEqual(got, []string{"fetch", "window.fetch", "axios.post"}) {
+		t.Fatalf("unexpected allowlisted calls %v", got)
+	}
+	// without an allowlist only calls through imports are kept
+	if got = names(nil); !reflect.DeepEqual(got, []string{"axios.post", "log"}) {
+		t.Fatalf("unexpected imported calls %v", got)
+	}
+
+	chunks, err := parser.ParseCallSitesWithRoot(tmp, path, []string{"fetch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks[0].ID == chunks[1].ID || chunks[0].StartLine != 5 ||
+		chunks[0].Content != `fetch("/api", { method: "POST", body })` {
+		t.Fatalf("unexpected fetch chunks %+v", chunks)
+	}
+}
+
+func Test_TSParser_NodeKinds(t *testing.T) {
+	tmp := t.TempDir()
+	src := `export abstract class Shape {
+  name = "shape"
+  abstract area(): number
+}
+declare function tick(): void
+`
+	writeFile(t, tmp, "shape.ts", src)
+	path := filepath.Join(tmp, "shape.ts")
+
+	kinds := func(parser *p.TSParser) map[string]models.SymbolKind {
+		symbols, _, err := parser.ParseFileWithRoot(tmp, path)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		out := map[string]models.SymbolKind{}
+		for _, sym := range symbols {
+			out[sym.Name] = sym.Kind
+		}
+		return out
+	}
+
+	// ambient function signatures are indexed by default
+	if got := kinds(p.New()); !reflect.DeepEqual(got, map[string]models.SymbolKind{"tick": models.SymbolFunction}) {
+		t.Fatalf("expected only tick from the default node kinds, got %v", got)
+	}
+
+	nodeKinds, err := p.ParseNodeKinds([]string{
+		"abstract_class_declaration=class",
+		"public_field_definition=property",
+		"function_signature=function",
+		"abstract_method_signature=Method",
+	})
+	if err != nil {
+		t.Fatalf("parse node kinds: %v", err)
+	}
+	want := map[string]models.SymbolKind{
+		"Shape": models.SymbolClass,
+		"name":  lsp.SymbolKindProperty,
+		"area":  models.SymbolMethod,
+		"tick":  models.SymbolFunction,
+	}
+	if got := kinds(p.NewWithNodeKinds(nodeKinds)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, spec := range []string{"abstract_class_declaration", "no_such_node=class", "function_signature=widget"} {
+		if _, err := p.ParseNodeKinds([]string{spec}); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+func Test_TSParser_FunctionConsts(t *testing.T) {
+	tmp := t.TempDir()
+	src := `export const handler = async (req) => {
+  return req
+}
+const parse = function (
+  text: string,
+): number { return Number(text) }
+const limit = 10
+`
+	writeFile(t, tmp, "handler.ts", src)
+
+	symbols, chunks, err := p.New().ParseFileWithRoot(tmp, filepath.Join(tmp, "handler.ts"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]struct {
+		kind      models.SymbolKind
+		signature string
+	}{
+		"handler": {models.SymbolFunction, "handler = async (req) =>"},
+		"parse":   {models.SymbolFunction, "parse = function ( text: string, ): number"},
+		"limit":   {models.SymbolVariable, "limit = 10"},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("expected %d symbols, got %+v", len(want), symbols)
+	}
+	for i, sym := range symbols {
+		w, ok := want[sym.Name]
+		if !ok || sym.Kind != w.kind || chunks[i].Signature != w.signature {
+			t.Fatalf("unexpected %s: kind %v, signature %q", sym.Name, sym.Kind, chunks[i].Signature)
+		}
+	}
+
+	summary, err := p.New().SummarizeFileWithRoot(tmp, filepath.Join(tmp, "handler.ts"))
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if len(summary.Exports) != 1 || summary.Exports[0].Kind != models.SymbolFunction ||
+		summary.Exports[0].ID != symbols[0].ID {
+		t.Fatalf("expected handler exported as the indexed function, got %+v", summary.Exports)
+	}
+}
+
+func Test_TSParser_EnumMembers(t *testing.T) {
+	tmp := t.TempDir()
+	src := `export enum Status {
+  /** still running */
+  Active = 'active',
+  Done,
+  'not-found' = 404, // missing
+}
+`
+	writeFile(t, tmp, "status.ts", src)
+
+	symbols, chunks, err := p.New().ParseFileWithRoot(tmp, filepath.Join(tmp, "status.ts"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	type member struct {
+		name      string
+		kind      models.SymbolKind
+		line      int32
+		signature string
+	}
+	want := []member{
+		{"Status", models.SymbolEnum, 1, "enum Status {"},
+		{"Status.Active", models.SymbolEnumMember, 3, "enum Status { Active = 'active' }"},
+		{"Status.Done", models.SymbolEnumMember, 4, "enum Status { Done }"},
+		{"Status.not-found", models.SymbolEnumMember, 5, "enum Status { 'not-found' = 404 }"},
+	}
+	var got []member
+	for i, sym := range symbols {
+		got = append(got, member{sym.Name, sym.Kind, sym.StartLine, chunks[i].Signature})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected symbols:\n got %+v\nwant %+v", got, want)
+	}
+	if symbols[1].Docstring != "still running" || symbols[3].Docstring != "missing" {
+		t.Fatalf("expected member docstrings, got %q and %q", symbols[1].Docstring, symbols[3].Docstring)
+	}
+}
+
+func Test_TSParser_Exported(t *testing.T) {
+	tmp := t.TempDir()
+	src := `export function load() {}
+function helper() {}
+export const a = 1, b = () => {}
+let c = 2
+export default class Store {
+  get() {}
+}
+export interface Options {}
+type Internal = string
+`
+	writeFile(t, tmp, "exports.ts", src)
+
+	symbols, _, err := p.New().ParseFileWithRoot(tmp, filepath.Join(tmp, "exports.ts"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	got := map[string]bool{}
+	for _, sym := range symbols {
+		got[sym.Name] = sym.Exported
+	}
+	want := map[string]bool{
+		"load": true, "helper": false, "a": true, "b": true, "c": false,
+		"Store": true, "get": false, "Options": true, "Internal": false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected exports:\n got %v\nwant %v", got, want)
+	}
+}
+
+func Test_TSParser_AmbientDeclarations(t *testing.T) {
+	tmp := t.TempDir()
+	src := `declare module "config" {
+  export function load(path: string): Config
+  interface Config { debug: boolean }
+}
+declare const VERSION: string
+export declare const BUILD: number
+const enum Direction { Up, Down }
+enum Color { Red }
+declare global { interface Window { app: unknown } }
+namespace Util { export function id() {} }
+function parse(s: string): number
+function parse(s: string) { return 1 }
+`
+	writeFile(t, tmp, "ambient.ts", src)
+	writeFile(t, tmp, "lib.d.ts", "export function greet(name: string): string\nexport type Name = string\n")
+
+	type flags struct {
+		kind      models.SymbolKind
+		exported  bool
+		ambient   bool
+		constEnum bool
+	}
+	parse := func(file string) map[string]flags {
+		symbols, _, err := p.New().ParseFileWithRoot(tmp, filepath.Join(tmp, file))
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		got := map[string]flags{}
+		for _, sym := range symbols {
+			got[sym.Name] = flags{sym.Kind, sym.Exported, sym.Ambient, sym.ConstEnum}
+		}
+		return got
+	}
+
+	// the parse overload is not ambient and is left out
+	want := map[string]flags{
+		"config":         {models.SymbolModule, false, true, false},
+		"load":           {models.SymbolFunction, true, true, false},
+		"Config":         {models.SymbolInterface, false, true, false},
+		"VERSION":        {models.SymbolVariable, false, true, false},
+		"BUILD":          {models.SymbolVariable, true, true, false},
+		"Direction":      {models.SymbolEnum, false, false, true},
+		"Direction.Up":   {models.SymbolEnumMember, false, false, false},
+		"Direction.Down": {models.SymbolEnumMember, false, false, false},
+		"Color":          {models.SymbolEnum, false, false, false},
+		"Color.Red":      {models.SymbolEnumMember, false, false, false},
+		"Window":         {models.SymbolInterface, false, true, false},
+		"Util":           {models.SymbolNamespace, false, false, false},
+		"id":             {models.SymbolFunction, true, false, false},
+		"parse":          {models.SymbolFunction, false, false, false},
+	}
+	if got := parse("ambient.ts"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected symbols:\n got %v\nwant %v", got, want)
+	}
+
+	want = map[string]flags{
+		"greet": {models.SymbolFunction, true, true, false},
+		"Name":  {models.SymbolType, true, true, false},
+	}
+	if got := parse("lib.d.ts"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected .d.ts symbols:\n got %v\nwant %v", got, want)
+	}
+}
+
+func Test_TSParser_ComponentProps(t *testing.T) {
+	tmp := t.TempDir()
+	src := `import React, { forwardRef, memo } from "react"
+
+export interface ButtonProps { label: string }
+
+export function Button({ label }: ButtonProps) { return <button>{label}</button> }
+export const Card: React.FC<CardProps> = ({ title }) => <div>{title}</div>
+export const Badge = memo((props: BadgeProps<string>) => <span />)
+export const Input = forwardRef<HTMLInputElement, InputProps>((props, ref) => <input ref={ref} />)
+export class Modal extends React.Component<ModalProps, ModalState> {}
+export function useToggle(initial: boolean) { return initial }
+export function Empty() { return null }
+function Hidden(props: HiddenProps) { return null }
+`
+	writeFile(t, tmp, "ui.tsx", src)
+	writeFile(t, tmp, "ui.ts", "export function Button(props: ButtonProps) { return null }\n")
+
+	props, err := p.New().ParseComponentPropsWithRoot(tmp, filepath.Join(tmp, "ui.tsx"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := []models.ComponentProps{
+		{Component: "Button", File: "ui.tsx", StartLine: 5, EndLine: 5, PropsType: "ButtonProps"},
+		{Component: "Card", File: "ui.tsx", StartLine: 6, EndLine: 6, PropsType: "CardProps"},
+		{Component: "Badge", File: "ui.tsx", StartLine: 7, EndLine: 7, PropsType: "BadgeProps<string>"},
+		{Component: "Input", File: "ui.tsx", StartLine: 8, EndLine: 8, PropsType: "InputProps"},
+		{Component: "Modal", File: "ui.tsx", StartLine: 9, EndLine: 9, PropsType: "ModalProps"},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Fatalf("unexpected props:\n got %+v\nwant %+v", props, want)
+	}
+
+	props, err = p.New().ParseComponentPropsWithRoot(tmp, filepath.Join(tmp, "ui.ts"))
+	if err != nil || len(props) != 0 {
+		t.Fatalf("expected no components outside .tsx files, got %+v, %v", props, err)
+	}
+}
+
+func Test_TSParser_SummarizeFile(t *testing.T) {
+	tmp := t.TempDir()
+	src := `import { x } from "./x"
+import type { Y } from "./y"
+
+/** Adds numbers */
+export function add(a: number, b: number): number {
+  return a + b
+}
+export const one = 1, two = 2
+export interface Shape { area(): number }
+export default class Square {}
+function hidden() {}
+type Local = string
+export { hidden as visible, Local }
+export { z } from "./z"
+export * from "./all"
+`
+	writeFile(t, tmp, "a.ts", src)
+
+	parser := p.New()
+	summary, err := parser.SummarizeFileWithRoot(tmp, filepath.Join(tmp, "a.ts"))
+	if err != nil {
+		t.Fatalf("SummarizeFileWithRoot error: %v", err)
+	}
+	if summary.File != "a.ts" || summary.Language != "ts" {
+		t.Fatalf("unexpected file metadata: %+v", summary)
+	}
+	if summary.Lines != 15 || summary.Imports != 2 {
+		t.Fatalf("expected 15 lines and 2 imports, got %d and %d", summary.Lines, summary.Imports)
+	}
+
+	var names []string
+	byName := map[string]models.ExportedSymbol{}
+	for _, e := range summary.Exports {
+		names = append(names, e.Name)
+		byName[e.Name] = e
+	}
+	want := "add,one,two,Shape,Square,visible,Local,z,*"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("exports = %s, want %s", got, want)
+	}
+	if add := byName["add"]; add.Kind != models.SymbolFunction || add.StartLine != 5 ||
+		add.Signature != "function add(a: number, b: number): number {" {
+		t.Fatalf("unexpected add export: %+v", add)
+	}
+	if sq := byName["Square"]; !sq.Default || sq.Kind != models.SymbolClass {
+		t.Fatalf("expected Square as the default class export: %+v", sq)
+	}
+	if v := byName["visible"]; v.Kind != models.SymbolFunction || v.StartLine != 11 {
+		t.Fatalf("expected visible to resolve to hidden(): %+v", v)
+	}
+	if z := byName["z"]; z.From != "./z" || z.ID != "" {
+		t.Fatalf("unexpected re-export: %+v", z)
+	}
+
+	// export IDs match the indexed symbols
+	symbols, _, err := parser.ParseFileWithRoot(tmp, filepath.Join(tmp, "a.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, s := range symbols {
+		ids[s.ID] = true
+	}
+	for _, name := range []string{"add", "one", "Shape", "Square", "visible"} {
+		if !ids[byName[name].ID] {
+			t.Errorf("export %s has no matching symbol ID", name)
+		}
+	}
+}
+
+func Test_TSParser_ConcurrentParse(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "a.ts", benchSource(20))
+	writeFile(t, tmp, "b.tsx", "export function Button(props: Props) { return <button>{props.label}</button> }\n")
+
+	parser := p.New()
+	want := map[string][]models.Symbol{}
+	for _, name := range []string{"a.ts", "b.tsx"} {
+		symbols, _, err := parser.ParseFileWithRoot(tmp, filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		want[name] = symbols
+	}
+
+	// pooled parsers must never be shared between concurrent parses
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				symbols, _, err := parser.ParseFileWithRoot(tmp, filepath.Join(tmp, name))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !reflect.DeepEqual(symbols, want[name]) {
+					errs <- fmt.Errorf("%s: symbols differ from a sequential parse", name)
+					return
+				}
+			}
+		}([]string{"a.ts", "b.tsx"}[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+// benchSource generates a TypeScript file of n exported functions and classes
+func benchSource(n int) string {
+	var b strings.Builder
+	b.WriteString("import { helper } from \"./helper\"\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "/** computes value %d */\n", i)
+		fmt.Fprintf(&b, "export function compute%d(a: number, b: number): number {\n", i)
+		fmt.Fprintf(&b, "\tconst sum = a + b + %d\n\treturn helper(sum)\n}\n\n", i)
+		fmt.Fprintf(&b, "export class Service%d {\n", i)
+		fmt.Fprintf(&b, "\tprivate count = %d\n", i)
+		b.WriteString("\tget(): number { return this.count }\n")
+		b.WriteString("\tset(value: number): void { this.count = value }\n}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	for _, size := range []struct {
+		name  string
+		decls int
+	}{
+		{"small", 5},
+		{"medium", 100},
+		{"large", 1000},
+	} {
+		b.Run(size.name, func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "bench.ts")
+			src := benchSource(size.decls)
+			if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+				b.Fatal(err)
+			}
+			parser := p.New()
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := parser.ParseFile(path); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package tsparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	vueScriptOpen  = regexp.MustCompile(`(?i)<script\b([^>]*)>`)
+	vueScriptClose = regexp.MustCompile(`(?i)</script\s*>`)
+	vueLangAttr    = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([a-z]+)`)
+)
+
+// vueScript reduces a Vue single-file component to its script blocks by
+// blanking every other byte except newlines, so lines and byte offsets found
+// in the result are those of the component. Blocks in a language other than
+// TypeScript or JavaScript are blanked too. tsx reports a tsx or jsx block.
+func vueScript(code []byte) (script []byte, tsx bool) {
+	script = make([]byte, len(code))
+	for i, b := range code {
+		if b == '\n' {
+			script[i] = '\n'
+		} else {
+			script[i] = ' '
+		}
+	}
+
+	offset := 0
+	for {
+		open := vueScriptOpen.FindSubmatchIndex(code[offset:])
+		if open == nil {
+			break
+		}
+		attrs := string(code[offset+open[2] : offset+open[3]])
+		start := offset + open[1]
+		end := len(code)
+		if closeTag := vueScriptClose.FindIndex(code[start:]); closeTag != nil {
+			end = start + closeTag[0]
+			offset = start + closeTag[1]
+		} else {
+			offset = end
+		}
+
+		lang := "js"
+		if m := vueLangAttr.FindStringSubmatch(attrs); m != nil {
+			lang = strings.ToLower(m[1])
+		}
+		switch lang {
+		case "ts", "js":
+		case "tsx", "jsx":
+			tsx = true
+		default:
+			continue
+		}
+		copy(script[start:end], code[start:end])
+	}
+	return script, tsx
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// cacheKey identifies a search whose hits can be reused
+type cacheKey struct {
+	query string
+	topK  int
+}
+
+type cacheEntry struct {
+	key        cacheKey
+	hits       []models.SemanticHit
+	generation uint64
+	expires    time.Time
+}
+
+// queryCache is a least-recently-used cache of search hits. Entries expire
+// after their TTL or once the index generation they were computed at has
+// passed. The zero value caches nothing until configured.
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	order   list.List // most recently used first
+}
+
+// get returns a copy of the cached hits for key if they are still current
+func (c *queryCache) get(key cacheKey, generation uint64, now time.Time) ([]models.SemanticHit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if entry.generation != generation || !now.Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return append([]models.SemanticHit(nil), entry.hits...), true
+}
+
+// put stores a copy of hits under key, evicting the least recently used
+// entries beyond size
+func (c *queryCache) put(
+	key cacheKey,
+	hits []models.SemanticHit,
+	generation uint64,
+	expires time.Time,
+	size int,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[cacheKey]*list.Element)
+	}
+	entry := &cacheEntry{
+		key:        key,
+		hits:       append([]models.SemanticHit(nil), hits...),
+		generation: generation,
+		expires:    expires,
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+	} else {
+		c.entries[key] = c.order.PushFront(entry)
+	}
+	for c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
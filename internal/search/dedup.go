@@ -0,0 +1,54 @@
+package search
+
+import "github.com/0x5457/ts-index/internal/models"
+
+// dedupHits collects hits, merging each into an earlier hit covering the same
+// code: the same file of the same source, with byte ranges sharing at least
+// half of the longer one. Nested declarations, such as a method in a large
+// class, share less and stay separate hits.
+type dedupHits struct {
+	hits []models.SemanticHit
+}
+
+// add appends hit, or merges it into its duplicate and reports false. A merged
+// hit keeps the higher score and the more specific chunk.
+func (d *dedupHits) add(hit models.SemanticHit) bool {
+	for i := range d.hits {
+		kept := &d.hits[i]
+		if !sameCode(kept, &hit) {
+			continue
+		}
+		if moreSpecific(hit.Chunk, kept.Chunk) {
+			kept.Chunk = hit.Chunk
+		}
+		kept.Score = max(kept.Score, hit.Score)
+		return false
+	}
+	d.hits = append(d.hits, hit)
+	return true
+}
+
+// sameCode reports whether two hits are duplicates. Chunks without a byte
+// range are never duplicates.
+func sameCode(a, b *models.SemanticHit) bool {
+	if a.Source != b.Source || a.Chunk.File != b.Chunk.File {
+		return false
+	}
+	aLen := a.Chunk.EndByte - a.Chunk.StartByte
+	bLen := b.Chunk.EndByte - b.Chunk.StartByte
+	if aLen <= 0 || bLen <= 0 {
+		return false
+	}
+	shared := min(a.Chunk.EndByte, b.Chunk.EndByte) - max(a.Chunk.StartByte, b.Chunk.StartByte)
+	return 2*shared >= max(aLen, bLen)
+}
+
+// moreSpecific reports whether chunk a describes its code better than b: a
+// declaration of any other kind beats a variable, and otherwise the narrower
+// chunk wins
+func moreSpecific(a, b models.CodeChunk) bool {
+	if aVar, bVar := a.Kind == models.SymbolVariable, b.Kind == models.SymbolVariable; aVar != bVar {
+		return bVar
+	}
+	return a.EndByte-a.StartByte < b.EndByte-b.StartByte
+}
@@ -0,0 +1,182 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// EvalQuery is a labeled query: a search for Query should find File, Symbol
+// or, when both are set, Symbol in File
+type EvalQuery struct {
+	Query  string `json:"query"`
+	File   string `json:"file,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// EvalHit is a search hit as reported by an evaluation
+type EvalHit struct {
+	File      string  `json:"file"`
+	Name      string  `json:"name"`
+	StartLine int32   `json:"start_line"`
+	Score     float32 `json:"score"`
+	// Expected is set on hits matching the query's label
+	Expected bool `json:"expected"`
+}
+
+// EvalResult is the outcome of one labeled query
+type EvalResult struct {
+	EvalQuery
+	// Rank is the 1-based rank of the first expected hit, 0 when none is in
+	// the top k
+	Rank      int       `json:"rank"`
+	LatencyMs float64   `json:"latency_ms"`
+	Hits      []EvalHit `json:"hits"`
+}
+
+// EvalReport summarizes search quality over a labeled query set
+type EvalReport struct {
+	K       int `json:"k"`
+	Queries int `json:"queries"`
+	// Recall is the fraction of queries with an expected hit in the top K
+	Recall float64 `json:"recall"`
+	// MRR is the mean reciprocal rank of the first expected hit, counting
+	// misses as 0
+	MRR           float64      `json:"mrr"`
+	MeanLatencyMs float64      `json:"mean_latency_ms"`
+	Results       []EvalResult `json:"results"`
+}
+
+// LoadEvalQueries reads labeled queries, one JSON object per line. Blank
+// lines are skipped; every query needs an expected file or symbol.
+func LoadEvalQueries(r io.Reader) ([]EvalQuery, error) {
+	var queries []EvalQuery
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var q EvalQuery
+		if err := json.Unmarshal([]byte(text), &q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(q.Query) == "" {
+			return nil, fmt.Errorf("line %d: query is required", line)
+		}
+		if q.File == "" && q.Symbol == "" {
+			return nil, fmt.Errorf("line %d: an expected file or symbol is required", line)
+		}
+		queries = append(queries, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// Evaluate runs each query with top k and scores the hits against the labels.
+// A failing search stops the evaluation.
+func (s *Service) Evaluate(ctx context.Context, queries []EvalQuery, k int) (*EvalReport, error) {
+	report := &EvalReport{K: k, Queries: len(queries), Results: make([]EvalResult, 0, len(queries))}
+	var reciprocal, latency float64
+	found := 0
+	for _, q := range queries {
+		start := time.Now()
+		hits, err := s.Search(ctx, q.Query, k)
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		if err != nil {
+			return nil, fmt.Errorf("search %q: %w", q.Query, err)
+		}
+		result := EvalResult{EvalQuery: q, LatencyMs: elapsed, Hits: make([]EvalHit, len(hits))}
+		for i, hit := range hits {
+			expected := q.matches(hit.Chunk)
+			if expected && result.Rank == 0 {
+				result.Rank = i + 1
+			}
+			result.Hits[i] = EvalHit{
+				File:      hit.Chunk.File,
+				Name:      hit.Chunk.Name,
+				StartLine: hit.Chunk.StartLine,
+				Score:     hit.Score,
+				Expected:  expected,
+			}
+		}
+		if result.Rank > 0 {
+			found++
+			reciprocal += 1 / float64(result.Rank)
+		}
+		latency += elapsed
+		report.Results = append(report.Results, result)
+	}
+	if n := float64(len(queries)); n > 0 {
+		report.Recall = float64(found) / n
+		report.MRR = reciprocal / n
+		report.MeanLatencyMs = latency / n
+	}
+	return report, nil
+}
+
+// matches reports whether chunk is what q expects. Files compare as cleaned
+// slash paths relative to the project.
+func (q EvalQuery) matches(chunk models.CodeChunk) bool {
+	if q.Symbol != "" && chunk.Name != q.Symbol {
+		return false
+	}
+	if q.File != "" && path.Clean(filepath.ToSlash(q.File)) != path.Clean(filepath.ToSlash(chunk.File)) {
+		return false
+	}
+	return true
+}
+
+// WriteText writes the report as a summary table followed by each query's
+// hits, with expected hits marked by an asterisk
+func (r *EvalReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "queries\trecall@%d\tMRR\tmean latency\n", r.K)
+	_, _ = fmt.Fprintf(tw, "%d\t%.3f\t%.3f\t%.1fms\n", r.Queries, r.Recall, r.MRR, r.MeanLatencyMs)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		rank := "miss"
+		if result.Rank > 0 {
+			rank = fmt.Sprintf("rank %d", result.Rank)
+		}
+		_, _ = fmt.Fprintf(w, "\n%q (%s, %.1fms), expected %s\n",
+			result.Query, rank, result.LatencyMs, result.expectation())
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for i, hit := range result.Hits {
+			mark := " "
+			if hit.Expected {
+				mark = "*"
+			}
+			_, _ = fmt.Fprintf(tw, "%s %d.\t%s:%d\t%s\t%.4f\n", mark, i+1, hit.File, hit.StartLine, hit.Name, hit.Score)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectation describes the label of q, e.g. "useAuth in src/auth.ts"
+func (q EvalQuery) expectation() string {
+	switch {
+	case q.Symbol == "":
+		return q.File
+	case q.File == "":
+		return q.Symbol
+	}
+	return q.Symbol + " in " + q.File
+}
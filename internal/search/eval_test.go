@@ -0,0 +1,66 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEvalQueries(t *testing.T) {
+	queries, err := LoadEvalQueries(strings.NewReader(`{"query": "auth", "file": "src/auth.ts"}
+
+{"query": "token", "symbol": "refresh"}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []EvalQuery{
+		{Query: "auth", File: "src/auth.ts"},
+		{Query: "token", Symbol: "refresh"},
+	}, queries)
+
+	for input, want := range map[string]string{
+		`{"query": "auth"}`:            "line 1: an expected file or symbol is required",
+		"\n" + `{"file": "a.ts"}`:      "line 2: query is required",
+		`{"query": "auth", "file": 1}`: "line 1:",
+	} {
+		_, err := LoadEvalQueries(strings.NewReader(input))
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	store := &stubVectorStore{hits: []models.SemanticHit{
+		{Chunk: models.CodeChunk{ID: "1", File: "src/auth.ts", Name: "login", StartLine: 1}, Score: 0.9},
+		{Chunk: models.CodeChunk{ID: "2", File: "src/auth.ts", Name: "refresh", StartLine: 9}, Score: 0.8},
+	}}
+	svc := &Service{Embedder: embeddings.NewLocal(4), Vector: store}
+	report, err := svc.Evaluate(context.Background(), []EvalQuery{
+		{Query: "login", File: "./src/auth.ts"},
+		{Query: "refresh", File: "src/auth.ts", Symbol: "refresh"},
+		{Query: "theme", Symbol: "useTheme"},
+	}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Queries)
+	assert.Equal(t, []int{1, 2, 0}, []int{report.Results[0].Rank, report.Results[1].Rank, report.Results[2].Rank})
+	assert.InDelta(t, 2.0/3, report.Recall, 1e-9)
+	assert.InDelta(t, (1+0.5)/3, report.MRR, 1e-9)
+	assert.False(t, report.Results[1].Hits[0].Expected)
+	assert.True(t, report.Results[1].Hits[1].Expected)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteText(&out))
+	assert.Contains(t, out.String(), "recall@2")
+	assert.Contains(t, out.String(), `"theme" (miss,`)
+	assert.Contains(t, out.String(), "expected refresh in src/auth.ts")
+	assert.Regexp(t, `\* 2\.\s+src/auth\.ts:9\s+refresh`, out.String())
+
+	store.empty = true
+	_, err = svc.Evaluate(context.Background(), []EvalQuery{{Query: "login", File: "a.ts"}}, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+// ErrReadOnly is returned by write operations on a federated store
+var ErrReadOnly = errors.New("federated vector store is read-only")
+
+// Source is a named vector store taking part in a federated search
+type Source struct {
+	Name  string
+	Store storage.VectorStore
+}
+
+// FederatedStore queries several vector stores and merges their hits into one ranking.
+// Indexes are written separately, so the store only supports Query.
+type FederatedStore struct {
+	sources []Source
+}
+
+// NewFederatedStore creates a store over sources; nil stores are skipped
+func NewFederatedStore(sources ...Source) *FederatedStore {
+	f := &FederatedStore{}
+	for _, src := range sources {
+		if src.Store != nil {
+			f.sources = append(f.sources, src)
+		}
+	}
+	return f
+}
+
+// NewFederatedService creates a search service that queries all sources at once.
+// All sources must have been indexed with the same embedding model as embedder.
+func NewFederatedService(embedder embeddings.Embedder, sources ...Source) *Service {
+	return &Service{Embedder: embedder, Vector: NewFederatedStore(sources...)}
+}
+
+func (f *FederatedStore) Upsert([]models.CodeChunk, [][]float32) error { return ErrReadOnly }
+func (f *FederatedStore) DeleteByFile(string) error                    { return ErrReadOnly }
+
+// Query asks every source for its top-k hits and returns the overall top-k.
+// Hits are merged on their raw similarity scores, which every store reports on
+// the same scale, so a weak best match stays weak; each hit records the source
+// it came from.
+func (f *FederatedStore) Query(embedding []float32, topK int) ([]models.SemanticHit, error) {
+	if len(f.sources) == 0 {
+		return nil, fmt.Errorf("no vector stores to search")
+	}
+
+	var all []models.SemanticHit
+	searched := 0
+	for _, src := range f.sources {
+		hits, err := src.Store.Query(embedding, topK)
+		if errors.Is(err, storage.ErrNoEmbeddings) {
+			// symbols-only indexes have nothing to contribute
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", src.Name, err)
+		}
+		searched++
+		for _, hit := range hits {
+			hit.Source = src.Name
+			all = append(all, hit)
+		}
+	}
+
+	if searched == 0 {
+		return nil, storage.ErrNoEmbeddings
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score > all[j].Score })
+	if topK > 0 && len(all) > topK {
+		all = all[:topK]
+	}
+	return all, nil
+}
+
+// HasEmbeddings reports whether any source may hold embeddings; sources that
+// cannot tell are assumed to
+func (f *FederatedStore) HasEmbeddings() (bool, error) {
+	for _, src := range f.sources {
+		checker, ok := src.Store.(storage.EmbeddingChecker)
+		if !ok {
+			return true, nil
+		}
+		has, err := checker.HasEmbeddings()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", src.Name, err)
+		}
+		if has {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Generation sums the generations of the sources that count their writes, so
+// it changes whenever one of them does
+func (f *FederatedStore) Generation() uint64 {
+	var gen uint64
+	for _, src := range f.sources {
+		if g, ok := src.Store.(storage.GenerationStore); ok {
+			gen += g.Generation()
+		}
+	}
+	return gen
+}
+
+// EmbeddingModel returns the embedding model recorded by the sources, which
+// must agree. Sources without a recorded model are skipped.
+func (f *FederatedStore) EmbeddingModel() (*models.EmbeddingModel, error) {
+	var found *models.EmbeddingModel
+	var foundIn string
+	for _, src := range f.sources {
+		store, ok := src.Store.(storage.EmbeddingModelStore)
+		if !ok {
+			continue
+		}
+		m, err := store.EmbeddingModel()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.Name, err)
+		}
+		if m == nil {
+			continue
+		}
+		if found != nil && *m != *found {
+			return nil, fmt.Errorf(
+				"%w: %s was built with %q, %s with %q",
+				ErrEmbeddingModelMismatch, foundIn, found.Name, src.Name, m.Name,
+			)
+		}
+		found, foundIn = m, src.Name
+	}
+	return found, nil
+}
+
+func (f *FederatedStore) SetEmbeddingModel(models.EmbeddingModel) error { return ErrReadOnly }
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// rerankOverfetch is how many times topK hits are retrieved for a reranker to
+// choose from
+const rerankOverfetch = 2
+
+// Reranker reorders the hits retrieved for a query, most relevant first. It
+// may drop hits but must not add any.
+type Reranker interface {
+	Rerank(query string, hits []models.SemanticHit) []models.SemanticHit
+}
+
+// HeuristicWeights are added to or subtracted from a hit's similarity score
+// when ranking it. A zero weight turns its heuristic off.
+type HeuristicWeights struct {
+	// NameMatch is added in proportion to the query words found in the
+	// chunk's name, e.g. half of it when one of two words is
+	NameMatch float32
+	// TestFile is subtracted from hits in test files, unless the query
+	// mentions tests
+	TestFile float32
+	// CallSite is subtracted from call site chunks, so definitions rank first
+	CallSite float32
+	// Length is subtracted in proportion to the chunk's lines, in full from
+	// lengthCap lines up
+	Length float32
+}
+
+// lengthCap is the chunk length at which HeuristicWeights.Length applies in full
+const lengthCap = 200
+
+// DefaultHeuristicWeights are small against the spread of similarity scores,
+// so they reorder close hits without overriding clearly better ones
+func DefaultHeuristicWeights() HeuristicWeights {
+	return HeuristicWeights{NameMatch: 0.1, TestFile: 0.05, CallSite: 0.05, Length: 0.02}
+}
+
+// HeuristicReranker reranks hits by their similarity score adjusted with
+// cheap heuristics on the chunk's name, file, kind and length, without model
+// calls. Hit scores are left as the vector store returned them.
+type HeuristicReranker struct {
+	Weights HeuristicWeights
+}
+
+var _ Reranker = (*HeuristicReranker)(nil)
+
+func NewHeuristicReranker(weights HeuristicWeights) *HeuristicReranker {
+	return &HeuristicReranker{Weights: weights}
+}
+
+// Rerank returns hits ordered by adjusted score; ties keep their order
+func (r *HeuristicReranker) Rerank(query string, hits []models.SemanticHit) []models.SemanticHit {
+	words := queryWords(query)
+	wantTests := false
+	for _, w := range words {
+		if strings.HasPrefix(w, "test") || strings.HasPrefix(w, "spec") {
+			wantTests = true
+		}
+	}
+
+	adjusted := make([]float32, len(hits))
+	order := make([]int, len(hits))
+	for i, hit := range hits {
+		order[i] = i
+		adjusted[i] = hit.Score + r.adjustment(hit.Chunk, words, wantTests)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return adjusted[order[a]] > adjusted[order[b]] })
+
+	out := make([]models.SemanticHit, len(hits))
+	for i, idx := range order {
+		out[i] = hits[idx]
+	}
+	return out
+}
+
+func (r *HeuristicReranker) adjustment(ch models.CodeChunk, words []string, wantTests bool) float32 {
+	var delta float32
+	if len(words) > 0 && r.Weights.NameMatch != 0 {
+		name := strings.ToLower(ch.Name)
+		matched := 0
+		for _, w := range words {
+			if strings.Contains(name, w) {
+				matched++
+			}
+		}
+		delta += r.Weights.NameMatch * float32(matched) / float32(len(words))
+	}
+	if !wantTests && IsTestFile(ch.File) {
+		delta -= r.Weights.TestFile
+	}
+	if ch.Kind == models.SymbolCall {
+		delta -= r.Weights.CallSite
+	}
+	if lines := ch.EndLine - ch.StartLine + 1; lines > 0 {
+		delta -= r.Weights.Length * float32(min(lines, lengthCap)) / lengthCap
+	}
+	return delta
+}
+
+// queryWords splits query into lowercase words of letters and digits, leaving
+// out one- and two-letter words
+func queryWords(query string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 2 {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// IsTestFile reports whether file follows a common test file convention:
+// *.test.* and *.spec.* files, and files under __tests__, test, tests or e2e
+// directories
+func IsTestFile(file string) bool {
+	file = "/" + strings.ToLower(file)
+	for _, marker := range []string{".test.", ".spec.", "/__tests__/", "/test/", "/tests/", "/e2e/"} {
+		if strings.Contains(file, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,16 @@
 package searchfx
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/embeddings"
 	"github.com/0x5457/ts-index/internal/search"
 	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
 	"go.uber.org/fx"
 )
 
@@ -11,16 +18,66 @@ import (
 type Params struct {
 	fx.In
 
-	Embedder embeddings.Embedder
-	VecStore storage.VectorStore `optional:"true"`
+	Config    *configfx.Config
+	Lifecycle fx.Lifecycle
+	Embedder  embeddings.Embedder
+	VecStore  storage.VectorStore `optional:"true"`
+}
+
+// NewSearchService creates a new search service instance.
+// When additional search databases are configured, the service searches all of
+// them together with the primary store.
+func NewSearchService(params Params) (*search.Service, error) {
+	var reranker search.Reranker
+	if params.Config.Rerank {
+		reranker = search.NewHeuristicReranker(search.DefaultHeuristicWeights())
+	}
+	if len(params.Config.SearchDBPaths) == 0 {
+		return &search.Service{
+			Embedder:  params.Embedder,
+			Vector:    params.VecStore, // Can be nil
+			CacheSize: params.Config.SearchCacheSize,
+			CacheTTL:  params.Config.SearchCacheTTL,
+			Reranker:  reranker,
+		}, nil
+	}
+
+	sources := []search.Source{{Name: params.Config.DBPath, Store: params.VecStore}}
+	var opened []*sqlvec.Store
+	for _, path := range params.Config.SearchDBPaths {
+		store, err := openSearchDB(path, params.Config.VectorDimension)
+		if err != nil {
+			for _, store := range opened {
+				_ = store.Close()
+			}
+			return nil, err
+		}
+		opened = append(opened, store)
+		sources = append(sources, search.Source{Name: path, Store: store})
+	}
+	params.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			var errs []error
+			for _, store := range opened {
+				errs = append(errs, store.Close())
+			}
+			return errors.Join(errs...)
+		},
+	})
+	svc := search.NewFederatedService(params.Embedder, sources...)
+	svc.CacheSize = params.Config.SearchCacheSize
+	svc.CacheTTL = params.Config.SearchCacheTTL
+	svc.Reranker = reranker
+	return svc, nil
 }
 
-// NewSearchService creates a new search service instance
-func NewSearchService(params Params) *search.Service {
-	return &search.Service{
-		Embedder: params.Embedder,
-		Vector:   params.VecStore, // Can be nil
+// openSearchDB opens an additional database to search. It is only searched, so
+// it is opened read-only; another process may be indexing it.
+func openSearchDB(path string, dimension int) (*sqlvec.Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("search database: %w", err)
 	}
+	return sqlvec.NewWithOptions(path, dimension, sqlvec.Options{ReadOnly: true})
 }
 
 // Module provides search components
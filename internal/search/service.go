@@ -2,17 +2,36 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/0x5457/ts-index/internal/embeddings"
 	"github.com/0x5457/ts-index/internal/models"
 	"github.com/0x5457/ts-index/internal/storage"
 )
 
+// ErrEmbeddingModelMismatch is returned when searching an index built with
+// another embedding model than the service's embedder
+var ErrEmbeddingModelMismatch = errors.New("embedding model mismatch")
+
 // Service orchestrates semantic search for code snippets
 type Service struct {
 	Embedder embeddings.Embedder
 	Vector   storage.VectorStore
+	// CacheSize and CacheTTL bound a cache of recent search results, used when
+	// both are positive and Vector implements storage.GenerationStore. Results
+	// are dropped once the store's generation changes.
+	CacheSize int
+	CacheTTL  time.Duration
+	// Reranker, when set, reorders twice topK retrieved hits before they are
+	// cut to topK. Hits are then delivered once all are retrieved.
+	Reranker Reranker
+
+	stats searchStats
+	cache queryCache
 }
 
 // Search performs vector search and returns the top-k most similar code snippets
@@ -21,22 +40,229 @@ func (s *Service) Search(
 	query string,
 	topK int,
 ) ([]models.SemanticHit, error) {
+	var hits []models.SemanticHit
+	err := s.search(ctx, query, topK, func(hit models.SemanticHit) error {
+		hits = append(hits, hit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// SearchStream is Search delivering hits over a channel, most similar first,
+// as the vector store reads them, so a client can show the first results
+// before the rest arrive. The hit channel is closed when the search ends; the
+// error channel then yields its error, if any. Cached results, and stores that
+// cannot stream, deliver their hits once the query completes.
+func (s *Service) SearchStream(
+	ctx context.Context,
+	query string,
+	topK int,
+) (<-chan models.SemanticHit, <-chan error) {
+	hitCh := make(chan models.SemanticHit)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		err := s.search(ctx, query, topK, func(hit models.SemanticHit) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			select {
+			case hitCh <- hit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(hitCh)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+	return hitCh, errCh
+}
+
+// search runs a semantic search, passing each hit to emit in order. An error
+// from emit stops the search and is returned.
+func (s *Service) search(
+	ctx context.Context,
+	query string,
+	topK int,
+	emit func(models.SemanticHit) error,
+) error {
 	// Check if vector store is available
 	if s.Vector == nil {
-		return nil, fmt.Errorf("vector store not available")
+		return fmt.Errorf("vector store not available")
+	}
+
+	// Repeated queries are answered from the cache while the index is unchanged
+	gens, cached := s.Vector.(storage.GenerationStore)
+	cached = cached && s.CacheSize > 0 && s.CacheTTL > 0
+	key := cacheKey{query: query, topK: topK}
+	var generation uint64
+	if cached {
+		generation = gens.Generation()
+		if hits, ok := s.cache.get(key, generation, time.Now()); ok {
+			s.stats.recordCacheHit()
+			return emitAll(hits, emit)
+		}
+	}
+
+	// Fail clearly, without embedding the query, on an index without embeddings
+	if checker, ok := s.Vector.(storage.EmbeddingChecker); ok {
+		has, err := checker.HasEmbeddings()
+		if err != nil {
+			return err
+		}
+		if !has {
+			return storage.ErrNoEmbeddings
+		}
+	}
+
+	// Vectors of another model live in another space, so their hits would be noise
+	model, err := s.indexModel()
+	if err != nil {
+		return err
+	}
+	if model != nil && model.Name != s.Embedder.ModelName() {
+		return fmt.Errorf(
+			"%w: the index was built with %q, the query is embedded with %q; reindex, or search with %q",
+			ErrEmbeddingModelMismatch, model.Name, s.Embedder.ModelName(), model.Name,
+		)
 	}
 
 	// Convert query to vector embedding
+	embedStart := time.Now()
 	qvec, err := s.Embedder.EmbedQuery(query)
+	embedDur := time.Since(embedStart)
 	if err != nil {
-		return nil, err
+		s.stats.record(embedDur, 0, false, err)
+		return err
+	}
+	if model != nil && model.Dimension > 0 && len(qvec) != model.Dimension {
+		return fmt.Errorf(
+			"%w: the index holds %d-dimensional embeddings, the query embedding has %d",
+			ErrEmbeddingModelMismatch, model.Dimension, len(qvec),
+		)
 	}
 
-	// Search for similar code snippets in the vector store
-	hits, err := s.Vector.Query(qvec, topK)
+	// Search for similar code snippets in the vector store. Duplicate hits are
+	// merged, so fewer than topK may remain; a streamed hit is sent as it
+	// arrives and not replaced by a more specific duplicate arriving later.
+	queryStart := time.Now()
+	var deduped dedupHits
+	if streamer, ok := s.Vector.(storage.StreamingVectorStore); ok && s.Reranker == nil {
+		err = streamer.QueryEach(ctx, qvec, topK, func(hit models.SemanticHit) error {
+			if !deduped.add(hit) {
+				return nil
+			}
+			return emit(hit)
+		})
+		s.stats.record(embedDur, time.Since(queryStart), true, err)
+	} else {
+		retrieve := topK
+		if s.Reranker != nil {
+			retrieve = topK * rerankOverfetch
+		}
+		var hits []models.SemanticHit
+		hits, err = s.Vector.Query(qvec, retrieve)
+		s.stats.record(embedDur, time.Since(queryStart), true, err)
+		if err == nil {
+			for _, hit := range hits {
+				deduped.add(hit)
+			}
+			if s.Reranker != nil {
+				deduped.hits = s.Reranker.Rerank(query, deduped.hits)
+				if topK > 0 && len(deduped.hits) > topK {
+					deduped.hits = deduped.hits[:topK]
+				}
+			}
+			err = emitAll(deduped.hits, emit)
+		}
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return hits, nil
+	if cached {
+		s.cache.put(key, deduped.hits, generation, time.Now().Add(s.CacheTTL), s.CacheSize)
+	}
+	return nil
+}
+
+// indexModel returns the embedding model recorded in the vector store, or nil
+// if the store does not record one
+func (s *Service) indexModel() (*models.EmbeddingModel, error) {
+	store, ok := s.Vector.(storage.EmbeddingModelStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.EmbeddingModel()
+}
+
+func emitAll(hits []models.SemanticHit, emit func(models.SemanticHit) error) error {
+	for _, hit := range hits {
+		if err := emit(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns cumulative latency statistics for the embed and vector-query
+// steps, along with the index provenance when the vector store records it
+func (s *Service) Stats() StatsSnapshot {
+	return s.withIndex(s.stats.snapshot())
+}
+
+// ResetStats clears the accumulated latency statistics and returns them as
+// Stats would have right before
+func (s *Service) ResetStats() StatsSnapshot {
+	return s.withIndex(s.stats.reset())
+}
+
+// withIndex adds the index provenance to snap
+func (s *Service) withIndex(snap StatsSnapshot) StatsSnapshot {
+	if store, ok := s.Vector.(storage.ProvenanceStore); ok {
+		// provenance is informational; a failed lookup leaves it out
+		if p, err := store.Provenance(); err == nil {
+			snap.Index = p
+		}
+	}
+	return snap
+}
+
+// Refine narrows an existing hit set by keyword without re-embedding.
+// Hits whose name or content contains the keyword (case-insensitive) are kept;
+// name matches are boosted ahead of content-only matches, preserving score order
+// within each group. An empty keyword returns hits unchanged.
+func (s *Service) Refine(hits []models.SemanticHit, keyword string) []models.SemanticHit {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return hits
+	}
+
+	type scored struct {
+		hit       models.SemanticHit
+		nameMatch bool
+	}
+	matched := make([]scored, 0, len(hits))
+	for _, hit := range hits {
+		nameMatch := strings.Contains(strings.ToLower(hit.Chunk.Name), keyword)
+		if nameMatch || strings.Contains(strings.ToLower(hit.Chunk.Content), keyword) {
+			matched = append(matched, scored{hit: hit, nameMatch: nameMatch})
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].nameMatch && !matched[j].nameMatch
+	})
+
+	out := make([]models.SemanticHit, len(matched))
+	for i, m := range matched {
+		out[i] = m.hit
+	}
+	return out
 }
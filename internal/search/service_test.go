@@ -0,0 +1,401 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/embeddings"
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubVectorStore struct {
+	hits []models.SemanticHit
+	// empty makes the store report that it has no embeddings
+	empty bool
+	// queries counts Query calls and topK records the last one's topK; gen
+	// is reported as the store generation
+	queries int
+	topK    int
+	gen     uint64
+}
+
+func (s *stubVectorStore) Upsert([]models.CodeChunk, [][]float32) error { return nil }
+func (s *stubVectorStore) DeleteByFile(string) error                    { return nil }
+func (s *stubVectorStore) Query(_ []float32, topK int) ([]models.SemanticHit, error) {
+	s.queries++
+	s.topK = topK
+	if s.empty {
+		return nil, storage.ErrNoEmbeddings
+	}
+	return s.hits, nil
+}
+func (s *stubVectorStore) HasEmbeddings() (bool, error) { return !s.empty, nil }
+func (s *stubVectorStore) Generation() uint64           { return s.gen }
+
+// streamingVectorStore is a stubVectorStore that streams its hits
+type streamingVectorStore struct {
+	stubVectorStore
+}
+
+func (s *streamingVectorStore) QueryEach(
+	_ context.Context,
+	_ []float32,
+	_ int,
+	fn func(models.SemanticHit) error,
+) error {
+	s.queries++
+	for _, hit := range s.hits {
+		if err := fn(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRefine(t *testing.T) {
+	hits := []models.SemanticHit{
+		{Chunk: models.CodeChunk{ID: "a", Name: "load", Content: "function load() { parseJSON() }"}},
+		{Chunk: models.CodeChunk{ID: "b", Name: "render", Content: "function render() {}"}},
+		{Chunk: models.CodeChunk{ID: "c", Name: "parseJSON", Content: "function parseJSON() {}"}},
+	}
+	svc := &Service{}
+
+	refined := svc.Refine(hits, "ParseJson")
+	ids := make([]string, len(refined))
+	for i, h := range refined {
+		ids[i] = h.Chunk.ID
+	}
+	// name match is boosted ahead of content-only match, non-matches dropped
+	assert.Equal(t, []string{"c", "a"}, ids)
+
+	assert.Equal(t, hits, svc.Refine(hits, "  "))
+}
+
+func TestSearchStats(t *testing.T) {
+	svc := &Service{
+		Embedder: embeddings.NewLocal(4),
+		Vector:   &stubVectorStore{hits: []models.SemanticHit{{Score: 1}}},
+	}
+
+	for range 3 {
+		_, err := svc.Search(context.Background(), "query", 1)
+		require.NoError(t, err)
+	}
+
+	stats := svc.Stats()
+	assert.Equal(t, int64(3), stats.Searches)
+	assert.Equal(t, int64(3), stats.Total.Count)
+	var bucketed int64
+	for _, b := range stats.Total.Buckets {
+		bucketed += b.Count
+	}
+	assert.Equal(t, int64(3), bucketed)
+
+	assert.Equal(t, int64(3), svc.ResetStats().Searches, "reset returns the cleared stats")
+	assert.Equal(t, int64(0), svc.Stats().Searches)
+}
+
+func TestResetStatsConcurrent(t *testing.T) {
+	svc := &Service{}
+	const searches = 1000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range searches {
+			svc.stats.record(time.Millisecond, time.Millisecond, true, nil)
+		}
+	}()
+
+	// every search lands in exactly one reset
+	var counted int64
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		counted += svc.ResetStats().Searches
+	}
+	assert.Equal(t, int64(searches), counted)
+}
+
+func TestFederatedSearch(t *testing.T) {
+	a := &stubVectorStore{hits: []models.SemanticHit{
+		{Chunk: models.CodeChunk{ID: "a1"}, Score: 0.4},
+		{Chunk: models.CodeChunk{ID: "a2"}, Score: -0.2},
+	}}
+	b := &stubVectorStore{hits: []models.SemanticHit{
+		{Chunk: models.CodeChunk{ID: "b1"}, Score: 0.3},
+	}}
+	svc := NewFederatedService(
+		embeddings.NewLocal(4),
+		Source{Name: "a.db", Store: a},
+		Source{Name: "missing.db", Store: nil},
+		Source{Name: "b.db", Store: b},
+	)
+
+	hits, err := svc.Search(context.Background(), "query", 2)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "a1", hits[0].Chunk.ID)
+	assert.Equal(t, "a.db", hits[0].Source)
+	// scores are the stores' own similarities, so a weak best match stays weak
+	assert.InDelta(t, 0.4, hits[0].Score, 1e-6)
+	assert.Equal(t, "b1", hits[1].Chunk.ID)
+	assert.Equal(t, "b.db", hits[1].Source)
+	assert.InDelta(t, 0.3, hits[1].Score, 1e-6)
+
+	assert.ErrorIs(t, svc.Vector.DeleteByFile("x.ts"), ErrReadOnly)
+}
+
+func TestSearchNoEmbeddings(t *testing.T) {
+	svc := &Service{Embedder: embeddings.NewLocal(4), Vector: &stubVectorStore{empty: true}}
+	_, err := svc.Search(context.Background(), "query", 1)
+	assert.ErrorIs(t, err, storage.ErrNoEmbeddings)
+
+	// federated sources without embeddings are skipped
+	fed := NewFederatedService(
+		embeddings.NewLocal(4),
+		Source{Name: "empty.db", Store: &stubVectorStore{empty: true}},
+		Source{Name: "full.db", Store: &stubVectorStore{hits: []models.SemanticHit{{Score: 1}}}},
+	)
+	hits, err := fed.Search(context.Background(), "query", 1)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	fed = NewFederatedService(embeddings.NewLocal(4), Source{Name: "empty.db", Store: &stubVectorStore{empty: true}})
+	_, err = fed.Search(context.Background(), "query", 1)
+	assert.ErrorIs(t, err, storage.ErrNoEmbeddings)
+}
+
+// modelVectorStore is a stubVectorStore recording an embedding model
+type modelVectorStore struct {
+	stubVectorStore
+	model models.EmbeddingModel
+}
+
+func (s *modelVectorStore) SetEmbeddingModel(m models.EmbeddingModel) error {
+	s.model = m
+	return nil
+}
+
+func (s *modelVectorStore) EmbeddingModel() (*models.EmbeddingModel, error) { return &s.model, nil }
+
+func TestSearchEmbeddingModel(t *testing.T) {
+	hits := []models.SemanticHit{{Chunk: models.CodeChunk{ID: "a"}, Score: 1}}
+	local := embeddings.NewLocal(4)
+
+	svc := &Service{Embedder: local, Vector: &modelVectorStore{
+		stubVectorStore: stubVectorStore{hits: hits},
+		model:           models.EmbeddingModel{Name: local.ModelName(), Dimension: 4},
+	}}
+	found, err := svc.Search(context.Background(), "query", 1)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	other := &modelVectorStore{
+		stubVectorStore: stubVectorStore{hits: hits},
+		model:           models.EmbeddingModel{Name: "gte-small", Dimension: 4},
+	}
+	svc = &Service{Embedder: local, Vector: other}
+	_, err = svc.Search(context.Background(), "query", 1)
+	assert.ErrorIs(t, err, ErrEmbeddingModelMismatch)
+	assert.Zero(t, other.queries)
+
+	svc = &Service{Embedder: embeddings.NewLocal(8), Vector: &modelVectorStore{
+		stubVectorStore: stubVectorStore{hits: hits},
+		model:           models.EmbeddingModel{Name: local.ModelName(), Dimension: 4},
+	}}
+	_, err = svc.Search(context.Background(), "query", 1)
+	assert.ErrorIs(t, err, ErrEmbeddingModelMismatch)
+
+	// federated sources must agree, and stores without a record are skipped
+	fed := NewFederatedService(local,
+		Source{Name: "a.db", Store: &stubVectorStore{hits: hits}},
+		Source{Name: "b.db", Store: svc.Vector},
+		Source{Name: "c.db", Store: other},
+	)
+	_, err = fed.Search(context.Background(), "query", 1)
+	assert.ErrorIs(t, err, ErrEmbeddingModelMismatch)
+}
+
+func TestSearchCache(t *testing.T) {
+	store := &stubVectorStore{hits: []models.SemanticHit{{Chunk: models.CodeChunk{ID: "a"}, Score: 1}}}
+	svc := &Service{
+		Embedder:  embeddings.NewLocal(4),
+		Vector:    store,
+		CacheSize: 1,
+		CacheTTL:  time.Minute,
+	}
+	search := func(query string, topK int) []models.SemanticHit {
+		t.Helper()
+		hits, err := svc.Search(context.Background(), query, topK)
+		require.NoError(t, err)
+		return hits
+	}
+
+	first := search("query", 1)
+	first[0].Score = 0 // callers may modify their hits
+	hits := search("query", 1)
+	assert.Equal(t, 1, store.queries)
+	assert.Equal(t, float32(1), hits[0].Score)
+	assert.Equal(t, int64(1), svc.Stats().CacheHits)
+	assert.Equal(t, int64(1), svc.Stats().Searches)
+
+	// top_k is part of the key
+	search("query", 2)
+	assert.Equal(t, 2, store.queries)
+
+	// the cache holds one entry, so "query"/1 was evicted
+	search("query", 1)
+	assert.Equal(t, 3, store.queries)
+
+	// a write to the index invalidates cached results
+	store.gen++
+	search("query", 1)
+	assert.Equal(t, 4, store.queries)
+
+	// an expired entry is queried again
+	svc.CacheTTL = time.Nanosecond
+	search("other", 1)
+	time.Sleep(time.Millisecond)
+	search("other", 1)
+	assert.Equal(t, 6, store.queries)
+}
+
+func TestSearchStream(t *testing.T) {
+	hits := []models.SemanticHit{
+		{Chunk: models.CodeChunk{ID: "a"}, Score: 1},
+		{Chunk: models.CodeChunk{ID: "b"}, Score: 0.5},
+	}
+	collect := func(svc *Service) ([]models.SemanticHit, error) {
+		t.Helper()
+		hitCh, errCh := svc.SearchStream(context.Background(), "query", 2)
+		var got []models.SemanticHit
+		for hit := range hitCh {
+			got = append(got, hit)
+		}
+		return got, <-errCh
+	}
+
+	store := &streamingVectorStore{stubVectorStore{hits: hits}}
+	svc := &Service{Embedder: embeddings.NewLocal(4), Vector: store, CacheSize: 1, CacheTTL: time.Minute}
+	got, err := collect(svc)
+	require.NoError(t, err)
+	assert.Equal(t, hits, got)
+
+	// the streamed result is cached
+	got, err = collect(svc)
+	require.NoError(t, err)
+	assert.Equal(t, hits, got)
+	assert.Equal(t, 1, store.queries)
+
+	// stores that cannot stream deliver their hits too
+	got, err = collect(&Service{Embedder: embeddings.NewLocal(4), Vector: &stubVectorStore{hits: hits}})
+	require.NoError(t, err)
+	assert.Equal(t, hits, got)
+
+	_, err = collect(&Service{Embedder: embeddings.NewLocal(4), Vector: &stubVectorStore{empty: true}})
+	assert.ErrorIs(t, err, storage.ErrNoEmbeddings)
+
+	// a canceled search stops streaming
+	ctx, cancel := context.WithCancel(context.Background())
+	hitCh, errCh := (&Service{Embedder: embeddings.NewLocal(4), Vector: store}).SearchStream(ctx, "query", 2)
+	<-hitCh
+	cancel()
+	for range hitCh {
+	}
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestSearchDedup(t *testing.T) {
+	chunk := func(id, file string, kind models.SymbolKind, start, end int32) models.CodeChunk {
+		return models.CodeChunk{ID: id, File: file, Kind: kind, StartByte: start, EndByte: end}
+	}
+	hits := []models.SemanticHit{
+		// export const handler = () => {} as a variable and as a function
+		{Chunk: chunk("var", "a.ts", models.SymbolVariable, 7, 100), Score: 0.9},
+		{Chunk: chunk("class", "a.ts", models.SymbolClass, 200, 1000), Score: 0.8},
+		{Chunk: chunk("fn", "a.ts", models.SymbolFunction, 13, 100), Score: 0.7},
+		// a method nested in the class is a hit of its own
+		{Chunk: chunk("method", "a.ts", models.SymbolMethod, 300, 400), Score: 0.6},
+		// the same range in another file or federated source is not a duplicate
+		{Chunk: chunk("other", "b.ts", models.SymbolFunction, 13, 100), Score: 0.5},
+		{Chunk: chunk("remote", "a.ts", models.SymbolFunction, 13, 100), Score: 0.4, Source: "remote.db"},
+		// chunks without byte ranges are kept
+		{Chunk: chunk("x", "c.ts", models.SymbolFunction, 0, 0), Score: 0.3},
+		{Chunk: chunk("y", "c.ts", models.SymbolFunction, 0, 0), Score: 0.2},
+	}
+	ids := func(hits []models.SemanticHit) []string {
+		var out []string
+		for _, hit := range hits {
+			out = append(out, hit.Chunk.ID)
+		}
+		return out
+	}
+
+	svc := &Service{Embedder: embeddings.NewLocal(4), Vector: &stubVectorStore{hits: hits}}
+	got, err := svc.Search(context.Background(), "query", len(hits))
+	require.NoError(t, err)
+	// the function replaces the variable, keeping its score
+	assert.Equal(t, []string{"fn", "class", "method", "other", "remote", "x", "y"}, ids(got))
+	assert.Equal(t, float32(0.9), got[0].Score)
+
+	// streamed hits are sent as they arrive, so the variable stays
+	svc = &Service{Embedder: embeddings.NewLocal(4), Vector: &streamingVectorStore{stubVectorStore{hits: hits}}}
+	hitCh, errCh := svc.SearchStream(context.Background(), "query", len(hits))
+	var streamed []models.SemanticHit
+	for hit := range hitCh {
+		streamed = append(streamed, hit)
+	}
+	require.NoError(t, <-errCh)
+	assert.Equal(t, []string{"var", "class", "method", "other", "remote", "x", "y"}, ids(streamed))
+}
+
+func TestHeuristicReranker(t *testing.T) {
+	chunk := func(id, name, file string, kind models.SymbolKind, lines int32) models.CodeChunk {
+		return models.CodeChunk{ID: id, Name: name, File: file, Kind: kind, StartLine: 1, EndLine: lines}
+	}
+	hits := []models.SemanticHit{
+		{Chunk: chunk("call", "parseConfig", "src/load.ts", models.SymbolCall, 1), Score: 0.80},
+		{Chunk: chunk("test", "parseConfig", "src/config.test.ts", models.SymbolFunction, 5), Score: 0.80},
+		{Chunk: chunk("long", "readFile", "src/io.ts", models.SymbolFunction, 400), Score: 0.79},
+		{Chunk: chunk("def", "parseConfig", "src/config.ts", models.SymbolFunction, 5), Score: 0.77},
+		{Chunk: chunk("short", "readFile", "src/fs.ts", models.SymbolFunction, 3), Score: 0.78},
+	}
+	ids := func(hits []models.SemanticHit) []string {
+		var out []string
+		for _, hit := range hits {
+			out = append(out, hit.Chunk.ID)
+		}
+		return out
+	}
+	r := NewHeuristicReranker(DefaultHeuristicWeights())
+
+	// the definition's name matches, the call site and test file are pushed down,
+	// and a long chunk drops below a shorter one of close score
+	reranked := r.Rerank("parse config", hits)
+	assert.Equal(t, []string{"def", "call", "test", "short", "long"}, ids(reranked))
+	assert.Equal(t, float32(0.77), reranked[0].Score)
+
+	// a query about tests does not penalize test files
+	reranked = r.Rerank("parse config tests", hits)
+	assert.Equal(t, "test", reranked[0].Chunk.ID)
+
+	// zero weights order by score alone
+	reranked = NewHeuristicReranker(HeuristicWeights{}).Rerank("parse config", hits)
+	assert.Equal(t, []string{"call", "test", "long", "short", "def"}, ids(reranked))
+
+	// the service retrieves more hits for the reranker and cuts them to topK,
+	// without streaming
+	store := &streamingVectorStore{stubVectorStore{hits: hits}}
+	svc := &Service{Embedder: embeddings.NewLocal(4), Vector: store, Reranker: r}
+	got, err := svc.Search(context.Background(), "parse config", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"def", "call"}, ids(got))
+	assert.Equal(t, 4, store.topK)
+}
@@ -0,0 +1,175 @@
+package search
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// histogramBuckets is the number of exponential latency buckets. Bucket i counts
+// samples up to 2^i milliseconds; the final bucket collects everything slower.
+const histogramBuckets = 16
+
+// LatencyHistogram accumulates durations into exponentially sized buckets
+type LatencyHistogram struct {
+	Count   int64    `json:"count"`
+	TotalMs float64  `json:"total_ms"`
+	MinMs   float64  `json:"min_ms"`
+	MaxMs   float64  `json:"max_ms"`
+	MeanMs  float64  `json:"mean_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Bucket is a single histogram bucket; UpperMs is 0 for the overflow bucket
+type Bucket struct {
+	UpperMs float64 `json:"upper_ms"`
+	Count   int64   `json:"count"`
+}
+
+// StatsSnapshot is a point-in-time copy of search latency statistics
+type StatsSnapshot struct {
+	Searches int64 `json:"searches"`
+	Errors   int64 `json:"errors"`
+	// CacheHits counts searches answered from the result cache; they are not
+	// included in Searches or the latency histograms
+	CacheHits int64            `json:"cache_hits"`
+	Embed     LatencyHistogram `json:"embed"`
+	Query     LatencyHistogram `json:"query"`
+	Total     LatencyHistogram `json:"total"`
+	Since     time.Time        `json:"since"`
+	// Index is the git version the searched index was built from, if recorded
+	Index *models.IndexProvenance `json:"index,omitempty"`
+}
+
+// histogram is the mutable counterpart of LatencyHistogram
+type histogram struct {
+	count   int64
+	total   time.Duration
+	min     time.Duration
+	max     time.Duration
+	buckets [histogramBuckets + 1]int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.total += d
+
+	idx := histogramBuckets
+	upper := time.Millisecond
+	for i := 0; i < histogramBuckets; i++ {
+		if d <= upper {
+			idx = i
+			break
+		}
+		upper *= 2
+	}
+	h.buckets[idx]++
+}
+
+func (h *histogram) snapshot() LatencyHistogram {
+	out := LatencyHistogram{
+		Count:   h.count,
+		TotalMs: millis(h.total),
+		MinMs:   millis(h.min),
+		MaxMs:   millis(h.max),
+		Buckets: make([]Bucket, 0, len(h.buckets)),
+	}
+	if h.count > 0 {
+		out.MeanMs = out.TotalMs / float64(h.count)
+	}
+	upper := time.Millisecond
+	for i, count := range h.buckets {
+		b := Bucket{Count: count}
+		if i < histogramBuckets {
+			b.UpperMs = millis(upper)
+			upper *= 2
+		}
+		out.Buckets = append(out.Buckets, b)
+	}
+	return out
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// searchStats holds cumulative in-process search timings
+type searchStats struct {
+	mu        sync.Mutex
+	searches  int64
+	errors    int64
+	cacheHits int64
+	embed     histogram
+	query     histogram
+	total     histogram
+	since     time.Time
+}
+
+// record adds one search; queried is false when the embed step failed before the KNN query
+func (s *searchStats) record(embed, query time.Duration, queried bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() {
+		s.since = time.Now()
+	}
+	s.searches++
+	if err != nil {
+		s.errors++
+	}
+	s.embed.observe(embed)
+	if queried {
+		s.query.observe(query)
+	}
+	s.total.observe(embed + query)
+}
+
+// recordCacheHit counts a search served from the result cache
+func (s *searchStats) recordCacheHit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() {
+		s.since = time.Now()
+	}
+	s.cacheHits++
+}
+
+func (s *searchStats) snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+// reset clears the statistics and returns them as they were, under one lock so
+// no search recorded in between is lost
+func (s *searchStats) reset() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := s.snapshotLocked()
+	s.searches = 0
+	s.errors = 0
+	s.cacheHits = 0
+	s.embed = histogram{}
+	s.query = histogram{}
+	s.total = histogram{}
+	s.since = time.Now()
+	return snap
+}
+
+func (s *searchStats) snapshotLocked() StatsSnapshot {
+	return StatsSnapshot{
+		Searches:  s.searches,
+		Errors:    s.errors,
+		CacheHits: s.cacheHits,
+		Embed:     s.embed.snapshot(),
+		Query:     s.query.snapshot(),
+		Total:     s.total.snapshot(),
+		Since:     s.since,
+	}
+}
@@ -0,0 +1,241 @@
+// Package memory keeps an index in memory, for one-off searches that should
+// not write a database file.
+package memory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+)
+
+var (
+	_ storage.SymbolStore      = (*Store)(nil)
+	_ storage.FileSymbolStore  = (*Store)(nil)
+	_ storage.VectorStore      = (*Store)(nil)
+	_ storage.EmbeddingChecker = (*Store)(nil)
+	_ storage.ChunkStore       = (*Store)(nil)
+
+	_ storage.IncrementalVectorStore = (*Store)(nil)
+)
+
+// Store holds symbols, chunks and their embeddings in memory. Queries compare
+// the query against every stored vector by cosine similarity.
+type Store struct {
+	mu      sync.RWMutex
+	symbols map[string]models.Symbol
+	chunks  map[string]models.CodeChunk
+	vectors map[string][]float32
+}
+
+func New() *Store {
+	return &Store{
+		symbols: make(map[string]models.Symbol),
+		chunks:  make(map[string]models.CodeChunk),
+		vectors: make(map[string][]float32),
+	}
+}
+
+func (s *Store) UpsertSymbols(symbols []models.Symbol) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sym := range symbols {
+		s.symbols[sym.ID] = sym
+	}
+	return nil
+}
+
+func (s *Store) DeleteSymbolsByFile(file string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sym := range s.symbols {
+		if sym.File == file {
+			delete(s.symbols, id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) FindByName(name string) ([]models.Symbol, error) {
+	return s.filterSymbols(func(sym models.Symbol) bool { return sym.Name == name }), nil
+}
+
+// SymbolsByFile returns the symbols of file ordered by line
+func (s *Store) SymbolsByFile(file string) ([]models.Symbol, error) {
+	return s.filterSymbols(func(sym models.Symbol) bool { return sym.File == file }), nil
+}
+
+func (s *Store) GetByID(id string) (*models.Symbol, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sym, ok := s.symbols[id]
+	if !ok {
+		return nil, nil
+	}
+	return &sym, nil
+}
+
+// filterSymbols returns the symbols keep selects, ordered by file and line
+func (s *Store) filterSymbols(keep func(models.Symbol) bool) []models.Symbol {
+	s.mu.RLock()
+	var out []models.Symbol
+	for _, sym := range s.symbols {
+		if keep(sym) {
+			out = append(out, sym)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].File != out[b].File {
+			return out[a].File < out[b].File
+		}
+		if out[a].StartLine != out[b].StartLine {
+			return out[a].StartLine < out[b].StartLine
+		}
+		return out[a].ID < out[b].ID
+	})
+	return out
+}
+
+func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d embeddings for %d chunks", len(embeddings), len(chunks))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ch := range chunks {
+		s.chunks[ch.ID] = ch
+		s.vectors[ch.ID] = embeddings[i]
+	}
+	return nil
+}
+
+func (s *Store) DeleteByFile(file string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ch := range s.chunks {
+		if ch.File == file {
+			delete(s.chunks, id)
+			delete(s.vectors, id)
+		}
+	}
+	return nil
+}
+
+// DeleteByIDs removes the chunks with the given ids. Unknown ids are ignored.
+func (s *Store) DeleteByIDs(ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.chunks, id)
+		delete(s.vectors, id)
+	}
+	return nil
+}
+
+// ChunksByFile returns the stored chunks of file ordered by line
+func (s *Store) ChunksByFile(file string) ([]models.CodeChunk, error) {
+	s.mu.RLock()
+	var out []models.CodeChunk
+	for _, ch := range s.chunks {
+		if ch.File == file {
+			out = append(out, ch)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].StartLine != out[b].StartLine {
+			return out[a].StartLine < out[b].StartLine
+		}
+		return out[a].ID < out[b].ID
+	})
+	return out, nil
+}
+
+// MoveChunks replaces each stored chunk ids[k] with chunks[k], keeping its
+// embedding. Unknown ids are ignored.
+func (s *Store) MoveChunks(ids []string, chunks []models.CodeChunk) error {
+	if len(ids) != len(chunks) {
+		return fmt.Errorf("got %d chunks for %d ids", len(chunks), len(ids))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// take every chunk out first, so one can move to the ID another is moving away from
+	vecs := make([][]float32, len(ids))
+	for k, id := range ids {
+		vecs[k] = s.vectors[id]
+		delete(s.chunks, id)
+		delete(s.vectors, id)
+	}
+	for k, ch := range chunks {
+		if vecs[k] != nil {
+			s.chunks[ch.ID] = ch
+			s.vectors[ch.ID] = vecs[k]
+		}
+	}
+	return nil
+}
+
+// Query returns the topK chunks most similar to embedding, scored by cosine
+// similarity
+func (s *Store) Query(embedding []float32, topK int) ([]models.SemanticHit, error) {
+	if topK <= 0 {
+		topK = 5
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.vectors) == 0 {
+		return nil, storage.ErrNoEmbeddings
+	}
+	hits := make([]models.SemanticHit, 0, len(s.vectors))
+	for id, vec := range s.vectors {
+		if len(vec) != len(embedding) {
+			return nil, fmt.Errorf("query has dimension %d, index has %d", len(embedding), len(vec))
+		}
+		hits = append(hits, models.SemanticHit{Chunk: s.chunks[id], Score: cosine(embedding, vec)})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Chunk.ID < hits[b].Chunk.ID
+	})
+	if len(hits) > topK {
+		hits = hits[:topK]
+	}
+	return hits, nil
+}
+
+func (s *Store) HasEmbeddings() (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.vectors) > 0, nil
+}
+
+// GetChunk returns the stored chunk with the given ID, or nil if there is none
+func (s *Store) GetChunk(id string) (*models.CodeChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ch, ok := s.chunks[id]
+	if !ok {
+		return nil, nil
+	}
+	return &ch, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if either is zero
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
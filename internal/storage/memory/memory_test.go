@@ -0,0 +1,77 @@
+package memory_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/storage/memory"
+)
+
+func Test_Store_Query(t *testing.T) {
+	store := memory.New()
+	if _, err := store.Query([]float32{1, 0}, 5); !errors.Is(err, storage.ErrNoEmbeddings) {
+		t.Fatalf("expected ErrNoEmbeddings from an empty store, got %v", err)
+	}
+
+	chunks := []models.CodeChunk{
+		{ID: "a", File: "a.ts", Name: "a"},
+		{ID: "b", File: "b.ts", Name: "b"},
+		{ID: "c", File: "a.ts", Name: "c"},
+	}
+	if err := store.Upsert(chunks, [][]float32{{1, 0}, {0, 1}, {1, 1}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	hits, err := store.Query([]float32{2, 0}, 2)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Chunk.ID != "a" || hits[1].Chunk.ID != "c" {
+		t.Fatalf("expected a then c, got %+v", hits)
+	}
+	if hits[0].Score < 0.999 {
+		t.Fatalf("expected a to score 1, got %f", hits[0].Score)
+	}
+
+	if err := store.DeleteByFile("a.ts"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	hits, err = store.Query([]float32{1, 0}, 5)
+	if err != nil || len(hits) != 1 || hits[0].Chunk.ID != "b" {
+		t.Fatalf("expected only b after deleting a.ts, got %+v, %v", hits, err)
+	}
+	if _, err := store.Query([]float32{1, 0, 0}, 5); err == nil {
+		t.Fatalf("expected a dimension mismatch error")
+	}
+}
+
+func Test_Store_Symbols(t *testing.T) {
+	store := memory.New()
+	if err := store.UpsertSymbols([]models.Symbol{
+		{ID: "2", Name: "b", File: "a.ts", StartLine: 5},
+		{ID: "1", Name: "a", File: "a.ts", StartLine: 1},
+		{ID: "3", Name: "a", File: "b.ts", StartLine: 1},
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	found, err := store.SymbolsByFile("a.ts")
+	if err != nil || len(found) != 2 || found[0].ID != "1" || found[1].ID != "2" {
+		t.Fatalf("expected symbols 1 and 2 of a.ts, got %+v, %v", found, err)
+	}
+	found, err = store.FindByName("a")
+	if err != nil || len(found) != 2 {
+		t.Fatalf("expected two symbols named a, got %+v, %v", found, err)
+	}
+	if err := store.DeleteSymbolsByFile("a.ts"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if sym, err := store.GetByID("1"); err != nil || sym != nil {
+		t.Fatalf("expected symbol 1 to be deleted, got %+v, %v", sym, err)
+	}
+	if sym, err := store.GetByID("3"); err != nil || sym == nil {
+		t.Fatalf("expected symbol 3, got %+v, %v", sym, err)
+	}
+}
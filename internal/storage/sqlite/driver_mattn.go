@@ -0,0 +1,18 @@
+//go:build cgo && !modernc
+
+package sqlite
+
+import _ "github.com/mattn/go-sqlite3"
+
+// DriverName is the database/sql driver the symbol store opens its database
+// with. With cgo it is mattn/go-sqlite3, the driver of the vector store, so a
+// database shared by both is never open through two SQLite builds at once.
+const DriverName = "sqlite3"
+
+// dsn returns the data source name opening path with mattn/go-sqlite3
+func dsn(path string, readOnly bool) string {
+	if readOnly {
+		return fileURI(path) + "?mode=ro&_query_only=1&_busy_timeout=" + busyTimeoutMillis
+	}
+	return fileURI(path) + "?_journal_mode=WAL&_busy_timeout=" + busyTimeoutMillis
+}
@@ -0,0 +1,20 @@
+//go:build !cgo || modernc
+
+package sqlite
+
+import _ "modernc.org/sqlite"
+
+// DriverName is the database/sql driver the symbol store opens its database
+// with. Without cgo, or with the modernc build tag, it is the pure-Go
+// modernc.org/sqlite, which cannot load sqlite-vec: use it for symbol-only
+// tools, not alongside the vector store on the same file.
+const DriverName = "sqlite"
+
+// dsn returns the data source name opening path with modernc.org/sqlite
+func dsn(path string, readOnly bool) string {
+	timeout := "_pragma=busy_timeout(" + busyTimeoutMillis + ")"
+	if readOnly {
+		return fileURI(path) + "?mode=ro&_pragma=query_only(1)&" + timeout
+	}
+	return fileURI(path) + "?_pragma=journal_mode(WAL)&" + timeout
+}
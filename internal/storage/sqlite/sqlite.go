@@ -4,26 +4,114 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/0x5457/ts-index/internal/models"
-	_ "modernc.org/sqlite"
+	"github.com/0x5457/ts-index/internal/storage"
 )
 
+var (
+	_ storage.SymbolFinder         = (*SymbolStore)(nil)
+	_ storage.EnclosingSymbolStore = (*SymbolStore)(nil)
+)
+
+// symbolColumns are the columns scanned by scanSymbol
+const symbolColumns = `id,name,kind,file,start_line,end_line,docstring,exported,ambient,const_enum`
+
+// flagColumns are the symbols columns added after its first schema
+var flagColumns = []string{"exported", "ambient", "const_enum"}
+
 type SymbolStore struct {
 	db *sql.DB
+	// symbols is what queries read symbols from: the symbols table, or for a
+	// read-only store on an older schema a subquery supplying the missing flag
+	// columns as 0
+	symbols string
+	// noComponentProps is set on a read-only store whose schema predates the
+	// component_props table
+	noComponentProps bool
 }
 
+// busyTimeoutMillis is how long a connection waits for another process's
+// lock on the database before failing
+const busyTimeoutMillis = "5000"
+
+// New opens or creates the symbol store at path. The database is switched to
+// WAL journaling, so read-only stores in other processes can keep reading
+// while it is written.
 func New(path string) (*SymbolStore, error) {
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open(DriverName, dsn(path, false))
 	if err != nil {
 		return nil, err
 	}
 	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SymbolStore{db: db, symbols: "symbols"}, nil
+}
+
+// NewReadOnly opens the existing symbol store at path without writing to it,
+// e.g. to search an index another process is updating. The schema is taken
+// as the writer left it.
+func NewReadOnly(path string) (*SymbolStore, error) {
+	db, err := sql.Open(DriverName, dsn(path, true))
+	if err != nil {
+		return nil, err
+	}
+	store, err := readOnlyStore(db)
+	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
-	return &SymbolStore{db: db}, nil
+	return store, nil
 }
 
+// readOnlyStore adapts its queries to the schema found in db, which it may
+// not migrate: columns and tables added since the index was built read as
+// their defaults
+func readOnlyStore(db *sql.DB) (*SymbolStore, error) {
+	store := &SymbolStore{db: db, symbols: "symbols"}
+	var missing []string
+	for _, column := range flagColumns {
+		var found int
+		if err := db.QueryRow(
+			`SELECT COUNT(*) FROM pragma_table_info('symbols') WHERE name = ?`, column,
+		).Scan(&found); err != nil {
+			return nil, err
+		}
+		if found == 0 {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		columns := strings.Split(symbolColumns, ",")
+		for i, column := range columns {
+			if slices.Contains(missing, column) {
+				columns[i] = "0 AS " + column
+			}
+		}
+		store.symbols = "(SELECT " + strings.Join(columns, ",") + " FROM symbols) AS symbols"
+	}
+	var tables int
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='component_props'`,
+	).Scan(&tables); err != nil {
+		return nil, err
+	}
+	store.noComponentProps = tables == 0
+	return store, nil
+}
+
+// fileURI returns path as an SQLite URI filename, escaping the characters
+// that would end its path part
+func fileURI(path string) string {
+	return "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+func (s *SymbolStore) Close() error { return s.db.Close() }
+
 func migrate(db *sql.DB) error {
 	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS symbols (
 		id TEXT PRIMARY KEY,
@@ -32,12 +120,42 @@ func migrate(db *sql.DB) error {
 		file TEXT NOT NULL,
 		start_line INTEGER NOT NULL,
 		end_line INTEGER NOT NULL,
-		docstring TEXT
+		docstring TEXT,
+		exported INTEGER NOT NULL DEFAULT 0,
+		ambient INTEGER NOT NULL DEFAULT 0,
+		const_enum INTEGER NOT NULL DEFAULT 0
 	);
 	CREATE INDEX IF NOT EXISTS idx_symbols_name ON symbols(name);
 	CREATE INDEX IF NOT EXISTS idx_symbols_file ON symbols(file);
-	CREATE INDEX IF NOT EXISTS idx_symbols_kind ON symbols(kind);`)
-	return err
+	CREATE INDEX IF NOT EXISTS idx_symbols_kind ON symbols(kind);
+	CREATE TABLE IF NOT EXISTS component_props (
+		component TEXT NOT NULL,
+		file TEXT NOT NULL,
+		start_line INTEGER NOT NULL,
+		end_line INTEGER NOT NULL,
+		props_type TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_component_props_component ON component_props(component);
+	CREATE INDEX IF NOT EXISTS idx_component_props_file ON component_props(file);`)
+	if err != nil {
+		return err
+	}
+	// symbols tables created by older versions, or by the vector store sharing
+	// the database, lack the flag columns
+	for _, column := range flagColumns {
+		var found int
+		if err := db.QueryRow(
+			`SELECT COUNT(*) FROM pragma_table_info('symbols') WHERE name = ?`, column,
+		).Scan(&found); err != nil {
+			return err
+		}
+		if found == 0 {
+			if _, err := db.Exec(`ALTER TABLE symbols ADD COLUMN ` + column + ` INTEGER NOT NULL DEFAULT 0`); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (s *SymbolStore) UpsertSymbols(symbols []models.Symbol) error {
@@ -45,15 +163,18 @@ func (s *SymbolStore) UpsertSymbols(symbols []models.Symbol) error {
 	if err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare(`INSERT INTO symbols(id,name,kind,file,start_line,end_line,docstring)
-		VALUES(?,?,?,?,?,?,?)
+	stmt, err := tx.Prepare(`INSERT INTO symbols(` + symbolColumns + `)
+		VALUES(?,?,?,?,?,?,?,?,?,?)
         ON CONFLICT(id) DO UPDATE SET
         name=excluded.name,
         kind=excluded.kind,
         file=excluded.file,
         start_line=excluded.start_line,
         end_line=excluded.end_line,
-        docstring=excluded.docstring`)
+        docstring=excluded.docstring,
+        exported=excluded.exported,
+        ambient=excluded.ambient,
+        const_enum=excluded.const_enum`)
 	if err != nil {
 		_ = tx.Rollback()
 		return err
@@ -68,6 +189,9 @@ func (s *SymbolStore) UpsertSymbols(symbols []models.Symbol) error {
 			sym.StartLine,
 			sym.EndLine,
 			sym.Docstring,
+			sym.Exported,
+			sym.Ambient,
+			sym.ConstEnum,
 		); err != nil {
 			_ = tx.Rollback()
 			return err
@@ -76,46 +200,175 @@ func (s *SymbolStore) UpsertSymbols(symbols []models.Symbol) error {
 	return tx.Commit()
 }
 
+// DeleteSymbolsByFile removes the symbols and component props of file
 func (s *SymbolStore) DeleteSymbolsByFile(file string) error {
-	_, err := s.db.Exec(`DELETE FROM symbols WHERE file = ?`, file)
+	if _, err := s.db.Exec(`DELETE FROM symbols WHERE file = ?`, file); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM component_props WHERE file = ?`, file)
 	return err
 }
 
 func (s *SymbolStore) FindByName(name string) ([]models.Symbol, error) {
+	rows, err := s.db.Query(`SELECT `+symbolColumns+` FROM `+s.symbols+` WHERE name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	return scanSymbols(rows)
+}
+
+// SymbolsByFile returns the symbols stored for file, in line order
+func (s *SymbolStore) SymbolsByFile(file string) ([]models.Symbol, error) {
+	rows, err := s.db.Query(
+		`SELECT `+symbolColumns+` FROM `+s.symbols+` WHERE file = ? ORDER BY start_line, end_line`,
+		file,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSymbols(rows)
+}
+
+// FindEnclosing returns the symbols of file whose lines contain line
+// (1-based), innermost first
+func (s *SymbolStore) FindEnclosing(file string, line int) ([]models.Symbol, error) {
 	rows, err := s.db.Query(
-		`SELECT id,name,kind,file,start_line,end_line,docstring FROM symbols WHERE name = ?`,
-		name,
+		`SELECT `+symbolColumns+` FROM `+s.symbols+`
+		WHERE file = ? AND start_line <= ? AND end_line >= ?
+		ORDER BY end_line - start_line, start_line DESC, id`,
+		file, line, line,
 	)
 	if err != nil {
 		return nil, err
 	}
+	return scanSymbols(rows)
+}
+
+// Find returns the symbols matching filter, ordered by file and line. The
+// filter is compiled into a single parameterized query.
+func (s *SymbolStore) Find(filter storage.SymbolFilter) ([]models.Symbol, error) {
+	var where []string
+	var args []any
+	if filter.Name != "" {
+		where = append(where, "name GLOB ?")
+		args = append(args, filter.Name)
+	}
+	if len(filter.Kinds) > 0 {
+		where = append(where, "kind IN (?"+strings.Repeat(",?", len(filter.Kinds)-1)+")")
+		for _, kind := range filter.Kinds {
+			args = append(args, fmt.Sprint(rune(kind)))
+		}
+	}
+	if filter.File != "" {
+		where = append(where, "file GLOB ?")
+		args = append(args, filter.File)
+	}
+	if filter.ExportedOnly {
+		where = append(where, "exported = 1")
+	}
+
+	query := `SELECT ` + symbolColumns + ` FROM ` + s.symbols
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY file, start_line, end_line, id`
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := filter.Limit
+		if limit <= 0 {
+			limit = -1 // no limit
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, max(filter.Offset, 0))
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSymbols(rows)
+}
+
+func scanSymbols(rows *sql.Rows) ([]models.Symbol, error) {
 	defer func() { _ = rows.Close() }()
 	var out []models.Symbol
 	for rows.Next() {
-		var sym models.Symbol
-		var kind string
-		if err := rows.Scan(&sym.ID, &sym.Name, &kind, &sym.File, &sym.StartLine, &sym.EndLine, &sym.Docstring); err != nil {
+		sym, err := scanSymbol(rows)
+		if err != nil {
 			return nil, err
 		}
-		sym.Kind = models.StringToSymbolKind(kind)
-		out = append(out, sym)
+		out = append(out, *sym)
 	}
 	return out, rows.Err()
 }
 
-func (s *SymbolStore) GetByID(id string) (*models.Symbol, error) {
-	row := s.db.QueryRow(
-		`SELECT id,name,kind,file,start_line,end_line,docstring FROM symbols WHERE id = ?`,
-		id,
-	)
+// scanSymbol scans a row of symbolColumns
+func scanSymbol(row interface{ Scan(...any) error }) (*models.Symbol, error) {
 	var sym models.Symbol
 	var kind string
-	if err := row.Scan(&sym.ID, &sym.Name, &kind, &sym.File, &sym.StartLine, &sym.EndLine, &sym.Docstring); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
+	if err := row.Scan(
+		&sym.ID, &sym.Name, &kind, &sym.File, &sym.StartLine, &sym.EndLine, &sym.Docstring,
+		&sym.Exported, &sym.Ambient, &sym.ConstEnum,
+	); err != nil {
 		return nil, err
 	}
 	sym.Kind = models.StringToSymbolKind(kind)
 	return &sym, nil
 }
+
+func (s *SymbolStore) GetByID(id string) (*models.Symbol, error) {
+	sym, err := scanSymbol(s.db.QueryRow(`SELECT `+symbolColumns+` FROM `+s.symbols+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return sym, err
+}
+
+// SetComponentProps replaces the component props stored for file
+func (s *SymbolStore) SetComponentProps(file string, props []models.ComponentProps) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM component_props WHERE file = ?`, file); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, p := range props {
+		if _, err := tx.Exec(
+			`INSERT INTO component_props(component,file,start_line,end_line,props_type) VALUES(?,?,?,?,?)`,
+			p.Component,
+			file,
+			p.StartLine,
+			p.EndLine,
+			p.PropsType,
+		); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindComponentProps returns the props of the components named component
+func (s *SymbolStore) FindComponentProps(component string) ([]models.ComponentProps, error) {
+	if s.noComponentProps {
+		return nil, nil
+	}
+	rows, err := s.db.Query(
+		`SELECT component,file,start_line,end_line,props_type FROM component_props
+		WHERE component = ? ORDER BY file, start_line`,
+		component,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []models.ComponentProps
+	for rows.Next() {
+		var p models.ComponentProps
+		if err := rows.Scan(&p.Component, &p.File, &p.StartLine, &p.EndLine, &p.PropsType); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,233 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/storage/sqlite"
+)
+
+func Test_SymbolStore_Find(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := []models.Symbol{
+		{ID: "1", Name: "useAuth", Kind: models.SymbolFunction, File: "src/auth.ts", StartLine: 1, Exported: true},
+		{ID: "2", Name: "AuthState", Kind: models.SymbolInterface, File: "src/auth.ts", StartLine: 5, Exported: true},
+		{ID: "3", Name: "refresh", Kind: models.SymbolFunction, File: "src/auth.ts", StartLine: 9},
+		{ID: "4", Name: "useTheme", Kind: models.SymbolFunction, File: "src/ui/theme.ts", StartLine: 1, Exported: true,
+			Ambient: true},
+		{ID: "6", Name: "Mode", Kind: models.SymbolEnum, File: "src/ui/theme.ts", StartLine: 4, ConstEnum: true},
+		{ID: "5", Name: "useAuth", Kind: models.SymbolFunction, File: "test/auth.test.ts", StartLine: 3},
+	}
+	if err := store.UpsertSymbols(symbols); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter storage.SymbolFilter
+		want   []string
+	}{
+		{name: "everything", want: []string{"1", "2", "3", "4", "6", "5"}},
+		{name: "name glob", filter: storage.SymbolFilter{Name: "use*"}, want: []string{"1", "4", "5"}},
+		{
+			name:   "kinds",
+			filter: storage.SymbolFilter{Kinds: []models.SymbolKind{models.SymbolInterface, models.SymbolClass}},
+			want:   []string{"2"},
+		},
+		{name: "file glob", filter: storage.SymbolFilter{File: "src/*"}, want: []string{"1", "2", "3", "4", "6"}},
+		{
+			name:   "exported functions in src",
+			filter: storage.SymbolFilter{Kinds: []models.SymbolKind{models.SymbolFunction}, File: "src/*", ExportedOnly: true},
+			want:   []string{"1", "4"},
+		},
+		{name: "limit and offset", filter: storage.SymbolFilter{Limit: 2, Offset: 1}, want: []string{"2", "3"}},
+		{name: "offset alone", filter: storage.SymbolFilter{Offset: 3}, want: []string{"4", "6", "5"}},
+		{name: "no match", filter: storage.SymbolFilter{Name: "missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := store.Find(tt.filter)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			var ids []string
+			for _, sym := range found {
+				ids = append(ids, sym.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, ids)
+				}
+			}
+		})
+	}
+
+	sym, err := store.GetByID("1")
+	if err != nil || sym == nil || !sym.Exported || sym.Kind != models.SymbolFunction {
+		t.Fatalf("expected exported function 1, got %+v, %v", sym, err)
+	}
+	if sym, err := store.GetByID("4"); err != nil || sym == nil || !sym.Ambient || sym.ConstEnum {
+		t.Fatalf("expected ambient symbol 4, got %+v, %v", sym, err)
+	}
+	if sym, err := store.GetByID("6"); err != nil || sym == nil || !sym.ConstEnum || sym.Ambient {
+		t.Fatalf("expected const enum 6, got %+v, %v", sym, err)
+	}
+}
+
+func Test_SymbolStore_MigratesExported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := sql.Open(sqlite.DriverName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE symbols (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file TEXT NOT NULL,
+		start_line INTEGER NOT NULL,
+		end_line INTEGER NOT NULL,
+		docstring TEXT
+	);
+	INSERT INTO symbols VALUES ('old', 'legacy', '12', 'a.ts', 1, 1, '')`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	store, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("open older index: %v", err)
+	}
+	found, err := store.Find(storage.SymbolFilter{ExportedOnly: true})
+	if err != nil || len(found) != 0 {
+		t.Fatalf("expected no exported symbols, got %v, %v", found, err)
+	}
+	if sym, err := store.GetByID("old"); err != nil || sym == nil || sym.Ambient || sym.ConstEnum {
+		t.Fatalf("expected the legacy symbol without flags, got %+v, %v", sym, err)
+	}
+	if _, err := sqlite.New(path); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+}
+
+func Test_SymbolStore_FindEnclosing(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertSymbols([]models.Symbol{
+		{ID: "class", Name: "Store", Kind: models.SymbolClass, File: "a.ts", StartLine: 1, EndLine: 9},
+		{ID: "get", Name: "get", Kind: models.SymbolMethod, File: "a.ts", StartLine: 2, EndLine: 4},
+		{ID: "set", Name: "set", Kind: models.SymbolMethod, File: "a.ts", StartLine: 5, EndLine: 8},
+		{ID: "other", Name: "Other", Kind: models.SymbolClass, File: "b.ts", StartLine: 1, EndLine: 9},
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	for line, want := range map[int][]string{
+		3:  {"get", "class"},
+		5:  {"set", "class"},
+		9:  {"class"},
+		10: nil,
+	} {
+		found, err := store.FindEnclosing("a.ts", line)
+		if err != nil {
+			t.Fatalf("find enclosing line %d: %v", line, err)
+		}
+		var ids []string
+		for _, sym := range found {
+			ids = append(ids, sym.ID)
+		}
+		if !slices.Equal(ids, want) {
+			t.Fatalf("expected %v to enclose line %d, got %v", want, line, ids)
+		}
+	}
+}
+
+func Test_SymbolStore_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	if _, err := sqlite.NewReadOnly(path); err == nil {
+		t.Fatal("expected opening a missing store read-only to fail")
+	}
+
+	writer, err := sqlite.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = writer.Close() }()
+	reader, err := sqlite.NewReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	// writes made after the reader opened are visible to it
+	sym := models.Symbol{ID: "1", Name: "useAuth", Kind: models.SymbolFunction, File: "src/auth.ts", StartLine: 1}
+	if err := writer.UpsertSymbols([]models.Symbol{sym}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if found, err := reader.FindByName("useAuth"); err != nil || len(found) != 1 {
+		t.Fatalf("expected the reader to find useAuth, got %+v, %v", found, err)
+	}
+	if err := reader.UpsertSymbols([]models.Symbol{sym}); err == nil {
+		t.Fatal("expected a write through the read-only store to fail")
+	}
+}
+
+func Test_SymbolStore_ReadOnlyOlderSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := sql.Open(sqlite.DriverName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec(`CREATE TABLE symbols (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		file TEXT NOT NULL,
+		start_line INTEGER NOT NULL,
+		end_line INTEGER NOT NULL,
+		docstring TEXT
+	);
+	INSERT INTO symbols VALUES ('old', 'legacy', '12', 'a.ts', 1, 3, '')`); err != nil {
+		t.Fatal(err)
+	}
+
+	// the missing flag columns and component_props read as their defaults
+	store, err := sqlite.NewReadOnly(path)
+	if err != nil {
+		t.Fatalf("open older index: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if found, err := store.FindByName("legacy"); err != nil || len(found) != 1 || found[0].Exported {
+		t.Fatalf("expected the legacy symbol without flags, got %+v, %v", found, err)
+	}
+	if found, err := store.Find(storage.SymbolFilter{ExportedOnly: true}); err != nil || len(found) != 0 {
+		t.Fatalf("expected no exported symbols, got %+v, %v", found, err)
+	}
+	if found, err := store.FindEnclosing("a.ts", 2); err != nil || len(found) != 1 {
+		t.Fatalf("expected the enclosing legacy symbol, got %+v, %v", found, err)
+	}
+	if props, err := store.FindComponentProps("Button"); err != nil || props != nil {
+		t.Fatalf("expected no component props, got %+v, %v", props, err)
+	}
+
+	var columns int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('symbols')`).Scan(&columns); err != nil {
+		t.Fatal(err)
+	}
+	if columns != 7 {
+		t.Fatalf("expected the read-only open to leave the schema alone, got %d columns", columns)
+	}
+}
@@ -0,0 +1,112 @@
+package sqlvec
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// projectionSeed makes the random projection reproducible across runs
+const projectionSeed = 0x7473696e646578
+
+// projection is a Gaussian random projection from inDim to outDim dimensions.
+// By the Johnson-Lindenstrauss lemma it approximately preserves distances,
+// so nearest-neighbour search keeps working on the reduced vectors.
+type projection struct {
+	inDim  int
+	outDim int
+	matrix []float32 // outDim rows of inDim entries
+}
+
+func newRandomProjection(inDim, outDim int) *projection {
+	rng := rand.New(rand.NewPCG(projectionSeed, uint64(inDim)<<32|uint64(outDim)))
+	scale := 1 / math.Sqrt(float64(outDim))
+	matrix := make([]float32, inDim*outDim)
+	for i := range matrix {
+		matrix[i] = float32(rng.NormFloat64() * scale)
+	}
+	return &projection{inDim: inDim, outDim: outDim, matrix: matrix}
+}
+
+func (p *projection) apply(v []float32) ([]float32, error) {
+	if len(v) != p.inDim {
+		return nil, fmt.Errorf(
+			"embedding dimension %d does not match the index projection input %d",
+			len(v), p.inDim,
+		)
+	}
+	out := make([]float32, p.outDim)
+	for row := range out {
+		weights := p.matrix[row*p.inDim : (row+1)*p.inDim]
+		var sum float32
+		for i, w := range weights {
+			sum += w * v[i]
+		}
+		out[row] = sum
+	}
+	return out, nil
+}
+
+func (p *projection) applyAll(vs [][]float32) ([][]float32, error) {
+	out := make([][]float32, len(vs))
+	for i, v := range vs {
+		projected, err := p.apply(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = projected
+	}
+	return out, nil
+}
+
+func migrateProjection(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS vec_projection (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		input_dim INTEGER NOT NULL,
+		output_dim INTEGER NOT NULL,
+		matrix BLOB NOT NULL
+	);`)
+	return err
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// loadProjection returns the projection persisted in the DB, or nil if vectors
+// are stored at full dimension
+func loadProjection(q queryRower) (*projection, error) {
+	var p projection
+	var blob []byte
+	err := q.QueryRow(`SELECT input_dim, output_dim, matrix FROM vec_projection WHERE id = 1`).
+		Scan(&p.inDim, &p.outDim, &blob)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) != 4*p.inDim*p.outDim {
+		return nil, fmt.Errorf("corrupt projection matrix: %d bytes for %dx%d", len(blob), p.outDim, p.inDim)
+	}
+	p.matrix = make([]float32, p.inDim*p.outDim)
+	for i := range p.matrix {
+		p.matrix[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return &p, nil
+}
+
+func (p *projection) save(tx *sql.Tx) error {
+	blob := make([]byte, 4*len(p.matrix))
+	for i, w := range p.matrix {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(w))
+	}
+	_, err := tx.Exec(
+		`INSERT INTO vec_projection(id, input_dim, output_dim, matrix) VALUES(1, ?, ?, ?)`,
+		p.inDim, p.outDim, blob,
+	)
+	return err
+}
@@ -0,0 +1,150 @@
+package sqlvec
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// index_meta keys of the recorded provenance and embedding model
+const (
+	metaCommit         = "commit"
+	metaDirty          = "dirty"
+	metaIndexedAt      = "indexed_at"
+	metaEmbedModel     = "embed_model"
+	metaEmbedDimension = "embed_dimension"
+)
+
+func migrateProvenance(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS index_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS file_commits (
+		file TEXT PRIMARY KEY,
+		commit_hash TEXT NOT NULL
+	);`)
+	return err
+}
+
+// SetProvenance records the commit the index was built from
+func (s *Store) SetProvenance(p models.IndexProvenance) error {
+	dirty := "false"
+	if p.Dirty {
+		dirty = "true"
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	return setMeta(tx, map[string]string{
+		metaCommit:    p.Commit,
+		metaDirty:     dirty,
+		metaIndexedAt: p.IndexedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// setMeta writes index_meta entries and commits tx, rolling it back on error
+func setMeta(tx *sql.Tx, meta map[string]string) error {
+	for key, value := range meta {
+		if _, err := tx.Exec(
+			`INSERT OR REPLACE INTO index_meta(key, value) VALUES(?, ?)`, key, value,
+		); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetEmbeddingModel records the embedding model the stored vectors come from
+func (s *Store) SetEmbeddingModel(m models.EmbeddingModel) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	return setMeta(tx, map[string]string{
+		metaEmbedModel:     m.Name,
+		metaEmbedDimension: strconv.Itoa(m.Dimension),
+	})
+}
+
+// EmbeddingModel returns the recorded embedding model, or nil if the index
+// was built before models were recorded or has no embeddings
+func (s *Store) EmbeddingModel() (*models.EmbeddingModel, error) {
+	if s.noMeta {
+		return nil, nil
+	}
+	var name, dim string
+	err := s.db.QueryRow(`SELECT value FROM index_meta WHERE key = ?`, metaEmbedModel).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRow(`SELECT value FROM index_meta WHERE key = ?`, metaEmbedDimension).Scan(&dim)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	m := &models.EmbeddingModel{Name: name}
+	m.Dimension, _ = strconv.Atoi(dim)
+	return m, nil
+}
+
+// Provenance returns the recorded provenance, or nil if the index was not
+// built from a git repository
+func (s *Store) Provenance() (*models.IndexProvenance, error) {
+	if s.noMeta {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT key, value FROM index_meta`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	meta := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		meta[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if meta[metaCommit] == "" {
+		return nil, nil
+	}
+	p := &models.IndexProvenance{Commit: meta[metaCommit], Dirty: meta[metaDirty] == "true"}
+	if at, err := time.Parse(time.RFC3339, meta[metaIndexedAt]); err == nil {
+		p.IndexedAt = at
+	}
+	return p, nil
+}
+
+// SetFileCommits records the commit that last modified each file
+func (s *Store) SetFileCommits(commits map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for file, commit := range commits {
+		if commit == "" {
+			_, err = tx.Exec(`DELETE FROM file_commits WHERE file = ?`, file)
+		} else {
+			_, err = tx.Exec(
+				`INSERT OR REPLACE INTO file_commits(file, commit_hash) VALUES(?, ?)`, file, commit,
+			)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return s.commit(tx)
+}
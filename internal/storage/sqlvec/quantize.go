@@ -0,0 +1,107 @@
+package sqlvec
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+// Vector element types of the vec_embeddings table
+const (
+	QuantizeFloat32 = "float32"
+	QuantizeInt8    = "int8"
+)
+
+// ValidQuantize reports whether q names a supported vector element type
+func ValidQuantize(q string) bool {
+	return q == "" || q == QuantizeFloat32 || q == QuantizeInt8
+}
+
+// vecTableSQL returns the statement creating vec_embeddings. Vectors are
+// compared by cosine distance, which also ignores the per-vector scale of
+// quantized int8 vectors.
+func vecTableSQL(dim int, quantize string) string {
+	column := fmt.Sprintf("embedding float32[%d] distance_metric=cosine", dim)
+	if quantize == QuantizeInt8 {
+		column = fmt.Sprintf("embedding int8[%d] distance_metric=cosine", dim)
+	}
+	return fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS vec_embeddings USING vec0(
+        %s
+    );`, column)
+}
+
+// vecTable describes an existing vec_embeddings table
+type vecTable struct {
+	quantize string
+	// cosine is false for float32 tables created before cosine distance became
+	// the default; those compare vectors by L2 distance
+	cosine bool
+}
+
+// loadVecTable reads the vec_embeddings definition, returning nil if the table
+// has not been created yet
+func loadVecTable(q queryRower) (*vecTable, error) {
+	var ddl string
+	err := q.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='vec_embeddings'`).
+		Scan(&ddl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	table := &vecTable{quantize: QuantizeFloat32, cosine: strings.Contains(ddl, "distance_metric=cosine")}
+	if strings.Contains(ddl, "int8[") {
+		table.quantize = QuantizeInt8
+	}
+	return table, nil
+}
+
+// similarity converts a vec0 distance into a score where higher means more
+// similar. Cosine tables score the cosine similarity, in [-1, 1]; L2 tables
+// score 1/(1+distance), in (0, 1]. Either way a vector scores 1 against itself.
+func similarity(distance float32, cosine bool) float32 {
+	if cosine {
+		return 1 - distance
+	}
+	return 1 / (1 + distance)
+}
+
+// quantizeInt8 scales v so its largest component maps to ±127 and rounds each
+// component to an int8. A fixed [-1, 1] range, as vec_quantize_int8 assumes,
+// would leave normalized high-dimensional embeddings only a few levels.
+func quantizeInt8(v []float32) []byte {
+	var maxAbs float64
+	for _, x := range v {
+		maxAbs = math.Max(maxAbs, math.Abs(float64(x)))
+	}
+	out := make([]byte, len(v))
+	if maxAbs == 0 {
+		return out
+	}
+	scale := 127 / maxAbs
+	for i, x := range v {
+		out[i] = byte(int8(math.Round(float64(x) * scale)))
+	}
+	return out
+}
+
+// serializeVector encodes v for the vec_embeddings element type
+func serializeVector(v []float32, quantize string) ([]byte, error) {
+	if quantize == QuantizeInt8 {
+		return quantizeInt8(v), nil
+	}
+	return sqlite_vec.SerializeFloat32(v)
+}
+
+// vectorParam is the SQL placeholder for a vector encoded by serializeVector
+func vectorParam(quantize string) string {
+	if quantize == QuantizeInt8 {
+		return "vec_int8(?)"
+	}
+	return "?"
+}
@@ -1,11 +1,16 @@
 package sqlvec
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -13,22 +18,202 @@ import (
 type Store struct {
 	db        *sql.DB
 	dimension int
+	reduceDim int
+	quantize  string
+	cosine    bool
+	batchSize int
+
+	projMu sync.RWMutex
+	proj   *projection
+
+	// gen counts committed writes, letting searches cache results per generation
+	gen atomic.Uint64
+
+	// noMeta and noCommits are set on a read-only store whose schema predates
+	// the index_meta and file_commits tables
+	noMeta, noCommits bool
+
+	// version is the connection a read-only store reads PRAGMA data_version
+	// on, which changes whenever another process commits
+	versionMu sync.Mutex
+	version   *sql.Conn
+}
+
+// Options tunes how vectors are stored
+type Options struct {
+	// ReduceDim randomly projects embeddings to this many dimensions before storing
+	// them, trading some recall for a smaller index. The projection is persisted in
+	// the DB on first write and applied to queries, so readers need not set it.
+	// 0 stores full-dimension vectors.
+	ReduceDim int
+	// Quantize selects the stored vector element type: QuantizeFloat32 (the
+	// default) or QuantizeInt8, which cuts vector storage about 4x at some cost
+	// in ranking accuracy. Like ReduceDim it is fixed when the index is created;
+	// an existing index keeps its type, so readers need not set it.
+	Quantize string
+	// UpsertBatchSize caps the chunks Upsert writes per transaction, so a large
+	// run commits as it goes and a late failure keeps the batches before it.
+	// 0 uses DefaultUpsertBatchSize.
+	UpsertBatchSize int
+	// ReadOnly opens an existing index without writing to it, so a search
+	// process can read a database an indexer is updating. Migrations are
+	// skipped and every write fails.
+	ReadOnly bool
+}
+
+// busyTimeoutMillis is how long a connection waits for another process's
+// lock on the database before failing
+const busyTimeoutMillis = "5000"
+
+// dsn returns the data source name opening path. Writers switch the database
+// to WAL journaling, which lets readers in other processes go on reading a
+// consistent snapshot during a write.
+func dsn(path string, readOnly bool) string {
+	uri := "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+	if readOnly {
+		return uri + "?mode=ro&_query_only=1&_busy_timeout=" + busyTimeoutMillis
+	}
+	return uri + "?_journal_mode=WAL&_busy_timeout=" + busyTimeoutMillis
 }
 
+// DefaultUpsertBatchSize is the number of chunks Upsert writes per transaction
+// unless Options.UpsertBatchSize is set
+const DefaultUpsertBatchSize = 1000
+
 func New(path string, dimension int) (*Store, error) {
+	return NewWithOptions(path, dimension, Options{})
+}
+
+func NewWithOptions(path string, dimension int, opts Options) (*Store, error) {
+	if !ValidQuantize(opts.Quantize) {
+		return nil, fmt.Errorf("unsupported vector quantization %q", opts.Quantize)
+	}
 	// enable sqlite-vec for all future connections
 	sqlite_vec.Auto()
-	db, err := sql.Open("sqlite3", path)
+	db, err := sql.Open("sqlite3", dsn(path, opts.ReadOnly))
 	if err != nil {
 		return nil, err
 	}
-	if err := migrate(db, dimension); err != nil {
+	store, err := open(db, dimension, opts)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// open checks the index in db against opts and, unless it is read-only,
+// migrates its schema
+func open(db *sql.DB, dimension int, opts Options) (*Store, error) {
+	// a read-only store cannot migrate, so tables added since the index was
+	// built are treated as empty
+	hasProj, hasMeta, hasCommits := true, true, true
+	if opts.ReadOnly {
+		var err error
+		if hasProj, err = tableExists(db, "vec_projection"); err != nil {
+			return nil, err
+		}
+		if hasMeta, err = tableExists(db, "index_meta"); err != nil {
+			return nil, err
+		}
+		if hasCommits, err = tableExists(db, "file_commits"); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := migrateProjection(db); err != nil {
+			return nil, err
+		}
+		if err := migrateProvenance(db); err != nil {
+			return nil, err
+		}
+	}
+	var proj *projection
+	if hasProj {
+		var err error
+		if proj, err = loadProjection(db); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case proj != nil:
+		if opts.ReduceDim > 0 && opts.ReduceDim != proj.outDim {
+			return nil, fmt.Errorf(
+				"index vectors are reduced to %d dimensions, not %d",
+				proj.outDim, opts.ReduceDim,
+			)
+		}
+		dimension = proj.outDim
+	case opts.ReduceDim > 0:
+		exists, err := tableExists(db, "vec_embeddings")
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf(
+				"index already stores full-dimension vectors; rebuild it to reduce dimensions",
+			)
+		}
+		// the table is created once the projection is fitted on the first write
+		dimension = 0
+	}
+	table, err := loadVecTable(db)
+	if err != nil {
 		return nil, err
 	}
-	return &Store{db: db, dimension: dimension}, nil
+	switch {
+	case table == nil:
+		table = &vecTable{quantize: opts.Quantize, cosine: true}
+		if table.quantize == "" {
+			table.quantize = QuantizeFloat32
+		}
+	case opts.Quantize != "" && opts.Quantize != table.quantize:
+		return nil, fmt.Errorf(
+			"index stores %s vectors, not %s; rebuild it to change quantization",
+			table.quantize, opts.Quantize,
+		)
+	}
+	quantize := table.quantize
+	if !opts.ReadOnly {
+		if err := migrate(db, dimension, quantize); err != nil {
+			return nil, err
+		}
+	}
+	batchSize := opts.UpsertBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultUpsertBatchSize
+	}
+	store := &Store{
+		db:        db,
+		dimension: dimension,
+		reduceDim: opts.ReduceDim,
+		quantize:  quantize,
+		cosine:    table.cosine,
+		batchSize: batchSize,
+		proj:      proj,
+		noMeta:    !hasMeta,
+		noCommits: !hasCommits,
+	}
+	if opts.ReadOnly {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		store.version = conn
+	}
+	return store, nil
 }
 
-func migrate(db *sql.DB, dim int) error {
+func tableExists(q queryRower, table string) (bool, error) {
+	var name string
+	err := q.QueryRow(`SELECT name FROM sqlite_master WHERE type='table' AND name=?`, table).
+		Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func migrate(db *sql.DB, dim int, quantize string) error {
 	// symbols table (reuse schema from sqlite store if not exists)
 	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS symbols (
 		id TEXT PRIMARY KEY,
@@ -69,9 +254,7 @@ func migrate(db *sql.DB, dim int) error {
 	// vec0 virtual table holds embeddings; dimension is fixed per table.
 	// If dim <= 0, defer creation until first Upsert when dimension is known.
 	if dim > 0 {
-		if _, err := db.Exec(fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS vec_embeddings USING vec0(
-            embedding float32[%d]
-        );`, dim)); err != nil {
+		if _, err := db.Exec(vecTableSQL(dim, quantize)); err != nil {
 			return err
 		}
 		if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS vec_map (
@@ -87,17 +270,63 @@ func migrate(db *sql.DB, dim int) error {
 	return nil
 }
 
-func (s *Store) Close() error { return s.db.Close() }
+func (s *Store) Close() error {
+	if s.version != nil {
+		_ = s.version.Close()
+	}
+	return s.db.Close()
+}
 
-// Ensure Store implements storage.VectorStore-like methods
+// Compact reclaims free pages left behind by deletes and reindexing.
+// VACUUM rebuilds the file including the vec0 shadow tables. In WAL mode the
+// rebuilt pages land in the -wal file, so it is checkpointed and truncated to
+// shrink the database file itself. PRAGMA optimize refreshes query planner
+// statistics afterwards.
+func (s *Store) Compact() error {
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	var busy, logPages, checkpointed int
+	if err := s.db.QueryRow(`PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logPages, &checkpointed); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("checkpoint: database is busy")
+	}
+	if _, err := s.db.Exec(`PRAGMA optimize`); err != nil {
+		return fmt.Errorf("optimize: %w", err)
+	}
+	return nil
+}
+
+// Upsert stores chunks with their embeddings, replacing chunks with the same
+// ID. Every UpsertBatchSize chunks are committed in their own transaction, so
+// on error the batches before the failing one stay stored; upserting again is
+// safe. The vector table's dimension is taken from the first batch.
 func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error {
 	if len(chunks) != len(embeddings) {
 		return fmt.Errorf("chunks and embeddings length mismatch")
 	}
+	for start := 0; start < len(chunks); start += s.batchSize {
+		end := min(start+s.batchSize, len(chunks))
+		if err := s.upsertBatch(chunks[start:end], embeddings[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertBatch stores chunks with their embeddings in a single transaction
+func (s *Store) upsertBatch(chunks []models.CodeChunk, embeddings [][]float32) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	embeddings, created, err := s.reduce(tx, embeddings)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 	// Ensure vec table exists with correct dimension
 	if err := s.ensureVecTable(tx, embeddings); err != nil {
 		_ = tx.Rollback()
@@ -128,14 +357,24 @@ func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error
 	defer func() { _ = chunkStmt.Close() }()
 
 	// prepare statements for vector write and mapping
-	insertVecStmt, err := tx.Prepare(`INSERT INTO vec_embeddings(embedding) VALUES(?)`)
+	insertVecStmt, err := tx.Prepare(
+		`INSERT INTO vec_embeddings(embedding) VALUES(` + vectorParam(s.quantize) + `)`,
+	)
 	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 	defer func() { _ = insertVecStmt.Close() }()
+	// vec0 tables reject INSERT OR REPLACE, so a chunk's vector is replaced by
+	// deleting its row and inserting it again under the same rowid
+	deleteVecStmt, err := tx.Prepare(`DELETE FROM vec_embeddings WHERE rowid = ?`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func() { _ = deleteVecStmt.Close() }()
 	replaceVecStmt, err := tx.Prepare(
-		`INSERT OR REPLACE INTO vec_embeddings(rowid, embedding) VALUES(?, ?)`,
+		`INSERT INTO vec_embeddings(rowid, embedding) VALUES(?, ` + vectorParam(s.quantize) + `)`,
 	)
 	if err != nil {
 		_ = tx.Rollback()
@@ -163,7 +402,7 @@ func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error
 			_ = tx.Rollback()
 			return err
 		}
-		v, err := sqlite_vec.SerializeFloat32(embeddings[i])
+		v, err := serializeVector(embeddings[i], s.quantize)
 		if err != nil {
 			_ = tx.Rollback()
 			return err
@@ -176,6 +415,10 @@ func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error
 			return err
 		}
 		if rid.Valid {
+			if _, err := deleteVecStmt.Exec(rid.Int64); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
 			if _, err := replaceVecStmt.Exec(rid.Int64, v); err != nil {
 				_ = tx.Rollback()
 				return err
@@ -196,7 +439,54 @@ func (s *Store) Upsert(chunks []models.CodeChunk, embeddings [][]float32) error
 			}
 		}
 	}
-	return tx.Commit()
+	if err := s.commit(tx); err != nil {
+		return err
+	}
+	if created != nil {
+		s.projMu.Lock()
+		s.proj = created
+		s.projMu.Unlock()
+	}
+	return nil
+}
+
+// reduce projects embeddings when dimensionality reduction is enabled. The
+// projection is created from the first batch's dimension and saved in tx;
+// it is returned so the caller can adopt it once tx commits.
+func (s *Store) reduce(tx *sql.Tx, embeddings [][]float32) ([][]float32, *projection, error) {
+	s.projMu.RLock()
+	proj := s.proj
+	s.projMu.RUnlock()
+	var created *projection
+	if proj == nil {
+		if s.reduceDim <= 0 || len(embeddings) == 0 {
+			return embeddings, nil, nil
+		}
+		inDim := len(embeddings[0])
+		if s.reduceDim >= inDim {
+			return nil, nil, fmt.Errorf(
+				"reduced dimension %d must be smaller than the embedding dimension %d",
+				s.reduceDim, inDim,
+			)
+		}
+		// another writer may have saved one since this store was opened
+		saved, err := loadProjection(tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if saved == nil {
+			saved = newRandomProjection(inDim, s.reduceDim)
+			if err := saved.save(tx); err != nil {
+				return nil, nil, err
+			}
+		}
+		proj, created = saved, saved
+	}
+	reduced, err := proj.applyAll(embeddings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reduced, created, nil
 }
 
 func (s *Store) DeleteByFile(file string) error {
@@ -224,75 +514,290 @@ func (s *Store) DeleteByFile(file string) error {
 		_ = tx.Rollback()
 		return err
 	}
+	if _, err := tx.Exec(`DELETE FROM file_commits WHERE file = ?`, file); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := deleteVectors(tx, ids); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return s.commit(tx)
+}
+
+// DeleteByIDs removes the chunks with the given ids together with their
+// embeddings and vec_map entries in a single transaction. Unknown ids are ignored.
+func (s *Store) DeleteByIDs(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`DELETE FROM chunks WHERE id = ?`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, id := range ids {
+		if _, err := stmt.Exec(id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := deleteVectors(tx, ids); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return s.commit(tx)
+}
+
+// deleteVectors removes the embeddings and vec_map entries of chunk ids
+func deleteVectors(tx *sql.Tx, ids []string) error {
 	for _, id := range ids {
 		// find rid via map
 		var rid sql.NullInt64
 		if err := tx.QueryRow(`SELECT rid FROM vec_map WHERE id = ?`, id).Scan(&rid); err != nil &&
 			!errors.Is(err, sql.ErrNoRows) {
-			_ = tx.Rollback()
 			return err
 		}
 		if rid.Valid {
 			if _, err := tx.Exec(`DELETE FROM vec_embeddings WHERE rowid = ?`, rid.Int64); err != nil {
-				_ = tx.Rollback()
 				return err
 			}
 			if _, err := tx.Exec(`DELETE FROM vec_map WHERE rid = ?`, rid.Int64); err != nil {
-				_ = tx.Rollback()
 				return err
 			}
 		}
 	}
-	return tx.Commit()
+	return nil
+}
+
+// commit commits a transaction that changed stored chunks and bumps the generation
+func (s *Store) commit(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.gen.Add(1)
+	return nil
+}
+
+// Generation implements storage.GenerationStore. A writable Store counts the
+// writes made through it, not those of other processes sharing the database.
+// A read-only Store reports the database's data_version, which changes with
+// every commit of another process.
+func (s *Store) Generation() uint64 {
+	if s.version == nil {
+		return s.gen.Load()
+	}
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	var version uint64
+	if err := s.version.QueryRowContext(context.Background(), `PRAGMA data_version`).Scan(&version); err != nil {
+		// a generation never reported before, so nothing cached is reused
+		return 1<<63 | s.gen.Add(1)
+	}
+	return version
+}
+
+// commitJoin returns the column and join selecting each chunk's last commit,
+// or an empty string on an index without file_commits
+func (s *Store) commitJoin() (column, join string) {
+	if s.noCommits {
+		return "''", ""
+	}
+	return "COALESCE(fc.commit_hash, '')", "LEFT JOIN file_commits fc ON fc.file = c.file"
+}
+
+// HasEmbeddings reports whether the vector table exists; indexes built with
+// symbols only never create it
+func (s *Store) HasEmbeddings() (bool, error) {
+	return tableExists(s.db, "vec_embeddings")
 }
 
 func (s *Store) Query(embedding []float32, topK int) ([]models.SemanticHit, error) {
+	var hits []models.SemanticHit
+	err := s.QueryEach(context.Background(), embedding, topK, func(hit models.SemanticHit) error {
+		hits = append(hits, hit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// QueryEach runs the KNN query of Query and passes each hit to fn as its row
+// is read, most similar first. An error from fn stops the query and is returned.
+func (s *Store) QueryEach(
+	ctx context.Context,
+	embedding []float32,
+	topK int,
+	fn func(models.SemanticHit) error,
+) error {
 	if topK <= 0 {
 		topK = 5
 	}
-	v, err := sqlite_vec.SerializeFloat32(embedding)
+	if ok, err := s.HasEmbeddings(); err != nil {
+		return err
+	} else if !ok {
+		return storage.ErrNoEmbeddings
+	}
+	s.projMu.RLock()
+	proj := s.proj
+	s.projMu.RUnlock()
+	if proj != nil {
+		projected, err := proj.apply(embedding)
+		if err != nil {
+			return err
+		}
+		embedding = projected
+	}
+	v, err := serializeVector(embedding, s.quantize)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// KNN via MATCH ... ORDER BY distance using sqlite-vec
-	rows, err := s.db.Query(`
+	commitColumn, commitJoin := s.commitJoin()
+	rows, err := s.db.QueryContext(ctx, `
         WITH knn AS (
             SELECT rowid, distance
             FROM vec_embeddings
-            WHERE embedding MATCH ?
+            WHERE embedding MATCH `+vectorParam(s.quantize)+`
             ORDER BY distance
             LIMIT ?
         )
         SELECT c.id, c.file, c.language, c.node_type, c.start_line, c.end_line, c.start_byte, c.end_byte,
-               c.content, c.docstring, c.signature, c.kind, c.name,
-               k.distance as score
+               c.content, c.docstring, c.signature, c.kind, c.name, `+commitColumn+`,
+               k.distance
         FROM knn k
         JOIN vec_map m ON m.rid = k.rowid
         JOIN chunks c ON c.id = m.id
+        `+commitJoin+`
         ORDER BY k.distance ASC
     `, v, topK)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var ch models.CodeChunk
+		var kind string
+		var distance float32
+		if err := rows.Scan(
+			&ch.ID, &ch.File, &ch.Language, &ch.NodeType, &ch.StartLine, &ch.EndLine, &ch.StartByte, &ch.EndByte,
+			&ch.Content, &ch.Docstring, &ch.Signature, &kind, &ch.Name, &ch.LastCommit, &distance,
+		); err != nil {
+			return err
+		}
+		ch.Kind = models.StringToSymbolKind(kind)
+		if err := fn(models.SemanticHit{Chunk: ch, Score: similarity(distance, s.cosine)}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetChunk returns the stored chunk with the given ID, or nil if there is none
+func (s *Store) GetChunk(id string) (*models.CodeChunk, error) {
+	commitColumn, commitJoin := s.commitJoin()
+	row := s.db.QueryRow(`
+        SELECT c.id, c.file, c.language, c.node_type, c.start_line, c.end_line, c.start_byte, c.end_byte,
+               c.content, c.docstring, c.signature, c.kind, c.name, `+commitColumn+`
+        FROM chunks c
+        `+commitJoin+`
+        WHERE c.id = ?
+    `, id)
+	var ch models.CodeChunk
+	var kind string
+	if err := row.Scan(
+		&ch.ID, &ch.File, &ch.Language, &ch.NodeType, &ch.StartLine, &ch.EndLine, &ch.StartByte, &ch.EndByte,
+		&ch.Content, &ch.Docstring, &ch.Signature, &kind, &ch.Name, &ch.LastCommit,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ch.Kind = models.StringToSymbolKind(kind)
+	return &ch, nil
+}
+
+// ChunksByFile returns the stored chunks of file ordered by line
+func (s *Store) ChunksByFile(file string) ([]models.CodeChunk, error) {
+	rows, err := s.db.Query(`
+        SELECT id, file, language, node_type, start_line, end_line, start_byte, end_byte,
+               content, docstring, signature, kind, name
+        FROM chunks
+        WHERE file = ?
+        ORDER BY start_line, id
+    `, file)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
-	var hits []models.SemanticHit
+	var out []models.CodeChunk
 	for rows.Next() {
 		var ch models.CodeChunk
 		var kind string
-		var score float32
 		if err := rows.Scan(
 			&ch.ID, &ch.File, &ch.Language, &ch.NodeType, &ch.StartLine, &ch.EndLine, &ch.StartByte, &ch.EndByte,
-			&ch.Content, &ch.Docstring, &ch.Signature, &kind, &ch.Name, &score,
+			&ch.Content, &ch.Docstring, &ch.Signature, &kind, &ch.Name,
 		); err != nil {
 			return nil, err
 		}
 		ch.Kind = models.StringToSymbolKind(kind)
-		hits = append(hits, models.SemanticHit{Chunk: ch, Score: 1 - score})
+		out = append(out, ch)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return out, rows.Err()
+}
+
+// MoveChunks replaces each stored chunk ids[k] with chunks[k] in a single
+// transaction, keeping its embedding. Unknown ids are ignored.
+func (s *Store) MoveChunks(ids []string, chunks []models.CodeChunk) error {
+	if len(ids) != len(chunks) {
+		return fmt.Errorf("ids and chunks length mismatch")
 	}
-	return hits, nil
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	// park every chunk under a temporary ID first, so one can move to the ID
+	// another is moving away from
+	for k, id := range ids {
+		tmp := fmt.Sprintf("\x00move%d", k)
+		if _, err := tx.Exec(`UPDATE chunks SET id = ? WHERE id = ?`, tmp, id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE vec_map SET id = ? WHERE id = ?`, tmp, id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	for k, ch := range chunks {
+		tmp := fmt.Sprintf("\x00move%d", k)
+		if _, err := tx.Exec(`UPDATE chunks SET
+			id=?,file=?,language=?,node_type=?,start_line=?,end_line=?,start_byte=?,end_byte=?,
+			content=?,docstring=?,signature=?,kind=?,name=?
+			WHERE id = ?`,
+			ch.ID, ch.File, ch.Language, ch.NodeType, ch.StartLine, ch.EndLine, ch.StartByte, ch.EndByte,
+			ch.Content, ch.Docstring, ch.Signature, fmt.Sprint(rune(ch.Kind)), ch.Name, tmp,
+		); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE vec_map SET id = ? WHERE id = ?`, ch.ID, tmp); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return s.commit(tx)
 }
 
 func (s *Store) ensureVecTable(tx *sql.Tx, embeddings [][]float32) error {
@@ -311,9 +816,7 @@ func (s *Store) ensureVecTable(tx *sql.Tx, embeddings [][]float32) error {
 		return fmt.Errorf("cannot create vec_embeddings: unknown embedding dimension")
 	}
 	dim := len(embeddings[0])
-	if _, err := tx.Exec(fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS vec_embeddings USING vec0(
-        embedding float32[%d]
-    );`, dim)); err != nil {
+	if _, err := tx.Exec(vecTableSQL(dim, s.quantize)); err != nil {
 		return err
 	}
 	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS vec_map (
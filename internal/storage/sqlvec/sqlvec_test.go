@@ -0,0 +1,663 @@
+package sqlvec_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x5457/ts-index/internal/models"
+	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/storage/sqlvec"
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+func Test_Store_DeleteByIDs(t *testing.T) {
+	store, err := sqlvec.New(filepath.Join(t.TempDir(), "index.db"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	chunks := []models.CodeChunk{
+		{ID: "a", File: "a.ts", Name: "a"},
+		{ID: "b", File: "a.ts", Name: "b"},
+		{ID: "c", File: "c.ts", Name: "c"},
+	}
+	vecs := [][]float32{{1, 0}, {0, 1}, {1, 1}}
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	if err := store.DeleteByIDs([]string{"a", "c", "missing"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	hits, err := store.Query([]float32{1, 0}, 10)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "b" {
+		t.Fatalf("expected only chunk b to remain, got %+v", hits)
+	}
+
+	// a re-inserted id gets a fresh vector row
+	if err := store.Upsert(chunks[:1], vecs[:1]); err != nil {
+		t.Fatalf("re-upsert: %v", err)
+	}
+	hits, err = store.Query([]float32{1, 0}, 10)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Chunk.ID != "a" {
+		t.Fatalf("expected chunk a to be searchable again, got %+v", hits)
+	}
+}
+
+func Test_Store_MoveChunks(t *testing.T) {
+	store, err := sqlvec.New(filepath.Join(t.TempDir(), "index.db"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	chunks := []models.CodeChunk{
+		{ID: "a", File: "a.ts", Name: "a", StartLine: 1},
+		{ID: "b", File: "a.ts", Name: "b", StartLine: 2},
+		{ID: "c", File: "c.ts", Name: "c", StartLine: 1},
+	}
+	if err := store.Upsert(chunks, [][]float32{{1, 0}, {0, 1}, {1, 1}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	// a and b swap IDs; each keeps its own vector
+	if err := store.MoveChunks([]string{"a", "b"}, []models.CodeChunk{
+		{ID: "b", File: "a.ts", Name: "a", StartLine: 2},
+		{ID: "a", File: "a.ts", Name: "b", StartLine: 1},
+	}); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	hits, err := store.Query([]float32{1, 0}, 1)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "b" || hits[0].Chunk.Name != "a" || hits[0].Chunk.StartLine != 2 {
+		t.Fatalf("expected chunk a moved to b at line 2, got %+v", hits)
+	}
+
+	stored, err := store.ChunksByFile("a.ts")
+	if err != nil {
+		t.Fatalf("chunks by file: %v", err)
+	}
+	if len(stored) != 2 || stored[0].ID != "a" || stored[0].Name != "b" || stored[1].ID != "b" {
+		t.Fatalf("expected the moved chunks of a.ts by line, got %+v", stored)
+	}
+}
+
+func Test_Store_UpsertBatches(t *testing.T) {
+	store, err := sqlvec.NewWithOptions(filepath.Join(t.TempDir(), "index.db"), 0, sqlvec.Options{UpsertBatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	// the fifth vector has the wrong dimension, failing the third batch
+	chunks := make([]models.CodeChunk, 5)
+	vecs := make([][]float32, 5)
+	for i := range chunks {
+		chunks[i] = models.CodeChunk{ID: fmt.Sprint(i), File: "a.ts"}
+		vecs[i] = []float32{float32(i), 1}
+	}
+	vecs[4] = []float32{1, 1, 1}
+	if err := store.Upsert(chunks, vecs); err == nil {
+		t.Fatalf("expected the last batch to fail")
+	}
+	hits, err := store.Query([]float32{1, 1}, 10)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 4 {
+		t.Fatalf("expected the first two batches to stay stored, got %+v", hits)
+	}
+
+	// retrying once the input is fixed stores the rest
+	vecs[4] = []float32{4, 1}
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if hits, err := store.Query([]float32{1, 1}, 10); err != nil || len(hits) != 5 {
+		t.Fatalf("expected all five chunks after the retry, got %+v, %v", hits, err)
+	}
+
+	// upserting a stored ID replaces its vector
+	if err := store.Upsert(chunks[:1], [][]float32{{-1, 0}}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if hits, err := store.Query([]float32{-1, 0}, 1); err != nil || len(hits) != 1 || hits[0].Chunk.ID != "0" {
+		t.Fatalf("expected the replaced vector of chunk 0, got %+v, %v", hits, err)
+	}
+}
+
+func Test_Store_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	store, err := sqlvec.New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const dim = 64
+	content := strings.Repeat("export const x = 1\n", 50)
+	chunks := make([]models.CodeChunk, 400)
+	vecs := make([][]float32, len(chunks))
+	for i := range chunks {
+		chunks[i] = models.CodeChunk{ID: fmt.Sprint(i), File: "big.ts", Name: fmt.Sprint(i), Content: content}
+		vecs[i] = make([]float32, dim)
+		vecs[i][i%dim] = 1
+	}
+	chunks[0].File, chunks[1].File = "keep.ts", "keep.ts"
+	vecs[1][0], vecs[1][1] = 0, 1
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.DeleteByFile("big.ts"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// closing checkpoints the WAL so the main file holds every page
+	_ = store.Close()
+	before := fileSize(t, path)
+
+	store, err = sqlvec.New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	// the WAL is checkpointed, so the file has shrunk while the store is open
+	if after := fileSize(t, path); after >= before {
+		t.Fatalf("expected compaction to shrink the index from %d bytes, got %d", before, after)
+	}
+	_ = store.Close()
+
+	store, err = sqlvec.New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+	hits, err := store.Query(vecs[1], 10)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Chunk.ID != "1" || hits[1].Chunk.ID != "0" || hits[0].Chunk.Content != content {
+		t.Fatalf("expected the chunks of keep.ts after compaction, got %+v", hits)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func Test_Store_ReduceDim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	store, err := sqlvec.NewWithOptions(path, 0, sqlvec.Options{ReduceDim: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one-hot vectors stay well separated after projection
+	const dim = 16
+	chunks := make([]models.CodeChunk, dim)
+	vecs := make([][]float32, dim)
+	for i := range chunks {
+		chunks[i] = models.CodeChunk{ID: string(rune('a' + i)), File: "a.ts"}
+		vecs[i] = make([]float32, dim)
+		vecs[i][i] = 1
+	}
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	hits, err := store.Query(vecs[3], 1)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "d" {
+		t.Fatalf("expected chunk d, got %+v", hits)
+	}
+	_ = store.Close()
+
+	// readers pick the persisted projection up without options
+	reader, err := sqlvec.New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, err = reader.Query(vecs[7], 1)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "h" {
+		t.Fatalf("expected chunk h, got %+v", hits)
+	}
+	_ = reader.Close()
+
+	if _, err := sqlvec.NewWithOptions(path, 0, sqlvec.Options{ReduceDim: 8}); err == nil {
+		t.Fatalf("expected error for a different reduced dimension")
+	}
+
+	// an index already holding full-dimension vectors cannot be reduced in place
+	fullPath := filepath.Join(t.TempDir(), "full.db")
+	full, err := sqlvec.New(fullPath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = full.Close()
+	if _, err := sqlvec.NewWithOptions(fullPath, 0, sqlvec.Options{ReduceDim: 1}); err == nil {
+		t.Fatalf("expected error when reducing a full-dimension index")
+	}
+}
+
+// Test_Store_QuantizeInt8 documents how much int8 quantization perturbs
+// rankings by comparing its top-K against a full-precision index
+func Test_Store_QuantizeInt8(t *testing.T) {
+	const (
+		dim     = 128
+		n       = 1000
+		queries = 20
+		topK    = 10
+	)
+	rng := rand.New(rand.NewPCG(1, 2))
+	unit := func() []float32 {
+		v := make([]float32, dim)
+		var norm float64
+		for i := range v {
+			x := rng.NormFloat64()
+			v[i] = float32(x)
+			norm += x * x
+		}
+		for i := range v {
+			v[i] /= float32(math.Sqrt(norm))
+		}
+		return v
+	}
+	chunks := make([]models.CodeChunk, n)
+	vecs := make([][]float32, n)
+	for i := range chunks {
+		chunks[i] = models.CodeChunk{ID: fmt.Sprint(i), File: "a.ts"}
+		vecs[i] = unit()
+	}
+
+	dir := t.TempDir()
+	full, err := sqlvec.New(filepath.Join(dir, "full.db"), dim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = full.Close() }()
+	quantPath := filepath.Join(dir, "int8.db")
+	quant, err := sqlvec.NewWithOptions(quantPath, dim, sqlvec.Options{Quantize: sqlvec.QuantizeInt8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = quant.Close() }()
+	if err := full.Upsert(chunks, vecs); err != nil {
+		t.Fatal(err)
+	}
+	if err := quant.Upsert(chunks, vecs); err != nil {
+		t.Fatal(err)
+	}
+
+	overlap := 0
+	for range queries {
+		q := unit()
+		want, err := full.Query(q, topK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := quant.Query(q, topK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != topK {
+			t.Fatalf("expected %d quantized hits, got %d", topK, len(got))
+		}
+		ids := make(map[string]bool, topK)
+		for _, hit := range want {
+			ids[hit.Chunk.ID] = true
+		}
+		for _, hit := range got {
+			if ids[hit.Chunk.ID] {
+				overlap++
+			}
+		}
+	}
+	ratio := float64(overlap) / float64(queries*topK)
+	t.Logf("int8 top-%d overlap with float32: %.2f", topK, ratio)
+	if ratio < 0.9 {
+		t.Fatalf("int8 top-%d overlap %.2f below 0.9", topK, ratio)
+	}
+
+	// the quantization is kept when reopened without options and cannot be changed
+	_ = quant.Close()
+	if _, err := sqlvec.NewWithOptions(quantPath, dim, sqlvec.Options{Quantize: sqlvec.QuantizeFloat32}); err == nil {
+		t.Fatal("expected error switching an int8 index to float32")
+	}
+	reopened, err := sqlvec.New(quantPath, dim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reopened.Close() }()
+	hits, err := reopened.Query(vecs[0], 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "0" {
+		t.Fatalf("expected chunk 0 as its own nearest neighbour, got %+v", hits)
+	}
+}
+
+func Test_Store_ScoreIdenticalQuery(t *testing.T) {
+	chunks := []models.CodeChunk{
+		{ID: "a", File: "a.ts", Name: "a"},
+		{ID: "b", File: "a.ts", Name: "b"},
+		{ID: "c", File: "a.ts", Name: "c"},
+	}
+	vecs := [][]float32{{3, 1}, {1, 2}, {-1, 0.5}}
+	check := func(t *testing.T, store *sqlvec.Store) {
+		t.Helper()
+		if err := store.Upsert(chunks, vecs); err != nil {
+			t.Fatal(err)
+		}
+		hits, err := store.Query(vecs[1], 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 3 || hits[0].Chunk.ID != "b" {
+			t.Fatalf("expected chunk b first, got %+v", hits)
+		}
+		if math.Abs(float64(hits[0].Score)-1) > 1e-4 {
+			t.Fatalf("expected an identical query to score 1, got %f", hits[0].Score)
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Fatalf("expected descending scores, got %+v", hits)
+			}
+		}
+	}
+
+	t.Run("cosine", func(t *testing.T) {
+		for _, q := range []string{sqlvec.QuantizeFloat32, sqlvec.QuantizeInt8} {
+			store, err := sqlvec.NewWithOptions(
+				filepath.Join(t.TempDir(), "index.db"), 2, sqlvec.Options{Quantize: q},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, store)
+			_ = store.Close()
+		}
+	})
+
+	t.Run("legacy L2", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "index.db")
+		sqlite_vec.Auto()
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`CREATE VIRTUAL TABLE vec_embeddings USING vec0(embedding float32[2])`); err != nil {
+			t.Fatal(err)
+		}
+		_ = db.Close()
+		store, err := sqlvec.New(path, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = store.Close() }()
+		check(t, store)
+	})
+}
+
+func Test_Store_NoEmbeddings(t *testing.T) {
+	store, err := sqlvec.New(filepath.Join(t.TempDir(), "index.db"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	has, err := store.HasEmbeddings()
+	if err != nil || has {
+		t.Fatalf("expected no embeddings in a fresh store, got %v, %v", has, err)
+	}
+	if _, err := store.Query([]float32{1, 0}, 5); !errors.Is(err, storage.ErrNoEmbeddings) {
+		t.Fatalf("expected ErrNoEmbeddings, got %v", err)
+	}
+
+	if err := store.Upsert([]models.CodeChunk{{ID: "a", File: "a.ts"}}, [][]float32{{1, 0}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	has, err = store.HasEmbeddings()
+	if err != nil || !has {
+		t.Fatalf("expected embeddings after upsert, got %v, %v", has, err)
+	}
+}
+
+func Test_Store_Generation(t *testing.T) {
+	store, err := sqlvec.New(filepath.Join(t.TempDir(), "index.db"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	gen := store.Generation()
+	if err := store.Upsert([]models.CodeChunk{{ID: "a", File: "a.ts"}}, [][]float32{{1, 0}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if store.Generation() == gen {
+		t.Fatal("expected upsert to bump the generation")
+	}
+	gen = store.Generation()
+	if _, err := store.Query([]float32{1, 0}, 1); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if store.Generation() != gen {
+		t.Fatal("expected queries to leave the generation alone")
+	}
+	if err := store.DeleteByFile("a.ts"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if store.Generation() == gen {
+		t.Fatal("expected delete to bump the generation")
+	}
+}
+
+func Test_Store_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	if _, err := sqlvec.NewWithOptions(path, 0, sqlvec.Options{ReadOnly: true}); err == nil {
+		t.Fatal("expected opening a missing index read-only to fail")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the read-only open not to create the database, got %v", err)
+	}
+
+	writer, err := sqlvec.New(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = writer.Close() }()
+	if err := writer.Upsert([]models.CodeChunk{{ID: "c0", File: "0.ts"}}, [][]float32{{1, 0}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	reader, err := sqlvec.NewWithOptions(path, 0, sqlvec.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+	if err := reader.Upsert([]models.CodeChunk{{ID: "x", File: "x.ts"}}, [][]float32{{1, 0}}); err == nil {
+		t.Fatal("expected a write through the read-only store to fail")
+	}
+
+	gen := reader.Generation()
+	if reader.Generation() != gen {
+		t.Fatal("expected the generation to hold while nothing is written")
+	}
+
+	// the reader keeps answering while the writer commits batch after batch
+	const batches = 50
+	done := make(chan error, 1)
+	go func() {
+		for i := 1; i <= batches; i++ {
+			chunks := []models.CodeChunk{
+				{ID: fmt.Sprintf("c%d", i), File: fmt.Sprintf("%d.ts", i)},
+				{ID: fmt.Sprintf("d%d", i), File: fmt.Sprintf("%d.ts", i)},
+			}
+			if err := writer.Upsert(chunks, [][]float32{{1, float32(i)}, {float32(i), 1}}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for writing := true; writing; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			writing = false
+		default:
+		}
+		hits, err := reader.Query([]float32{1, 0}, 200)
+		if err != nil {
+			t.Fatalf("read during writes: %v", err)
+		}
+		if len(hits) == 0 || len(hits)%2 == 0 {
+			t.Fatalf("expected whole batches on top of the first chunk, got %d hits", len(hits))
+		}
+	}
+	hits, err := reader.Query([]float32{1, 0}, 200)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1+2*batches {
+		t.Fatalf("expected the reader to see all %d chunks, got %d", 1+2*batches, len(hits))
+	}
+	if reader.Generation() == gen {
+		t.Fatal("expected another process's writes to change the reader's generation")
+	}
+}
+
+// Test_Store_ReadOnlyBaselineSchema reads an index written before the
+// projection, provenance and commit tables existed, which a read-only store
+// cannot add
+func Test_Store_ReadOnlyBaselineSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	sqlite_vec.Auto()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	vec, err := sqlite_vec.SerializeFloat32([]float32{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE symbols (id TEXT PRIMARY KEY, name TEXT NOT NULL, kind TEXT NOT NULL, file TEXT NOT NULL,
+			start_line INTEGER NOT NULL, end_line INTEGER NOT NULL, docstring TEXT)`,
+		`CREATE TABLE chunks (id TEXT PRIMARY KEY, file TEXT NOT NULL, language TEXT, node_type TEXT,
+			start_line INTEGER, end_line INTEGER, start_byte INTEGER, end_byte INTEGER, content TEXT,
+			docstring TEXT, signature TEXT, kind TEXT, name TEXT)`,
+		`CREATE VIRTUAL TABLE vec_embeddings USING vec0(embedding float32[2])`,
+		`CREATE TABLE vec_map (rid INTEGER UNIQUE NOT NULL, id TEXT UNIQUE NOT NULL)`,
+		`INSERT INTO chunks VALUES('a', 'a.ts', 'typescript', 'function_declaration', 1, 3, 0, 40,
+			'function a() {}', '', 'function a()', 'function', 'a')`,
+		`INSERT INTO vec_map(rid, id) VALUES(1, 'a')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO vec_embeddings(rowid, embedding) VALUES(1, ?)`, vec); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := sqlvec.NewWithOptions(path, 0, sqlvec.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	hits, err := store.Query([]float32{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.ID != "a" || hits[0].Chunk.LastCommit != "" {
+		t.Fatalf("expected chunk a without a commit, got %+v", hits)
+	}
+	if ch, err := store.GetChunk("a"); err != nil || ch == nil {
+		t.Fatalf("expected chunk a, got %+v, %v", ch, err)
+	}
+	if m, err := store.EmbeddingModel(); err != nil || m != nil {
+		t.Fatalf("expected no recorded model, got %+v, %v", m, err)
+	}
+	if p, err := store.Provenance(); err != nil || p != nil {
+		t.Fatalf("expected no provenance, got %+v, %v", p, err)
+	}
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'index_meta'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("expected the read-only open to leave the schema alone")
+	}
+}
+
+func Test_Store_QueryEach(t *testing.T) {
+	store, err := sqlvec.New(filepath.Join(t.TempDir(), "index.db"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	chunks := []models.CodeChunk{
+		{ID: "a", File: "a.ts"},
+		{ID: "b", File: "b.ts"},
+		{ID: "c", File: "c.ts"},
+	}
+	vecs := [][]float32{{1, 0}, {0, 1}, {1, 0.2}}
+	if err := store.Upsert(chunks, vecs); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	var ids []string
+	err = store.QueryEach(context.Background(), []float32{1, 0}, 10, func(hit models.SemanticHit) error {
+		ids = append(ids, hit.Chunk.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if fmt.Sprint(ids) != "[a c b]" {
+		t.Fatalf("expected hits in distance order, got %v", ids)
+	}
+
+	// an error from fn stops the query
+	stop := errors.New("stop")
+	ids = nil
+	err = store.QueryEach(context.Background(), []float32{1, 0}, 10, func(hit models.SemanticHit) error {
+		ids = append(ids, hit.Chunk.ID)
+		return stop
+	})
+	if !errors.Is(err, stop) || len(ids) != 1 {
+		t.Fatalf("expected the query to stop after one hit, got %v, %v", ids, err)
+	}
+}
@@ -1,6 +1,14 @@
 package storage
 
-import "github.com/0x5457/ts-index/internal/models"
+import (
+	"context"
+	"errors"
+
+	"github.com/0x5457/ts-index/internal/models"
+)
+
+// ErrNoEmbeddings is returned when querying an index built without embeddings
+var ErrNoEmbeddings = errors.New("index has no embeddings; run index without --symbols-only first")
 
 type SymbolStore interface {
 	UpsertSymbols(symbols []models.Symbol) error
@@ -9,8 +17,107 @@ type SymbolStore interface {
 	GetByID(id string) (*models.Symbol, error)
 }
 
+// FileSymbolStore is implemented by symbol stores that can list a file's symbols
+type FileSymbolStore interface {
+	SymbolsByFile(file string) ([]models.Symbol, error)
+}
+
+// EnclosingSymbolStore is implemented by symbol stores that can look up the
+// symbols containing a line with a range query
+type EnclosingSymbolStore interface {
+	// FindEnclosing returns the symbols of file whose lines contain line
+	// (1-based), innermost first
+	FindEnclosing(file string, line int) ([]models.Symbol, error)
+}
+
+// SymbolFilter selects symbols for SymbolFinder.Find. Zero fields select
+// everything.
+type SymbolFilter struct {
+	// Name is a case-sensitive glob on symbol names, with * and ? wildcards
+	Name  string
+	Kinds []models.SymbolKind
+	// File is a glob on file paths relative to the project, e.g. "src/*.ts";
+	// * also matches across directories
+	File string
+	// ExportedOnly keeps the declarations made in an export statement
+	ExportedOnly bool
+	// Limit caps the number of symbols returned, after skipping Offset of them
+	Limit  int
+	Offset int
+}
+
+// SymbolFinder is implemented by symbol stores that can query symbols by any
+// combination of name, kind, file and export
+type SymbolFinder interface {
+	// Find returns the symbols matching filter, ordered by file and line
+	Find(filter SymbolFilter) ([]models.Symbol, error)
+}
+
+// ComponentPropsStore is implemented by symbol stores that keep the props type
+// of React components
+type ComponentPropsStore interface {
+	// SetComponentProps replaces the component props stored for file
+	SetComponentProps(file string, props []models.ComponentProps) error
+	FindComponentProps(component string) ([]models.ComponentProps, error)
+}
+
 type VectorStore interface {
 	Upsert(chunks []models.CodeChunk, embeddings [][]float32) error
 	DeleteByFile(file string) error
 	Query(embedding []float32, topK int) ([]models.SemanticHit, error)
 }
+
+// StreamingVectorStore is implemented by vector stores that can hand out query
+// hits one at a time, most similar first, as they are read
+type StreamingVectorStore interface {
+	QueryEach(ctx context.Context, embedding []float32, topK int, fn func(models.SemanticHit) error) error
+}
+
+// EmbeddingChecker is implemented by vector stores that can tell whether they
+// hold any embeddings, so a search can fail before embedding its query
+type EmbeddingChecker interface {
+	HasEmbeddings() (bool, error)
+}
+
+// GenerationStore is implemented by vector stores that count their writes.
+// The generation changes whenever stored chunks or vectors change.
+type GenerationStore interface {
+	Generation() uint64
+}
+
+// ChunkStore is implemented by vector stores that can look up a stored chunk by ID
+type ChunkStore interface {
+	GetChunk(id string) (*models.CodeChunk, error)
+}
+
+// EmbeddingModelStore is implemented by vector stores that record which
+// embedding model their vectors come from
+type EmbeddingModelStore interface {
+	SetEmbeddingModel(m models.EmbeddingModel) error
+	// EmbeddingModel returns the recorded model, or nil if none was recorded
+	EmbeddingModel() (*models.EmbeddingModel, error)
+}
+
+// IncrementalVectorStore is implemented by vector stores that can update a
+// file's chunks one at a time, so reindexing an edited file only embeds the
+// chunks that changed
+type IncrementalVectorStore interface {
+	// ChunksByFile returns the stored chunks of file ordered by line
+	ChunksByFile(file string) ([]models.CodeChunk, error)
+	// MoveChunks replaces each stored chunk ids[k] with chunks[k], keeping its
+	// embedding. The new chunk may have another ID, such as after its lines moved.
+	MoveChunks(ids []string, chunks []models.CodeChunk) error
+	// DeleteByIDs removes the chunks with the given IDs, ignoring unknown ones
+	DeleteByIDs(ids []string) error
+}
+
+// ProvenanceStore is implemented by vector stores that record which git
+// version of the project was indexed
+type ProvenanceStore interface {
+	SetProvenance(p models.IndexProvenance) error
+	// Provenance returns the recorded provenance, or nil if none was recorded
+	Provenance() (*models.IndexProvenance, error)
+	// SetFileCommits records the last-modifying commit of each file; an empty
+	// commit clears the file's entry
+	SetFileCommits(commits map[string]string) error
+}
@@ -1,8 +1,12 @@
 package storagefx
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/0x5457/ts-index/internal/config/configfx"
 	"github.com/0x5457/ts-index/internal/storage"
+	"github.com/0x5457/ts-index/internal/storage/memory"
 	"github.com/0x5457/ts-index/internal/storage/sqlite"
 	"github.com/0x5457/ts-index/internal/storage/sqlvec"
 	"go.uber.org/fx"
@@ -12,30 +16,87 @@ import (
 type Params struct {
 	fx.In
 
-	Config *configfx.Config
+	Config    *configfx.Config
+	Lifecycle fx.Lifecycle
+	// Memory is the shared in-memory store, set when Config.InMemory is
+	Memory *memory.Store `optional:"true"`
+}
+
+// NewMemoryStore creates the in-memory store backing both the symbol and the
+// vector store when the index is not persisted
+func NewMemoryStore(config *configfx.Config) *memory.Store {
+	if !config.InMemory {
+		return nil
+	}
+	return memory.New()
 }
 
 // NewSymbolStore creates a new symbol store instance
 func NewSymbolStore(params Params) (storage.SymbolStore, error) {
+	if params.Config.InMemory {
+		return params.Memory, nil
+	}
 	if params.Config.DBPath == "" {
 		// Return nil when no database path is provided (e.g., in MCP client mode)
 		return nil, nil
 	}
-	return sqlite.New(params.Config.DBPath)
+	if params.Config.ReadOnly {
+		store, err := sqlite.NewReadOnly(params.Config.DBPath)
+		if err != nil {
+			return nil, fmt.Errorf("open index read-only: %w", err)
+		}
+		closeOnStop(params.Lifecycle, store.Close)
+		return store, nil
+	}
+	store, err := sqlite.New(params.Config.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	closeOnStop(params.Lifecycle, store.Close)
+	return store, nil
 }
 
 // NewVectorStore creates a new vector store instance
 func NewVectorStore(params Params) (storage.VectorStore, error) {
+	if params.Config.InMemory {
+		return params.Memory, nil
+	}
 	if params.Config.DBPath == "" {
 		// Return nil when no database path is provided (e.g., in MCP client mode)
 		return nil, nil
 	}
-	return sqlvec.New(params.Config.DBPath, params.Config.VectorDimension)
+	store, err := sqlvec.NewWithOptions(
+		params.Config.DBPath,
+		params.Config.VectorDimension,
+		sqlvec.Options{
+			ReduceDim: params.Config.ReduceDim,
+			Quantize:  params.Config.Quantize,
+			ReadOnly:  params.Config.ReadOnly,
+		},
+	)
+	if err != nil {
+		if params.Config.ReadOnly {
+			return nil, fmt.Errorf("open index read-only: %w", err)
+		}
+		return nil, err
+	}
+	closeOnStop(params.Lifecycle, store.Close)
+	return store, nil
+}
+
+// closeOnStop closes a store's database when the app stops. Stores are
+// created before the services using them, so they are closed after those
+// services' stop hooks have run.
+func closeOnStop(lc fx.Lifecycle, closeStore func() error) {
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error { return closeStore() },
+	})
 }
 
 // Module provides storage components
 var Module = fx.Module("storage",
 	fx.Provide(
+		fx.Annotate(NewMemoryStore, fx.ResultTags(`optional:"true"`)),
 		fx.Annotate(NewSymbolStore, fx.ResultTags(`optional:"true"`)),
 		fx.Annotate(NewVectorStore, fx.ResultTags(`optional:"true"`)),
 	),